@@ -39,7 +39,7 @@ const (
 	jumpContainerName = "jump"
 	jumpPodLabelKey   = "automated-break-glass-access/cluster"
 
-	// Lifespan for jump pods in seconds. Currently, PrivateLink jump pods will expire after 8 hours
+	// Default lifespan for break-glass access in seconds. Unless overridden with --ttl, access will expire after 8 hours
 	jumpPodLifespan = 28800
 )
 
@@ -59,12 +59,21 @@ func NewCmdAccess(streams genericclioptions.IOStreams, client *k8s.LazyClient) *
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(accessCmdComplete(cmd))
+
+			sessions, err := defaultSessionStore()
+			cmdutil.CheckErr(err)
+			ops.sessions = sessions
 			cmdutil.CheckErr(ops.Run(cmd))
 		},
 	}
 	accessCmd.AddCommand(newCmdCleanup(client, streams))
+	accessCmd.AddCommand(newCmdList(streams))
+	accessCmd.AddCommand(newCmdReap(streams))
 	accessCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
 	accessCmd.Flags().StringVar(&ops.clusterID, "cluster-id", "", "Provide the internal ID of the cluster")
+	accessCmd.Flags().DurationVar(&ops.ttl, "ttl", jumpPodLifespan*time.Second, "How long the access lasts before the kubeconfig or jump pod is reaped")
+	accessCmd.Flags().BoolVar(&ops.noShell, "no-shell", false, "For PrivateLink and Private Service Connect clusters, print instructions to exec into the jump pod instead of attaching a shell")
+	accessCmd.Flags().BoolVar(&ops.deleteOnExit, "delete-on-exit", false, "For PrivateLink and Private Service Connect clusters, delete the jump pod once the attached shell exits")
 	_ = accessCmd.MarkFlagRequired("reason")
 	_ = accessCmd.MarkFlagRequired("cluster-id")
 
@@ -83,6 +92,18 @@ func accessCmdComplete(cmd *cobra.Command) error {
 type clusterAccessOptions struct {
 	reason    string
 	clusterID string
+	ttl       time.Duration
+
+	// noShell prints instructions to exec into jump pods instead of attaching a shell
+	noShell bool
+	// deleteOnExit deletes jump pods once the attached shell exits
+	deleteOnExit bool
+
+	// sessions tracks the access granted, when nil sessions aren't recorded
+	sessions *sessionStore
+	// owner and hiveClusterID are recorded on sessions
+	owner         string
+	hiveClusterID string
 
 	genericclioptions.IOStreams
 	kubeCli *k8s.LazyClient
@@ -141,6 +162,8 @@ func (c *clusterAccessOptions) Run(cmd *cobra.Command) error {
 		return err
 	}
 	c.Println(fmt.Sprintf("Internal Cluster ID: %s", cluster.ID()))
+	c.owner = osdctlutil.GetCurrentOCMUsername(conn)
+	c.reapExpiredLocalSessions()
 
 	// Retrieve the kubeconfig secret from the cluster's namespace on hive
 	ns, err := getClusterNamespace(c.kubeCli, cluster.ID())
@@ -161,6 +184,11 @@ func (c *clusterAccessOptions) Run(cmd *cobra.Command) error {
 	if cluster.AWS().PrivateLink() || isPscCluster {
 		c.Println("")
 		c.Println("Cluster is PrivateLink or Private Service Connect, and is only accessible via a jump pod on Hive")
+		if hive, err := osdctlutil.GetHiveCluster(cluster.ID()); err != nil {
+			c.Errorln(fmt.Sprintf("Failed to determine the cluster's hive shard, the jump pod won't be found by 'break-glass list': %v", err))
+		} else {
+			c.hiveClusterID = hive.ID()
+		}
 		return c.createJumpPodAccess(cluster, kubeconfigSecret)
 	}
 
@@ -179,6 +207,14 @@ func (c *clusterAccessOptions) createJumpPodAccess(cluster *clustersmgmtv1.Clust
 		c.Errorln("Failed to create pod")
 		return err
 	}
+	session := c.openSession(breakGlassSession{
+		Type:          sessionTypeJumpPod,
+		ClusterID:     cluster.ID(),
+		ClusterName:   cluster.Name(),
+		HiveClusterID: c.hiveClusterID,
+		Namespace:     pod.Namespace,
+		Name:          pod.Name,
+	})
 
 	c.Println(fmt.Sprintf("Jump pod created. Waiting for it to start"))
 	c.Println("")
@@ -190,11 +226,49 @@ func (c *clusterAccessOptions) createJumpPodAccess(cluster *clustersmgmtv1.Clust
 		c.Println("Once the pod is running:")
 	} else {
 		c.Println("Pod detected as running")
+
+		if !c.noShell && c.canAttachShell() {
+			return c.attachJumpPodShell(cluster, pod, session)
+		}
 	}
 	c.Println(fmt.Sprintf("Use \n\n    oc exec -it --as %s -n %s %s -- /bin/bash\n\nto run commands in the pod. All 'oc' commands run within the pod will be executed against the cluster '%s' (this can be verified by running `oc cluster-info` in the pod)", impersonateUser, pod.Namespace, pod.Name, cluster.Name()))
 	return err
 }
 
+// attachJumpPodShell attaches an interactive shell in the jump pod, and deletes the pod afterwards if requested
+func (c *clusterAccessOptions) attachJumpPodShell(cluster *clustersmgmtv1.Cluster, pod corev1.Pod, session breakGlassSession) error {
+	c.Println(fmt.Sprintf("Attaching a shell to the pod. All 'oc' commands run within it will be executed against the cluster '%s'", cluster.Name()))
+	c.Println("When you are done, type 'exit' (or use ctl-D) to return to the original terminal")
+	c.Println("")
+
+	err := c.execJumpPodShell(context.TODO(), pod)
+	if err != nil {
+		c.Errorln(fmt.Sprintf("Error while running in shell: %v", err))
+	}
+
+	if !c.deleteOnExit {
+		c.Println(fmt.Sprintf("Finished executing against cluster '%s'. The jump pod is kept until it expires, reattach with\n\n    oc exec -it --as %s -n %s %s -- /bin/bash\n", cluster.Name(), impersonateUser, pod.Namespace, pod.Name))
+		return err
+	}
+
+	c.Println(fmt.Sprintf("Deleting jump pod %s", pod.Name))
+	if deleteErr := c.kubeCli.Delete(context.TODO(), &pod); deleteErr != nil && !kerr.IsNotFound(deleteErr) {
+		c.Errorln("Failed to delete pod, it can be deleted with 'osdctl cluster break-glass cleanup'")
+		if err == nil {
+			err = deleteErr
+		}
+		return err
+	}
+	if c.sessions != nil {
+		if closeErr := c.sessions.Close(sessionActionClose, session); closeErr != nil {
+			c.Errorln(fmt.Sprintf("Failed to record break-glass session as closed: %v", closeErr))
+		}
+	}
+	c.Println("Access has been dropped.")
+
+	return err
+}
+
 // createLocalKubeconfigAccess grants access to a cluster by writing the cluster's kubeconfig file to the local filesystem and (optionally) updating the user's cli environment
 func (c *clusterAccessOptions) createLocalKubeconfigAccess(cluster *clustersmgmtv1.Cluster, kubeconfigSecret corev1.Secret) error {
 	c.Println("Retrieving kubeconfig secret from Hive")
@@ -217,6 +291,7 @@ func (c *clusterAccessOptions) createLocalKubeconfigAccess(cluster *clustersmgmt
 			c.Errorln("Failed to write Secret to file")
 			return err
 		}
+		c.openKubeconfigSession(cluster, kubeconfigFilePath)
 
 		c.Println(fmt.Sprintf("File has been written to '%s' for manual use", kubeconfigFilePath))
 		return fmt.Errorf("could not parse cluster's kubeconfig Secret")
@@ -230,7 +305,11 @@ func (c *clusterAccessOptions) createLocalKubeconfigAccess(cluster *clustersmgmt
 		c.Errorln("\nFailed to determine if the cluster is private.\nIf you're not able to access the cluster, try modifying the resulting kubeconfig according to the SOP: https://github.com/openshift/ops-sop/blob/master/v4/howto/break-glass-kubeadmin.md#for-clusters-with-private-api")
 	} else if listening == clustersmgmtv1.ListeningMethodInternal {
 		// If the cluster has a private API, it must be accessed using a special API url from one of the bastions
-		return c.createPrivateAPIAccess(rawKubeconfig, kubeconfigFilePath)
+		if err := c.createPrivateAPIAccess(rawKubeconfig, kubeconfigFilePath); err != nil {
+			return err
+		}
+		c.openKubeconfigSession(cluster, kubeconfigFilePath)
+		return nil
 	}
 
 	// Write the kubeconfig to the temp filesystem
@@ -240,6 +319,7 @@ func (c *clusterAccessOptions) createLocalKubeconfigAccess(cluster *clustersmgmt
 		c.Errorln("Failed to save kubeconfig")
 		return err
 	}
+	c.openKubeconfigSession(cluster, kubeconfigFilePath)
 
 	c.Println("")
 	c.Println(fmt.Sprintf("Kubeconfig successfully written to '%s'", kubeconfigFilePath))
@@ -290,6 +370,60 @@ func (c *clusterAccessOptions) createLocalKubeconfigAccess(cluster *clustersmgmt
 	return nil
 }
 
+// lifespan returns how long access lasts, defaulting to the jump pod lifespan
+func (c *clusterAccessOptions) lifespan() time.Duration {
+	if c.ttl <= 0 {
+		return jumpPodLifespan * time.Second
+	}
+	return c.ttl
+}
+
+// openKubeconfigSession records a session for a kubeconfig written to the local filesystem
+func (c *clusterAccessOptions) openKubeconfigSession(cluster *clustersmgmtv1.Cluster, path string) {
+	c.openSession(breakGlassSession{
+		Type:        sessionTypeKubeconfig,
+		ClusterID:   cluster.ID(),
+		ClusterName: cluster.Name(),
+		Path:        path,
+	})
+}
+
+// openSession records a session, filling in the fields common to all sessions. Failing to record a session doesn't
+// prevent access, since the credentials already exist by the time it is recorded.
+func (c *clusterAccessOptions) openSession(session breakGlassSession) breakGlassSession {
+	if c.sessions == nil {
+		return session
+	}
+
+	session.Reason = c.reason
+	session.Owner = c.owner
+	session.CreatedAt = c.sessions.now().UTC()
+	session.ExpiresAt = session.CreatedAt.Add(c.lifespan())
+	if err := c.sessions.Open(session); err != nil {
+		c.Errorln(fmt.Sprintf("Failed to record break-glass session: %v", err))
+		return session
+	}
+	c.Println(fmt.Sprintf("Access expires at %s and will be cleaned up by 'osdctl cluster break-glass reap'", session.ExpiresAt.Format(time.RFC3339)))
+
+	return session
+}
+
+// reapExpiredLocalSessions deletes kubeconfig files of expired sessions, so forgotten credentials are cleaned up the
+// next time break-glass is used
+func (c *clusterAccessOptions) reapExpiredLocalSessions() {
+	if c.sessions == nil {
+		return
+	}
+
+	reaped, err := c.sessions.ReapLocal()
+	for _, session := range reaped {
+		c.Println(fmt.Sprintf("Deleted expired kubeconfig for cluster '%s': %s", session.ClusterID, session.Path))
+	}
+	if err != nil {
+		c.Errorln(fmt.Sprintf("Failed to reap expired break-glass sessions: %v", err))
+	}
+}
+
 // createPrivateAPIAccess provides the necessary changes to access clusters with Private APIs
 func (c *clusterAccessOptions) createPrivateAPIAccess(rawKubeconfig []byte, kubeconfigFilePath string) error {
 	c.Println("Cluster is private. Updating kubeconfig to execute commands against the rh-api")
@@ -374,14 +508,22 @@ func (c *clusterAccessOptions) createJumpPod(kubeconfigSecret corev1.Secret, clu
 	name := fmt.Sprintf("jumphost-%s-%d", time.Now().Format("20060102-150405-"), (time.Now().Nanosecond() / 1000000))
 	ns := kubeconfigSecret.Namespace
 	label := map[string]string{jumpPodLabelKey: clusterid}
+	lifespan := int64(c.lifespan().Seconds())
 
 	deploy := corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: ns,
 			Labels:    label,
+			Annotations: map[string]string{
+				jumpPodExpiresAtAnnotation: time.Now().Add(c.lifespan()).UTC().Format(time.RFC3339),
+				jumpPodOwnerAnnotation:     c.owner,
+				jumpPodReasonAnnotation:    c.reason,
+			},
 		},
 		Spec: corev1.PodSpec{
+			// The pod is stopped once it expires even if it is never reaped
+			ActiveDeadlineSeconds: &lifespan,
 			Volumes: []corev1.Volume{
 				{
 					Name: kubeconfigSecretKey,
@@ -398,7 +540,7 @@ func (c *clusterAccessOptions) createJumpPod(kubeconfigSecret corev1.Secret, clu
 					Name:    jumpContainerName,
 					Image:   jumpImage,
 					Command: []string{"/bin/sh"},
-					Args:    []string{"-c", fmt.Sprintf("sleep %d", jumpPodLifespan)},
+					Args:    []string{"-c", fmt.Sprintf("sleep %d", lifespan)},
 					Env: []corev1.EnvVar{
 						{
 							Name:  "KUBECONFIG",
@@ -245,6 +245,14 @@ func TestClusterAccessOptions_createJumpPod(t *testing.T) {
 		}
 
 		// Verify pod was built correctly
+		// Verify expiry
+		if pod.Spec.ActiveDeadlineSeconds == nil || *pod.Spec.ActiveDeadlineSeconds != jumpPodLifespan {
+			t.Errorf("Unexpected activeDeadlineSeconds: expected %d, got %v", jumpPodLifespan, pod.Spec.ActiveDeadlineSeconds)
+		}
+		if _, err := time.Parse(time.RFC3339, pod.Annotations[jumpPodExpiresAtAnnotation]); err != nil {
+			t.Errorf("Pod's expiry annotation is not a valid timestamp: %v", err)
+		}
+
 		// Verify volume
 		if len(pod.Spec.Volumes) != 1 {
 			t.Errorf("Unexpected number of volumes: expected 1, got %d", len(pod.Spec.Volumes))
@@ -30,6 +30,10 @@ func newCmdCleanup(client *k8s.LazyClient, streams genericclioptions.IOStreams)
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(cleanupCmdComplete(cmd))
+
+			sessions, err := defaultSessionStore()
+			cmdutil.CheckErr(err)
+			ops.sessions = sessions
 			cmdutil.CheckErr(ops.Run(cmd))
 		},
 	}
@@ -53,6 +57,9 @@ type cleanupAccessOptions struct {
 	reason    string
 	clusterID string
 
+	// sessions tracks the access granted, when nil sessions aren't recorded as closed
+	sessions *sessionStore
+
 	genericclioptions.IOStreams
 	kubeCli *k8s.LazyClient
 }
@@ -169,6 +176,14 @@ func (c *cleanupAccessOptions) dropPrivateLinkAccess(cluster *clustersmgmtv1.Clu
 			return err
 		}
 
+		deleted := map[string]bool{}
+		for _, pod := range pods.Items {
+			deleted[pod.Name] = true
+		}
+		c.closeSessions(func(session breakGlassSession) bool {
+			return session.Type == sessionTypeJumpPod && session.Namespace == ns.Name && deleted[session.Name]
+		})
+
 		c.Println(fmt.Sprintf("Waiting for %d pod(s) to terminate", numPods))
 		err = wait.PollImmediate(jumpPodPollInterval, jumpPodPollTimeout, func() (done bool, err error) {
 			// For some reason, we have to recreate the podList after deleting the pods, otherwise the listOpts don't filter properly,
@@ -196,6 +211,10 @@ func (c *cleanupAccessOptions) dropPrivateLinkAccess(cluster *clustersmgmtv1.Clu
 // Basically it just unsets KUBECONFIG if it appears to be set to the given cluster, since we can't make assumptions
 // around local files.
 func (c *cleanupAccessOptions) dropLocalAccess(cluster *clustersmgmtv1.Cluster) error {
+	if err := c.deleteLocalKubeconfigs(cluster); err != nil {
+		return err
+	}
+
 	c.Println("Unsetting $KUBECONFIG for cluster")
 	kubeconfigPath, found := os.LookupEnv("KUBECONFIG")
 	if !found {
@@ -230,3 +249,74 @@ func (c *cleanupAccessOptions) dropLocalAccess(cluster *clustersmgmtv1.Cluster)
 	c.Println("Access has been dropped.")
 	return nil
 }
+
+// deleteLocalKubeconfigs offers to delete the kubeconfig files break-glass wrote for the cluster
+func (c *cleanupAccessOptions) deleteLocalKubeconfigs(cluster *clustersmgmtv1.Cluster) error {
+	if c.sessions == nil {
+		return nil
+	}
+
+	sessions, err := c.sessions.Sessions()
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, session := range sessions {
+		if session.Type == sessionTypeKubeconfig && session.ClusterID == cluster.ID() {
+			paths = append(paths, session.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	c.Println(fmt.Sprintf("Found %d kubeconfig file(s) for '%s':", len(paths), cluster.Name()))
+	for _, path := range paths {
+		c.Println(fmt.Sprintf("- %s", path))
+	}
+	c.Print("Delete them? [y/N] ")
+	input, err := c.Readln()
+	if err != nil {
+		c.Errorln("Failed to read user input")
+		return err
+	}
+	if !isAffirmative(input) {
+		return nil
+	}
+
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			c.Errorln(fmt.Sprintf("Failed to delete '%s'", path))
+			return err
+		}
+	}
+	c.closeSessions(func(session breakGlassSession) bool {
+		return session.Type == sessionTypeKubeconfig && session.ClusterID == cluster.ID()
+	})
+	c.Println("Deleted kubeconfig file(s).")
+
+	return nil
+}
+
+// closeSessions records all tracked sessions matching the filter as closed
+func (c *cleanupAccessOptions) closeSessions(filter func(breakGlassSession) bool) {
+	if c.sessions == nil {
+		return
+	}
+
+	sessions, err := c.sessions.Sessions()
+	if err != nil {
+		c.Errorln(fmt.Sprintf("Failed to read break-glass sessions: %v", err))
+		return
+	}
+
+	for _, session := range sessions {
+		if !filter(session) {
+			continue
+		}
+		if err := c.sessions.Close(sessionActionClose, session); err != nil {
+			c.Errorln(fmt.Sprintf("Failed to record break-glass session as closed: %v", err))
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/printer"
+	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	sessionStatusActive  = "active"
+	sessionStatusExpired = "expired"
+	// sessionStatusMissing means the session's kubeconfig file or jump pod no longer exists
+	sessionStatusMissing = "missing"
+)
+
+// hiveClientFunc returns a client for the given hive shard, elevated with reason
+type hiveClientFunc func(hiveClusterID, reason string) (kclient.Client, error)
+
+func newHiveClient(hiveClusterID, reason string) (kclient.Client, error) {
+	return k8s.NewAsBackplaneClusterAdmin(hiveClusterID, kclient.Options{}, reason, "Elevation required to manage break-glass jump pods")
+}
+
+// hiveClusterIDsFunc returns the IDs of the hive shards to search for the jump pods of the sessions, or of every hive
+// shard with allShards
+type hiveClusterIDsFunc func(sessions []breakGlassSession, allShards bool) ([]string, error)
+
+func lookupHiveClusterIDs(sessions []breakGlassSession, allShards bool) ([]string, error) {
+	ocm, err := osdctlutil.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer ocm.Close()
+
+	return hiveClusterIDs(ocm, sessions, allShards)
+}
+
+func newCmdList(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &listSessionsOptions{IOStreams: streams, hiveClient: newHiveClient, hiveIDs: lookupHiveClusterIDs}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List break-glass sessions",
+		Long: `List break-glass sessions opened from this machine, i.e. kubeconfig files written locally and jump pods created on hive.
+
+When --reason is provided, the hive shards are also searched for jump pods, including those created by others. By default
+only the shards jump pods were created on from this machine are searched, use --all-shards to search every hive shard.`,
+		Example: `  # List local kubeconfigs and jump pods
+  osdctl cluster break-glass list
+
+  # List jump pods on every hive shard
+  osdctl cluster break-glass list --all-shards --reason OHSS-1234`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(ops.complete())
+			cmdutil.CheckErr(ops.run(context.TODO()))
+		},
+	}
+	listCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for searching hive shards for jump pods, which requires elevation (usually an OHSS or PD ticket)")
+	listCmd.Flags().BoolVar(&ops.allShards, "all-shards", false, "Search every hive shard for jump pods instead of only those jump pods were created on from this machine")
+
+	return listCmd
+}
+
+// listSessionsOptions contains the objects and information required to list break-glass sessions
+type listSessionsOptions struct {
+	reason    string
+	allShards bool
+
+	genericclioptions.IOStreams
+	sessions   *sessionStore
+	hiveClient hiveClientFunc
+	hiveIDs    hiveClusterIDsFunc
+}
+
+func (o *listSessionsOptions) complete() error {
+	if o.allShards && o.reason == "" {
+		return fmt.Errorf("--reason is required to search all hive shards")
+	}
+
+	sessions, err := defaultSessionStore()
+	if err != nil {
+		return err
+	}
+	o.sessions = sessions
+
+	return nil
+}
+
+func (o *listSessionsOptions) run(ctx context.Context) error {
+	sessions, err := o.sessions.Sessions()
+	if err != nil {
+		return err
+	}
+
+	local, hive, err := searchHiveShards(ctx, sessions, o.reason, o.allShards, o.hiveIDs, o.hiveClient)
+	if err != nil {
+		return err
+	}
+
+	return printSessions(o.Out, mergeSessions(local, hive, o.sessions.now()))
+}
+
+// listedSession is a session along with its status
+type listedSession struct {
+	breakGlassSession
+	Status string
+}
+
+// searchHiveShards lists jump pods on hive shards when a reason for doing so is provided, returning the sessions
+// tracked locally along with the jump pods found on each searched shard
+func searchHiveShards(ctx context.Context, local []breakGlassSession, reason string, allShards bool, hiveIDs hiveClusterIDsFunc, hiveClient hiveClientFunc) ([]breakGlassSession, map[string][]breakGlassSession, error) {
+	hive := map[string][]breakGlassSession{}
+	if reason == "" {
+		return local, hive, nil
+	}
+
+	ids, err := hiveIDs(local, allShards)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, id := range ids {
+		client, err := hiveClient(id, reason)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create client for hive %s: %w", id, err)
+		}
+
+		pods, err := listJumpPods(ctx, client, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		hive[id] = pods
+	}
+
+	return local, hive, nil
+}
+
+// mergeSessions combines locally tracked sessions with the jump pods found on searched hive shards. Jump pods found
+// on hive are preferred over tracked ones, and tracked jump pods missing from a searched shard are marked as missing.
+func mergeSessions(local []breakGlassSession, hive map[string][]breakGlassSession, now time.Time) []listedSession {
+	found := map[string]bool{}
+	var merged []listedSession
+	for _, sessions := range hive {
+		for _, session := range sessions {
+			found[session.Location()] = true
+			merged = append(merged, listedSession{breakGlassSession: session, Status: sessionStatus(session, now)})
+		}
+	}
+
+	for _, session := range local {
+		status := sessionStatus(session, now)
+		switch session.Type {
+		case sessionTypeJumpPod:
+			if found[session.Location()] {
+				continue
+			}
+			if _, searched := hive[session.HiveClusterID]; searched {
+				status = sessionStatusMissing
+			}
+		case sessionTypeKubeconfig:
+			if _, err := os.Stat(session.Path); os.IsNotExist(err) {
+				status = sessionStatusMissing
+			}
+		}
+		merged = append(merged, listedSession{breakGlassSession: session, Status: status})
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.Before(merged[j].CreatedAt)
+	})
+
+	return merged
+}
+
+func sessionStatus(session breakGlassSession, now time.Time) string {
+	if session.Expired(now) {
+		return sessionStatusExpired
+	}
+	return sessionStatusActive
+}
+
+func printSessions(out io.Writer, sessions []listedSession) error {
+	if len(sessions) == 0 {
+		_, err := fmt.Fprintln(out, "No break-glass sessions found")
+		return err
+	}
+
+	p := printer.NewTablePrinter(out, 20, 1, 3, ' ')
+	p.AddRow([]string{"TYPE", "CLUSTER", "LOCATION", "OWNER", "REASON", "CREATED", "EXPIRES", "STATUS"})
+	for _, session := range sessions {
+		p.AddRow([]string{
+			session.Type,
+			session.ClusterID,
+			session.Location(),
+			session.Owner,
+			session.Reason,
+			session.CreatedAt.Format(time.RFC3339),
+			session.ExpiresAt.Format(time.RFC3339),
+			session.Status,
+		})
+	}
+
+	return p.Flush()
+}
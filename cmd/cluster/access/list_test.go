@@ -0,0 +1,104 @@
+package access
+
+import (
+	"bytes"
+	"context"
+	"os"
+	fpath "path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestJumpPod(name string, created time.Time, expiresAt time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "uhc-ns",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{jumpPodLabelKey: "cluster-1"},
+			Annotations:       map[string]string{jumpPodExpiresAtAnnotation: expiresAt.Format(time.RFC3339)},
+		},
+	}
+}
+
+func TestMergeSessions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	existingPath := fpath.Join(t.TempDir(), "kubeconfig")
+	assert.NoError(t, os.WriteFile(existingPath, []byte("kubeconfig"), 0600))
+
+	local := []breakGlassSession{
+		{Type: sessionTypeKubeconfig, Path: existingPath, CreatedAt: now.Add(-5 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{Type: sessionTypeKubeconfig, Path: fpath.Join(t.TempDir(), "deleted"), CreatedAt: now.Add(-4 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{Type: sessionTypeJumpPod, HiveClusterID: "hive-1", Namespace: "uhc-ns", Name: "found", CreatedAt: now.Add(-3 * time.Hour)},
+		{Type: sessionTypeJumpPod, HiveClusterID: "hive-1", Namespace: "uhc-ns", Name: "gone", CreatedAt: now.Add(-2 * time.Hour)},
+		{Type: sessionTypeJumpPod, HiveClusterID: "hive-2", Namespace: "uhc-ns", Name: "unsearched", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)},
+	}
+	hive := map[string][]breakGlassSession{
+		"hive-1": {jumpPodSession("hive-1", *newTestJumpPod("found", now.Add(-3*time.Hour), now.Add(time.Hour)))},
+	}
+
+	var statuses []string
+	for _, session := range mergeSessions(local, hive, now) {
+		statuses = append(statuses, session.Location()+" "+session.Status)
+	}
+	assert.Equal(t, []string{
+		existingPath + " active",
+		local[1].Path + " missing",
+		"hive-1/uhc-ns/found active",
+		"hive-1/uhc-ns/gone missing",
+		"hive-2/uhc-ns/unsearched expired",
+	}, statuses)
+}
+
+func TestReapSessions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestSessionStore(t, now)
+	hiveClient := fake.NewClientBuilder().WithObjects(
+		newTestJumpPod("expired", now.Add(-9*time.Hour), now.Add(-time.Hour)),
+		newTestJumpPod("active", now.Add(-time.Hour), now.Add(time.Hour)),
+	).Build()
+
+	tracked := jumpPodSession("hive-1", *newTestJumpPod("expired", now.Add(-9*time.Hour), now.Add(-time.Hour)))
+	assert.NoError(t, store.Open(tracked))
+
+	out := &bytes.Buffer{}
+	ops := &reapSessionsOptions{listSessionsOptions: listSessionsOptions{
+		reason:    "OHSS-1",
+		IOStreams: genericclioptions.IOStreams{Out: out, ErrOut: out},
+		sessions:  store,
+		hiveIDs: func(sessions []breakGlassSession, allShards bool) ([]string, error) {
+			return []string{"hive-1"}, nil
+		},
+		hiveClient: func(hiveClusterID, reason string) (kclient.Client, error) {
+			return hiveClient, nil
+		},
+	}}
+
+	ops.dryRun = true
+	assert.NoError(t, ops.run(context.TODO()))
+	assert.Contains(t, out.String(), "Deleting expired jump-pod for cluster 'cluster-1': hive-1/uhc-ns/expired")
+	pods := corev1.PodList{}
+	assert.NoError(t, hiveClient.List(context.TODO(), &pods))
+	assert.Len(t, pods.Items, 2)
+
+	ops.dryRun = false
+	assert.NoError(t, ops.run(context.TODO()))
+	assert.NoError(t, hiveClient.List(context.TODO(), &pods))
+	assert.Len(t, pods.Items, 1)
+	assert.Equal(t, "active", pods.Items[0].Name)
+
+	sessions, err := store.Sessions()
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	events, err := store.AuditLog()
+	assert.NoError(t, err)
+	assert.Equal(t, sessionActionExpire, events[len(events)-1].Action)
+}
@@ -0,0 +1,129 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func newCmdReap(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &reapSessionsOptions{listSessionsOptions: listSessionsOptions{IOStreams: streams, hiveClient: newHiveClient, hiveIDs: lookupHiveClusterIDs}}
+	reapCmd := &cobra.Command{
+		Use:   "reap",
+		Short: "Delete expired break-glass kubeconfigs and jump pods",
+		Long: `Delete expired break-glass sessions. Kubeconfig files written locally are deleted once they expire, which also
+happens automatically whenever break-glass is run.
+
+When --reason is provided, expired jump pods are deleted from hive too, including those created by others. By default
+only the shards jump pods were created on from this machine are searched, use --all-shards to search every hive shard.`,
+		Example: `  # Delete expired local kubeconfigs and jump pods created from this machine
+  osdctl cluster break-glass reap --reason OHSS-1234
+
+  # Show which jump pods would be deleted from every hive shard
+  osdctl cluster break-glass reap --all-shards --reason OHSS-1234 --dry-run`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(ops.complete())
+			cmdutil.CheckErr(ops.run(context.TODO()))
+		},
+	}
+	reapCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for deleting jump pods on hive, which requires elevation (usually an OHSS or PD ticket)")
+	reapCmd.Flags().BoolVar(&ops.allShards, "all-shards", false, "Search every hive shard for expired jump pods instead of only those jump pods were created on from this machine")
+	reapCmd.Flags().BoolVar(&ops.dryRun, "dry-run", false, "Only print the sessions which would be deleted")
+
+	return reapCmd
+}
+
+// reapSessionsOptions contains the objects and information required to reap expired break-glass sessions
+type reapSessionsOptions struct {
+	listSessionsOptions
+	dryRun bool
+}
+
+func (o *reapSessionsOptions) run(ctx context.Context) error {
+	sessions, err := o.sessions.Sessions()
+	if err != nil {
+		return err
+	}
+
+	local, hive, err := searchHiveShards(ctx, sessions, o.reason, o.allShards, o.hiveIDs, o.hiveClient)
+	if err != nil {
+		return err
+	}
+
+	reaped := 0
+	for _, session := range mergeSessions(local, hive, o.sessions.now()) {
+		switch session.Status {
+		case sessionStatusActive:
+			continue
+		case sessionStatusMissing:
+			// Already gone, e.g. deleted by hand or by someone else, so only stop tracking it
+			if !o.dryRun {
+				if err := o.sessions.Close(sessionActionClose, session.breakGlassSession); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if session.Type == sessionTypeJumpPod {
+			if _, searched := hive[session.HiveClusterID]; !searched {
+				// Deleting jump pods requires a reason for elevation
+				continue
+			}
+		}
+
+		osdctlutil.StreamPrintln(o.IOStreams, fmt.Sprintf("Deleting expired %s for cluster '%s': %s", session.Type, session.ClusterID, session.Location()))
+		reaped++
+		if o.dryRun {
+			continue
+		}
+
+		if err := o.deleteSession(ctx, session.breakGlassSession); err != nil {
+			return err
+		}
+		if err := o.sessions.Close(sessionActionExpire, session.breakGlassSession); err != nil {
+			return err
+		}
+	}
+
+	if reaped == 0 {
+		osdctlutil.StreamPrintln(o.IOStreams, "No expired break-glass sessions found")
+	}
+	if o.reason == "" {
+		osdctlutil.StreamPrintln(o.IOStreams, "Provide --reason to also reap expired jump pods on hive")
+	}
+
+	return nil
+}
+
+// deleteSession deletes the kubeconfig file or jump pod of a session
+func (o *reapSessionsOptions) deleteSession(ctx context.Context, session breakGlassSession) error {
+	if session.Type == sessionTypeKubeconfig {
+		if err := os.Remove(session.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete expired kubeconfig %s: %w", session.Path, err)
+		}
+		return nil
+	}
+
+	client, err := o.hiveClient(session.HiveClusterID, o.reason)
+	if err != nil {
+		return fmt.Errorf("failed to create client for hive %s: %w", session.HiveClusterID, err)
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: session.Name, Namespace: session.Namespace}}
+	if err := client.Delete(ctx, pod); err != nil && !kerr.IsNotFound(err) {
+		return fmt.Errorf("failed to delete expired jump pod %s: %w", session.Location(), err)
+	}
+
+	return nil
+}
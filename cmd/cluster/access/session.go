@@ -0,0 +1,327 @@
+package access
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	fpath "path/filepath"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	kclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	sessionTypeKubeconfig = "kubeconfig"
+	sessionTypeJumpPod    = "jump-pod"
+
+	sessionActionOpen   = "open"
+	sessionActionClose  = "close"
+	sessionActionExpire = "expire"
+
+	// Annotations recording who opened a jump pod session, why, and when it expires, so that sessions opened by
+	// other SREs can be audited and reaped too
+	jumpPodExpiresAtAnnotation = "automated-break-glass-access/expires-at"
+	jumpPodOwnerAnnotation     = "automated-break-glass-access/owner"
+	jumpPodReasonAnnotation    = "automated-break-glass-access/reason"
+
+	sessionsFileName = "sessions.json"
+	auditFileName    = "audit.log"
+)
+
+// breakGlassSession is an active break-glass access to a cluster, either a kubeconfig written to the local filesystem
+// or a jump pod on hive
+type breakGlassSession struct {
+	Type        string    `json:"type"`
+	ClusterID   string    `json:"clusterId"`
+	ClusterName string    `json:"clusterName,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	Owner       string    `json:"owner,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+
+	// Path is the kubeconfig file of kubeconfig sessions
+	Path string `json:"path,omitempty"`
+
+	// HiveClusterID, Namespace, and Name identify the pod of jump pod sessions
+	HiveClusterID string `json:"hiveClusterId,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	Name          string `json:"name,omitempty"`
+}
+
+// Location returns where the session's credentials live
+func (s breakGlassSession) Location() string {
+	if s.Type == sessionTypeJumpPod {
+		return fmt.Sprintf("%s/%s/%s", s.HiveClusterID, s.Namespace, s.Name)
+	}
+	return s.Path
+}
+
+// Expired returns true if the session expired before now
+func (s breakGlassSession) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !s.ExpiresAt.After(now)
+}
+
+// sessionEvent is a single entry of the audit log
+type sessionEvent struct {
+	Time    time.Time         `json:"time"`
+	Action  string            `json:"action"`
+	Session breakGlassSession `json:"session"`
+}
+
+// sessionStore tracks active break-glass sessions and records an audit log of every session opened and closed.
+// Both are stored as files in dir.
+type sessionStore struct {
+	dir string
+	now func() time.Time
+}
+
+// defaultSessionStore returns a sessionStore in the user's config directory
+func defaultSessionStore() (*sessionStore, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine config directory: %w", err)
+	}
+
+	return newSessionStore(fpath.Join(configDir, "osdctl", "break-glass")), nil
+}
+
+func newSessionStore(dir string) *sessionStore {
+	return &sessionStore{dir: dir, now: time.Now}
+}
+
+// Sessions returns all active sessions
+func (s *sessionStore) Sessions() ([]breakGlassSession, error) {
+	data, err := os.ReadFile(fpath.Join(s.dir, sessionsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read break-glass sessions: %w", err)
+	}
+
+	var sessions []breakGlassSession
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to parse break-glass sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Open records a new active session. Opening a session at the same location as an existing one replaces it, since
+// e.g. kubeconfig files are overwritten when break-glass is run for the same cluster again.
+func (s *sessionStore) Open(session breakGlassSession) error {
+	sessions, err := s.Sessions()
+	if err != nil {
+		return err
+	}
+
+	sessions = removeSession(sessions, session)
+	if err := s.save(append(sessions, session)); err != nil {
+		return err
+	}
+
+	return s.Audit(sessionActionOpen, session)
+}
+
+// Close removes an active session, recording the given action (sessionActionClose or sessionActionExpire) in the
+// audit log. Sessions which aren't tracked, e.g. jump pods created by others, are still audited.
+func (s *sessionStore) Close(action string, session breakGlassSession) error {
+	sessions, err := s.Sessions()
+	if err != nil {
+		return err
+	}
+
+	if err := s.save(removeSession(sessions, session)); err != nil {
+		return err
+	}
+
+	return s.Audit(action, session)
+}
+
+// Audit appends an event to the audit log
+func (s *sessionStore) Audit(action string, session breakGlassSession) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+
+	f, err := os.OpenFile(fpath.Join(s.dir, auditFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open break-glass audit log: %w", err)
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(sessionEvent{Time: s.now().UTC(), Action: action, Session: session})
+}
+
+// AuditLog returns all events in the audit log, oldest first
+func (s *sessionStore) AuditLog() ([]sessionEvent, error) {
+	f, err := os.Open(fpath.Join(s.dir, auditFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open break-glass audit log: %w", err)
+	}
+	defer f.Close()
+
+	var events []sessionEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event sessionEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to parse break-glass audit log: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
+
+// save atomically replaces the active sessions
+func (s *sessionStore) save(sessions []breakGlassSession) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := osdctlutil.WriteFileAtomic(fpath.Join(s.dir, sessionsFileName), data); err != nil {
+		return fmt.Errorf("failed to save break-glass sessions: %w", err)
+	}
+
+	return nil
+}
+
+// ReapLocal deletes kubeconfig files of expired sessions and closes them, returning the reaped sessions
+func (s *sessionStore) ReapLocal() ([]breakGlassSession, error) {
+	sessions, err := s.Sessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var reaped []breakGlassSession
+	for _, session := range sessions {
+		if session.Type != sessionTypeKubeconfig || !session.Expired(s.now()) {
+			continue
+		}
+
+		if err := os.Remove(session.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return reaped, fmt.Errorf("failed to delete expired kubeconfig %s: %w", session.Path, err)
+		}
+		if err := s.Close(sessionActionExpire, session); err != nil {
+			return reaped, err
+		}
+		reaped = append(reaped, session)
+	}
+
+	return reaped, nil
+}
+
+// removeSession returns sessions without any session at the same location as session
+func removeSession(sessions []breakGlassSession, session breakGlassSession) []breakGlassSession {
+	var remaining []breakGlassSession
+	for _, s := range sessions {
+		if s.Type == session.Type && s.Location() == session.Location() {
+			continue
+		}
+		remaining = append(remaining, s)
+	}
+
+	return remaining
+}
+
+// jumpPodSession converts a jump pod into a session, relying on the annotations set by createJumpPod. Jump pods
+// created before they were annotated expire jumpPodLifespan after their creation.
+func jumpPodSession(hiveClusterID string, pod corev1.Pod) breakGlassSession {
+	session := breakGlassSession{
+		Type:          sessionTypeJumpPod,
+		ClusterID:     pod.Labels[jumpPodLabelKey],
+		Reason:        pod.Annotations[jumpPodReasonAnnotation],
+		Owner:         pod.Annotations[jumpPodOwnerAnnotation],
+		CreatedAt:     pod.CreationTimestamp.Time,
+		ExpiresAt:     pod.CreationTimestamp.Add(jumpPodLifespan * time.Second),
+		HiveClusterID: hiveClusterID,
+		Namespace:     pod.Namespace,
+		Name:          pod.Name,
+	}
+
+	if expiresAt, err := time.Parse(time.RFC3339, pod.Annotations[jumpPodExpiresAtAnnotation]); err == nil {
+		session.ExpiresAt = expiresAt
+	}
+
+	return session
+}
+
+// listJumpPods returns all jump pods on a hive shard as sessions
+func listJumpPods(ctx context.Context, hiveClient kclient.Client, hiveClusterID string) ([]breakGlassSession, error) {
+	requirement, err := labels.NewRequirement(jumpPodLabelKey, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := corev1.PodList{}
+	if err := hiveClient.List(ctx, &pods, &kclient.ListOptions{LabelSelector: labels.NewSelector().Add(*requirement)}); err != nil {
+		return nil, fmt.Errorf("failed to list jump pods on %s: %w", hiveClusterID, err)
+	}
+
+	sessions := make([]breakGlassSession, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		sessions = append(sessions, jumpPodSession(hiveClusterID, pod))
+	}
+
+	return sessions, nil
+}
+
+// hiveClusterIDs returns the hive shards to search for jump pods: every provision shard when allShards is set, and
+// otherwise only the shards that sessions were opened on
+func hiveClusterIDs(conn *sdk.Connection, sessions []breakGlassSession, allShards bool) ([]string, error) {
+	if !allShards {
+		seen := map[string]bool{}
+		var ids []string
+		for _, session := range sessions {
+			if session.Type == sessionTypeJumpPod && session.HiveClusterID != "" && !seen[session.HiveClusterID] {
+				seen[session.HiveClusterID] = true
+				ids = append(ids, session.HiveClusterID)
+			}
+		}
+		return ids, nil
+	}
+
+	shards, err := conn.ClustersMgmt().V1().ProvisionShards().List().Send()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provision shards: %w", err)
+	}
+
+	var ids []string
+	for _, shard := range shards.Items().Slice() {
+		server, ok := shard.HiveConfig().GetServer()
+		if !ok {
+			continue
+		}
+
+		resp, err := conn.ClustersMgmt().V1().Clusters().List().
+			Parameter("search", fmt.Sprintf("api.url='%s'", server)).
+			Send()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find hive cluster for %s: %w", server, err)
+		}
+		if resp.Items().Empty() {
+			continue
+		}
+		ids = append(ids, resp.Items().Get(0).ID())
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,113 @@
+package access
+
+import (
+	"os"
+	fpath "path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestSessionStore(t *testing.T, now time.Time) *sessionStore {
+	s := newSessionStore(fpath.Join(t.TempDir(), "break-glass"))
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestSessionStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSessionStore(t, now)
+
+	sessions, err := s.Sessions()
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	kubeconfig := breakGlassSession{Type: sessionTypeKubeconfig, ClusterID: "cluster-1", Path: "/tmp/kubeconfig", Reason: "OHSS-1"}
+	jumpPod := breakGlassSession{Type: sessionTypeJumpPod, ClusterID: "cluster-2", HiveClusterID: "hive-1", Namespace: "uhc-ns", Name: "jump", Reason: "OHSS-2"}
+	assert.NoError(t, s.Open(kubeconfig))
+	assert.NoError(t, s.Open(jumpPod))
+
+	// Reopening the same kubeconfig replaces the existing session
+	kubeconfig.Reason = "OHSS-3"
+	assert.NoError(t, s.Open(kubeconfig))
+
+	sessions, err = s.Sessions()
+	assert.NoError(t, err)
+	assert.Equal(t, []breakGlassSession{jumpPod, kubeconfig}, sessions)
+
+	assert.NoError(t, s.Close(sessionActionClose, jumpPod))
+	sessions, err = s.Sessions()
+	assert.NoError(t, err)
+	assert.Equal(t, []breakGlassSession{kubeconfig}, sessions)
+
+	events, err := s.AuditLog()
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+	assert.Equal(t, sessionActionClose, events[3].Action)
+	assert.Equal(t, "OHSS-2", events[3].Session.Reason)
+	assert.Equal(t, now, events[3].Time)
+}
+
+func TestSessionStore_ReapLocal(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestSessionStore(t, now)
+
+	expiredPath := fpath.Join(t.TempDir(), "expired-kubeconfig")
+	activePath := fpath.Join(t.TempDir(), "active-kubeconfig")
+	for _, path := range []string{expiredPath, activePath} {
+		assert.NoError(t, os.WriteFile(path, []byte("kubeconfig"), 0600))
+	}
+
+	expired := breakGlassSession{Type: sessionTypeKubeconfig, Path: expiredPath, ExpiresAt: now.Add(-time.Minute)}
+	active := breakGlassSession{Type: sessionTypeKubeconfig, Path: activePath, ExpiresAt: now.Add(time.Minute)}
+	// Jump pods are never reaped locally
+	jumpPod := breakGlassSession{Type: sessionTypeJumpPod, HiveClusterID: "hive-1", Name: "jump", ExpiresAt: now.Add(-time.Minute)}
+	for _, session := range []breakGlassSession{expired, active, jumpPod} {
+		assert.NoError(t, s.Open(session))
+	}
+
+	reaped, err := s.ReapLocal()
+	assert.NoError(t, err)
+	assert.Equal(t, []breakGlassSession{expired}, reaped)
+	assert.NoFileExists(t, expiredPath)
+	assert.FileExists(t, activePath)
+
+	sessions, err := s.Sessions()
+	assert.NoError(t, err)
+	assert.Equal(t, []breakGlassSession{active, jumpPod}, sessions)
+
+	events, err := s.AuditLog()
+	assert.NoError(t, err)
+	assert.Equal(t, sessionActionExpire, events[len(events)-1].Action)
+}
+
+func TestJumpPodSession(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "jump",
+			Namespace:         "uhc-ns",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{jumpPodLabelKey: "cluster-1"},
+			Annotations: map[string]string{
+				jumpPodExpiresAtAnnotation: "2024-01-01T14:00:00Z",
+				jumpPodOwnerAnnotation:     "sre",
+				jumpPodReasonAnnotation:    "OHSS-1",
+			},
+		},
+	}
+
+	session := jumpPodSession("hive-1", pod)
+	assert.Equal(t, "cluster-1", session.ClusterID)
+	assert.Equal(t, "sre", session.Owner)
+	assert.Equal(t, "OHSS-1", session.Reason)
+	assert.Equal(t, created.Add(2*time.Hour), session.ExpiresAt)
+	assert.Equal(t, "hive-1/uhc-ns/jump", session.Location())
+
+	// Jump pods created before they were annotated fall back to the default lifespan
+	pod.Annotations = nil
+	assert.Equal(t, created.Add(8*time.Hour), jumpPodSession("hive-1", pod).ExpiresAt)
+}
@@ -0,0 +1,73 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+// jumpPodExecOptions returns the options to exec an interactive shell in a jump pod. KUBECONFIG is set explicitly
+// so that the shell targets the cluster even if a login script in the image overrides the pod's environment.
+func jumpPodExecOptions() *corev1.PodExecOptions {
+	return &corev1.PodExecOptions{
+		Container: jumpContainerName,
+		Command:   []string{"/usr/bin/env", fmt.Sprintf("KUBECONFIG=/tmp/%s", kubeconfigSecretKey), "/bin/bash", "-l"},
+		Stdin:     true,
+		Stdout:    true,
+		Stderr:    false,
+		TTY:       true,
+	}
+}
+
+// canAttachShell returns true if the user's input is a terminal an interactive shell can be attached to
+func (c *clusterAccessOptions) canAttachShell() bool {
+	t := term.TTY{In: c.In, Out: c.Out}
+	return t.IsTerminalIn()
+}
+
+// execJumpPodShell attaches an interactive shell in the jump pod to the user's terminal until the shell exits,
+// forwarding terminal resizes to the pod
+func (c *clusterAccessOptions) execJumpPodShell(ctx context.Context, pod corev1.Pod) error {
+	cfg, err := c.kubeCli.RESTConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get hive REST config: %w", err)
+	}
+
+	return execInteractiveShell(ctx, cfg, pod, c.IOStreams.In, c.IOStreams.Out)
+}
+
+func execInteractiveShell(ctx context.Context, cfg *rest.Config, pod corev1.Pod, in io.Reader, out io.Writer) error {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().Resource("pods").Name(pod.Name).
+		Namespace(pod.Namespace).SubResource("exec")
+	req.VersionedParams(jumpPodExecOptions(), scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+
+	t := term.TTY{In: in, Out: out, Raw: true}
+	sizeQueue := t.MonitorSize(t.GetSize())
+
+	// Safe puts the terminal into raw mode for the duration of the shell and restores it afterwards, even on interrupt
+	return t.Safe(func() error {
+		return exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+			Stdin:             t.In,
+			Stdout:            t.Out,
+			Tty:               true,
+			TerminalSizeQueue: sizeQueue,
+		})
+	})
+}
@@ -0,0 +1,29 @@
+package access
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// TestJumpPodExecOptions tests that the shell is attached to the jump container with a TTY and KUBECONFIG set
+func TestJumpPodExecOptions(t *testing.T) {
+	opts := jumpPodExecOptions()
+
+	assert.Equal(t, jumpContainerName, opts.Container)
+	assert.Equal(t, []string{"/usr/bin/env", "KUBECONFIG=/tmp/" + kubeconfigSecretKey, "/bin/bash", "-l"}, opts.Command)
+	assert.True(t, opts.Stdin)
+	assert.True(t, opts.Stdout)
+	assert.True(t, opts.TTY)
+}
+
+// TestCanAttachShell tests that no shell is attached when the input isn't a terminal, e.g. when piped
+func TestCanAttachShell(t *testing.T) {
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	streams.In = strings.NewReader("")
+	c := &clusterAccessOptions{IOStreams: streams}
+
+	assert.False(t, c.canAttachShell())
+}
@@ -129,7 +129,14 @@ func (o *cpdOptions) run() error {
 		}
 		fmt.Printf("Attempting to run: osdctl network verify-egress --cluster-id %s\n", o.clusterID)
 		ev := &network.EgressVerification{ClusterId: o.clusterID}
-		ev.Run(context.Background())
+		report, err := ev.Run(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to run network verifier: %w\nManual investigation required", err)
+		}
+		if report.Failed() > 0 {
+			ev.NotifyFailures(report)
+			return fmt.Errorf("network verifier found blocked egresses on %d subnet(s)", report.Failed())
+		}
 		return nil
 	}
 
@@ -0,0 +1,216 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// etcdDefragCmd defragments the member of the etcd pod it's run in. The endpoints of the other members are unset, so
+// only the local member is defragmented.
+const etcdDefragCmd = "unset ETCDCTL_ENDPOINTS && etcdctl --command-timeout=30s --endpoints=https://localhost:2379 defrag"
+
+type etcdDefragOptions struct {
+	clusterID     string
+	reason        string
+	healthTimeout time.Duration
+
+	out          io.Writer
+	etcdctl      etcdctlRunner
+	pollInterval time.Duration
+	confirm      func() bool
+}
+
+func newCmdEtcdDefrag() *cobra.Command {
+	opts := &etcdDefragOptions{
+		out:          os.Stdout,
+		pollInterval: 10 * time.Second,
+		confirm:      utils.ConfirmPrompt,
+	}
+	cmd := &cobra.Command{
+		Use:   "etcd-defrag --cluster-id <cluster-id> --reason <reason for escalation>",
+		Short: "Defragments the etcd members one at a time",
+		Long: `Defragments the etcd members one at a time to reclaim the space freed by compaction
+
+  The members which are not the leader are defragmented first, and the leader last. Defragmenting a member blocks its
+  reads and writes, so after each member the command waits for every etcd endpoint to be healthy again, and stops if
+  they aren't within the health timeout. The command refuses to start unless every member is reachable and healthy.
+
+  Once every member has been defragmented, active NOSPACE alarms are disarmed.`,
+		Example: `  # Defragment the etcd members of a cluster
+  osdctl cluster etcd-defrag --cluster-id ${CLUSTER_ID} --reason OHSS-1234`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(opts.run(context.Background()))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.clusterID, "cluster-id", "", "Provide the internal Cluster ID or name of the cluster to defragment etcd on")
+	cmd.Flags().StringVar(&opts.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usually an OHSS or PD ticket)")
+	cmd.Flags().DurationVar(&opts.healthTimeout, "health-timeout", 5*time.Minute, "How long to wait for the etcd endpoints to be healthy after defragmenting a member")
+	_ = cmd.MarkFlagRequired("cluster-id")
+	_ = cmd.MarkFlagRequired("reason")
+
+	return cmd
+}
+
+func (o *etcdDefragOptions) run(ctx context.Context) error {
+	kubeCli, kconfig, clientset, err := common.GetKubeConfigAndClient(o.clusterID, o.reason, "Defragmenting etcd members using osdctl")
+	if err != nil {
+		return err
+	}
+	o.etcdctl = newEtcdctlRunner(kconfig, clientset)
+
+	pods := &corev1.PodList{}
+	if err := kubeCli.List(ctx, pods, client.InNamespace(EtcdNamespaceName), client.MatchingLabels{EtcdPodMatchLabelName: EtcdPodMatchValueName}); err != nil {
+		return err
+	}
+	pod, err := readyEtcdPod(pods.Items)
+	if err != nil {
+		return err
+	}
+
+	return o.defrag(ctx, pod)
+}
+
+// defrag defragments every member, running the cluster wide etcdctl commands in the pod
+func (o *etcdDefragOptions) defrag(ctx context.Context, pod string) error {
+	before, err := getEtcdDiagnostics(o.etcdctl, pod)
+	if err != nil {
+		return err
+	}
+	if err := o.checkHealthy(before, pod); err != nil {
+		return fmt.Errorf("refusing to defragment: %w", err)
+	}
+
+	members := etcdDefragOrder(before.Members)
+	fmt.Fprintln(o.out, "The etcd members will be defragmented in this order:")
+	for _, member := range members {
+		role := ""
+		if member.Leader {
+			role = " (leader)"
+		}
+		fmt.Fprintf(o.out, "  %s%s: %s, %.0f%% fragmented\n", member.Name, role, formatEtcdBytes(member.DBSize), member.fragmentation())
+	}
+	noSpace := false
+	for _, member := range before.Members {
+		noSpace = noSpace || slices.Contains(member.Alarms, "NOSPACE")
+	}
+	if noSpace {
+		fmt.Fprintln(o.out, "The NOSPACE alarms will be disarmed afterwards.")
+	}
+	if !o.confirm() {
+		return fmt.Errorf("operation cancelled by user")
+	}
+
+	for _, member := range members {
+		fmt.Fprintf(o.out, "[INFO] Defragmenting %s\n", member.Name)
+		output, err := o.etcdctl("etcd-"+member.Name, etcdDefragCmd)
+		if err != nil {
+			return fmt.Errorf("failed to defragment %s: %w", member.Name, err)
+		}
+		fmt.Fprint(o.out, output)
+
+		if err := o.waitHealthy(ctx, pod); err != nil {
+			return fmt.Errorf("etcd didn't recover after defragmenting %s: %w", member.Name, err)
+		}
+	}
+
+	if noSpace {
+		output, err := o.etcdctl(pod, "etcdctl alarm disarm")
+		if err != nil {
+			return fmt.Errorf("failed to disarm the etcd alarms: %w", err)
+		}
+		fmt.Fprint(o.out, output)
+	}
+
+	after, err := getEtcdDiagnostics(o.etcdctl, pod)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(o.out, "\nThe etcd members have been defragmented:")
+	return printEtcdDiagnostics(o.out, after, o.clusterID)
+}
+
+// checkHealthy returns an error unless every member is reachable and every endpoint is healthy
+func (o *etcdDefragOptions) checkHealthy(d *etcdDiagnostics, pod string) error {
+	for _, member := range d.Members {
+		if !member.Reachable {
+			return fmt.Errorf("etcd member %s is unreachable. Run \"osdctl cluster etcd-health-check --cluster-id %s\" and replace it first", member.Name, o.clusterID)
+		}
+	}
+	if d.leader() == nil {
+		return fmt.Errorf("etcd has no leader")
+	}
+
+	health, err := getEtcdEndpointHealth(o.etcdctl, pod)
+	if err != nil {
+		return err
+	}
+	if unhealthy := unhealthyEtcdEndpoints(health); len(unhealthy) > 0 {
+		return fmt.Errorf("etcd endpoints are unhealthy: %s", strings.Join(unhealthy, ", "))
+	}
+	return nil
+}
+
+// waitHealthy waits until every etcd endpoint is healthy, or the health timeout passes
+func (o *etcdDefragOptions) waitHealthy(ctx context.Context, pod string) error {
+	deadline := time.Now().Add(o.healthTimeout)
+	for {
+		health, err := getEtcdEndpointHealth(o.etcdctl, pod)
+		if err != nil {
+			return err
+		}
+		unhealthy := unhealthyEtcdEndpoints(health)
+		if len(unhealthy) == 0 {
+			fmt.Fprintln(o.out, "[INFO] All etcd endpoints are healthy")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("endpoints still unhealthy after %s: %s", o.healthTimeout, strings.Join(unhealthy, ", "))
+		}
+
+		fmt.Fprintf(o.out, "[INFO] Waiting for unhealthy etcd endpoints: %s\n", strings.Join(unhealthy, ", "))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(o.pollInterval):
+		}
+	}
+}
+
+// etcdDefragOrder returns the reachable members with the leader last, so the leader is only blocked once the followers
+// have recovered
+func etcdDefragOrder(members []etcdMemberStatus) []etcdMemberStatus {
+	var ordered []etcdMemberStatus
+	for _, member := range members {
+		if member.Reachable {
+			ordered = append(ordered, member)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return !ordered[i].Leader && ordered[j].Leader })
+	return ordered
+}
+
+func unhealthyEtcdEndpoints(health []etcdEndpointHealth) []string {
+	var unhealthy []string
+	for _, endpoint := range health {
+		if !endpoint.Health {
+			unhealthy = append(unhealthy, endpoint.Endpoint)
+		}
+	}
+	return unhealthy
+}
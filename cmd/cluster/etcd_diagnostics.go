@@ -0,0 +1,407 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/printer"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// etcdFragmentationWarnPercent and etcdFragmentationWarnInUse mirror the etcdDatabaseHighFragmentationRatio alert,
+	// which fires when less than half of a database of more than 100MB is in use
+	etcdFragmentationWarnPercent = 50
+	etcdFragmentationWarnInUse   = 100 * 1000 * 1000
+
+	// etcdRaftLagWarn is how many raft entries a member can be behind the most recent member before it is reported
+	etcdRaftLagWarn = 1000
+
+	// etcdLeaderChangesWarn is how many leader elections can be seen in the etcd logs before they are reported
+	etcdLeaderChangesWarn = 3
+)
+
+// etcdctlRunner runs an etcdctl command in the etcdctl container of an etcd pod and returns its output
+type etcdctlRunner func(pod, command string) (string, error)
+
+func newEtcdctlRunner(kconfig *rest.Config, clientset *kubernetes.Clientset) etcdctlRunner {
+	return func(pod, command string) (string, error) {
+		return Etcdctlhealth(kconfig, clientset, command, pod)
+	}
+}
+
+// etcdMemberList is the output of 'etcdctl member list -w json'
+type etcdMemberList struct {
+	Members []struct {
+		ID         uint64   `json:"ID"`
+		Name       string   `json:"name"`
+		ClientURLs []string `json:"clientURLs"`
+		IsLearner  bool     `json:"isLearner"`
+	} `json:"members"`
+}
+
+// etcdEndpointStatus is an element of the output of 'etcdctl endpoint status -w json'
+type etcdEndpointStatus struct {
+	Endpoint string `json:"Endpoint"`
+	Status   struct {
+		Header struct {
+			MemberID uint64 `json:"member_id"`
+		} `json:"header"`
+		Version          string `json:"version"`
+		DBSize           int64  `json:"dbSize"`
+		DBSizeInUse      int64  `json:"dbSizeInUse"`
+		Leader           uint64 `json:"leader"`
+		RaftIndex        uint64 `json:"raftIndex"`
+		RaftTerm         uint64 `json:"raftTerm"`
+		RaftAppliedIndex uint64 `json:"raftAppliedIndex"`
+	} `json:"Status"`
+}
+
+// etcdAlarmList is the output of 'etcdctl alarm list -w json'
+type etcdAlarmList struct {
+	Alarms []struct {
+		MemberID uint64 `json:"memberID"`
+		Alarm    int32  `json:"alarm"`
+	} `json:"alarms"`
+}
+
+// etcdEndpointHealth is an element of the output of 'etcdctl endpoint health -w json'
+type etcdEndpointHealth struct {
+	Endpoint string `json:"endpoint"`
+	Health   bool   `json:"health"`
+	Error    string `json:"error"`
+}
+
+// etcdAlarmTypes are the names of etcd's AlarmType enum
+var etcdAlarmTypes = map[int32]string{
+	1: "NOSPACE",
+	2: "CORRUPT",
+}
+
+// etcdMemberStatus is the parsed status of an etcd member
+type etcdMemberStatus struct {
+	Name     string
+	ID       uint64
+	Endpoint string
+	// Reachable is false if the member's endpoint didn't report its status
+	Reachable        bool
+	Leader           bool
+	Learner          bool
+	Version          string
+	DBSize           int64
+	DBSizeInUse      int64
+	RaftIndex        uint64
+	RaftAppliedIndex uint64
+	RaftTerm         uint64
+	// RaftIndexLag is how many raft entries the member is behind the most recent member
+	RaftIndexLag uint64
+	Alarms       []string
+}
+
+// fragmentation returns the percentage of the member's database which is not in use and would be freed by a defrag
+func (m etcdMemberStatus) fragmentation() float64 {
+	if m.DBSize == 0 {
+		return 0
+	}
+	return float64(m.DBSize-m.DBSizeInUse) / float64(m.DBSize) * 100
+}
+
+// etcdLeaderChange is a leader election seen in the etcd logs
+type etcdLeaderChange struct {
+	Time   time.Time
+	Leader string
+	Term   uint64
+}
+
+// etcdDiagnostics are the parsed diagnostics of an etcd cluster
+type etcdDiagnostics struct {
+	Members []etcdMemberStatus
+	// LeaderChanges are only known since the etcd containers were last started
+	LeaderChanges []etcdLeaderChange
+}
+
+// alarms returns the active alarms of all members
+func (d *etcdDiagnostics) alarms() []string {
+	var alarms []string
+	for _, member := range d.Members {
+		for _, alarm := range member.Alarms {
+			alarms = append(alarms, fmt.Sprintf("%s on %s", alarm, member.Name))
+		}
+	}
+	return alarms
+}
+
+// leader returns the member which is the leader, or nil if no reachable member reported being the leader
+func (d *etcdDiagnostics) leader() *etcdMemberStatus {
+	for i := range d.Members {
+		if d.Members[i].Leader {
+			return &d.Members[i]
+		}
+	}
+	return nil
+}
+
+// getEtcdDiagnostics returns the status of every etcd member, as seen from the etcd pod
+func getEtcdDiagnostics(etcdctl etcdctlRunner, pod string) (*etcdDiagnostics, error) {
+	output, err := etcdctl(pod, "etcdctl member list -w json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd members: %w", err)
+	}
+	var members etcdMemberList
+	if err := json.Unmarshal([]byte(output), &members); err != nil {
+		return nil, fmt.Errorf("failed to parse the etcd member list: %w", err)
+	}
+
+	// etcdctl fails if any endpoint doesn't respond, but still prints the status of the others
+	output, err = etcdctl(pod, "etcdctl endpoint status -w json 2>/dev/null; true")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the etcd endpoint status: %w", err)
+	}
+	var statuses []etcdEndpointStatus
+	if strings.TrimSpace(output) != "" {
+		if err := json.Unmarshal([]byte(output), &statuses); err != nil {
+			return nil, fmt.Errorf("failed to parse the etcd endpoint status: %w", err)
+		}
+	}
+
+	output, err = etcdctl(pod, "etcdctl alarm list -w json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd alarms: %w", err)
+	}
+	var alarms etcdAlarmList
+	if err := json.Unmarshal([]byte(output), &alarms); err != nil {
+		return nil, fmt.Errorf("failed to parse the etcd alarms: %w", err)
+	}
+
+	diagnostics := &etcdDiagnostics{}
+	var maxRaftIndex uint64
+	for _, member := range members.Members {
+		m := etcdMemberStatus{Name: member.Name, ID: member.ID, Learner: member.IsLearner}
+		if len(member.ClientURLs) > 0 {
+			m.Endpoint = member.ClientURLs[0]
+		}
+		for _, status := range statuses {
+			if status.Status.Header.MemberID != member.ID {
+				continue
+			}
+			m.Reachable = true
+			m.Endpoint = status.Endpoint
+			m.Leader = status.Status.Leader == member.ID
+			m.Version = status.Status.Version
+			m.DBSize = status.Status.DBSize
+			m.DBSizeInUse = status.Status.DBSizeInUse
+			m.RaftIndex = status.Status.RaftIndex
+			m.RaftAppliedIndex = status.Status.RaftAppliedIndex
+			m.RaftTerm = status.Status.RaftTerm
+			maxRaftIndex = max(maxRaftIndex, m.RaftIndex)
+		}
+		for _, alarm := range alarms.Alarms {
+			if alarm.MemberID != member.ID {
+				continue
+			}
+			name, ok := etcdAlarmTypes[alarm.Alarm]
+			if !ok {
+				name = strconv.Itoa(int(alarm.Alarm))
+			}
+			m.Alarms = append(m.Alarms, name)
+		}
+		diagnostics.Members = append(diagnostics.Members, m)
+	}
+	for i := range diagnostics.Members {
+		if diagnostics.Members[i].Reachable {
+			diagnostics.Members[i].RaftIndexLag = maxRaftIndex - diagnostics.Members[i].RaftIndex
+		}
+	}
+	sort.Slice(diagnostics.Members, func(i, j int) bool { return diagnostics.Members[i].Name < diagnostics.Members[j].Name })
+
+	return diagnostics, nil
+}
+
+// readyEtcdPod returns the name of an etcd pod whose containers are all ready, to run etcdctl in
+func readyEtcdPod(pods []corev1.Pod) (string, error) {
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || len(pod.Status.ContainerStatuses) == 0 {
+			continue
+		}
+		ready := true
+		for _, container := range pod.Status.ContainerStatuses {
+			ready = ready && container.Ready
+		}
+		if ready {
+			return pod.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no etcd pod is ready to run etcdctl in")
+}
+
+// getEtcdEndpointHealth returns the health of every etcd endpoint, as seen from the etcd pod
+func getEtcdEndpointHealth(etcdctl etcdctlRunner, pod string) ([]etcdEndpointHealth, error) {
+	// etcdctl fails if any endpoint is unhealthy, but still prints the health of all of them
+	output, err := etcdctl(pod, "etcdctl endpoint health -w json 2>/dev/null; true")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the etcd endpoint health: %w", err)
+	}
+	var health []etcdEndpointHealth
+	if err := json.Unmarshal([]byte(output), &health); err != nil {
+		return nil, fmt.Errorf("failed to parse the etcd endpoint health: %w", err)
+	}
+	return health, nil
+}
+
+// etcdLeaderElectedRegex matches the raft log line of a member seeing a new leader, e.g. "raft.node: 8e9e05c52164694d
+// elected leader 91bc3c398fb3c146 at term 5"
+var etcdLeaderElectedRegex = regexp.MustCompile(`elected leader ([0-9a-f]+) at term (\d+)`)
+
+// parseEtcdLeaderChanges returns the leader elections logged by an etcd container
+func parseEtcdLeaderChanges(logs io.Reader) ([]etcdLeaderChange, error) {
+	var changes []etcdLeaderChange
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		match := etcdLeaderElectedRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		term, err := strconv.ParseUint(match[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		change := etcdLeaderChange{Leader: match[1], Term: term}
+		var entry struct {
+			Timestamp time.Time `json:"ts"`
+		}
+		if json.Unmarshal([]byte(line), &entry) == nil {
+			change.Time = entry.Timestamp
+		}
+		changes = append(changes, change)
+	}
+	return changes, scanner.Err()
+}
+
+// getEtcdLeaderChanges returns the leader elections logged by any of the etcd pods, with the leader's member ID
+// replaced by its name. Every member logs each election, so they are deduplicated by their term.
+func getEtcdLeaderChanges(ctx context.Context, clientset kubernetes.Interface, pods []corev1.Pod, members []etcdMemberStatus) ([]etcdLeaderChange, error) {
+	names := map[string]string{}
+	for _, member := range members {
+		names[fmt.Sprintf("%x", member.ID)] = member.Name
+	}
+
+	byTerm := map[uint64]etcdLeaderChange{}
+	for _, pod := range pods {
+		logs, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: "etcd"}).Stream(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the logs of %s: %w", pod.Name, err)
+		}
+		changes, err := parseEtcdLeaderChanges(logs)
+		logs.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the logs of %s: %w", pod.Name, err)
+		}
+		for _, change := range changes {
+			if name, ok := names[change.Leader]; ok {
+				change.Leader = name
+			}
+			// Keep the earliest time any member saw the election
+			if seen, ok := byTerm[change.Term]; !ok || seen.Time.IsZero() || (!change.Time.IsZero() && change.Time.Before(seen.Time)) {
+				byTerm[change.Term] = change
+			}
+		}
+	}
+
+	var changes []etcdLeaderChange
+	for _, change := range byTerm {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Term < changes[j].Term })
+	return changes, nil
+}
+
+// etcdFindings returns the problems found in the diagnostics along with the runbook to address each
+func etcdFindings(d *etcdDiagnostics, clusterID string) []string {
+	var findings []string
+	for _, member := range d.Members {
+		switch {
+		case !member.Reachable:
+			findings = append(findings, fmt.Sprintf("%s is unreachable", member.Name))
+		case member.fragmentation() >= etcdFragmentationWarnPercent && member.DBSizeInUse >= etcdFragmentationWarnInUse:
+			findings = append(findings, fmt.Sprintf("%s is %.0f%% fragmented. Run \"osdctl cluster etcd-defrag --cluster-id %s\" to reclaim the space", member.Name, member.fragmentation(), clusterID))
+		}
+		if member.RaftIndexLag >= etcdRaftLagWarn {
+			findings = append(findings, fmt.Sprintf("%s is %d raft entries behind. Check its disk and network latency", member.Name, member.RaftIndexLag))
+		}
+	}
+	if alarms := d.alarms(); len(alarms) > 0 {
+		findings = append(findings, fmt.Sprintf("Active alarms: %s. Run \"osdctl cluster etcd-defrag --cluster-id %s\", which disarms NOSPACE alarms once every member is defragmented", strings.Join(alarms, ", "), clusterID))
+	}
+	if len(d.LeaderChanges) > etcdLeaderChangesWarn {
+		findings = append(findings, fmt.Sprintf("%d leader elections were logged. Frequent elections point at slow disks or an overloaded control plane", len(d.LeaderChanges)))
+	}
+	return findings
+}
+
+// printEtcdDiagnostics prints a table of the members, the leader history, and the findings
+func printEtcdDiagnostics(w io.Writer, d *etcdDiagnostics, clusterID string) error {
+	p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	p.AddRow([]string{"MEMBER", "ENDPOINT", "LEADER", "VERSION", "DB SIZE", "IN USE", "FRAGMENTED", "RAFT INDEX", "LAG", "ALARMS"})
+	for _, member := range d.Members {
+		if !member.Reachable {
+			p.AddRow([]string{member.Name, member.Endpoint, "-", "-", "-", "-", "-", "-", "-", "UNREACHABLE"})
+			continue
+		}
+		alarms := strings.Join(member.Alarms, ",")
+		if alarms == "" {
+			alarms = "-"
+		}
+		p.AddRow([]string{
+			member.Name,
+			member.Endpoint,
+			strconv.FormatBool(member.Leader),
+			member.Version,
+			formatEtcdBytes(member.DBSize),
+			formatEtcdBytes(member.DBSizeInUse),
+			fmt.Sprintf("%.0f%%", member.fragmentation()),
+			strconv.FormatUint(member.RaftIndex, 10),
+			strconv.FormatUint(member.RaftIndexLag, 10),
+			alarms,
+		})
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nLeader elections since the etcd containers started: %d\n", len(d.LeaderChanges))
+	for _, change := range d.LeaderChanges {
+		when := "unknown time"
+		if !change.Time.IsZero() {
+			when = change.Time.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "  term %d: %s elected at %s\n", change.Term, change.Leader, when)
+	}
+
+	findings := etcdFindings(d, clusterID)
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "\nNo problems found")
+		return nil
+	}
+	fmt.Fprintln(w)
+	for _, finding := range findings {
+		fmt.Fprintf(w, "[WARN] %s\n", finding)
+	}
+	return nil
+}
+
+// formatEtcdBytes formats a size in bytes in MB, the unit etcd's quota and alerts use
+func formatEtcdBytes(size int64) string {
+	return fmt.Sprintf("%.1f MB", float64(size)/1000/1000)
+}
@@ -0,0 +1,176 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testEtcdMemberList = `{"header":{"cluster_id":1,"member_id":10},"members":[
+		{"ID":10,"name":"ip-10-0-1-1","clientURLs":["https://10.0.1.1:2379"]},
+		{"ID":11,"name":"ip-10-0-2-2","clientURLs":["https://10.0.2.2:2379"]},
+		{"ID":12,"name":"ip-10-0-3-3","clientURLs":["https://10.0.3.3:2379"]}]}`
+	testEtcdEndpointStatus = `[
+		{"Endpoint":"https://10.0.1.1:2379","Status":{"header":{"member_id":10},"version":"3.5.9","dbSize":400000000,"dbSizeInUse":150000000,"leader":11,"raftIndex":5000,"raftTerm":7,"raftAppliedIndex":5000}},
+		{"Endpoint":"https://10.0.2.2:2379","Status":{"header":{"member_id":11},"version":"3.5.9","dbSize":200000000,"dbSizeInUse":150000000,"leader":11,"raftIndex":5002,"raftTerm":7,"raftAppliedIndex":5002}},
+		{"Endpoint":"https://10.0.3.3:2379","Status":{"header":{"member_id":12},"version":"3.5.9","dbSize":200000000,"dbSizeInUse":150000000,"leader":11,"raftIndex":3000,"raftTerm":7,"raftAppliedIndex":3000}}]`
+	testEtcdHealthy = `[{"endpoint":"https://10.0.1.1:2379","health":true},{"endpoint":"https://10.0.2.2:2379","health":true},{"endpoint":"https://10.0.3.3:2379","health":true}]`
+)
+
+// fakeEtcdctl answers etcdctl commands by their prefix, and records the pod and command of every call
+type fakeEtcdctl struct {
+	outputs map[string][]string
+	calls   []string
+}
+
+func (f *fakeEtcdctl) run(pod, command string) (string, error) {
+	f.calls = append(f.calls, pod+": "+command)
+	for prefix, outputs := range f.outputs {
+		if !strings.HasPrefix(command, prefix) {
+			continue
+		}
+		output := outputs[0]
+		// The last output is repeated for further calls
+		if len(outputs) > 1 {
+			f.outputs[prefix] = outputs[1:]
+		}
+		return output, nil
+	}
+	return "", fmt.Errorf("unexpected command %q", command)
+}
+
+func newFakeEtcdctl(alarms string) *fakeEtcdctl {
+	return &fakeEtcdctl{outputs: map[string][]string{
+		"etcdctl member list":     {testEtcdMemberList},
+		"etcdctl endpoint status": {testEtcdEndpointStatus},
+		"etcdctl endpoint health": {testEtcdHealthy},
+		"etcdctl alarm list":      {alarms},
+		"etcdctl alarm disarm":    {""},
+		"unset ETCDCTL_ENDPOINTS": {"Finished defragmenting etcd member[https://localhost:2379]\n"},
+	}}
+}
+
+func TestGetEtcdDiagnostics(t *testing.T) {
+	etcdctl := newFakeEtcdctl(`{"header":{},"alarms":[{"memberID":10,"alarm":1}]}`)
+
+	diagnostics, err := getEtcdDiagnostics(etcdctl.run, "etcd-ip-10-0-1-1")
+	require.NoError(t, err)
+	require.Len(t, diagnostics.Members, 3)
+
+	first := diagnostics.Members[0]
+	assert.Equal(t, "ip-10-0-1-1", first.Name)
+	assert.True(t, first.Reachable)
+	assert.False(t, first.Leader)
+	assert.InDelta(t, 62.5, first.fragmentation(), 0.01)
+	assert.Equal(t, uint64(2), first.RaftIndexLag)
+	assert.Equal(t, []string{"NOSPACE"}, first.Alarms)
+
+	assert.True(t, diagnostics.Members[1].Leader)
+	assert.Equal(t, "ip-10-0-2-2", diagnostics.leader().Name)
+	assert.Equal(t, uint64(2002), diagnostics.Members[2].RaftIndexLag)
+
+	findings := etcdFindings(diagnostics, "cluster-id")
+	require.Len(t, findings, 3)
+	assert.Contains(t, findings[0], "ip-10-0-1-1 is 62% fragmented")
+	assert.Contains(t, findings[1], "ip-10-0-3-3 is 2002 raft entries behind")
+	assert.Contains(t, findings[2], "NOSPACE on ip-10-0-1-1")
+}
+
+func TestGetEtcdDiagnosticsUnreachableMember(t *testing.T) {
+	etcdctl := newFakeEtcdctl(`{"header":{}}`)
+	etcdctl.outputs["etcdctl endpoint status"] = []string{testEtcdEndpointStatus[:strings.LastIndex(testEtcdEndpointStatus, ",\n")] + "]"}
+
+	diagnostics, err := getEtcdDiagnostics(etcdctl.run, "etcd-ip-10-0-1-1")
+	require.NoError(t, err)
+	assert.False(t, diagnostics.Members[2].Reachable)
+	assert.Equal(t, "https://10.0.3.3:2379", diagnostics.Members[2].Endpoint)
+
+	var out bytes.Buffer
+	require.NoError(t, printEtcdDiagnostics(&out, diagnostics, "cluster-id"))
+	assert.Contains(t, out.String(), "UNREACHABLE")
+	assert.Contains(t, out.String(), "ip-10-0-3-3 is unreachable")
+}
+
+func TestParseEtcdLeaderChanges(t *testing.T) {
+	logs := strings.Join([]string{
+		`{"level":"info","ts":"2024-06-01T10:00:00.000Z","logger":"raft","msg":"raft.node: a elected leader b at term 6"}`,
+		`{"level":"info","ts":"2024-06-01T10:00:01.000Z","msg":"published local member to cluster through raft"}`,
+		`raft.node: a elected leader c at term 7`,
+	}, "\n")
+
+	changes, err := parseEtcdLeaderChanges(strings.NewReader(logs))
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, etcdLeaderChange{Time: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC), Leader: "b", Term: 6}, changes[0])
+	assert.Equal(t, "c", changes[1].Leader)
+	assert.True(t, changes[1].Time.IsZero())
+}
+
+func newTestEtcdDefragOptions(etcdctl *fakeEtcdctl) (*etcdDefragOptions, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &etcdDefragOptions{
+		clusterID:     "cluster-id",
+		healthTimeout: time.Minute,
+		out:           out,
+		etcdctl:       etcdctl.run,
+		confirm:       func() bool { return true },
+	}, out
+}
+
+func TestEtcdDefrag(t *testing.T) {
+	etcdctl := newFakeEtcdctl(`{"header":{},"alarms":[{"memberID":10,"alarm":1}]}`)
+	opts, out := newTestEtcdDefragOptions(etcdctl)
+
+	require.NoError(t, opts.defrag(context.Background(), "etcd-ip-10-0-1-1"))
+
+	var defrags []string
+	for _, call := range etcdctl.calls {
+		if strings.HasSuffix(call, etcdDefragCmd) {
+			defrags = append(defrags, strings.TrimSuffix(call, ": "+etcdDefragCmd))
+		}
+	}
+	// The leader is defragmented last
+	assert.Equal(t, []string{"etcd-ip-10-0-1-1", "etcd-ip-10-0-3-3", "etcd-ip-10-0-2-2"}, defrags)
+	assert.Contains(t, etcdctl.calls, "etcd-ip-10-0-1-1: etcdctl alarm disarm")
+	assert.Contains(t, out.String(), "The etcd members have been defragmented")
+}
+
+func TestEtcdDefragStopsWhenUnhealthy(t *testing.T) {
+	unhealthy := `[{"endpoint":"https://10.0.1.1:2379","health":false,"error":"context deadline exceeded"}]`
+	etcdctl := newFakeEtcdctl(`{"header":{}}`)
+	etcdctl.outputs["etcdctl endpoint health"] = []string{testEtcdHealthy, unhealthy}
+	opts, _ := newTestEtcdDefragOptions(etcdctl)
+	opts.healthTimeout = 0
+
+	err := opts.defrag(context.Background(), "etcd-ip-10-0-1-1")
+	require.ErrorContains(t, err, "etcd didn't recover after defragmenting ip-10-0-1-1")
+
+	var defrags int
+	for _, call := range etcdctl.calls {
+		if strings.HasSuffix(call, etcdDefragCmd) {
+			defrags++
+		}
+	}
+	assert.Equal(t, 1, defrags)
+	assert.NotContains(t, strings.Join(etcdctl.calls, "\n"), "alarm disarm")
+}
+
+func TestEtcdDefragRefusesWhenUnhealthy(t *testing.T) {
+	etcdctl := newFakeEtcdctl(`{"header":{}}`)
+	etcdctl.outputs["etcdctl endpoint health"] = []string{`[{"endpoint":"https://10.0.3.3:2379","health":false}]`}
+	opts, _ := newTestEtcdDefragOptions(etcdctl)
+	opts.confirm = func() bool {
+		t.Fatal("the defrag shouldn't be confirmed")
+		return false
+	}
+
+	err := opts.defrag(context.Background(), "etcd-ip-10-0-1-1")
+	assert.ErrorContains(t, err, "refusing to defragment: etcd endpoints are unhealthy: https://10.0.3.3:2379")
+}
@@ -30,12 +30,6 @@ const (
 	EtcdLabelSelector       = "k8s-app=etcd"
 )
 
-var etcdctlCmd = []string{
-	"etcdctl endpoint status -w table",
-	"etcdctl endpoint health -w table",
-	"etcdctl member list -w table",
-}
-
 type LogCapture struct {
 	buffer bytes.Buffer
 }
@@ -58,9 +52,13 @@ type etcdHealthCheckOptions struct {
 func newCmdEtcdHealthCheck() *cobra.Command {
 	opts := etcdHealthCheckOptions{}
 	cmd := &cobra.Command{
-		Use:               "etcd-health-check --cluster-id <cluster-id> --reason <reason for escalation>",
-		Short:             "Checks the etcd components and member health",
-		Long:              `Checks etcd component health status for member replacement`,
+		Use:   "etcd-health-check --cluster-id <cluster-id> --reason <reason for escalation>",
+		Short: "Checks the etcd components and member health",
+		Long: `Checks etcd component health status for member replacement
+
+  Besides the control plane nodes, etcd pods, and member health, prints the database size, fragmentation, active alarms,
+  and raft index lag of each member along with the leader elections logged since the etcd containers started, and
+  points at the runbook for any problem found.`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -108,13 +106,25 @@ func EtcdHealthCheck(opts etcdHealthCheckOptions) error {
 		return err
 	}
 
-	for _, v := range etcdctlCmd {
-		output, err := Etcdctlhealth(kconfig, clientset, v, podlist.Items[0].Name)
-		if err != nil {
-			fmt.Println(err)
-		}
-		fmt.Printf("$ %s\n", v)
-		fmt.Println(output)
+	pod, err := readyEtcdPod(podlist.Items)
+	if err != nil {
+		return err
+	}
+	diagnostics, err := getEtcdDiagnostics(newEtcdctlRunner(kconfig, clientset), pod)
+	if err != nil {
+		return err
+	}
+	diagnostics.LeaderChanges, err = getEtcdLeaderChanges(context.TODO(), clientset, podlist.Items, diagnostics.Members)
+	if err != nil {
+		// The leader history is only informational, so the diagnostics are still printed without it
+		fmt.Printf("[WARN] Failed to read the leader history: %v\n", err)
+	}
+
+	fmt.Println("+----------------------------------------------------------------+")
+	fmt.Println("|               ETCD MEMBER DIAGNOSTICS                          |")
+	fmt.Println("+----------------------------------------------------------------+")
+	if err := printEtcdDiagnostics(os.Stdout, diagnostics, opts.clusterID); err != nil {
+		return err
 	}
 
 	if unhealthyMember != "" {
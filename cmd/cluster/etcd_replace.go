@@ -3,13 +3,18 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/openshift/osdctl/pkg/workflow"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -19,9 +24,22 @@ import (
 )
 
 type etcdOptions struct {
-	nodeId    string
-	reason    string
-	clusterID string
+	nodeId      string
+	reason      string
+	clusterID   string
+	dryRun      bool
+	joinTimeout time.Duration
+
+	workflow workflow.Options
+
+	kubeCli   client.Client
+	kconfig   *rest.Config
+	clientset *kubernetes.Clientset
+	etcdctl   etcdctlRunner
+
+	out          io.Writer
+	pollInterval time.Duration
+	confirm      func() bool
 }
 
 // Secrets List
@@ -39,20 +57,42 @@ const (
 )
 
 func newCmdEtcdMemberReplacement() *cobra.Command {
-	opts := &etcdOptions{}
+	opts := &etcdOptions{
+		out:          os.Stdout,
+		pollInterval: 15 * time.Second,
+		confirm:      utils.ConfirmPrompt,
+	}
 	replaceCmd := &cobra.Command{
-		Use:               "etcd-member-replace --cluster-id <cluster-identifier>",
-		Short:             "Replaces an unhealthy etcd node",
-		Long:              `Replaces an unhealthy ectd node using the member id provided`,
+		Use:   "etcd-member-replace --cluster-id <cluster-identifier>",
+		Short: "Replaces an unhealthy etcd node",
+		Long: `Replaces an unhealthy ectd node using the member id provided
+
+  Before anything is changed, pre-flight checks confirm that the member on the node is unhealthy, that every other
+  member is healthy, and that etcd keeps quorum once the member is removed. Once the etcd pods are redeployed, the
+  command waits for the new member to join and verifies that the quorum guard override was reverted.
+
+  The replacement is run as a sequence of checkpointed steps. If a step fails, fix the problem and re-run the command
+  with --resume to continue from the failed step, or with --rollback to undo the completed steps.`,
+		Example: `  # Print the steps of the replacement
+  osdctl cluster etcd-member-replace --cluster-id ${CLUSTER_ID} --node ${NODE} --reason OHSS-1234 --plan
+
+  # Run the pre-flight checks and print every etcdctl command, patch, and secret deletion without changing anything
+  osdctl cluster etcd-member-replace --cluster-id ${CLUSTER_ID} --node ${NODE} --reason OHSS-1234 --dry-run
+
+  # Continue a replacement which failed partway
+  osdctl cluster etcd-member-replace --cluster-id ${CLUSTER_ID} --node ${NODE} --reason OHSS-1234 --resume`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(opts.EtcdReplaceMember())
 		},
 	}
+	opts.workflow.AddFlags(replaceCmd.Flags())
 	replaceCmd.Flags().StringVar(&opts.clusterID, "cluster-id", "", "Provide internal Cluster ID")
 	replaceCmd.Flags().StringVar(&opts.nodeId, "node", "", "Node ID (required)")
 	replaceCmd.Flags().StringVar(&opts.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usually an OHSS or PD ticket)")
+	replaceCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Run the pre-flight checks and print the changes the replacement would make without making them")
+	replaceCmd.Flags().DurationVar(&opts.joinTimeout, "join-timeout", 20*time.Minute, "How long to wait for the new etcd member to join once the etcd pods are redeployed")
 	replaceCmd.MarkFlagRequired("cluster-id")
 	replaceCmd.MarkFlagRequired("node")
 	replaceCmd.MarkFlagRequired("reason")
@@ -60,113 +100,349 @@ func newCmdEtcdMemberReplacement() *cobra.Command {
 }
 
 func (opts *etcdOptions) EtcdReplaceMember() error {
-	kubeCli, kconfig, clientset, err := common.GetKubeConfigAndClient(opts.clusterID, opts.reason, fmt.Sprintf("Replacing unhealthy etcd node %s using osdctl", opts.nodeId))
+	if opts.nodeId == "" {
+		return fmt.Errorf("node name cannot be blank. Please provide node using --node flag")
+	}
+	if err := opts.workflow.Validate(); err != nil {
+		return err
+	}
+	if opts.dryRun && (opts.workflow.Resume || opts.workflow.Plan || opts.workflow.Rollback) {
+		return fmt.Errorf("--dry-run cannot be used with --resume, --plan, or --rollback")
+	}
+
+	store, err := workflow.DefaultStore()
 	if err != nil {
 		return err
 	}
+	w, err := workflow.New(store, "etcd-member-replace", opts.clusterID, opts.out)
+	if err != nil {
+		return err
+	}
+	opts.addSteps(w)
 
-	listOptions := metav1.ListOptions{
-		LabelSelector: EtcdLabelSelector,
+	if !opts.workflow.Plan {
+		opts.kubeCli, opts.kconfig, opts.clientset, err = common.GetKubeConfigAndClient(opts.clusterID, opts.reason, fmt.Sprintf("Replacing unhealthy etcd node %s using osdctl", opts.nodeId))
+		if err != nil {
+			return err
+		}
+		opts.etcdctl = newEtcdctlRunner(opts.kconfig, opts.clientset)
 	}
 
-	if opts.nodeId == "" {
-		return fmt.Errorf("node name cannot be blank. Please provide node using --node flag")
+	if opts.dryRun {
+		return opts.printDryRun(context.TODO())
 	}
 
-	// Get the etcd pods
-	pods, err := clientset.CoreV1().Pods(EtcdNamespaceName).List(context.TODO(), listOptions)
-	if err != nil {
+	if err := opts.workflow.Execute(context.TODO(), w); err != nil {
 		return err
 	}
+	if !opts.workflow.Plan && !opts.workflow.Rollback {
+		fmt.Fprintln(opts.out, "The etcd member has been successfully replaced and has rejoined the cluster.")
+	}
+
+	return nil
+}
+
+// podName returns the name of the etcd pod of the member being replaced
+func (opts *etcdOptions) podName() string {
+	return "etcd-" + opts.nodeId
+}
+
+// secretNames returns the names of the secrets of the member being replaced, which are recreated by the etcd operator
+func (opts *etcdOptions) secretNames() []string {
+	var names []string
+	for _, secret := range secrets {
+		names = append(names, secret+opts.nodeId)
+	}
+	return names
+}
+
+// addSteps adds the steps replacing the unhealthy etcd member to w
+func (opts *etcdOptions) addSteps(w *workflow.Workflow) {
+	w.AddStep(workflow.Step{
+		Name:        "check-member-unhealthy",
+		Description: fmt.Sprintf("Check that the etcd member on %s is the only unhealthy member and quorum survives its removal", opts.nodeId),
+		Do: func(ctx context.Context) error {
+			_, _, err := opts.preflight(ctx)
+			return err
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "remove-member",
+		Description: fmt.Sprintf("Remove the etcd member on %s", opts.nodeId),
+		Do:          opts.removeEtcdMember,
+		Verify:      opts.memberRemoved,
+	})
+	w.AddStep(workflow.Step{
+		Name:        "disable-quorum-guard",
+		Description: "Turn the quorum guard off",
+		Do: func(ctx context.Context) error {
+			return patchEtcd(opts.kubeCli, EtcdQuorumTurnOffPatch)
+		},
+		Rollback: func(ctx context.Context) error {
+			return patchEtcd(opts.kubeCli, EtcdQuorumTurnOnPatch)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "delete-secrets",
+		Description: "Delete the secrets of the unhealthy etcd member",
+		Do: func(ctx context.Context) error {
+			return opts.removeEtcdSecrets(opts.clientset)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "force-redeployment",
+		Description: "Force etcd redeployment",
+		Do: func(ctx context.Context) error {
+			timeStamp := time.Now().Format(time.RFC3339Nano)
+			return patchEtcd(opts.kubeCli, fmt.Sprintf(EtcdForceRedeployPatch, timeStamp))
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "wait-member-joined",
+		Description: fmt.Sprintf("Wait for the new etcd member on %s to join and every member to be healthy", opts.nodeId),
+		Do:          opts.waitMemberJoined,
+	})
+	w.AddStep(workflow.Step{
+		Name:        "enable-quorum-guard",
+		Description: "Turn the quorum guard back on",
+		Do: func(ctx context.Context) error {
+			return patchEtcd(opts.kubeCli, EtcdQuorumTurnOnPatch)
+		},
+		Verify: opts.overrideReverted,
+	})
+}
+
+// etcdctlPod returns the name of a ready etcd pod to run etcdctl in. The pod of the member being replaced is never
+// ready, so etcdctl runs against a healthy member.
+func (opts *etcdOptions) etcdctlPod(ctx context.Context) (string, error) {
+	pods := &corev1.PodList{}
+	if err := opts.kubeCli.List(ctx, pods, client.InNamespace(EtcdNamespaceName), client.MatchingLabels{EtcdPodMatchLabelName: EtcdPodMatchValueName}); err != nil {
+		return "", err
+	}
+	var candidates []corev1.Pod
 	for _, pod := range pods.Items {
-		for _, c := range pod.Status.ContainerStatuses {
-			if c.State.Waiting != nil && (c.State.Waiting.Reason == "CrashLoopBackOff" || c.State.Waiting.Reason == "Error") {
-				podName := "etcd-" + opts.nodeId
-				if podName != pod.ObjectMeta.Name {
-					return fmt.Errorf("the etcd member seems to be healthy or is not present. Please run health-check again")
-				}
-				err := opts.ReplaceEtcdMember(kubeCli, kconfig, clientset, pod.ObjectMeta.Name)
-				if err != nil {
-					return err
-				}
-				return nil
-			}
+		if pod.Name != opts.podName() {
+			candidates = append(candidates, pod)
 		}
 	}
-	return fmt.Errorf("none of the etcd members seems to be unhealthy. Please verify once again")
+	return readyEtcdPod(candidates)
 }
 
-func (opts *etcdOptions) ReplaceEtcdMember(kubeCli client.Client, kconfig *rest.Config, clientset *kubernetes.Clientset, pod string) error {
-	// Remove the etcd member
-	fmt.Printf("[INFO] Starting etcd member replacement for pod %s", pod)
-	err := opts.removeEtcdMember(kconfig, clientset, pod)
+// preflight checks that the replacement is safe, returning the etcd pod to run etcdctl in along with the member being
+// replaced
+func (opts *etcdOptions) preflight(ctx context.Context) (string, *etcdMemberStatus, error) {
+	pod, err := opts.etcdctlPod(ctx)
 	if err != nil {
-		return err
+		return "", nil, err
 	}
-
-	// Turn off the quorum guard
-	fmt.Println("[INFO] Turning the quorum guard off")
-	err = patchEtcd(kubeCli, EtcdQuorumTurnOffPatch)
+	diagnostics, err := getEtcdDiagnostics(opts.etcdctl, pod)
 	if err != nil {
-		fmt.Println("[ERROR] Could not Turn the quorum guard off. Refer error below")
-		return err
+		return "", nil, err
+	}
+	health, err := getEtcdEndpointHealth(opts.etcdctl, pod)
+	if err != nil {
+		return "", nil, err
+	}
+	member, err := checkEtcdReplacement(diagnostics, health, opts.nodeId)
+	if err != nil {
+		return "", nil, err
 	}
 
-	// Delete the secrets for the unhealthy etcd member
-	fmt.Println("[INFO] Deleting the secrets for the unhealthy etcd member")
-	err = opts.removeEtcdSecrets(clientset)
+	fmt.Fprintf(opts.out, "[INFO] The etcd member on %s is unhealthy, every other member is healthy, and quorum survives its removal\n", opts.nodeId)
+	return pod, member, nil
+}
+
+// checkEtcdReplacement returns the member on the node if it's unhealthy, every other member is healthy, and the other
+// members keep quorum once it's removed
+func checkEtcdReplacement(d *etcdDiagnostics, health []etcdEndpointHealth, node string) (*etcdMemberStatus, error) {
+	healthyEndpoints := map[string]bool{}
+	for _, endpoint := range health {
+		healthyEndpoints[endpoint.Endpoint] = endpoint.Health
+	}
+	healthy := func(m etcdMemberStatus) bool {
+		return m.Reachable && healthyEndpoints[m.Endpoint]
+	}
+
+	var target *etcdMemberStatus
+	var unhealthyOthers []string
+	healthyVoters, voters := 0, 0
+	for i, member := range d.Members {
+		if !member.Learner {
+			voters++
+		}
+		if member.Name == node {
+			target = &d.Members[i]
+			continue
+		}
+		if !healthy(member) {
+			unhealthyOthers = append(unhealthyOthers, member.Name)
+			continue
+		}
+		if !member.Learner {
+			healthyVoters++
+		}
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("no etcd member found for node %s", node)
+	}
+	if healthy(*target) {
+		return nil, fmt.Errorf("the etcd member on %s is healthy. Run \"osdctl cluster etcd-health-check\" to find the unhealthy member", node)
+	}
+	if len(unhealthyOthers) > 0 {
+		return nil, fmt.Errorf("other etcd members are unhealthy too: %s. Replacing %s could lose quorum", strings.Join(unhealthyOthers, ", "), node)
+	}
+	// The member is removed while the others have quorum, and they need to keep it as the smaller cluster
+	if remaining := voters - 1; healthyVoters < remaining/2+1 {
+		return nil, fmt.Errorf("only %d of the %d remaining etcd members are healthy, which is not a quorum", healthyVoters, remaining)
+	}
+	if healthyVoters < voters/2+1 {
+		return nil, fmt.Errorf("only %d of %d etcd members are healthy, which is not a quorum to remove a member with", healthyVoters, voters)
+	}
+
+	return target, nil
+}
+
+// printDryRun runs the pre-flight checks and prints every change the replacement would make
+func (opts *etcdOptions) printDryRun(ctx context.Context) error {
+	pod, member, err := opts.preflight(ctx)
 	if err != nil {
-		fmt.Println("[ERROR] Could not delete the secrets for the unhealthy etcd member. Refer error below")
 		return err
 	}
 
-	// Force etcd redeployment.
-	fmt.Println("[INFO] Forcing etcd redeployment")
-	timeStamp := time.Now().Format(time.RFC3339Nano)
-	patch := fmt.Sprintf(EtcdForceRedeployPatch, timeStamp)
-	err = patchEtcd(kubeCli, patch)
+	fmt.Fprintf(opts.out, "\nReplacing the etcd member on %s would:\n", opts.nodeId)
+	fmt.Fprintf(opts.out, "  1. Run in pod %s/%s: etcdctl member remove %x\n", EtcdNamespaceName, pod, member.ID)
+	fmt.Fprintf(opts.out, "  2. Patch etcd/cluster: %s\n", EtcdQuorumTurnOffPatch)
+	for _, name := range opts.secretNames() {
+		fmt.Fprintf(opts.out, "  3. Delete secret %s/%s\n", EtcdNamespaceName, name)
+	}
+	fmt.Fprintf(opts.out, "  4. Patch etcd/cluster: %s\n", fmt.Sprintf(EtcdForceRedeployPatch, "<timestamp>"))
+	fmt.Fprintf(opts.out, "  5. Wait up to %s for a new member on %s to join\n", opts.joinTimeout, opts.nodeId)
+	fmt.Fprintf(opts.out, "  6. Patch etcd/cluster: %s\n", EtcdQuorumTurnOnPatch)
+	fmt.Fprintln(opts.out, "This is a dry run, nothing changed.")
+
+	return nil
+}
+
+// memberID returns the etcd member id of the member being replaced, or an empty string if it isn't a member
+func (opts *etcdOptions) memberID(ctx context.Context) (string, string, error) {
+	pod, err := opts.etcdctlPod(ctx)
 	if err != nil {
-		fmt.Println("[ERROR] Could not force etcd redeployment. Refer error below")
-		return err
+		return "", "", err
+	}
+	diagnostics, err := getEtcdDiagnostics(opts.etcdctl, pod)
+	if err != nil {
+		return "", "", err
+	}
+	for _, member := range diagnostics.Members {
+		if member.Name == opts.nodeId {
+			return fmt.Sprintf("%x", member.ID), pod, nil
+		}
 	}
 
-	// Turn the quorum guard back
-	fmt.Println("[INFO] Turning the quorum guard back on")
-	err = patchEtcd(kubeCli, EtcdQuorumTurnOnPatch)
+	return "", pod, nil
+}
+
+// memberRemoved returns true once the member being replaced is no longer an etcd member
+func (opts *etcdOptions) memberRemoved(ctx context.Context) (bool, error) {
+	memberId, _, err := opts.memberID(ctx)
 	if err != nil {
-		fmt.Println("[ERROR]  Could not Turn the quorum guard back on. Refer error below")
-		return err
+		return false, err
 	}
 
-	fmt.Println("The etcd member has been successfully replaced. Please verify by running health check after few minutes.")
-	return nil
+	return memberId == "", nil
 }
 
-func (opts *etcdOptions) removeEtcdMember(kconfig *rest.Config, clientset *kubernetes.Clientset, pod string) error {
-	fmt.Println()
-	cmd := "etcdctl member list -w table | grep " + opts.nodeId + " | awk '{ print $2 }'"
-	memberId, err := Etcdctlhealth(kconfig, clientset, cmd, pod)
+func (opts *etcdOptions) removeEtcdMember(ctx context.Context) error {
+	fmt.Fprintln(opts.out)
+	memberId, pod, err := opts.memberID(ctx)
 	if err != nil {
 		return err
 	}
-	memberId = strings.TrimSpace(memberId)
-	fmt.Printf("[INFO] Replacing pod %s having member id %s.\n", pod, memberId)
+	if memberId == "" {
+		return fmt.Errorf("no etcd member found for node %s", opts.nodeId)
+	}
+	fmt.Fprintf(opts.out, "[INFO] Replacing pod %s having member id %s.\n", opts.podName(), memberId)
 
-	prompt := utils.ConfirmPrompt()
-	if !prompt {
+	if !opts.confirm() {
 		return fmt.Errorf("operation cancelled by user")
 	}
 
-	remove_cmd := "etcdctl member remove " + memberId
-	output, err := Etcdctlhealth(kconfig, clientset, remove_cmd, pod)
+	output, err := opts.etcdctl(pod, "etcdctl member remove "+memberId)
 	if err != nil {
-		fmt.Println("[ERROR] Could not replace pod. Refer error below")
+		fmt.Fprintln(opts.out, "[ERROR] Could not replace pod. Refer error below")
 		return err
 	}
-	fmt.Println(output)
+	fmt.Fprintln(opts.out, output)
 	return nil
 }
 
+// waitMemberJoined waits until a member on the node has joined as a voting member and every endpoint is healthy
+func (opts *etcdOptions) waitMemberJoined(ctx context.Context) error {
+	deadline := time.Now().Add(opts.joinTimeout)
+	for {
+		joined, err := opts.memberJoined(ctx)
+		if err != nil {
+			// The etcd pods are being redeployed, so etcdctl is expected to fail until they are back
+			fmt.Fprintf(opts.out, "[INFO] Waiting for the etcd pods: %v\n", err)
+		} else if joined {
+			fmt.Fprintf(opts.out, "[INFO] The new etcd member on %s has joined and every member is healthy\n", opts.nodeId)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the new etcd member on %s didn't join within %s", opts.nodeId, opts.joinTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opts.pollInterval):
+		}
+	}
+}
+
+// memberJoined returns true if a voting member on the node is reachable and every endpoint is healthy
+func (opts *etcdOptions) memberJoined(ctx context.Context) (bool, error) {
+	pod, err := opts.etcdctlPod(ctx)
+	if err != nil {
+		return false, err
+	}
+	diagnostics, err := getEtcdDiagnostics(opts.etcdctl, pod)
+	if err != nil {
+		return false, err
+	}
+	health, err := getEtcdEndpointHealth(opts.etcdctl, pod)
+	if err != nil {
+		return false, err
+	}
+
+	return etcdMemberJoined(diagnostics, health, opts.nodeId), nil
+}
+
+// etcdMemberJoined returns true if a voting member on the node is reachable and every endpoint is healthy
+func etcdMemberJoined(d *etcdDiagnostics, health []etcdEndpointHealth, node string) bool {
+	if len(unhealthyEtcdEndpoints(health)) > 0 {
+		return false
+	}
+	for _, member := range d.Members {
+		if member.Name == node {
+			return member.Reachable && !member.Learner
+		}
+	}
+	return false
+}
+
+// overrideReverted returns true if the etcd CR no longer has unsupported config overrides
+func (opts *etcdOptions) overrideReverted(ctx context.Context) (bool, error) {
+	etcdCR := &operatorv1.Etcd{}
+	if err := opts.kubeCli.Get(ctx, client.ObjectKey{Name: "cluster"}, etcdCR); err != nil {
+		return false, err
+	}
+	raw := string(etcdCR.Spec.UnsupportedConfigOverrides.Raw)
+
+	return raw == "" || raw == "null", nil
+}
+
 func patchEtcd(kubeCli client.Client, patch string) error {
 	etcdCR := &operatorv1.Etcd{}
 	err := kubeCli.Get(context.TODO(), client.ObjectKey{Name: "cluster"}, etcdCR)
@@ -181,10 +457,10 @@ func patchEtcd(kubeCli client.Client, patch string) error {
 }
 
 func (opts *etcdOptions) removeEtcdSecrets(clientset *kubernetes.Clientset) error {
-	for _, secret := range secrets {
-		name := secret + opts.nodeId
+	for _, name := range opts.secretNames() {
 		err := clientset.CoreV1().Secrets("openshift-etcd").Delete(context.TODO(), name, metav1.DeleteOptions{})
-		if err != nil {
+		// The secrets may already have been deleted by a previous run which failed partway
+		if err != nil && !apierrors.IsNotFound(err) {
 			return err
 		}
 	}
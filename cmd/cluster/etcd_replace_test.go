@@ -0,0 +1,129 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testEtcdMembers() *etcdDiagnostics {
+	return &etcdDiagnostics{Members: []etcdMemberStatus{
+		{Name: "master-0", ID: 10, Endpoint: "https://10.0.1.1:2379", Reachable: true, Leader: true},
+		{Name: "master-1", ID: 11, Endpoint: "https://10.0.2.2:2379", Reachable: true},
+		{Name: "master-2", ID: 12, Endpoint: "https://10.0.3.3:2379"},
+	}}
+}
+
+func testEtcdHealth(healthy ...bool) []etcdEndpointHealth {
+	endpoints := []string{"https://10.0.1.1:2379", "https://10.0.2.2:2379", "https://10.0.3.3:2379"}
+	var health []etcdEndpointHealth
+	for i, h := range healthy {
+		health = append(health, etcdEndpointHealth{Endpoint: endpoints[i], Health: h})
+	}
+	return health
+}
+
+func TestCheckEtcdReplacement(t *testing.T) {
+	member, err := checkEtcdReplacement(testEtcdMembers(), testEtcdHealth(true, true, false), "master-2")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12), member.ID)
+
+	_, err = checkEtcdReplacement(testEtcdMembers(), testEtcdHealth(true, true, false), "master-1")
+	assert.ErrorContains(t, err, "the etcd member on master-1 is healthy")
+
+	_, err = checkEtcdReplacement(testEtcdMembers(), testEtcdHealth(true, true, false), "master-3")
+	assert.ErrorContains(t, err, "no etcd member found for node master-3")
+
+	_, err = checkEtcdReplacement(testEtcdMembers(), testEtcdHealth(true, false, false), "master-2")
+	assert.ErrorContains(t, err, "other etcd members are unhealthy too: master-1")
+
+	// Two members with one of them down have no quorum to remove a member with
+	twoMembers := &etcdDiagnostics{Members: testEtcdMembers().Members[1:]}
+	_, err = checkEtcdReplacement(twoMembers, testEtcdHealth(true, true, false), "master-2")
+	assert.ErrorContains(t, err, "not a quorum")
+}
+
+func TestEtcdMemberJoined(t *testing.T) {
+	members := testEtcdMembers()
+	assert.False(t, etcdMemberJoined(members, testEtcdHealth(true, true, false), "master-2"))
+
+	members.Members[2].Reachable = true
+	assert.True(t, etcdMemberJoined(members, testEtcdHealth(true, true, true), "master-2"))
+
+	members.Members[2].Learner = true
+	assert.False(t, etcdMemberJoined(members, testEtcdHealth(true, true, true), "master-2"))
+
+	assert.False(t, etcdMemberJoined(&etcdDiagnostics{Members: members.Members[:2]}, testEtcdHealth(true, true), "master-2"))
+}
+
+func newEtcdReplaceTestClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, operatorv1.Install(scheme))
+
+	etcdPod := func(name string, ready bool) client.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: EtcdNamespaceName, Labels: map[string]string{EtcdPodMatchLabelName: EtcdPodMatchValueName}},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "etcd", Ready: ready}},
+			},
+		}
+	}
+	objs = append(objs, etcdPod("etcd-ip-10-0-3-3", false), etcdPod("etcd-ip-10-0-1-1", true))
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func TestEtcdReplaceDryRun(t *testing.T) {
+	// The member on ip-10-0-3-3 doesn't report its status
+	etcdctl := newFakeEtcdctl(`{"header":{}}`)
+	statuses := testEtcdEndpointStatus[:strings.LastIndex(testEtcdEndpointStatus, ",\n")] + "]"
+	etcdctl.outputs["etcdctl endpoint status"] = []string{statuses}
+	etcdctl.outputs["etcdctl endpoint health"] = []string{`[{"endpoint":"https://10.0.1.1:2379","health":true},{"endpoint":"https://10.0.2.2:2379","health":true},{"endpoint":"https://10.0.3.3:2379","health":false}]`}
+
+	out := &bytes.Buffer{}
+	opts := &etcdOptions{
+		nodeId:      "ip-10-0-3-3",
+		joinTimeout: 20 * time.Minute,
+		kubeCli:     newEtcdReplaceTestClient(t),
+		etcdctl:     etcdctl.run,
+		out:         out,
+	}
+
+	require.NoError(t, opts.printDryRun(context.Background()))
+	assert.Contains(t, out.String(), "Run in pod openshift-etcd/etcd-ip-10-0-1-1: etcdctl member remove c")
+	assert.Contains(t, out.String(), "Delete secret openshift-etcd/etcd-serving-metrics-ip-10-0-3-3")
+	assert.Contains(t, out.String(), EtcdQuorumTurnOffPatch)
+	assert.Contains(t, out.String(), "This is a dry run, nothing changed.")
+	for _, call := range etcdctl.calls {
+		assert.NotContains(t, call, "member remove")
+		assert.NotContains(t, call, "etcd-ip-10-0-3-3:")
+	}
+}
+
+func TestEtcdOverrideReverted(t *testing.T) {
+	etcdCR := &operatorv1.Etcd{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	etcdCR.Spec.UnsupportedConfigOverrides.Raw = []byte(`{"useUnsupportedUnsafeNonHANonProductionUnstableEtcd":true}`)
+	opts := &etcdOptions{kubeCli: newEtcdReplaceTestClient(t, etcdCR)}
+
+	reverted, err := opts.overrideReverted(context.Background())
+	require.NoError(t, err)
+	assert.False(t, reverted)
+
+	require.NoError(t, patchEtcd(opts.kubeCli, EtcdQuorumTurnOnPatch))
+	reverted, err = opts.overrideReverted(context.Background())
+	require.NoError(t, err)
+	assert.True(t, reverted)
+}
@@ -1,20 +1,159 @@
 package cluster
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
-	"log"
-	"strconv"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	configv1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	hiveinternalv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/osdctl/pkg/k8s"
 	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	healthOutputText  = "text"
+	healthOutputJSON  = "json"
+	healthOutputJUnit = "junit"
+)
+
+var healthOutputFormats = []string{healthOutputText, healthOutputJSON, healthOutputJUnit}
+
+// healthStatus is the outcome of a health check
+type healthStatus string
+
+const (
+	healthPass healthStatus = "pass"
+	healthWarn healthStatus = "warn"
+	healthFail healthStatus = "fail"
+	// healthError means the check could not be run, e.g. because of missing permissions
+	healthError healthStatus = "error"
+)
+
+// exitCode returns the exit code of the health command when this is the overall status. 1 is left to the errors
+// preventing the checks from running at all, like invalid flags, which exit 1 as with every other command
+func (s healthStatus) exitCode() int {
+	switch s {
+	case healthWarn:
+		return 2
+	case healthFail:
+		return 3
+	case healthError:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// healthCheckResult is the outcome of a single health check along with the evidence it is based on
+type healthCheckResult struct {
+	Name        string       `json:"name"`
+	Status      healthStatus `json:"status"`
+	Summary     string       `json:"summary"`
+	Evidence    []string     `json:"evidence,omitempty"`
+	Remediation string       `json:"remediation,omitempty"`
+}
+
+// healthCheck is a single check of the health check suite
+type healthCheck struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context, env *healthCheckEnv) healthCheckResult
+}
+
+// healthCheckEnv provides checks with the clients they need. Clients are only created when a check first needs them, so
+// that running a subset of checks doesn't require access to everything.
+type healthCheckEnv struct {
+	cluster *v1.Cluster
+	verbose bool
+	now     func() time.Time
+
+	newKubeClient  func() (client.Client, error)
+	newHiveClient  func() (client.Client, error)
+	newCloudClient func() (osdCloud.ClusterHealthClient, error)
+
+	kubeClient, hiveClient client.Client
+	cloudClient            osdCloud.ClusterHealthClient
+	kubeErr, hiveErr       error
+	cloudErr               error
+	kubeInit, hiveInit     bool
+	cloudInit              bool
+}
+
+func (e *healthCheckEnv) kube() (client.Client, error) {
+	if !e.kubeInit {
+		e.kubeClient, e.kubeErr = e.newKubeClient()
+		e.kubeInit = true
+	}
+	return e.kubeClient, e.kubeErr
+}
+
+func (e *healthCheckEnv) hive() (client.Client, error) {
+	if !e.hiveInit {
+		e.hiveClient, e.hiveErr = e.newHiveClient()
+		e.hiveInit = true
+	}
+	return e.hiveClient, e.hiveErr
+}
+
+func (e *healthCheckEnv) cloud() (osdCloud.ClusterHealthClient, error) {
+	if !e.cloudInit {
+		e.cloudClient, e.cloudErr = e.newCloudClient()
+		e.cloudInit = true
+	}
+	return e.cloudClient, e.cloudErr
+}
+
+func (e *healthCheckEnv) close() {
+	if e.cloudClient != nil {
+		e.cloudClient.Close()
+	}
+}
+
+// healthReport is the outcome of running the health check suite against a cluster
+type healthReport struct {
+	ClusterID string              `json:"clusterId"`
+	Name      string              `json:"name"`
+	Timestamp time.Time           `json:"timestamp"`
+	Status    healthStatus        `json:"status"`
+	Results   []healthCheckResult `json:"results"`
+}
+
+// overallStatus returns fail if any check failed, otherwise error if any check could not be run, otherwise warn if any
+// check warned
+func overallStatus(results []healthCheckResult) healthStatus {
+	status := healthPass
+	for _, result := range results {
+		switch {
+		case result.Status == healthFail:
+			return healthFail
+		case result.Status == healthError:
+			status = healthError
+		case result.Status == healthWarn && status == healthPass:
+			status = healthWarn
+		}
+	}
+
+	return status
+}
+
 // healthOptions defines the struct for running health command
 // This command requires the ocm API Token https://cloud.redhat.com/openshift/token be available in the OCM_TOKEN env variable.
 
@@ -23,25 +162,52 @@ type healthOptions struct {
 	output     string
 	verbose    bool
 	awsProfile string
+	checks     []string
+	reason     string
 }
 
-// newCmdHealth implements the health command to describe number of running instances in cluster and the expected number of nodes
+// newCmdHealth implements the health command running a suite of checks against the cluster
 func newCmdHealth() *cobra.Command {
 	ops := newHealthOptions()
 	healthCmd := &cobra.Command{
-		Use:               "health",
-		Short:             "Describes health of cluster nodes and provides other cluster vitals.",
+		Use:   "health",
+		Short: "Describes health of cluster nodes and provides other cluster vitals.",
+		Long: fmt.Sprintf(`Describes health of cluster nodes and provides other cluster vitals.
+
+  Runs a suite of health checks against the cluster, each reporting pass, warn, or fail along with the evidence it is
+  based on and a remediation hint. A check which cannot be run, e.g. because of missing permissions, reports error.
+
+  Available checks:
+%s
+  The exit code reflects the overall status: 0 if every check passed, 2 if any check warned, 3 if any check failed, and
+  4 if any check could not be run and none failed. It is 1 if the checks could not be run at all, e.g. invalid flags.`, describeHealthChecks()),
+		Example: `
+  # Run every check
+  osdctl cluster health -C ${CLUSTER_ID}
+
+  # Only check the nodes and cluster operators, and write a JUnit report
+  osdctl cluster health -C ${CLUSTER_ID} --checks nodes,cluster-operators -o junit > health.xml
+
+  # Include the checks requiring elevation, like certificate expiry
+  osdctl cluster health -C ${CLUSTER_ID} --reason OHSS-1234`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			report, err := ops.run(context.Background())
+			cmdutil.CheckErr(err)
+			if code := report.Status.exitCode(); code != 0 {
+				os.Exit(code)
+			}
 		},
 	}
 
 	healthCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
 	healthCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "C", "", "Internal Cluster ID")
 	healthCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS Profile")
+	healthCmd.Flags().StringSliceVar(&ops.checks, "checks", nil, "Comma-separated list of checks to run, by default all checks are run")
+	healthCmd.Flags().StringVarP(&ops.output, "output", "o", healthOutputText, fmt.Sprintf("Output format, one of: %s", strings.Join(healthOutputFormats, ", ")))
+	healthCmd.Flags().StringVar(&ops.reason, "reason", "", "(optional) The reason for elevating, usually an OHSS or PD ticket. Required by checks reading secrets, like certificate-expiry")
 	healthCmd.MarkFlagRequired("cluster-id")
 	return healthCmd
 }
@@ -51,154 +217,258 @@ func newHealthOptions() *healthOptions {
 }
 
 func (o *healthOptions) complete(cmd *cobra.Command, _ []string) error {
+	if _, err := selectHealthChecks(o.checks); err != nil {
+		return err
+	}
+	for _, format := range healthOutputFormats {
+		if o.output == format {
+			return nil
+		}
+	}
 
-	return nil
+	return fmt.Errorf("unsupported output format %q, must be one of: %s", o.output, strings.Join(healthOutputFormats, ", "))
 }
 
-type ClusterHealthCondensedObject struct {
-	ID       string   `yaml:"ID"`
-	Name     string   `yaml:"Name"`
-	Provider string   `yaml:"Provider"`
-	AZs      []string `yaml:"AZs"`
-	Expected struct {
-		Master int         `yaml:"Master"`
-		Infra  int         `yaml:"Infra"`
-		Worker interface{} `yaml:"Worker"`
-	} `yaml:"Expected nodes"`
-	Actual struct {
-		Total          int `yaml:"Total"`
-		Stopped        int `yaml:"Stopped"`
-		RunningMasters int `yaml:"Running Masters"`
-		RunningInfra   int `yaml:"Running Infra"`
-		RunningWorker  int `yaml:"Running Worker"`
-	} `yaml:"Actual nodes"`
+// describeHealthChecks lists the available checks for the command's help
+func describeHealthChecks() string {
+	var sb strings.Builder
+	for _, check := range healthChecks {
+		fmt.Fprintf(&sb, "    %-22s %s\n", check.Name, check.Description)
+	}
+
+	return sb.String()
+}
+
+// selectHealthChecks returns the checks with the names, or every check if no names are provided
+func selectHealthChecks(names []string) ([]healthCheck, error) {
+	if len(names) == 0 {
+		return healthChecks, nil
+	}
+
+	var selected []healthCheck
+	for _, name := range names {
+		found := false
+		for _, check := range healthChecks {
+			if check.Name == strings.TrimSpace(name) {
+				selected = append(selected, check)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var available []string
+			for _, check := range healthChecks {
+				available = append(available, check.Name)
+			}
+			return nil, fmt.Errorf("unknown check %q, must be one of: %s", name, strings.Join(available, ", "))
+		}
+	}
+
+	return selected, nil
 }
 
-func (o *healthOptions) run() error {
+func (o *healthOptions) run(ctx context.Context) (*healthReport, error) {
+	checks, err := selectHealthChecks(o.checks)
+	if err != nil {
+		return nil, err
+	}
 
 	ocmClient, err := utils.CreateConnection()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer ocmClient.Close()
 
-	clusterResp, err := ocmClient.ClustersMgmt().V1().Clusters().Cluster(o.clusterID).Get().Send()
+	cluster, err := utils.GetClusterAnyStatus(ocmClient, o.clusterID)
 	if err != nil {
-		fmt.Println(err)
-		return err
+		return nil, err
 	}
-	cluster := clusterResp.Body()
-	healthObject := createHealthObject(cluster)
 
-	if cluster.Nodes().AutoscaleCompute().MinReplicas() != 0 {
-		min := strconv.Itoa(cluster.Nodes().AutoscaleCompute().MinReplicas())
-		max := strconv.Itoa(cluster.Nodes().AutoscaleCompute().MaxReplicas())
-		healthObject.Expected.Worker = string(fmt.Sprintf("%v - %v", min, max))
+	env := &healthCheckEnv{
+		cluster: cluster,
+		verbose: o.verbose,
+		now:     time.Now,
+		newKubeClient: func() (client.Client, error) {
+			return newHealthKubeClient(cluster.ID(), o.reason)
+		},
+		newHiveClient: func() (client.Client, error) {
+			hive, err := utils.GetHiveCluster(cluster.ID())
+			if err != nil {
+				return nil, err
+			}
+			return newHealthKubeClient(hive.ID(), "")
+		},
+		newCloudClient: func() (osdCloud.ClusterHealthClient, error) {
+			var cloudClient osdCloud.ClusterHealthClient
+			switch cluster.CloudProvider().ID() {
+			case "gcp":
+				cloudClient, err = osdCloud.NewGcpCluster(ocmClient, cluster.ID())
+			case "aws":
+				cloudClient, err = osdCloud.NewAwsCluster(ocmClient, cluster.ID(), o.awsProfile)
+			default:
+				return nil, fmt.Errorf("unknown cloud provider found: %s", cluster.CloudProvider().ID())
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := cloudClient.Login(); err != nil {
+				cloudClient.Close()
+				return nil, err
+			}
+			return cloudClient, nil
+		},
 	}
-	if cluster.Nodes().Compute() != 0 {
-		healthObject.Expected.Worker = int(cluster.Nodes().Compute())
+	defer env.close()
+
+	report := runHealthChecks(ctx, env, checks)
+	if err := report.write(os.Stdout, o.output); err != nil {
+		return nil, err
 	}
 
-	runningMasters := 0
-	runningInfra := 0
-	runningWorkers := 0
-	totalStopped := 0
-	totalCluster := 0
+	return report, nil
+}
 
-	var clusterHealthClient osdCloud.ClusterHealthClient
-	var ownedLabel string
-	infraID := cluster.InfraID()
-	if cluster.CloudProvider().ID() == "gcp" {
-		clusterHealthClient, err = osdCloud.NewGcpCluster(ocmClient, o.clusterID)
-		if err != nil {
-			return err
+// newHealthKubeClient returns a client able to read everything the checks inspect, elevated if a reason is provided
+func newHealthKubeClient(clusterID, reason string) (client.Client, error) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme,
+		certificatesv1.AddToScheme,
+		policyv1.AddToScheme,
+		configv1.Install,
+		mcfgv1.Install,
+		operatorv1.Install,
+		hiveinternalv1alpha1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			return nil, err
 		}
-		ownedLabel = "kubernetes-io-cluster-" + infraID
-		defer clusterHealthClient.Close()
-	} else if cluster.CloudProvider().ID() == "aws" {
-		clusterHealthClient, err = osdCloud.NewAwsCluster(ocmClient, o.clusterID, o.awsProfile)
-		if err != nil {
-			return err
-		}
-		ownedLabel = "kubernetes.io/cluster/" + infraID
-		defer clusterHealthClient.Close()
-	} else {
-		return errors.New(fmt.Sprintf("Unknown cloud provider found: %s", cluster.CloudProvider().ID()))
 	}
-	err = clusterHealthClient.Login()
-	if err != nil {
-		return err
+
+	if reason != "" {
+		return k8s.NewAsBackplaneClusterAdmin(clusterID, client.Options{Scheme: scheme}, reason, "Running osdctl cluster health checks")
 	}
-	for _, zone := range clusterHealthClient.GetAZs() {
-		instances, err := clusterHealthClient.GetAllVirtualMachines(zone)
-		if err != nil {
-			return err
-		}
-		for _, instance := range instances {
-			name := instance.Name
-			state := instance.State
-			labels := instance.Labels
-			belongsToCluster := false
-			for label := range labels {
-				if label == ownedLabel {
-					belongsToCluster = true
-				}
-			}
-			if !belongsToCluster {
-				if o.verbose {
-					log.Printf("Skipping a machine not belonging to the cluster: %s\n", name)
-				}
-				continue
-			}
-			totalCluster += 1
-			if state != "running" {
-				totalStopped += 1
-			} else {
-				if strings.HasPrefix(name, infraID) && strings.Contains(name, "master") {
-					runningMasters += 1
-				} else if strings.HasPrefix(name, infraID) && strings.Contains(name, "infra") {
-					runningInfra += 1
-				} else if strings.HasPrefix(name, infraID) && strings.Contains(name, "worker") {
-					runningWorkers += 1
-				}
-			}
-		}
+	return k8s.New(clusterID, client.Options{Scheme: scheme})
+}
+
+// runHealthChecks runs the checks in order against the cluster
+func runHealthChecks(ctx context.Context, env *healthCheckEnv, checks []healthCheck) *healthReport {
+	report := &healthReport{
+		ClusterID: env.cluster.ID(),
+		Name:      env.cluster.Name(),
+		Timestamp: env.now().UTC(),
 	}
+	for _, check := range checks {
+		result := check.Run(ctx, env)
+		result.Name = check.Name
+		report.Results = append(report.Results, result)
+	}
+	report.Status = overallStatus(report.Results)
 
-	healthObject.Actual.Stopped = totalStopped
-	healthObject.Actual.RunningMasters = runningMasters
-	healthObject.Actual.RunningInfra = runningInfra
-	healthObject.Actual.RunningWorker = runningWorkers
-	healthObject.Actual.Total = totalCluster
+	return report
+}
 
-	if err != nil {
-		log.Fatalf("Error getting instances %v", err)
+// write renders the report in the requested format to w
+func (r *healthReport) write(w io.Writer, format string) error {
+	switch format {
+	case healthOutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case healthOutputJUnit:
+		return r.writeJUnit(w)
+	default:
+		return r.writeText(w)
+	}
+}
+
+func (r *healthReport) writeText(w io.Writer) error {
+	fmt.Fprintf(w, "Health of cluster %s (%s): %s\n\n", r.Name, r.ClusterID, strings.ToUpper(string(r.Status)))
+
+	p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	p.AddRow([]string{"CHECK", "STATUS", "SUMMARY"})
+	for _, result := range r.Results {
+		p.AddRow([]string{result.Name, strings.ToUpper(string(result.Status)), result.Summary})
+	}
+	if err := p.Flush(); err != nil {
 		return err
 	}
 
-	healthOutput, err := yaml.Marshal(&healthObject)
-	if err != nil {
-		log.Fatalf("error: %v", err)
+	for _, result := range r.Results {
+		if result.Status == healthPass {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (%s): %s\n", result.Name, result.Status, result.Summary)
+		for _, evidence := range result.Evidence {
+			fmt.Fprintf(w, "  - %s\n", evidence)
+		}
+		if result.Remediation != "" {
+			fmt.Fprintf(w, "  Remediation: %s\n", result.Remediation)
+		}
 	}
-	fmt.Printf("\n \n")
-	fmt.Println(string(healthOutput))
 
 	return nil
 }
 
-func createHealthObject(cluster *v1.Cluster) *ClusterHealthCondensedObject {
+type healthJUnitTestSuite struct {
+	XMLName   xml.Name              `xml:"testsuite"`
+	Name      string                `xml:"name,attr"`
+	Tests     int                   `xml:"tests,attr"`
+	Failures  int                   `xml:"failures,attr"`
+	Errors    int                   `xml:"errors,attr"`
+	Timestamp string                `xml:"timestamp,attr"`
+	TestCases []healthJUnitTestCase `xml:"testcase"`
+}
 
-	var healthObject ClusterHealthCondensedObject
+type healthJUnitTestCase struct {
+	Name      string              `xml:"name,attr"`
+	ClassName string              `xml:"classname,attr"`
+	Failure   *healthJUnitMessage `xml:"failure,omitempty"`
+	Error     *healthJUnitMessage `xml:"error,omitempty"`
+	SystemOut string              `xml:"system-out,omitempty"`
+}
 
-	cloudProvider := cluster.CloudProvider().ID()
-	cloudProviderMessage := strings.ToUpper(cloudProvider)
+type healthJUnitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
 
-	healthObject.ID = cluster.ID()
-	healthObject.Name = cluster.Name()
-	healthObject.Provider = cloudProviderMessage
-	healthObject.AZs = cluster.Nodes().AvailabilityZones()
-	healthObject.Expected.Infra = cluster.Nodes().Infra()
-	healthObject.Expected.Master = cluster.Nodes().Master()
+// writeJUnit renders a test case per check. Failed checks are failures and checks which could not be run are errors.
+// Warnings pass, with their evidence in the test case's output.
+func (r *healthReport) writeJUnit(w io.Writer) error {
+	suite := healthJUnitTestSuite{
+		Name:      fmt.Sprintf("cluster health %s", r.ClusterID),
+		Tests:     len(r.Results),
+		Timestamp: r.Timestamp.Format(time.RFC3339),
+	}
+	for _, result := range r.Results {
+		details := strings.Join(result.Evidence, "\n")
+		if result.Remediation != "" {
+			details = strings.TrimSpace(details + "\nRemediation: " + result.Remediation)
+		}
 
-	return &healthObject
+		testCase := healthJUnitTestCase{Name: result.Name, ClassName: r.ClusterID}
+		switch result.Status {
+		case healthFail:
+			testCase.Failure = &healthJUnitMessage{Message: result.Summary, Body: details}
+			suite.Failures++
+		case healthError:
+			testCase.Error = &healthJUnitMessage{Message: result.Summary, Body: details}
+			suite.Errors++
+		case healthWarn:
+			testCase.SystemOut = strings.TrimSpace("warning: " + result.Summary + "\n" + details)
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
 }
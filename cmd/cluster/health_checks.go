@@ -0,0 +1,715 @@
+package cluster
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	hiveinternalv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// pendingCSRFailAge is how long a CSR can stay pending before it is considered stuck rather than awaiting approval
+	pendingCSRFailAge = time.Hour
+
+	certificateExpiryWarn = 30 * 24 * time.Hour
+	certificateExpiryFail = 7 * 24 * time.Hour
+)
+
+// certificateNamespaces are the namespaces whose TLS secrets are checked for expiry
+var certificateNamespaces = []string{
+	"openshift-config",
+	"openshift-etcd",
+	"openshift-ingress",
+	"openshift-kube-apiserver",
+	"openshift-kube-apiserver-operator",
+	"openshift-kube-controller-manager",
+	"openshift-kube-scheduler",
+}
+
+// healthChecks are the checks of the health check suite, in the order they are run
+var healthChecks = []healthCheck{
+	{Name: "cloud-vms", Description: "Running cloud VMs match the expected number of nodes", Run: checkCloudVMs},
+	{Name: "cloud-load-balancers", Description: "Every listener of the cluster's load balancers has a healthy target", Run: checkCloudLoadBalancers},
+	{Name: "cloud-volumes", Description: "No cloud volumes are failed or stuck attaching or detaching", Run: checkCloudVolumes},
+	{Name: "cloud-dns", Description: "The API and ingress DNS records of the cluster exist", Run: checkCloudDNS},
+	{Name: "cloud-firewall", Description: "The cluster's security groups or firewall rules admit API traffic", Run: checkCloudFirewall},
+	{Name: "nodes", Description: "Nodes are Ready and schedulable", Run: checkNodes},
+	{Name: "cluster-operators", Description: "ClusterOperators are available and not degraded", Run: checkClusterOperators},
+	{Name: "etcd", Description: "All etcd members are available and etcd pods are ready", Run: checkEtcd},
+	{Name: "machine-config-pools", Description: "MachineConfigPools are not degraded or stuck updating", Run: checkMachineConfigPools},
+	{Name: "pending-csrs", Description: "No CertificateSigningRequests are left pending", Run: checkPendingCSRs},
+	{Name: "certificate-expiry", Description: "Platform TLS certificates are not about to expire (requires --reason)", Run: checkCertificateExpiry},
+	{Name: "pdbs", Description: "No PodDisruptionBudgets block node drains", Run: checkPodDisruptionBudgets},
+	{Name: "syncsets", Description: "Hive applied every SyncSet and SelectorSyncSet", Run: checkSyncSets},
+}
+
+// errorResult is the result of a check which could not be run
+func errorResult(summary string, err error) healthCheckResult {
+	result := healthCheckResult{Status: healthError, Summary: summary, Evidence: []string{err.Error()}}
+	if apierrors.IsForbidden(err) {
+		result.Remediation = "Re-run with --reason to elevate"
+	}
+
+	return result
+}
+
+func checkCloudVMs(_ context.Context, env *healthCheckEnv) healthCheckResult {
+	cloud, err := env.cloud()
+	if err != nil {
+		return errorResult("failed to log in to the cloud provider", err)
+	}
+
+	cluster := env.cluster
+	infraID := cluster.InfraID()
+	ownedLabel := "kubernetes.io/cluster/" + infraID
+	if cluster.CloudProvider().ID() == "gcp" {
+		ownedLabel = "kubernetes-io-cluster-" + infraID
+	}
+
+	var runningMasters, runningInfra, runningWorkers, stopped int
+	for _, zone := range cloud.GetAZs() {
+		instances, err := cloud.GetAllVirtualMachines(zone)
+		if err != nil {
+			return errorResult("failed to list cloud VMs", err)
+		}
+		for _, instance := range instances {
+			if _, ok := instance.Labels[ownedLabel]; !ok {
+				if env.verbose && instance.Name != "" {
+					log.Printf("Skipping a machine not belonging to the cluster: %s\n", instance.Name)
+				}
+				continue
+			}
+			if instance.State != "running" {
+				stopped++
+				continue
+			}
+			// The role is matched after the infra ID, which could contain a role name itself
+			role, found := strings.CutPrefix(instance.Name, infraID)
+			if !found {
+				continue
+			}
+			switch {
+			case strings.Contains(role, "master"):
+				runningMasters++
+			case strings.Contains(role, "infra"):
+				runningInfra++
+			case strings.Contains(role, "worker"):
+				runningWorkers++
+			}
+		}
+	}
+
+	expectedMasters, expectedInfra := cluster.Nodes().Master(), cluster.Nodes().Infra()
+	minWorkers, maxWorkers := cluster.Nodes().Compute(), cluster.Nodes().Compute()
+	if autoscale := cluster.Nodes().AutoscaleCompute(); autoscale.MinReplicas() != 0 {
+		minWorkers, maxWorkers = autoscale.MinReplicas(), autoscale.MaxReplicas()
+	}
+
+	result := healthCheckResult{
+		Status: healthPass,
+		Evidence: []string{
+			fmt.Sprintf("running masters: %d, expected %d", runningMasters, expectedMasters),
+			fmt.Sprintf("running infra: %d, expected %d", runningInfra, expectedInfra),
+			fmt.Sprintf("running workers: %d, expected %d-%d", runningWorkers, minWorkers, maxWorkers),
+			fmt.Sprintf("stopped: %d", stopped),
+		},
+	}
+	switch {
+	case runningMasters < expectedMasters || runningInfra < expectedInfra || runningWorkers < minWorkers:
+		result.Status = healthFail
+		result.Summary = "fewer VMs are running than expected"
+		result.Remediation = "Check the Machines in openshift-machine-api and the cloud provider console for terminated or failed instances"
+	case stopped > 0 || runningWorkers > maxWorkers:
+		result.Status = healthWarn
+		result.Summary = "stopped VMs or more workers than expected"
+		result.Remediation = "Check whether the stopped VMs were stopped by the customer, and whether the extra workers belong to a machinepool OCM is unaware of"
+	default:
+		result.Summary = fmt.Sprintf("%d masters, %d infra, and %d workers running", runningMasters, runningInfra, runningWorkers)
+	}
+
+	return result
+}
+
+func checkCloudLoadBalancers(_ context.Context, env *healthCheckEnv) healthCheckResult {
+	cloud, err := env.cloud()
+	if err != nil {
+		return errorResult("failed to log in to the cloud provider", err)
+	}
+	loadBalancers, err := cloud.GetLoadBalancers()
+	if err != nil {
+		return errorResult("failed to list load balancers", err)
+	}
+	if len(loadBalancers) == 0 {
+		return healthCheckResult{
+			Status:      healthWarn,
+			Summary:     "no load balancers belonging to the cluster were found",
+			Remediation: "Check whether the load balancers lost the cluster's tags or labels, or were deleted",
+		}
+	}
+
+	// Ingress load balancers only consider the nodes running a router healthy, so listeners are only required to have
+	// one healthy target
+	result := healthCheckResult{Status: healthPass, Summary: fmt.Sprintf("all %d load balancers have healthy targets", len(loadBalancers))}
+	var unhealthy []string
+	for _, loadBalancer := range loadBalancers {
+		for _, listener := range loadBalancer.Listeners {
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s %s/%s: %d healthy, %d unhealthy targets",
+				loadBalancer.Name, listener.Protocol, listener.Port, len(listener.HealthyTargets), len(listener.UnhealthyTargets)))
+			if len(listener.HealthyTargets) == 0 {
+				unhealthy = append(unhealthy, fmt.Sprintf("%s %s/%s", loadBalancer.Name, listener.Protocol, listener.Port))
+			}
+		}
+	}
+	if len(unhealthy) > 0 {
+		result.Status = healthFail
+		result.Summary = fmt.Sprintf("listeners without healthy targets: %s", strings.Join(unhealthy, ", "))
+		result.Remediation = "Check the health checks of the load balancers, and whether the apiservers or routers are running on the targets"
+	}
+
+	return result
+}
+
+func checkCloudVolumes(_ context.Context, env *healthCheckEnv) healthCheckResult {
+	cloud, err := env.cloud()
+	if err != nil {
+		return errorResult("failed to log in to the cloud provider", err)
+	}
+	volumes, err := cloud.GetVolumes()
+	if err != nil {
+		return errorResult("failed to list volumes", err)
+	}
+
+	result := healthCheckResult{Status: healthPass}
+	var failed, stuck, unattached int
+	for _, volume := range volumes {
+		switch {
+		case volume.State == "error" || volume.State == "failed":
+			failed++
+			result.Evidence = append(result.Evidence, fmt.Sprintf("volume %s is in state %s", volume.ID, volume.State))
+		case volume.Stuck():
+			stuck++
+			for _, attachment := range volume.Attachments {
+				result.Evidence = append(result.Evidence, fmt.Sprintf("volume %s is %s %s", volume.ID, attachment.State, attachment.VirtualMachine))
+			}
+		case len(volume.Attachments) == 0:
+			unattached++
+		}
+	}
+	result.Evidence = append(result.Evidence, fmt.Sprintf("unattached volumes: %d", unattached))
+
+	switch {
+	case failed > 0:
+		result.Status = healthFail
+		result.Summary = fmt.Sprintf("%d of %d volumes have failed", failed, len(volumes))
+		result.Remediation = "Check the cloud provider console for the cause, and whether the volumes back PersistentVolumes still in use"
+	case stuck > 0:
+		result.Status = healthWarn
+		result.Summary = fmt.Sprintf("%d volumes are stuck attaching or detaching", stuck)
+		result.Remediation = "Check the pods using the volumes, and detach the volumes if they remain stuck, e.g. with 'osdctl cluster detach-stuck-volume' on AWS"
+	default:
+		result.Summary = fmt.Sprintf("all %d volumes are attached or available", len(volumes))
+	}
+
+	return result
+}
+
+func checkCloudDNS(_ context.Context, env *healthCheckEnv) healthCheckResult {
+	cloud, err := env.cloud()
+	if err != nil {
+		return errorResult("failed to log in to the cloud provider", err)
+	}
+	zones, err := cloud.GetDNSZones()
+	if err != nil {
+		return errorResult("failed to list DNS zones", err)
+	}
+
+	domain := strings.ToLower((&osdCloud.BaseClient{Cluster: env.cluster}).ClusterDomain())
+	expected := []string{"api." + domain, "*.apps." + domain}
+
+	result := healthCheckResult{Status: healthPass, Summary: "the API and ingress records exist"}
+	found := map[string]bool{}
+	for _, zone := range zones {
+		visibility := "public"
+		if zone.Private {
+			visibility = "private"
+		}
+		result.Evidence = append(result.Evidence, fmt.Sprintf("%s zone %s has %d records of the cluster", visibility, zone.Name, len(zone.Records)))
+		for _, record := range zone.Records {
+			found[strings.ToLower(record.Name)] = true
+		}
+	}
+
+	var missing []string
+	for _, name := range expected {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		result.Status = healthFail
+		result.Summary = fmt.Sprintf("missing DNS records: %s", strings.Join(missing, ", "))
+		result.Remediation = "Check the DNS status of the ingress controllers and the cluster's DNSRecords in openshift-ingress-operator"
+	}
+
+	return result
+}
+
+// apiServerPort is the port the cluster's security groups or firewall rules have to admit for the API to be reachable
+const apiServerPort = 6443
+
+func checkCloudFirewall(_ context.Context, env *healthCheckEnv) healthCheckResult {
+	cloud, err := env.cloud()
+	if err != nil {
+		return errorResult("failed to log in to the cloud provider", err)
+	}
+	rules, err := cloud.GetFirewallRules()
+	if err != nil {
+		return errorResult("failed to list firewall rules", err)
+	}
+
+	var allowing, denying []string
+	for _, rule := range rules {
+		if !rule.Ingress || !firewallRuleMatchesPort(rule, "tcp", apiServerPort) {
+			continue
+		}
+		if rule.Allow {
+			allowing = append(allowing, rule.Name)
+		} else {
+			denying = append(denying, rule.Name)
+		}
+	}
+	allowing, denying = dedupe(allowing), dedupe(denying)
+
+	result := healthCheckResult{
+		Status:   healthPass,
+		Summary:  fmt.Sprintf("%d rules admit API traffic", len(allowing)),
+		Evidence: []string{fmt.Sprintf("rules: %d", len(rules))},
+	}
+	for _, name := range allowing {
+		result.Evidence = append(result.Evidence, fmt.Sprintf("%s admits tcp/%d", name, apiServerPort))
+	}
+	for _, name := range denying {
+		result.Evidence = append(result.Evidence, fmt.Sprintf("%s denies tcp/%d", name, apiServerPort))
+	}
+	switch {
+	case len(allowing) == 0:
+		result.Status = healthFail
+		result.Summary = fmt.Sprintf("no rule admits API traffic on tcp/%d", apiServerPort)
+		result.Remediation = "Check whether the customer removed the rules of the control plane's security group or firewall"
+	case len(denying) > 0:
+		result.Status = healthWarn
+		result.Summary = fmt.Sprintf("rules deny API traffic on tcp/%d", apiServerPort)
+		result.Remediation = "Check whether the customer added the deny rules, and whether they take precedence over the allowing rules"
+	}
+
+	return result
+}
+
+// firewallRuleMatchesPort returns whether the rule applies to the port of the protocol
+func firewallRuleMatchesPort(rule osdCloud.FirewallRule, protocol string, port int) bool {
+	if rule.Protocol != "all" && !strings.EqualFold(rule.Protocol, protocol) {
+		return false
+	}
+	if rule.Ports == "" {
+		return true
+	}
+	from, to, found := strings.Cut(rule.Ports, "-")
+	if !found {
+		to = from
+	}
+	fromPort, err := strconv.Atoi(from)
+	if err != nil {
+		return false
+	}
+	toPort, err := strconv.Atoi(to)
+	if err != nil {
+		return false
+	}
+	return fromPort <= port && port <= toPort
+}
+
+func checkNodes(ctx context.Context, env *healthCheckEnv) healthCheckResult {
+	c, err := env.kube()
+	if err != nil {
+		return errorResult("failed to create cluster client", err)
+	}
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return errorResult("failed to list nodes", err)
+	}
+
+	result := healthCheckResult{Status: healthPass, Summary: fmt.Sprintf("all %d nodes are Ready", len(nodes.Items))}
+	var notReady, cordoned int
+	for _, node := range nodes.Items {
+		ready := false
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				ready = condition.Status == corev1.ConditionTrue
+				if !ready {
+					result.Evidence = append(result.Evidence, fmt.Sprintf("node %s is not Ready: %s %s", node.Name, condition.Reason, condition.Message))
+				}
+			}
+		}
+		if !ready {
+			notReady++
+		}
+		if node.Spec.Unschedulable {
+			cordoned++
+			result.Evidence = append(result.Evidence, fmt.Sprintf("node %s is cordoned", node.Name))
+		}
+	}
+
+	switch {
+	case notReady > 0:
+		result.Status = healthFail
+		result.Summary = fmt.Sprintf("%d of %d nodes are not Ready", notReady, len(nodes.Items))
+		result.Remediation = "Check the kubelet and the Machine of each node, e.g. with 'oc describe node' and 'oc get machines -n openshift-machine-api'"
+	case cordoned > 0:
+		result.Status = healthWarn
+		result.Summary = fmt.Sprintf("%d nodes are cordoned", cordoned)
+		result.Remediation = "Check whether an upgrade or drain is in progress, otherwise uncordon the nodes with 'oc adm uncordon'"
+	}
+
+	return result
+}
+
+func checkClusterOperators(ctx context.Context, env *healthCheckEnv) healthCheckResult {
+	c, err := env.kube()
+	if err != nil {
+		return errorResult("failed to create cluster client", err)
+	}
+	operators := &configv1.ClusterOperatorList{}
+	if err := c.List(ctx, operators); err != nil {
+		return errorResult("failed to list cluster operators", err)
+	}
+
+	var unhealthy, progressing []string
+	var evidence []string
+	for _, co := range operators.Items {
+		for _, condition := range co.Status.Conditions {
+			switch {
+			case condition.Type == configv1.OperatorDegraded && condition.Status == configv1.ConditionTrue,
+				condition.Type == configv1.OperatorAvailable && condition.Status == configv1.ConditionFalse:
+				unhealthy = append(unhealthy, co.Name)
+				evidence = append(evidence, fmt.Sprintf("%s %s=%s: %s", co.Name, condition.Type, condition.Status, condition.Message))
+			case condition.Type == configv1.OperatorProgressing && condition.Status == configv1.ConditionTrue:
+				progressing = append(progressing, co.Name)
+				evidence = append(evidence, fmt.Sprintf("%s Progressing: %s", co.Name, condition.Message))
+			}
+		}
+	}
+
+	switch {
+	case len(unhealthy) > 0:
+		return healthCheckResult{
+			Status:      healthFail,
+			Summary:     fmt.Sprintf("degraded or unavailable: %s", strings.Join(dedupe(unhealthy), ", ")),
+			Evidence:    evidence,
+			Remediation: "Check the operator's namespace for failing pods and follow the SOP for the operator's alert",
+		}
+	case len(progressing) > 0:
+		return healthCheckResult{
+			Status:      healthWarn,
+			Summary:     fmt.Sprintf("progressing: %s", strings.Join(dedupe(progressing), ", ")),
+			Evidence:    evidence,
+			Remediation: "Check whether an upgrade is in progress, operators progressing for long are likely stuck",
+		}
+	}
+
+	return healthCheckResult{Status: healthPass, Summary: fmt.Sprintf("all %d cluster operators are available", len(operators.Items))}
+}
+
+func checkEtcd(ctx context.Context, env *healthCheckEnv) healthCheckResult {
+	c, err := env.kube()
+	if err != nil {
+		return errorResult("failed to create cluster client", err)
+	}
+
+	etcd := &operatorv1.Etcd{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "cluster"}, etcd); err != nil {
+		return errorResult("failed to get the etcd operator", err)
+	}
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(EtcdNamespaceName), client.MatchingLabels{EtcdPodMatchLabelName: EtcdPodMatchValueName}); err != nil {
+		return errorResult("failed to list etcd pods", err)
+	}
+
+	result := healthCheckResult{Status: healthPass}
+	for _, condition := range etcd.Status.Conditions {
+		if condition.Type != EtcdMemberConditionType {
+			continue
+		}
+		result.Summary = condition.Message
+		result.Evidence = append(result.Evidence, fmt.Sprintf("%s=%s: %s", condition.Type, condition.Status, condition.Message))
+		if condition.Status != operatorv1.ConditionTrue || strings.Contains(condition.Message, "unhealthy") {
+			result.Status = healthFail
+		}
+	}
+	for _, pod := range pods.Items {
+		ready := 0
+		for _, container := range pod.Status.ContainerStatuses {
+			if container.Ready {
+				ready++
+			}
+		}
+		if ready != len(pod.Status.ContainerStatuses) {
+			result.Status = healthFail
+			result.Evidence = append(result.Evidence, fmt.Sprintf("pod %s has %d/%d containers ready", pod.Name, ready, len(pod.Status.ContainerStatuses)))
+		}
+	}
+
+	if result.Summary == "" {
+		result.Summary = fmt.Sprintf("%d etcd pods", len(pods.Items))
+	}
+	if result.Status == healthFail {
+		result.Remediation = fmt.Sprintf("Run 'osdctl cluster etcd-health-check --cluster-id %s' and replace an unhealthy member with 'osdctl cluster etcd-member-replace'", env.cluster.ID())
+	}
+
+	return result
+}
+
+func checkMachineConfigPools(ctx context.Context, env *healthCheckEnv) healthCheckResult {
+	c, err := env.kube()
+	if err != nil {
+		return errorResult("failed to create cluster client", err)
+	}
+	pools := &mcfgv1.MachineConfigPoolList{}
+	if err := c.List(ctx, pools); err != nil {
+		return errorResult("failed to list machine config pools", err)
+	}
+
+	result := healthCheckResult{Status: healthPass, Summary: fmt.Sprintf("all %d machine config pools are updated", len(pools.Items))}
+	var degraded, updating []string
+	for _, pool := range pools.Items {
+		for _, condition := range pool.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case mcfgv1.MachineConfigPoolDegraded:
+				degraded = append(degraded, pool.Name)
+				result.Evidence = append(result.Evidence, fmt.Sprintf("%s is degraded: %s", pool.Name, condition.Message))
+			case mcfgv1.MachineConfigPoolUpdating:
+				updating = append(updating, pool.Name)
+				result.Evidence = append(result.Evidence, fmt.Sprintf("%s is updating: %d/%d machines updated", pool.Name, pool.Status.UpdatedMachineCount, pool.Status.MachineCount))
+			}
+		}
+		if pool.Status.DegradedMachineCount > 0 {
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s has %d degraded machines", pool.Name, pool.Status.DegradedMachineCount))
+		}
+	}
+
+	switch {
+	case len(degraded) > 0:
+		result.Status = healthFail
+		result.Summary = fmt.Sprintf("degraded: %s", strings.Join(degraded, ", "))
+		result.Remediation = "Check the machine-config-daemon logs on the degraded nodes, the failing node is named in the pool's NodeDegraded condition"
+	case len(updating) > 0:
+		result.Status = healthWarn
+		result.Summary = fmt.Sprintf("updating: %s", strings.Join(updating, ", "))
+		result.Remediation = "Check whether an upgrade is in progress, and for PodDisruptionBudgets blocking drains if the pool doesn't progress"
+	}
+
+	return result
+}
+
+func checkPendingCSRs(ctx context.Context, env *healthCheckEnv) healthCheckResult {
+	c, err := env.kube()
+	if err != nil {
+		return errorResult("failed to create cluster client", err)
+	}
+	csrs := &certificatesv1.CertificateSigningRequestList{}
+	if err := c.List(ctx, csrs); err != nil {
+		return errorResult("failed to list certificate signing requests", err)
+	}
+
+	result := healthCheckResult{Status: healthPass, Summary: "no pending certificate signing requests"}
+	var pending, stuck int
+	for _, csr := range csrs.Items {
+		if len(csr.Status.Conditions) > 0 {
+			continue
+		}
+		pending++
+		age := env.now().Sub(csr.CreationTimestamp.Time)
+		if age > pendingCSRFailAge {
+			stuck++
+		}
+		result.Evidence = append(result.Evidence, fmt.Sprintf("%s requested by %s pending for %s", csr.Name, csr.Spec.Username, age.Round(time.Second)))
+	}
+
+	switch {
+	case stuck > 0:
+		result.Status = healthFail
+		result.Summary = fmt.Sprintf("%d certificate signing requests pending for more than %s", stuck, pendingCSRFailAge)
+		result.Remediation = "Check the cluster-machine-approver logs, node CSRs are only approved automatically for Machines it knows about"
+	case pending > 0:
+		result.Status = healthWarn
+		result.Summary = fmt.Sprintf("%d certificate signing requests pending", pending)
+		result.Remediation = "Recently created CSRs are usually approved within minutes, re-run the check to see whether they are"
+	}
+
+	return result
+}
+
+func checkCertificateExpiry(ctx context.Context, env *healthCheckEnv) healthCheckResult {
+	c, err := env.kube()
+	if err != nil {
+		return errorResult("failed to create cluster client", err)
+	}
+
+	result := healthCheckResult{Status: healthPass}
+	var soonest time.Duration = -1
+	checked := 0
+	for _, namespace := range certificateNamespaces {
+		secrets := &corev1.SecretList{}
+		if err := c.List(ctx, secrets, client.InNamespace(namespace), client.MatchingFields{"type": string(corev1.SecretTypeTLS)}); err != nil {
+			return errorResult(fmt.Sprintf("failed to list TLS secrets in %s", namespace), err)
+		}
+		for _, secret := range secrets.Items {
+			cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+			if err != nil {
+				continue
+			}
+			checked++
+			remaining := cert.NotAfter.Sub(env.now())
+			if soonest < 0 || remaining < soonest {
+				soonest = remaining
+			}
+
+			switch {
+			case remaining < certificateExpiryFail:
+				result.Status = healthFail
+			case remaining < certificateExpiryWarn:
+				if result.Status == healthPass {
+					result.Status = healthWarn
+				}
+			default:
+				continue
+			}
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s/%s expires at %s", secret.Namespace, secret.Name, cert.NotAfter.UTC().Format(time.RFC3339)))
+		}
+	}
+	sort.Strings(result.Evidence)
+
+	switch result.Status {
+	case healthFail:
+		result.Summary = "certificates expired or expire within 7 days"
+		result.Remediation = "Check the operator owning the secret, it normally rotates certificates well before they expire"
+	case healthWarn:
+		result.Summary = "certificates expire within 30 days"
+		result.Remediation = "Platform certificates are rotated automatically, check again closer to the expiry and escalate if they aren't"
+	default:
+		result.Summary = fmt.Sprintf("%d certificates checked", checked)
+		if soonest >= 0 {
+			result.Summary += fmt.Sprintf(", the soonest expires in %d days", int(soonest.Hours()/24))
+		}
+	}
+
+	return result
+}
+
+// parseCertificate parses the first certificate of a PEM encoded chain
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func checkPodDisruptionBudgets(ctx context.Context, env *healthCheckEnv) healthCheckResult {
+	c, err := env.kube()
+	if err != nil {
+		return errorResult("failed to create cluster client", err)
+	}
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := c.List(ctx, pdbs); err != nil {
+		return errorResult("failed to list pod disruption budgets", err)
+	}
+
+	result := healthCheckResult{Status: healthPass, Summary: "no pod disruption budgets block drains"}
+	for _, pdb := range pdbs.Items {
+		if pdb.Status.ExpectedPods > 0 && pdb.Status.DisruptionsAllowed == 0 {
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s/%s allows no disruptions: %d/%d pods healthy, %d desired",
+				pdb.Namespace, pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.ExpectedPods, pdb.Status.DesiredHealthy))
+		}
+	}
+	if len(result.Evidence) > 0 {
+		result.Status = healthWarn
+		result.Summary = fmt.Sprintf("%d pod disruption budgets block drains", len(result.Evidence))
+		result.Remediation = "Nodes running the protected pods cannot be drained during upgrades, ask the owner of customer workloads to relax the budget or scale up"
+	}
+
+	return result
+}
+
+func checkSyncSets(ctx context.Context, env *healthCheckEnv) healthCheckResult {
+	hive, err := env.hive()
+	if err != nil {
+		return errorResult("failed to create hive client", err)
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := hive.List(ctx, namespaces, client.MatchingLabels{"api.openshift.com/id": env.cluster.ID()}); err != nil {
+		return errorResult("failed to find the cluster's namespace on hive", err)
+	}
+	if len(namespaces.Items) != 1 {
+		return errorResult("failed to find the cluster's namespace on hive", fmt.Errorf("expected 1 namespace labelled api.openshift.com/id=%s, found %d", env.cluster.ID(), len(namespaces.Items)))
+	}
+	clusterSyncs := &hiveinternalv1alpha1.ClusterSyncList{}
+	if err := hive.List(ctx, clusterSyncs, client.InNamespace(namespaces.Items[0].Name)); err != nil {
+		return errorResult("failed to list cluster syncs", err)
+	}
+	if len(clusterSyncs.Items) != 1 {
+		return errorResult("failed to find the cluster sync", fmt.Errorf("expected 1 clustersync, found %d in namespace %s", len(clusterSyncs.Items), namespaces.Items[0].Name))
+	}
+
+	clusterSync := clusterSyncs.Items[0]
+	result := healthCheckResult{Status: healthPass}
+	statuses := append(append([]hiveinternalv1alpha1.SyncStatus{}, clusterSync.Status.SyncSets...), clusterSync.Status.SelectorSyncSets...)
+	for _, status := range statuses {
+		if status.Result == hiveinternalv1alpha1.FailureSyncSetResult {
+			result.Evidence = append(result.Evidence, fmt.Sprintf("%s: %s", status.Name, status.FailureMessage))
+		}
+	}
+	if len(result.Evidence) > 0 {
+		result.Status = healthFail
+		result.Summary = fmt.Sprintf("%d of %d syncsets failed to apply", len(result.Evidence), len(statuses))
+		result.Remediation = fmt.Sprintf("Check the failure messages, then force a resync with 'osdctl cluster resync -C %s'", env.cluster.ID())
+		return result
+	}
+	result.Summary = fmt.Sprintf("all %d syncsets applied", len(statuses))
+
+	return result
+}
+
+// dedupe returns the strings in order without duplicates
+func dedupe(items []string) []string {
+	seen := map[string]bool{}
+	var deduped []string
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			deduped = append(deduped, item)
+		}
+	}
+
+	return deduped
+}
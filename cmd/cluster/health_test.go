@@ -0,0 +1,473 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	configv1 "github.com/openshift/api/config/v1"
+	hiveinternalv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var healthTestNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newHealthTestClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, certificatesv1.AddToScheme(scheme))
+	require.NoError(t, configv1.Install(scheme))
+	require.NoError(t, hiveinternalv1alpha1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).
+		WithIndex(&corev1.Secret{}, "type", func(o client.Object) []string {
+			return []string{string(o.(*corev1.Secret).Type)}
+		}).Build()
+}
+
+func newHealthTestEnv(t *testing.T, cluster *v1.ClusterBuilder, objs ...client.Object) *healthCheckEnv {
+	c, err := cluster.Build()
+	require.NoError(t, err)
+	kube := newHealthTestClient(t, objs...)
+
+	return &healthCheckEnv{
+		cluster:        c,
+		now:            func() time.Time { return healthTestNow },
+		newKubeClient:  func() (client.Client, error) { return kube, nil },
+		newHiveClient:  func() (client.Client, error) { return kube, nil },
+		newCloudClient: func() (osdCloud.ClusterHealthClient, error) { return nil, assert.AnError },
+	}
+}
+
+type fakeHealthCloud struct {
+	vms           []osdCloud.VirtualMachine
+	loadBalancers []osdCloud.LoadBalancer
+	volumes       []osdCloud.Volume
+	zones         []osdCloud.DNSZone
+	rules         []osdCloud.FirewallRule
+}
+
+func (f *fakeHealthCloud) Login() error            { return nil }
+func (f *fakeHealthCloud) GetCluster() *v1.Cluster { return nil }
+func (f *fakeHealthCloud) GetAZs() []string        { return []string{"us-east-1a"} }
+func (f *fakeHealthCloud) Close()                  {}
+func (f *fakeHealthCloud) GetAllVirtualMachines(string) ([]osdCloud.VirtualMachine, error) {
+	return f.vms, nil
+}
+func (f *fakeHealthCloud) GetLoadBalancers() ([]osdCloud.LoadBalancer, error) {
+	return f.loadBalancers, nil
+}
+func (f *fakeHealthCloud) GetVolumes() ([]osdCloud.Volume, error)   { return f.volumes, nil }
+func (f *fakeHealthCloud) GetDNSZones() ([]osdCloud.DNSZone, error) { return f.zones, nil }
+func (f *fakeHealthCloud) GetFirewallRules() ([]osdCloud.FirewallRule, error) {
+	return f.rules, nil
+}
+
+func TestOverallStatus(t *testing.T) {
+	tests := []struct {
+		statuses []healthStatus
+		expected healthStatus
+	}{
+		{statuses: []healthStatus{healthPass, healthPass}, expected: healthPass},
+		{statuses: []healthStatus{healthPass, healthWarn}, expected: healthWarn},
+		{statuses: []healthStatus{healthWarn, healthError}, expected: healthError},
+		{statuses: []healthStatus{healthError, healthFail, healthWarn}, expected: healthFail},
+	}
+
+	for _, test := range tests {
+		var results []healthCheckResult
+		for _, status := range test.statuses {
+			results = append(results, healthCheckResult{Status: status})
+		}
+		assert.Equal(t, test.expected, overallStatus(results), "statuses %v", test.statuses)
+	}
+	assert.Equal(t, 0, healthPass.exitCode())
+	assert.Equal(t, 2, healthWarn.exitCode())
+	assert.Equal(t, 3, healthFail.exitCode())
+}
+
+func TestSelectHealthChecks(t *testing.T) {
+	checks, err := selectHealthChecks(nil)
+	require.NoError(t, err)
+	assert.Len(t, checks, len(healthChecks))
+
+	checks, err = selectHealthChecks([]string{"etcd", " nodes"})
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+	assert.Equal(t, "etcd", checks[0].Name)
+	assert.Equal(t, "nodes", checks[1].Name)
+
+	_, err = selectHealthChecks([]string{"bogus"})
+	assert.ErrorContains(t, err, "unknown check")
+}
+
+func TestCheckCloudVMs(t *testing.T) {
+	owned := map[string]string{"kubernetes.io/cluster/infra-abc": "owned"}
+	vm := func(name, state string) osdCloud.VirtualMachine {
+		return osdCloud.VirtualMachine{Name: name, State: state, Labels: owned}
+	}
+	cluster := v1.NewCluster().InfraID("infra-abc").CloudProvider(v1.NewCloudProvider().ID("aws")).
+		Nodes(v1.NewClusterNodes().Master(3).Infra(2).Compute(2))
+
+	tests := []struct {
+		name     string
+		vms      []osdCloud.VirtualMachine
+		expected healthStatus
+	}{
+		{
+			name: "expected VMs running",
+			vms: []osdCloud.VirtualMachine{
+				vm("infra-abc-master-0", "running"), vm("infra-abc-master-1", "running"), vm("infra-abc-master-2", "running"),
+				vm("infra-abc-infra-a", "running"), vm("infra-abc-infra-b", "running"),
+				vm("infra-abc-worker-a", "running"), vm("infra-abc-worker-b", "running"),
+				{Name: "someone-elses-vm", State: "running"},
+			},
+			expected: healthPass,
+		},
+		{
+			name: "missing master",
+			vms: []osdCloud.VirtualMachine{
+				vm("infra-abc-master-0", "running"), vm("infra-abc-master-1", "running"), vm("infra-abc-master-2", "stopped"),
+				vm("infra-abc-infra-a", "running"), vm("infra-abc-infra-b", "running"),
+				vm("infra-abc-worker-a", "running"), vm("infra-abc-worker-b", "running"),
+			},
+			expected: healthFail,
+		},
+		{
+			name: "stopped extra worker",
+			vms: []osdCloud.VirtualMachine{
+				vm("infra-abc-master-0", "running"), vm("infra-abc-master-1", "running"), vm("infra-abc-master-2", "running"),
+				vm("infra-abc-infra-a", "running"), vm("infra-abc-infra-b", "running"),
+				vm("infra-abc-worker-a", "running"), vm("infra-abc-worker-b", "running"), vm("infra-abc-worker-c", "stopped"),
+			},
+			expected: healthWarn,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			env := newHealthTestEnv(t, cluster)
+			env.newCloudClient = func() (osdCloud.ClusterHealthClient, error) { return &fakeHealthCloud{vms: test.vms}, nil }
+			result := checkCloudVMs(context.Background(), env)
+			assert.Equal(t, test.expected, result.Status, result.Evidence)
+		})
+	}
+
+	result := checkCloudVMs(context.Background(), newHealthTestEnv(t, cluster))
+	assert.Equal(t, healthError, result.Status)
+}
+
+func newCloudHealthTestEnv(t *testing.T, cloud *fakeHealthCloud) *healthCheckEnv {
+	cluster := v1.NewCluster().Name("mycluster").InfraID("infra-abc").DNS(v1.NewDNS().BaseDomain("abcd.s1.devshift.org"))
+	env := newHealthTestEnv(t, cluster)
+	env.newCloudClient = func() (osdCloud.ClusterHealthClient, error) { return cloud, nil }
+
+	return env
+}
+
+func TestCheckCloudLoadBalancers(t *testing.T) {
+	listener := func(port string, healthy, unhealthy int) osdCloud.LoadBalancerListener {
+		l := osdCloud.LoadBalancerListener{Protocol: "TCP", Port: port}
+		for i := 0; i < healthy; i++ {
+			l.HealthyTargets = append(l.HealthyTargets, "i-healthy")
+		}
+		for i := 0; i < unhealthy; i++ {
+			l.UnhealthyTargets = append(l.UnhealthyTargets, "i-unhealthy")
+		}
+		return l
+	}
+
+	tests := []struct {
+		name          string
+		loadBalancers []osdCloud.LoadBalancer
+		expected      healthStatus
+	}{
+		{
+			name: "routers only on some targets",
+			loadBalancers: []osdCloud.LoadBalancer{
+				{Name: "infra-abc-int", Listeners: []osdCloud.LoadBalancerListener{listener("6443", 3, 0), listener("22623", 3, 0)}},
+				{Name: "a1b2c3", Listeners: []osdCloud.LoadBalancerListener{listener("443", 2, 3)}},
+			},
+			expected: healthPass,
+		},
+		{
+			name: "listener without healthy targets",
+			loadBalancers: []osdCloud.LoadBalancer{
+				{Name: "infra-abc-ext", Listeners: []osdCloud.LoadBalancerListener{listener("6443", 0, 3)}},
+			},
+			expected: healthFail,
+		},
+		{
+			name:     "no load balancers",
+			expected: healthWarn,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := checkCloudLoadBalancers(context.Background(), newCloudHealthTestEnv(t, &fakeHealthCloud{loadBalancers: test.loadBalancers}))
+			assert.Equal(t, test.expected, result.Status, result.Evidence)
+		})
+	}
+}
+
+func TestCheckCloudVolumes(t *testing.T) {
+	attached := osdCloud.VolumeAttachment{VirtualMachine: "i-1", State: "attached"}
+	detaching := osdCloud.VolumeAttachment{VirtualMachine: "i-2", State: "detaching"}
+
+	tests := []struct {
+		name     string
+		volumes  []osdCloud.Volume
+		expected healthStatus
+	}{
+		{
+			name: "attached and available",
+			volumes: []osdCloud.Volume{
+				{ID: "vol-1", State: "in-use", Attachments: []osdCloud.VolumeAttachment{attached}},
+				{ID: "vol-2", State: "available"},
+			},
+			expected: healthPass,
+		},
+		{
+			name: "stuck detaching",
+			volumes: []osdCloud.Volume{
+				{ID: "vol-1", State: "in-use", Attachments: []osdCloud.VolumeAttachment{detaching}},
+			},
+			expected: healthWarn,
+		},
+		{
+			name: "failed",
+			volumes: []osdCloud.Volume{
+				{ID: "vol-1", State: "error"},
+				{ID: "vol-2", State: "in-use", Attachments: []osdCloud.VolumeAttachment{detaching}},
+			},
+			expected: healthFail,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := checkCloudVolumes(context.Background(), newCloudHealthTestEnv(t, &fakeHealthCloud{volumes: test.volumes}))
+			assert.Equal(t, test.expected, result.Status, result.Evidence)
+		})
+	}
+}
+
+func TestCheckCloudDNS(t *testing.T) {
+	zones := []osdCloud.DNSZone{
+		{Name: "mycluster.abcd.s1.devshift.org.", Private: true, Records: []osdCloud.DNSRecord{
+			{Name: "api.mycluster.abcd.s1.devshift.org.", Type: "A"},
+			{Name: "*.apps.mycluster.abcd.s1.devshift.org.", Type: "A"},
+		}},
+	}
+	result := checkCloudDNS(context.Background(), newCloudHealthTestEnv(t, &fakeHealthCloud{zones: zones}))
+	assert.Equal(t, healthPass, result.Status, result.Evidence)
+
+	zones[0].Records = zones[0].Records[:1]
+	result = checkCloudDNS(context.Background(), newCloudHealthTestEnv(t, &fakeHealthCloud{zones: zones}))
+	assert.Equal(t, healthFail, result.Status)
+	assert.Contains(t, result.Summary, "*.apps.mycluster.abcd.s1.devshift.org.")
+}
+
+func TestCheckCloudFirewall(t *testing.T) {
+	allowAPI := osdCloud.FirewallRule{Name: "infra-abc-api", Ingress: true, Allow: true, Protocol: "tcp", Ports: "6443"}
+	allowRange := osdCloud.FirewallRule{Name: "infra-abc-master-sg", Ingress: true, Allow: true, Protocol: "tcp", Ports: "6000-7000"}
+	allowSSH := osdCloud.FirewallRule{Name: "infra-abc-ssh", Ingress: true, Allow: true, Protocol: "tcp", Ports: "22"}
+	egress := osdCloud.FirewallRule{Name: "infra-abc-master-sg", Allow: true, Protocol: "all"}
+	deny := osdCloud.FirewallRule{Name: "customer-deny", Ingress: true, Protocol: "all"}
+
+	tests := []struct {
+		name     string
+		rules    []osdCloud.FirewallRule
+		expected healthStatus
+	}{
+		{name: "API port allowed", rules: []osdCloud.FirewallRule{allowAPI, allowSSH, egress}, expected: healthPass},
+		{name: "API port allowed by a range", rules: []osdCloud.FirewallRule{allowRange}, expected: healthPass},
+		{name: "API port not allowed", rules: []osdCloud.FirewallRule{allowSSH, egress}, expected: healthFail},
+		{name: "API port denied", rules: []osdCloud.FirewallRule{allowAPI, deny}, expected: healthWarn},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := checkCloudFirewall(context.Background(), newCloudHealthTestEnv(t, &fakeHealthCloud{rules: test.rules}))
+			assert.Equal(t, test.expected, result.Status, result.Evidence)
+		})
+	}
+}
+
+func TestCheckNodes(t *testing.T) {
+	node := func(name string, ready corev1.ConditionStatus, unschedulable bool) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+
+	result := checkNodes(context.Background(), newHealthTestEnv(t, v1.NewCluster(), node("a", corev1.ConditionTrue, false), node("b", corev1.ConditionTrue, false)))
+	assert.Equal(t, healthPass, result.Status)
+
+	result = checkNodes(context.Background(), newHealthTestEnv(t, v1.NewCluster(), node("a", corev1.ConditionTrue, true)))
+	assert.Equal(t, healthWarn, result.Status)
+
+	result = checkNodes(context.Background(), newHealthTestEnv(t, v1.NewCluster(), node("a", corev1.ConditionTrue, true), node("b", corev1.ConditionUnknown, false)))
+	assert.Equal(t, healthFail, result.Status)
+	assert.Equal(t, "1 of 2 nodes are not Ready", result.Summary)
+}
+
+func TestCheckClusterOperators(t *testing.T) {
+	co := func(name string, conditions ...configv1.ClusterOperatorStatusCondition) *configv1.ClusterOperator {
+		return &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: configv1.ClusterOperatorStatus{Conditions: conditions}}
+	}
+	available := configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue}
+
+	result := checkClusterOperators(context.Background(), newHealthTestEnv(t, v1.NewCluster(),
+		co("dns", available),
+		co("ingress", available, configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue}),
+	))
+	assert.Equal(t, healthWarn, result.Status)
+	assert.Equal(t, "progressing: ingress", result.Summary)
+
+	result = checkClusterOperators(context.Background(), newHealthTestEnv(t, v1.NewCluster(),
+		co("dns", available),
+		co("ingress", configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: configv1.ConditionFalse},
+			configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue}),
+	))
+	assert.Equal(t, healthFail, result.Status)
+	assert.Equal(t, "degraded or unavailable: ingress", result.Summary)
+	assert.Len(t, result.Evidence, 2)
+}
+
+func TestCheckPendingCSRs(t *testing.T) {
+	csr := func(name string, age time.Duration, approved bool) *certificatesv1.CertificateSigningRequest {
+		c := &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(healthTestNow.Add(-age))},
+		}
+		if approved {
+			c.Status.Conditions = []certificatesv1.CertificateSigningRequestCondition{{Type: certificatesv1.CertificateApproved, Status: corev1.ConditionTrue}}
+		}
+		return c
+	}
+
+	result := checkPendingCSRs(context.Background(), newHealthTestEnv(t, v1.NewCluster(), csr("a", 2*time.Hour, true)))
+	assert.Equal(t, healthPass, result.Status)
+
+	result = checkPendingCSRs(context.Background(), newHealthTestEnv(t, v1.NewCluster(), csr("a", 2*time.Hour, true), csr("b", time.Minute, false)))
+	assert.Equal(t, healthWarn, result.Status)
+
+	result = checkPendingCSRs(context.Background(), newHealthTestEnv(t, v1.NewCluster(), csr("a", 2*time.Hour, false), csr("b", time.Minute, false)))
+	assert.Equal(t, healthFail, result.Status)
+	assert.Len(t, result.Evidence, 2)
+}
+
+func newTLSSecret(t *testing.T, namespace, name string, notAfter time.Time) *corev1.Secret {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})},
+	}
+}
+
+func TestCheckCertificateExpiry(t *testing.T) {
+	healthy := newTLSSecret(t, "openshift-ingress", "router-certs", healthTestNow.Add(90*24*time.Hour))
+	expiring := newTLSSecret(t, "openshift-kube-apiserver", "serving-cert", healthTestNow.Add(10*24*time.Hour))
+	expired := newTLSSecret(t, "openshift-etcd", "etcd-peer", healthTestNow.Add(-time.Hour))
+	ignored := newTLSSecret(t, "customer", "expired", healthTestNow.Add(-time.Hour))
+
+	result := checkCertificateExpiry(context.Background(), newHealthTestEnv(t, v1.NewCluster(), healthy, ignored))
+	assert.Equal(t, healthPass, result.Status)
+	assert.Equal(t, "1 certificates checked, the soonest expires in 90 days", result.Summary)
+
+	result = checkCertificateExpiry(context.Background(), newHealthTestEnv(t, v1.NewCluster(), healthy, expiring))
+	assert.Equal(t, healthWarn, result.Status)
+	assert.Len(t, result.Evidence, 1)
+
+	result = checkCertificateExpiry(context.Background(), newHealthTestEnv(t, v1.NewCluster(), healthy, expiring, expired))
+	assert.Equal(t, healthFail, result.Status)
+	assert.Len(t, result.Evidence, 2)
+}
+
+func TestCheckSyncSets(t *testing.T) {
+	cluster := v1.NewCluster().ID("abc")
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "uhc-production-abc", Labels: map[string]string{"api.openshift.com/id": "abc"}}}
+	clusterSync := &hiveinternalv1alpha1.ClusterSync{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace.Name, Name: "my-cluster"},
+		Status: hiveinternalv1alpha1.ClusterSyncStatus{
+			SyncSets: []hiveinternalv1alpha1.SyncStatus{{Name: "ss", Result: hiveinternalv1alpha1.SuccessSyncSetResult}},
+			SelectorSyncSets: []hiveinternalv1alpha1.SyncStatus{
+				{Name: "sss-ok", Result: hiveinternalv1alpha1.SuccessSyncSetResult},
+				{Name: "sss-broken", Result: hiveinternalv1alpha1.FailureSyncSetResult, FailureMessage: "webhook denied"},
+			},
+		},
+	}
+
+	result := checkSyncSets(context.Background(), newHealthTestEnv(t, cluster, namespace, clusterSync))
+	assert.Equal(t, healthFail, result.Status)
+	assert.Equal(t, "1 of 3 syncsets failed to apply", result.Summary)
+	assert.Equal(t, []string{"sss-broken: webhook denied"}, result.Evidence)
+
+	result = checkSyncSets(context.Background(), newHealthTestEnv(t, cluster, namespace))
+	assert.Equal(t, healthError, result.Status)
+}
+
+func TestHealthReportWrite(t *testing.T) {
+	report := &healthReport{
+		ClusterID: "abc",
+		Name:      "my-cluster",
+		Timestamp: healthTestNow,
+		Results: []healthCheckResult{
+			{Name: "nodes", Status: healthPass, Summary: "all 3 nodes are Ready"},
+			{Name: "pdbs", Status: healthWarn, Summary: "1 pod disruption budgets block drains", Evidence: []string{"ns/pdb allows no disruptions"}},
+			{Name: "etcd", Status: healthFail, Summary: "1 unhealthy", Remediation: "replace it"},
+			{Name: "certificate-expiry", Status: healthError, Summary: "forbidden"},
+		},
+	}
+	report.Status = overallStatus(report.Results)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, report.write(out, healthOutputJUnit))
+	junit := out.String()
+	assert.Contains(t, junit, `<testsuite name="cluster health abc" tests="4" failures="1" errors="1"`)
+	assert.Contains(t, junit, `<failure message="1 unhealthy">Remediation: replace it</failure>`)
+	assert.Contains(t, junit, `<system-out>warning: 1 pod disruption budgets block drains`)
+
+	out.Reset()
+	require.NoError(t, report.write(out, healthOutputText))
+	text := out.String()
+	assert.True(t, strings.HasPrefix(text, "Health of cluster my-cluster (abc): FAIL"))
+	assert.Contains(t, text, "  Remediation: replace it")
+	assert.NotContains(t, text, "nodes (pass)")
+
+	out.Reset()
+	require.NoError(t, report.write(out, healthOutputJSON))
+	assert.Contains(t, out.String(), `"status": "fail"`)
+}
@@ -0,0 +1,261 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/utils"
+)
+
+// pullSecretRepairer rebuilds the pull secret of a cluster from the auths of its owner's OCM access token, and applies
+// it through the hive SyncSet of classic clusters or the ManifestWork of HCP clusters, as transfer-owner does
+type pullSecretRepairer struct {
+	out          io.Writer
+	confirm      func() bool
+	pollInterval time.Duration
+	timeout      time.Duration
+
+	// ocmPullSecret returns the auths of the owner's OCM access token, as a dockerconfigjson
+	ocmPullSecret func() ([]byte, error)
+	// clusterPullSecret returns the dockerconfigjson of the cluster's openshift-config/pull-secret
+	clusterPullSecret func() ([]byte, error)
+	// apply replaces the cluster's pull secret
+	apply func(pullSecret []byte) error
+}
+
+// pullSecretAuths is the part of a dockerconfigjson the repair compares
+type pullSecretAuths struct {
+	Auths map[string]struct {
+		Auth  string `json:"auth"`
+		Email string `json:"email"`
+	} `json:"auths"`
+}
+
+// newPullSecretRepairer logs into the cluster and into the hive or service cluster managing it, elevated with the
+// reason
+func newPullSecretRepairer(ocm *sdk.Connection, clusterID, reason string) (*pullSecretRepairer, error) {
+	cluster, err := utils.GetCluster(ocm, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := utils.GetSubscription(ocm, cluster.ID())
+	if err != nil {
+		return nil, err
+	}
+	account, err := utils.GetAccount(ocm, subscription.Creator().ID())
+	if err != nil {
+		return nil, err
+	}
+	if account.Username() == "" {
+		return nil, fmt.Errorf("found empty 'username' for account:'%s', needed for the access token", account.HREF())
+	}
+
+	hypershift := cluster.Hypershift().Enabled()
+	elevationReasons := []string{reason, "Repairing the cluster pull secret using osdctl"}
+	var masterClusterID, mgmtClusterName string
+	if hypershift {
+		mgmtCluster, err := utils.GetManagementCluster(cluster.ID())
+		if err != nil {
+			return nil, err
+		}
+		mgmtClusterName = mgmtCluster.Name()
+		serviceCluster, err := utils.GetServiceCluster(cluster.ID())
+		if err != nil {
+			return nil, err
+		}
+		masterClusterID = serviceCluster.ID()
+	} else {
+		hiveCluster, err := utils.GetHiveCluster(cluster.ID())
+		if err != nil {
+			return nil, err
+		}
+		masterClusterID = hiveCluster.ID()
+	}
+
+	masterKubeCli, _, masterClientSet, err := common.GetKubeConfigAndClient(masterClusterID, elevationReasons...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve Kubernetes configuration and client for cluster ID %s: %w", masterClusterID, err)
+	}
+	_, _, targetClientSet, err := common.GetKubeConfigAndClient(cluster.ID(), elevationReasons...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve Kubernetes configuration and client for cluster with ID %s: %w", cluster.ID(), err)
+	}
+
+	return &pullSecretRepairer{
+		out:          os.Stdout,
+		confirm:      utils.ConfirmPrompt,
+		pollInterval: 10 * time.Second,
+		timeout:      5 * time.Minute,
+		ocmPullSecret: func() ([]byte, error) {
+			return newOwnerPullSecret(ocm, account.Username())
+		},
+		clusterPullSecret: func() ([]byte, error) {
+			return readClusterPullSecret(targetClientSet)
+		},
+		apply: func(pullSecret []byte) error {
+			if hypershift {
+				if err := updateManifestWork(ocm, masterKubeCli, cluster.ID(), mgmtClusterName, pullSecret); err != nil {
+					return fmt.Errorf("failed to update pull secret for service cluster with ID %s: %w", cluster.ID(), err)
+				}
+				return nil
+			}
+			if err := updatePullSecret(ocm, masterKubeCli, masterClientSet, cluster.ID(), pullSecret); err != nil {
+				return fmt.Errorf("failed to update pull secret for Hive cluster with ID %s: %w", cluster.ID(), err)
+			}
+			if err := rolloutTelemeterClientPods(targetClientSet, "openshift-monitoring", "app.kubernetes.io/name=telemeter-client"); err != nil {
+				return fmt.Errorf("failed to roll out Telemeter Client pods in namespace 'openshift-monitoring' with label selector 'app.kubernetes.io/name=telemeter-client': %w", err)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// repair shows the redacted diff between the cluster's pull secret and the expected one, then applies the expected
+// one once confirmed and waits until the cluster has it
+func (r *pullSecretRepairer) repair() error {
+	current, err := r.clusterPullSecret()
+	if err != nil {
+		return err
+	}
+	ocmPullSecret, err := r.ocmPullSecret()
+	if err != nil {
+		return fmt.Errorf("failed to get the pull secret from OCM: %w", err)
+	}
+	expected, err := expectedPullSecret(current, ocmPullSecret)
+	if err != nil {
+		return err
+	}
+
+	diff, err := diffPullSecrets(current, expected)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(r.out, "The cluster pull secret will be rebuilt from OCM. Auths which are only in the cluster pull secret are kept:")
+	for _, line := range diff {
+		fmt.Fprintf(r.out, "  %s\n", line)
+	}
+	if !r.confirm() {
+		return fmt.Errorf("operation aborted by the user")
+	}
+
+	if err := r.apply(expected); err != nil {
+		return err
+	}
+
+	return r.verify(ocmPullSecret)
+}
+
+// verify waits until every auth of the OCM pull secret is in the cluster's pull secret
+func (r *pullSecretRepairer) verify(ocmPullSecret []byte) error {
+	deadline := time.Now().Add(r.timeout)
+	for {
+		current, err := r.clusterPullSecret()
+		if err != nil {
+			return err
+		}
+		mismatched, err := mismatchedPullSecretAuths(current, ocmPullSecret)
+		if err != nil {
+			return err
+		}
+		if len(mismatched) == 0 {
+			fmt.Fprintln(r.out, "Pull secret verification successful.")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the cluster pull secret still doesn't match OCM after %s for: %s", r.timeout, strings.Join(mismatched, ", "))
+		}
+		fmt.Fprintf(r.out, "Waiting for the cluster pull secret to be synced: %s\n", strings.Join(mismatched, ", "))
+		time.Sleep(r.pollInterval)
+	}
+}
+
+// expectedPullSecret returns the cluster's pull secret with the auths of the OCM pull secret replaced. The OCM pull
+// secret alone is expected when the cluster's is missing or can't be parsed.
+func expectedPullSecret(current, ocmPullSecret []byte) ([]byte, error) {
+	if len(current) > 0 {
+		if expected, err := buildNewSecret(current, ocmPullSecret); err == nil {
+			return expected, nil
+		}
+	}
+	var auths pullSecretAuths
+	if err := json.Unmarshal(ocmPullSecret, &auths); err != nil {
+		return nil, fmt.Errorf("failed to parse the OCM pull secret: %w", err)
+	}
+	return ocmPullSecret, nil
+}
+
+// diffPullSecrets returns a line per registry of the expected pull secret, describing how its auth changes. The auths
+// themselves are redacted to a fingerprint.
+func diffPullSecrets(current, expected []byte) ([]string, error) {
+	var before, after pullSecretAuths
+	// A pull secret which can't be parsed has no auths to keep
+	_ = json.Unmarshal(current, &before)
+	if err := json.Unmarshal(expected, &after); err != nil {
+		return nil, fmt.Errorf("failed to parse the expected pull secret: %w", err)
+	}
+
+	registries := make([]string, 0, len(after.Auths))
+	for registry := range after.Auths {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+
+	var diff []string
+	for _, registry := range registries {
+		auth := after.Auths[registry]
+		old, found := before.Auths[registry]
+		switch {
+		case !found:
+			diff = append(diff, fmt.Sprintf("+ %s: email %s, auth %s", registry, auth.Email, redactAuth(auth.Auth)))
+		case old.Auth == auth.Auth && old.Email == auth.Email:
+			diff = append(diff, fmt.Sprintf("  %s: unchanged", registry))
+		default:
+			var changes []string
+			if old.Email != auth.Email {
+				changes = append(changes, fmt.Sprintf("email %s -> %s", old.Email, auth.Email))
+			}
+			if old.Auth != auth.Auth {
+				changes = append(changes, fmt.Sprintf("auth %s -> %s", redactAuth(old.Auth), redactAuth(auth.Auth)))
+			}
+			diff = append(diff, fmt.Sprintf("~ %s: %s", registry, strings.Join(changes, ", ")))
+		}
+	}
+	return diff, nil
+}
+
+// mismatchedPullSecretAuths returns the registries of the OCM pull secret whose auth or email differs in the cluster's
+func mismatchedPullSecretAuths(current, ocmPullSecret []byte) ([]string, error) {
+	var actual, expected pullSecretAuths
+	if err := json.Unmarshal(ocmPullSecret, &expected); err != nil {
+		return nil, fmt.Errorf("failed to parse the OCM pull secret: %w", err)
+	}
+	// A pull secret which can't be parsed doesn't match any registry
+	_ = json.Unmarshal(current, &actual)
+
+	var mismatched []string
+	for registry, auth := range expected.Auths {
+		if actual.Auths[registry] != auth {
+			mismatched = append(mismatched, registry)
+		}
+	}
+	sort.Strings(mismatched)
+	return mismatched, nil
+}
+
+// redactAuth returns a fingerprint of the auth, to tell auths apart without showing them
+func redactAuth(auth string) string {
+	if auth == "" {
+		return "<empty>"
+	}
+	sum := sha256.Sum256([]byte(auth))
+	return "<redacted sha256:" + hex.EncodeToString(sum[:])[:8] + ">"
+}
@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClusterPullSecret = `{"auths":{"cloud.openshift.com":{"auth":"b2xk","email":"old@example.com"},"quay.io":{"auth":"cXVheQ==","email":"new@example.com"},"registry.example.com":{"auth":"Y3VzdG9tZXI=","email":"customer@example.com"}}}`
+	testOCMPullSecret     = `{"auths":{"cloud.openshift.com":{"auth":"bmV3","email":"new@example.com"},"quay.io":{"auth":"cXVheQ==","email":"new@example.com"},"registry.redhat.io":{"auth":"cmVk","email":"new@example.com"}}}`
+)
+
+func TestExpectedPullSecret(t *testing.T) {
+	expected, err := expectedPullSecret([]byte(testClusterPullSecret), []byte(testOCMPullSecret))
+	require.NoError(t, err)
+	// The auths only in the cluster pull secret are kept
+	mismatched, err := mismatchedPullSecretAuths(expected, []byte(`{"auths":{"registry.example.com":{"auth":"Y3VzdG9tZXI=","email":"customer@example.com"}}}`))
+	require.NoError(t, err)
+	assert.Empty(t, mismatched)
+	mismatched, err = mismatchedPullSecretAuths(expected, []byte(testOCMPullSecret))
+	require.NoError(t, err)
+	assert.Empty(t, mismatched)
+
+	// A broken cluster pull secret is replaced by the OCM one
+	for _, current := range []string{"", "{}", "not json"} {
+		expected, err := expectedPullSecret([]byte(current), []byte(testOCMPullSecret))
+		require.NoError(t, err)
+		mismatched, err := mismatchedPullSecretAuths(expected, []byte(testOCMPullSecret))
+		require.NoError(t, err)
+		assert.Empty(t, mismatched, current)
+	}
+}
+
+func TestDiffPullSecrets(t *testing.T) {
+	expected, err := expectedPullSecret([]byte(testClusterPullSecret), []byte(testOCMPullSecret))
+	require.NoError(t, err)
+
+	diff, err := diffPullSecrets([]byte(testClusterPullSecret), expected)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"~ cloud.openshift.com: email old@example.com -> new@example.com, auth " + redactAuth("b2xk") + " -> " + redactAuth("bmV3"),
+		"  quay.io: unchanged",
+		"  registry.example.com: unchanged",
+		"+ registry.redhat.io: email new@example.com, auth " + redactAuth("cmVk"),
+	}, diff)
+	for _, line := range diff {
+		assert.NotContains(t, line, "bmV3")
+	}
+	assert.Regexp(t, `^<redacted sha256:[0-9a-f]{8}>$`, redactAuth("bmV3"))
+}
+
+func newTestPullSecretRepairer(cluster *[]byte, confirmed bool) (*pullSecretRepairer, *bytes.Buffer, *int) {
+	out := &bytes.Buffer{}
+	applied := 0
+	return &pullSecretRepairer{
+		out:           out,
+		confirm:       func() bool { return confirmed },
+		timeout:       time.Millisecond,
+		ocmPullSecret: func() ([]byte, error) { return []byte(testOCMPullSecret), nil },
+		clusterPullSecret: func() ([]byte, error) {
+			return *cluster, nil
+		},
+		apply: func(pullSecret []byte) error {
+			applied++
+			*cluster = pullSecret
+			return nil
+		},
+	}, out, &applied
+}
+
+func TestPullSecretRepair(t *testing.T) {
+	cluster := []byte(testClusterPullSecret)
+	r, out, applied := newTestPullSecretRepairer(&cluster, true)
+
+	require.NoError(t, r.repair())
+	assert.Equal(t, 1, *applied)
+	assert.Contains(t, out.String(), "+ registry.redhat.io")
+	assert.Contains(t, out.String(), "Pull secret verification successful.")
+	assert.NotContains(t, out.String(), "cmVk")
+}
+
+func TestPullSecretRepairAborted(t *testing.T) {
+	cluster := []byte(testClusterPullSecret)
+	r, _, applied := newTestPullSecretRepairer(&cluster, false)
+
+	assert.ErrorContains(t, r.repair(), "operation aborted by the user")
+	assert.Equal(t, 0, *applied)
+}
+
+func TestPullSecretRepairNotSynced(t *testing.T) {
+	cluster := []byte(testClusterPullSecret)
+	r, _, _ := newTestPullSecretRepairer(&cluster, true)
+	// The cluster keeps its old pull secret
+	r.apply = func(pullSecret []byte) error { return nil }
+
+	err := r.repair()
+	assert.ErrorContains(t, err, "the cluster pull secret still doesn't match OCM")
+	assert.ErrorContains(t, err, "cloud.openshift.com, registry.redhat.io")
+}
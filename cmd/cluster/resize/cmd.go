@@ -14,6 +14,8 @@ func NewCmdResize() *cobra.Command {
 	resize.AddCommand(
 		newCmdResizeInfra(),
 		newCmdResizeControlPlane(),
+		newCmdResizeStatus(),
+		newCmdResizeRecommend(),
 	)
 
 	return resize
@@ -18,12 +18,14 @@ import (
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	bpelevate "github.com/openshift/backplane-cli/pkg/elevate"
 	"github.com/openshift/osdctl/cmd/servicelog"
 	"github.com/openshift/osdctl/pkg/k8s"
 	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -48,6 +50,13 @@ type controlPlane struct {
 
 	// reason to provide for elevation (eg: OHSS/PG ticket)
 	reason string
+
+	// wait for the resize to complete, reporting its progress
+	wait    bool
+	timeout time.Duration
+
+	// skipValidation skips checking the machine type against the cloud provider
+	skipValidation bool
 }
 
 // This command requires to previously be logged in via `ocm login`
@@ -60,10 +69,23 @@ func newCmdResizeControlPlane() *cobra.Command {
 
   Requires previous login to the api server via "ocm backplane login".
   The user will be prompted to send a service log after initiating the resize. The resize process runs asynchronously,
-  and this command exits immediately after sending the service log. Any issues with the resize will be reported via PagerDuty.`,
+  and this command exits immediately after sending the service log. Any issues with the resize will be reported via PagerDuty.
+
+  Use --wait to report the progress of each control plane node until the resize completes before sending the service
+  log, or "osdctl cluster resize status" to follow a resize which is already in progress.
+
+  Before resizing, the machine type is checked to be supported, offered in every availability zone of the cluster, and
+  within the vCPU quota. If it isn't, the smallest machine type passing these checks which also fits the current
+  utilization of the control plane nodes is suggested, as by "osdctl cluster resize recommend".`,
 		Example: `
   # Resize all control plane instances to m5.4xlarge using control plane machine sets
-  osdctl cluster resize control-plane -c "${CLUSTER_ID}" --machine-type m5.4xlarge --reason "${OHSS}"`,
+  osdctl cluster resize control-plane -c "${CLUSTER_ID}" --machine-type m5.4xlarge --reason "${OHSS}"
+
+  # Resize and wait for the resize to complete
+  osdctl cluster resize control-plane -c "${CLUSTER_ID}" --machine-type m5.4xlarge --reason "${OHSS}" --wait
+
+  # Recommend a machine type to resize control plane nodes to
+  osdctl cluster resize recommend --cluster-id "${CLUSTER_ID}" --role control-plane`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -74,8 +96,11 @@ func newCmdResizeControlPlane() *cobra.Command {
 		},
 	}
 	resizeControlPlaneNodeCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "c", "", "The internal ID of the cluster to perform actions on")
-	resizeControlPlaneNodeCmd.Flags().StringVar(&ops.newMachineType, "machine-type", "", "The target AWS machine type to resize to (e.g. m5.2xlarge)")
+	resizeControlPlaneNodeCmd.Flags().StringVar(&ops.newMachineType, "machine-type", "", "The target AWS or GCP machine type to resize to (e.g. m5.2xlarge), see 'osdctl cluster resize recommend'")
 	resizeControlPlaneNodeCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usually an OHSS or PD ticket)")
+	resizeControlPlaneNodeCmd.Flags().BoolVar(&ops.wait, "wait", false, "Wait for the resize to complete, reporting the progress of each control plane node")
+	resizeControlPlaneNodeCmd.Flags().DurationVar(&ops.timeout, "timeout", defaultControlPlaneResizeTimeout, "How long to wait for the resize to complete when using --wait")
+	resizeControlPlaneNodeCmd.Flags().BoolVar(&ops.skipValidation, "skip-validation", false, "Skip checking the machine type against the cloud provider, e.g. when cloud credentials are unavailable")
 	resizeControlPlaneNodeCmd.MarkFlagRequired("cluster-id")
 	resizeControlPlaneNodeCmd.MarkFlagRequired("machine-type")
 	resizeControlPlaneNodeCmd.MarkFlagRequired("reason")
@@ -114,6 +139,16 @@ func (o *controlPlane) New() error {
 	if err := machinev1.Install(scheme); err != nil {
 		return err
 	}
+	// Register Machines, the etcd operator, and Nodes and Pods to watch the progress of the resize
+	if err := machinev1beta1.Install(scheme); err != nil {
+		return err
+	}
+	if err := operatorv1.Install(scheme); err != nil {
+		return err
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return err
+	}
 
 	c, err := k8s.New(o.clusterID, client.Options{Scheme: scheme})
 	if err != nil {
@@ -122,7 +157,7 @@ func (o *controlPlane) New() error {
 
 	cAdmin, err := k8s.NewAsBackplaneClusterAdmin(o.cluster.ID(), client.Options{Scheme: scheme}, []string{
 		o.reason,
-		fmt.Sprintf("Need elevation for %s cluster in order to resize it to instance type %s", o.clusterID, o.newMachineType),
+		fmt.Sprintf("Need elevation for %s cluster in order to resize its control plane nodes", o.clusterID),
 	}...)
 	if err != nil {
 		return err
@@ -135,6 +170,37 @@ func (o *controlPlane) New() error {
 
 func (o *controlPlane) embiggenMachineType() {}
 
+// validateMachineType checks the machine type to resize to against the cloud provider, suggesting the recommended
+// machine type if it cannot be used
+func (o *controlPlane) validateMachineType(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet) error {
+	if o.skipValidation {
+		return nil
+	}
+
+	current, err := providerSpecInstanceType(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value)
+	if err != nil {
+		return fmt.Errorf("failed to determine the current machine type: %v", err)
+	}
+	var replicas int64 = 3
+	if cpms.Spec.Replicas != nil {
+		replicas = int64(*cpms.Spec.Replicas)
+	}
+
+	connection, err := utils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	advisor, err := newSizingAdvisor(ctx, connection, o.cluster, o.client, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to check the machine type against the cloud provider, use --skip-validation to skip the check: %v", err)
+	}
+	defer advisor.close()
+
+	return advisor.validate(ctx, newControlPlaneSizingRequest(current, replicas), o.newMachineType)
+}
+
 type optionsDialogResponse int64
 
 const (
@@ -446,6 +512,10 @@ func (o *controlPlane) run(ctx context.Context) error {
 		return fmt.Errorf("control plane machine set is unexpectedly in %s state, must be %s - check for service logs, support exceptions, ask for a second opinion", cpms.Spec.State, machinev1.ControlPlaneMachineSetStateActive)
 	}
 
+	if err := o.validateMachineType(ctx, cpms); err != nil {
+		return err
+	}
+
 	patch := client.MergeFrom(cpms.DeepCopy())
 
 	var (
@@ -490,7 +560,15 @@ func (o *controlPlane) run(ctx context.Context) error {
 		return fmt.Errorf("failed patching control plane machine set: %v", err)
 	}
 
-	log.Println("Control plane machine set patched successfully. The resize is now in progress and will complete asynchronously. This command will exit after sending a service log, and any issues will be reported via PagerDuty.")
+	if !o.wait {
+		log.Println("Control plane machine set patched successfully. The resize is now in progress and will complete asynchronously. This command will exit after sending a service log, and any issues will be reported via PagerDuty.")
+		return promptGenerateResizeSL(o.clusterID, o.newMachineType)
+	}
+
+	log.Println("Control plane machine set patched successfully. Waiting for the resize to complete, this usually takes around 45 minutes.")
+	if err := watchControlPlaneResize(ctx, o.client, os.Stdout, o.timeout, defaultControlPlaneResizeInterval); err != nil {
+		return fmt.Errorf("%v, follow it with 'osdctl cluster resize status -c %s' once the problem is resolved", err, o.clusterID)
+	}
 
 	return promptGenerateResizeSL(o.clusterID, o.newMachineType)
 }
@@ -0,0 +1,464 @@
+package resize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	controlPlaneMachineRoleLabel = "machine.openshift.io/cluster-api-machine-role"
+	etcdNamespace                = "openshift-etcd"
+	etcdMembersAvailable         = "EtcdMembersAvailable"
+
+	defaultControlPlaneResizeTimeout  = 90 * time.Minute
+	defaultControlPlaneResizeInterval = 30 * time.Second
+)
+
+// Stages a control plane machine goes through during a resize, in order
+const (
+	stagePendingReplacement = "pending replacement"
+	stageDeleting           = "old machine deleting"
+	stageProvisioning       = "new machine provisioning"
+	stageNodeJoining        = "node joining"
+	stageEtcdJoining        = "waiting for etcd member"
+	stageDone               = "etcd member added"
+	stageFailed             = "failed"
+)
+
+// controlPlaneResizeStatus defines the struct for running the resize status command
+type controlPlaneResizeStatus struct {
+	clusterID string
+	watch     bool
+	timeout   time.Duration
+	interval  time.Duration
+
+	client client.Client
+	out    io.Writer
+}
+
+func newCmdResizeStatus() *cobra.Command {
+	ops := &controlPlaneResizeStatus{out: os.Stdout}
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Report the progress of a control plane resize",
+		Long: `Report the progress of a control plane resize
+
+  Watches the control plane machine set, the control plane machines, and etcd membership, reporting the progress of each
+  control plane node until the resize completes, fails, or times out. When the resize doesn't complete, a summary of what
+  is blocking it and what to check next is printed.`,
+		Example: `
+  # Watch a control plane resize until it completes
+  osdctl cluster resize status -c "${CLUSTER_ID}"
+
+  # Print the current progress once
+  osdctl cluster resize status -c "${CLUSTER_ID}" --watch=false`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ops.New(); err != nil {
+				return err
+			}
+			if !ops.watch {
+				progress, err := getControlPlaneProgress(context.Background(), ops.client)
+				if err != nil {
+					return err
+				}
+				return printControlPlaneProgress(ops.out, progress)
+			}
+			return watchControlPlaneResize(context.Background(), ops.client, ops.out, ops.timeout, ops.interval)
+		},
+	}
+	statusCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "c", "", "The internal ID of the cluster to report the control plane resize progress of")
+	statusCmd.Flags().BoolVar(&ops.watch, "watch", true, "Keep watching until the resize completes, fails, or times out")
+	statusCmd.Flags().DurationVar(&ops.timeout, "timeout", defaultControlPlaneResizeTimeout, "How long to watch the resize for before giving up")
+	statusCmd.Flags().DurationVar(&ops.interval, "interval", defaultControlPlaneResizeInterval, "How often to check the progress of the resize")
+	statusCmd.MarkFlagRequired("cluster-id")
+
+	return statusCmd
+}
+
+func (o *controlPlaneResizeStatus) New() error {
+	connection, err := utils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return errors.New("this command should not be used for HCP clusters")
+	}
+
+	o.clusterID = cluster.ID()
+	o.client, err = newControlPlaneStatusClient(o.clusterID)
+
+	return err
+}
+
+// newControlPlaneStatusClient returns a client able to read everything watched during a control plane resize
+func newControlPlaneStatusClient(clusterID string) (client.Client, error) {
+	scheme := runtime.NewScheme()
+	if err := machinev1.Install(scheme); err != nil {
+		return nil, err
+	}
+	if err := machinev1beta1.Install(scheme); err != nil {
+		return nil, err
+	}
+	if err := operatorv1.Install(scheme); err != nil {
+		return nil, err
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	return k8s.New(clusterID, client.Options{Scheme: scheme})
+}
+
+// controlPlaneMachineProgress is the progress of the resize of a single control plane machine
+type controlPlaneMachineProgress struct {
+	Machine      string
+	Node         string
+	Phase        string
+	InstanceType string
+	Stage        string
+	// Since is how long the machine has been deleting, or has existed otherwise, to spot machines stuck in a stage
+	Since time.Duration
+	// Reason explains why the machine is in a failed or blocked stage
+	Reason string
+}
+
+// controlPlaneProgress is the progress of a control plane resize
+type controlPlaneProgress struct {
+	TargetInstanceType string
+	Replicas           int32
+	UpdatedReplicas    int32
+	ReadyReplicas      int32
+	// EtcdMembers is the message of the etcd operator's EtcdMembersAvailable condition
+	EtcdMembers string
+	Machines    []controlPlaneMachineProgress
+	// Failures are problems which won't resolve themselves without intervention
+	Failures []string
+}
+
+// Done returns true when every control plane machine runs the target instance type and has joined etcd
+func (p *controlPlaneProgress) Done() bool {
+	if p.Replicas == 0 || p.UpdatedReplicas != p.Replicas || p.ReadyReplicas != p.Replicas {
+		return false
+	}
+
+	done := int32(0)
+	for _, m := range p.Machines {
+		if m.Stage != stageDone {
+			return false
+		}
+		done++
+	}
+
+	return done == p.Replicas
+}
+
+// Failed returns true if the resize can't progress without intervention
+func (p *controlPlaneProgress) Failed() bool {
+	return len(p.Failures) > 0
+}
+
+// getControlPlaneProgress determines the progress of a control plane resize from the control plane machine set, the
+// control plane machines and their nodes, and the etcd pods and operator
+func getControlPlaneProgress(ctx context.Context, c client.Client) (*controlPlaneProgress, error) {
+	cpms := &machinev1.ControlPlaneMachineSet{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: cpmsNamespace, Name: cpmsName}, cpms); err != nil {
+		return nil, fmt.Errorf("error retrieving control plane machine set: %v", err)
+	}
+
+	target, err := providerSpecInstanceType(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value)
+	if err != nil {
+		return nil, fmt.Errorf("error reading control plane machine set instance type: %v", err)
+	}
+
+	progress := &controlPlaneProgress{
+		TargetInstanceType: target,
+		Replicas:           cpms.Status.Replicas,
+		UpdatedReplicas:    cpms.Status.UpdatedReplicas,
+		ReadyReplicas:      cpms.Status.ReadyReplicas,
+	}
+	if cpms.Spec.Replicas != nil {
+		progress.Replicas = *cpms.Spec.Replicas
+	}
+	if degraded := meta.FindStatusCondition(cpms.Status.Conditions, "Degraded"); degraded != nil && degraded.Status == metav1.ConditionTrue {
+		progress.Failures = append(progress.Failures, fmt.Sprintf("control plane machine set is degraded: %s", degraded.Message))
+	}
+
+	etcd := &operatorv1.Etcd{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "cluster"}, etcd); err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("error retrieving etcd operator status: %v", err)
+	}
+	for _, cond := range etcd.Status.Conditions {
+		if cond.Type == etcdMembersAvailable {
+			progress.EtcdMembers = cond.Message
+		}
+	}
+
+	machines := &machinev1beta1.MachineList{}
+	if err := c.List(ctx, machines, client.InNamespace(cpmsNamespace), client.MatchingLabels{controlPlaneMachineRoleLabel: "master"}); err != nil {
+		return nil, fmt.Errorf("error retrieving control plane machines: %v", err)
+	}
+
+	for _, machine := range machines.Items {
+		m, err := machineProgress(ctx, c, machine, target)
+		if err != nil {
+			return nil, err
+		}
+		if m.Stage == stageFailed {
+			progress.Failures = append(progress.Failures, fmt.Sprintf("machine %s failed: %s", m.Machine, m.Reason))
+		}
+		progress.Machines = append(progress.Machines, m)
+	}
+
+	sort.Slice(progress.Machines, func(i, j int) bool {
+		return progress.Machines[i].Machine < progress.Machines[j].Machine
+	})
+
+	return progress, nil
+}
+
+// machineProgress determines the stage of the resize a control plane machine is in
+func machineProgress(ctx context.Context, c client.Client, machine machinev1beta1.Machine, target string) (controlPlaneMachineProgress, error) {
+	m := controlPlaneMachineProgress{
+		Machine: machine.Name,
+		Since:   time.Since(machine.CreationTimestamp.Time).Round(time.Second),
+	}
+	if machine.Status.Phase != nil {
+		m.Phase = *machine.Status.Phase
+	}
+	if machine.Status.NodeRef != nil {
+		m.Node = machine.Status.NodeRef.Name
+	}
+	if machine.Spec.ProviderSpec.Value != nil {
+		instanceType, err := providerSpecInstanceType(machine.Spec.ProviderSpec.Value)
+		if err != nil {
+			return m, fmt.Errorf("error reading instance type of machine %s: %v", machine.Name, err)
+		}
+		m.InstanceType = instanceType
+	}
+
+	switch {
+	case m.Phase == machinev1beta1.PhaseFailed:
+		m.Stage = stageFailed
+		if machine.Status.ErrorMessage != nil {
+			m.Reason = *machine.Status.ErrorMessage
+		}
+		return m, nil
+	case machine.DeletionTimestamp != nil:
+		m.Stage = stageDeleting
+		m.Since = time.Since(machine.DeletionTimestamp.Time).Round(time.Second)
+		return m, nil
+	case m.InstanceType != target:
+		m.Stage = stagePendingReplacement
+		return m, nil
+	case m.Phase != machinev1beta1.PhaseRunning || m.Node == "":
+		m.Stage = stageProvisioning
+		return m, nil
+	}
+
+	node := &corev1.Node{}
+	if err := c.Get(ctx, client.ObjectKey{Name: m.Node}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			m.Stage = stageNodeJoining
+			return m, nil
+		}
+		return m, fmt.Errorf("error retrieving node %s: %v", m.Node, err)
+	}
+	if !isConditionTrue(node.Status.Conditions, corev1.NodeReady) {
+		m.Stage = stageNodeJoining
+		return m, nil
+	}
+
+	pod := &corev1.Pod{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: etcdNamespace, Name: "etcd-" + m.Node}, pod); err != nil {
+		if apierrors.IsNotFound(err) {
+			m.Stage = stageEtcdJoining
+			return m, nil
+		}
+		return m, fmt.Errorf("error retrieving etcd pod of node %s: %v", m.Node, err)
+	}
+	if !isPodReady(pod) {
+		m.Stage = stageEtcdJoining
+		return m, nil
+	}
+
+	m.Stage = stageDone
+	return m, nil
+}
+
+// providerSpecInstanceType returns the AWS instance type or GCP machine type of a machine provider spec
+func providerSpecInstanceType(raw *runtime.RawExtension) (string, error) {
+	if raw == nil {
+		return "", errors.New("machine has no provider spec")
+	}
+
+	spec := struct {
+		// InstanceType is set on AWS
+		InstanceType string `json:"instanceType"`
+		// MachineType is set on GCP
+		MachineType string `json:"machineType"`
+	}{}
+	if err := json.Unmarshal(raw.Raw, &spec); err != nil {
+		return "", err
+	}
+	if spec.InstanceType != "" {
+		return spec.InstanceType, nil
+	}
+
+	return spec.MachineType, nil
+}
+
+func isConditionTrue(conditions []corev1.NodeCondition, conditionType corev1.NodeConditionType) bool {
+	for _, cond := range conditions {
+		if cond.Type == conditionType {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func printControlPlaneProgress(w io.Writer, p *controlPlaneProgress) error {
+	fmt.Fprintf(w, "Control plane machine set: %d/%d updated to %s, %d/%d ready\n", p.UpdatedReplicas, p.Replicas, p.TargetInstanceType, p.ReadyReplicas, p.Replicas)
+	if p.EtcdMembers != "" {
+		fmt.Fprintf(w, "etcd: %s\n", p.EtcdMembers)
+	}
+
+	table := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	table.AddRow([]string{"MACHINE", "NODE", "INSTANCE TYPE", "PHASE", "STAGE", "AGE"})
+	for _, m := range p.Machines {
+		table.AddRow([]string{m.Machine, m.Node, m.InstanceType, m.Phase, m.Stage, m.Since.String()})
+	}
+
+	return table.Flush()
+}
+
+// nextSteps returns what to check for each machine which is failed or hasn't progressed
+func (p *controlPlaneProgress) nextSteps() []string {
+	steps := append([]string{}, p.Failures...)
+	for _, m := range p.Machines {
+		switch m.Stage {
+		case stageFailed:
+			steps = append(steps, fmt.Sprintf("Inspect the failed machine with 'oc describe machine -n %s %s', e.g. for capacity or quota errors for %s, then delete it so the control plane machine set replaces it", cpmsNamespace, m.Machine, m.InstanceType))
+		case stageDeleting:
+			steps = append(steps, fmt.Sprintf("Machine %s has been deleting for %s, its drain is likely blocked. Check for pods blocked by PodDisruptionBudgets with 'oc get pods -A -o wide --field-selector spec.nodeName=%s'", m.Machine, m.Since, m.Node))
+		case stageProvisioning:
+			steps = append(steps, fmt.Sprintf("Machine %s is still %s, check the machine controller with 'oc logs -n %s deploy/machine-api-controllers -c machine-controller'", m.Machine, stageProvisioning, cpmsNamespace))
+		case stageNodeJoining:
+			steps = append(steps, fmt.Sprintf("Node %s of machine %s is not Ready, check for pending CSRs with 'oc get csr' and the node's conditions with 'oc describe node %s'", m.Node, m.Machine, m.Node))
+		case stageEtcdJoining:
+			steps = append(steps, fmt.Sprintf("etcd on node %s has not joined, check the etcd members with 'osdctl cluster etcd-health-check'", m.Node))
+		case stagePendingReplacement:
+			steps = append(steps, fmt.Sprintf("Machine %s has not been replaced yet, check the control plane machine set operator with 'oc logs -n %s deploy/control-plane-machine-set-operator'", m.Machine, cpmsNamespace))
+		}
+	}
+
+	return steps
+}
+
+// fingerprint summarizes the progress without the ages of machines, to detect when it changes
+func (p *controlPlaneProgress) fingerprint() string {
+	key := fmt.Sprintf("%d/%d/%d %s", p.UpdatedReplicas, p.ReadyReplicas, p.Replicas, p.EtcdMembers)
+	for _, m := range p.Machines {
+		key += fmt.Sprintf(" %s/%s/%s/%s", m.Machine, m.Node, m.Phase, m.Stage)
+	}
+
+	return key
+}
+
+func printNextSteps(w io.Writer, p *controlPlaneProgress) {
+	steps := p.nextSteps()
+	if len(steps) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nThe resize needs attention:")
+	for _, step := range steps {
+		fmt.Fprintf(w, "  - %s\n", step)
+	}
+}
+
+// watchControlPlaneResize reports the progress of a control plane resize whenever it changes, until it completes,
+// fails, or times out
+func watchControlPlaneResize(ctx context.Context, c client.Client, out io.Writer, timeout, interval time.Duration) error {
+	var (
+		last     string
+		progress *controlPlaneProgress
+	)
+
+	err := wait.PollUntilContextTimeout(ctx, interval, timeout, true, func(ctx context.Context) (bool, error) {
+		p, err := getControlPlaneProgress(ctx, c)
+		if err != nil {
+			log.Printf("error retrieving control plane resize progress, continuing to wait: %s", err)
+			return false, nil
+		}
+		progress = p
+
+		// Only report changes, ignoring the ages which change on every check
+		if key := p.fingerprint(); key != last {
+			last = key
+			fmt.Fprintf(out, "\n%s\n", time.Now().Format(time.RFC3339))
+			if err := printControlPlaneProgress(out, p); err != nil {
+				return false, err
+			}
+		}
+
+		return p.Done() || p.Failed(), nil
+	})
+
+	switch {
+	case err == nil && progress.Failed():
+		printNextSteps(out, progress)
+		return errors.New("the control plane resize failed")
+	case err == nil:
+		fmt.Fprintln(out, "\nThe control plane resize completed successfully")
+		return nil
+	case wait.Interrupted(err):
+		fmt.Fprintf(out, "\nTimed out after %s waiting for the control plane resize to complete\n", timeout)
+		if progress != nil {
+			printNextSteps(out, progress)
+		}
+		return errors.New("timed out waiting for the control plane resize")
+	default:
+		return err
+	}
+}
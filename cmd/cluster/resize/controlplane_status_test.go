@@ -0,0 +1,201 @@
+package resize
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestStatusClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	for _, install := range []func(*runtime.Scheme) error{machinev1.Install, machinev1beta1.Install, operatorv1.Install, corev1.AddToScheme} {
+		if err := install(scheme); err != nil {
+			t.Fatalf("failed to build scheme: %s", err)
+		}
+	}
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func awsProviderSpec(instanceType string) machinev1beta1.ProviderSpec {
+	return machinev1beta1.ProviderSpec{Value: &runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"instanceType":%q}`, instanceType))}}
+}
+
+func newTestCPMS(instanceType string, replicas, updated, ready int32) *machinev1.ControlPlaneMachineSet {
+	cpms := &machinev1.ControlPlaneMachineSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cpmsNamespace, Name: cpmsName},
+		Spec:       machinev1.ControlPlaneMachineSetSpec{Replicas: &replicas},
+		Status:     machinev1.ControlPlaneMachineSetStatus{Replicas: replicas, UpdatedReplicas: updated, ReadyReplicas: ready},
+	}
+	cpms.Spec.Template.OpenShiftMachineV1Beta1Machine = &machinev1.OpenShiftMachineV1Beta1MachineTemplate{
+		Spec: machinev1beta1.MachineSpec{ProviderSpec: awsProviderSpec(instanceType)},
+	}
+
+	return cpms
+}
+
+func newTestMachine(name, instanceType, phase, node string) *machinev1beta1.Machine {
+	machine := &machinev1beta1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cpmsNamespace,
+			Name:      name,
+			Labels:    map[string]string{controlPlaneMachineRoleLabel: "master"},
+		},
+		Spec:   machinev1beta1.MachineSpec{ProviderSpec: awsProviderSpec(instanceType)},
+		Status: machinev1beta1.MachineStatus{Phase: &phase},
+	}
+	if node != "" {
+		machine.Status.NodeRef = &corev1.ObjectReference{Name: node}
+	}
+
+	return machine
+}
+
+func newTestNode(name string, ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}},
+	}
+}
+
+func newTestEtcdPod(node string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: etcdNamespace, Name: "etcd-" + node},
+		Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func TestGetControlPlaneProgress(t *testing.T) {
+	deleting := newTestMachine("master-0", "m5.xlarge", machinev1beta1.PhaseRunning, "node-0")
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+	deleting.Finalizers = []string{"machine.machine.openshift.io"}
+
+	failedMessage := "InsufficientInstanceCapacity"
+	failed := newTestMachine("master-f", "m5.2xlarge", machinev1beta1.PhaseFailed, "")
+	failed.Status.ErrorMessage = &failedMessage
+
+	c := newTestStatusClient(t,
+		newTestCPMS("m5.2xlarge", 3, 1, 3),
+		&operatorv1.Etcd{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Status: operatorv1.EtcdStatus{StaticPodOperatorStatus: operatorv1.StaticPodOperatorStatus{OperatorStatus: operatorv1.OperatorStatus{
+				Conditions: []operatorv1.OperatorCondition{{Type: etcdMembersAvailable, Message: "3 members are available"}},
+			}}},
+		},
+		deleting,
+		newTestMachine("master-1", "m5.xlarge", machinev1beta1.PhaseRunning, "node-1"),
+		newTestMachine("master-a", "m5.2xlarge", "Provisioning", ""),
+		newTestMachine("master-b", "m5.2xlarge", machinev1beta1.PhaseRunning, "node-b"),
+		newTestNode("node-b", false),
+		newTestMachine("master-c", "m5.2xlarge", machinev1beta1.PhaseRunning, "node-c"),
+		newTestNode("node-c", true),
+		newTestEtcdPod("node-c", false),
+		newTestMachine("master-d", "m5.2xlarge", machinev1beta1.PhaseRunning, "node-d"),
+		newTestNode("node-d", true),
+		newTestEtcdPod("node-d", true),
+		failed,
+	)
+
+	progress, err := getControlPlaneProgress(context.Background(), c)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"master-0": stageDeleting,
+		"master-1": stagePendingReplacement,
+		"master-a": stageProvisioning,
+		"master-b": stageNodeJoining,
+		"master-c": stageEtcdJoining,
+		"master-d": stageDone,
+		"master-f": stageFailed,
+	}
+	if len(progress.Machines) != len(expected) {
+		t.Fatalf("expected %d machines, got %d", len(expected), len(progress.Machines))
+	}
+	for _, m := range progress.Machines {
+		if m.Stage != expected[m.Machine] {
+			t.Errorf("expected machine %s to be in stage %q, got %q", m.Machine, expected[m.Machine], m.Stage)
+		}
+	}
+
+	if progress.TargetInstanceType != "m5.2xlarge" {
+		t.Errorf("expected target instance type m5.2xlarge, got %s", progress.TargetInstanceType)
+	}
+	if progress.EtcdMembers != "3 members are available" {
+		t.Errorf("expected etcd members message, got %q", progress.EtcdMembers)
+	}
+	if !progress.Failed() || !strings.Contains(progress.Failures[0], failedMessage) {
+		t.Errorf("expected the failed machine to be reported, got %v", progress.Failures)
+	}
+	if progress.Done() {
+		t.Errorf("expected the resize not to be done")
+	}
+}
+
+func TestControlPlaneProgressDone(t *testing.T) {
+	objs := []client.Object{newTestCPMS("m5.2xlarge", 3, 3, 3)}
+	for i := 0; i < 3; i++ {
+		node := fmt.Sprintf("node-%d", i)
+		objs = append(objs,
+			newTestMachine(fmt.Sprintf("master-%d", i), "m5.2xlarge", machinev1beta1.PhaseRunning, node),
+			newTestNode(node, true),
+			newTestEtcdPod(node, true),
+		)
+	}
+
+	progress, err := getControlPlaneProgress(context.Background(), newTestStatusClient(t, objs...))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !progress.Done() {
+		t.Errorf("expected the resize to be done, got %+v", progress)
+	}
+	if steps := progress.nextSteps(); len(steps) != 0 {
+		t.Errorf("expected no next steps, got %v", steps)
+	}
+}
+
+func TestWatchControlPlaneResizeTimeout(t *testing.T) {
+	c := newTestStatusClient(t,
+		newTestCPMS("m5.2xlarge", 1, 0, 1),
+		newTestMachine("master-0", "m5.2xlarge", "Provisioning", ""),
+	)
+
+	out := &bytes.Buffer{}
+	err := watchControlPlaneResize(context.Background(), c, out, 50*time.Millisecond, 10*time.Millisecond)
+	if err == nil {
+		t.Fatalf("expected a timeout error")
+	}
+
+	// The progress is only printed once since it doesn't change
+	if n := strings.Count(out.String(), "Control plane machine set:"); n != 1 {
+		t.Errorf("expected progress to be printed once, got %d times:\n%s", n, out.String())
+	}
+	if !strings.Contains(out.String(), "machine-api-controllers") {
+		t.Errorf("expected the summary to point at the machine controller, got:\n%s", out.String())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"strings"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/openshift/osdctl/pkg/k8s"
 	"github.com/openshift/osdctl/pkg/osdCloud"
 	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/openshift/osdctl/pkg/workflow"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -36,6 +38,10 @@ const (
 	GCPresizedInfraNodeServiceLogTemplate = "https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/gcp/GCP_infranode_resized_auto.json"
 	infraNodeLabel                        = "node-role.kubernetes.io/infra"
 	temporaryInfraNodeLabel               = "osdctl.openshift.io/infra-resize-temporary-machinepool"
+
+	// Keys of the values recorded in the workflow state so that a resumed resize targets the same instance type
+	originalMachinePoolKey = "originalMachinePool"
+	instanceTypeKey        = "instanceType"
 )
 
 type Infra struct {
@@ -57,6 +63,11 @@ type Infra struct {
 
 	// OHSS ticket to reference in SL
 	ohss string
+
+	workflow workflow.Options
+
+	// skipValidation skips checking the instance type against the cloud provider
+	skipValidation bool
 }
 
 func newCmdResizeInfra() *cobra.Command {
@@ -73,6 +84,12 @@ func newCmdResizeInfra() *cobra.Command {
   Remember to follow the SOP for preparation and follow up steps:
 
     https://github.com/openshift/ops-sop/blob/master/v4/howto/resize-infras-workers.md
+
+  Before resizing, the instance type is checked to be supported, offered in every availability zone of the cluster, and
+  within the vCPU quota for both the temporary and the new machinepool.
+
+  The resize is run as a sequence of checkpointed steps. If a step fails, fix the problem and re-run the command with
+  --resume to continue from the failed step, or with --rollback to undo the completed steps.
 `,
 		Example: `
   # Automatically vertically scale infra nodes to the next size
@@ -80,6 +97,12 @@ func newCmdResizeInfra() *cobra.Command {
 
   # Resize infra nodes to a specific instance type
   osdctl cluster resize infra --cluster-id ${CLUSTER_ID} --instance-type "r5.xlarge"
+
+  # Recommend an instance type to resize infra nodes to
+  osdctl cluster resize recommend --cluster-id ${CLUSTER_ID} --role infra
+
+  # Continue a resize which failed partway
+  osdctl cluster resize infra --cluster-id ${CLUSTER_ID} --reason OHSS-1234 --justification "..." --ohss OHSS-1234 --resume
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return r.RunInfra(context.Background())
@@ -91,6 +114,8 @@ func newCmdResizeInfra() *cobra.Command {
 	infraResizeCmd.Flags().StringVar(&r.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usually an OHSS or PD ticket)")
 	infraResizeCmd.Flags().StringVar(&r.justification, "justification", "", "The justification behind resize")
 	infraResizeCmd.Flags().StringVar(&r.ohss, "ohss", "", "OHSS ticket tracking this infra node resize")
+	infraResizeCmd.Flags().BoolVar(&r.skipValidation, "skip-validation", false, "Skip checking the instance type against the cloud provider, e.g. when cloud credentials are unavailable")
+	r.workflow.AddFlags(infraResizeCmd.Flags())
 
 	infraResizeCmd.MarkFlagRequired("cluster-id")
 	infraResizeCmd.MarkFlagRequired("justification")
@@ -161,15 +186,39 @@ func (r *Infra) New() error {
 }
 
 func (r *Infra) RunInfra(ctx context.Context) error {
+	if err := r.workflow.Validate(); err != nil {
+		return err
+	}
 	if err := r.New(); err != nil {
 		return fmt.Errorf("failed to initialize command: %v", err)
 	}
 
+	store, err := workflow.DefaultStore()
+	if err != nil {
+		return err
+	}
+	w, err := workflow.New(store, "resize-infra", r.clusterId, os.Stdout)
+	if err != nil {
+		return err
+	}
+
 	log.Printf("resizing infra nodes for %s - %s", r.cluster.Name(), r.clusterId)
-	originalMp, err := r.getInfraMachinePool(ctx)
+
+	// The original machinepool is deleted partway, so a resumed run relies on the one recorded when it started
+	originalMp := &hivev1.MachinePool{}
+	found, err := w.Value(originalMachinePoolKey, originalMp)
 	if err != nil {
 		return err
 	}
+	if found {
+		if _, err := w.Value(instanceTypeKey, &r.instanceType); err != nil {
+			return err
+		}
+	} else {
+		if originalMp, err = r.getInfraMachinePool(ctx); err != nil {
+			return err
+		}
+	}
 	originalInstanceType, err := getInstanceType(originalMp)
 	if err != nil {
 		return fmt.Errorf("failed to parse instance type from machinepool: %v", err)
@@ -189,153 +238,234 @@ func (r *Infra) RunInfra(ctx context.Context) error {
 		return fmt.Errorf("failed to parse instance type from machinepool: %v", err)
 	}
 
-	// Create the temporary machinepool
-	log.Printf("planning to resize to instance type from %s to %s", originalInstanceType, instanceType)
-	if !utils.ConfirmPrompt() {
-		log.Printf("exiting")
-		return nil
+	if err := r.addSteps(w, originalMp, newMp, tempMp); err != nil {
+		return err
 	}
 
-	log.Printf("creating temporary machinepool %s, with instance type %s", tempMp.Name, instanceType)
-	if err := r.hiveAdmin.Create(ctx, tempMp); err != nil {
-		return err
+	if !w.Resumable() && !r.workflow.Plan && !r.workflow.Rollback {
+		if !r.skipValidation {
+			if err := r.validateInstanceType(ctx, originalInstanceType, *originalMp.Spec.Replicas); err != nil {
+				return err
+			}
+		}
+		log.Printf("planning to resize to instance type from %s to %s", originalInstanceType, instanceType)
+		if !utils.ConfirmPrompt() {
+			log.Printf("exiting")
+			return nil
+		}
+		if err := w.SetValue(originalMachinePoolKey, originalMp); err != nil {
+			return err
+		}
+		if err := w.SetValue(instanceTypeKey, r.instanceType); err != nil {
+			return err
+		}
 	}
 
-	// This selector will match all infra nodes
-	selector, err := labels.Parse(infraNodeLabel)
+	return r.workflow.Execute(ctx, w)
+}
+
+// validateInstanceType checks the instance type being resized to against the cloud provider, recommending one which
+// can be used if it cannot
+func (r *Infra) validateInstanceType(ctx context.Context, originalInstanceType string, replicas int64) error {
+	ocmClient, err := utils.CreateConnection()
 	if err != nil {
 		return err
 	}
+	defer ocmClient.Close()
 
-	if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
-		nodes := &corev1.NodeList{}
-
-		if err := r.client.List(ctx, nodes, &client.ListOptions{LabelSelector: selector}); err != nil {
-			log.Printf("error retrieving nodes list, continuing to wait: %s", err)
-			return false, nil
-		}
+	advisor, err := newSizingAdvisor(ctx, ocmClient, r.cluster, r.client, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to check the instance type against the cloud provider, use --skip-validation to skip the check: %v", err)
+	}
+	defer advisor.close()
 
-		readyNodes := 0
-		log.Printf("waiting for %d infra nodes to be reporting Ready", int(*originalMp.Spec.Replicas)*2)
-		for _, node := range nodes.Items {
-			for _, cond := range node.Status.Conditions {
-				if cond.Type == corev1.NodeReady {
-					if cond.Status == corev1.ConditionTrue {
-						readyNodes++
-						log.Printf("found node %s reporting Ready", node.Name)
-					}
-				}
-			}
-		}
+	return advisor.validate(ctx, newInfraSizingRequest(originalInstanceType, replicas), r.instanceType)
+}
 
-		switch {
-		case readyNodes >= int(*originalMp.Spec.Replicas)*2:
-			return true, nil
-		default:
-			log.Printf("found %d infra nodes reporting Ready, continuing to wait", readyNodes)
-			return false, nil
-		}
-	}); err != nil {
+// addSteps adds the steps of the "machinepool dance" to w: the infra nodes are moved to a temporary machinepool of the
+// new instance type while the original machinepool is replaced with one of the new instance type
+func (r *Infra) addSteps(w *workflow.Workflow, originalMp, newMp, tempMp *hivev1.MachinePool) error {
+	replicas := int(*originalMp.Spec.Replicas)
+	originalNodeSelector, tempNodeSelector, err := infraNodeSelectors()
+	if err != nil {
 		return err
 	}
 
-	// Identify the original nodes and temp nodes
-	// requireInfra matches all infra nodes using the selector from above
+	w.AddStep(workflow.Step{
+		Name:        "create-temporary-machinepool",
+		Description: fmt.Sprintf("Create temporary machinepool %s", tempMp.Name),
+		Do: func(ctx context.Context) error {
+			return r.createMachinePool(ctx, tempMp)
+		},
+		Rollback: func(ctx context.Context) error {
+			return r.deleteMachinePool(ctx, tempMp)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "wait-for-temporary-nodes",
+		Description: fmt.Sprintf("Wait for %d infra nodes to be Ready", replicas*2),
+		Do: func(ctx context.Context) error {
+			return r.waitForReadyInfraNodes(ctx, replicas*2)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "delete-original-machinepool",
+		Description: fmt.Sprintf("Delete original machinepool %s", originalMp.Name),
+		Do: func(ctx context.Context) error {
+			return r.deleteMachinePool(ctx, originalMp)
+		},
+		Rollback: func(ctx context.Context) error {
+			if err := r.createMachinePool(ctx, recreatableMachinePool(originalMp)); err != nil {
+				return err
+			}
+			// The temporary machinepool is deleted by the next rollback, which must not leave the cluster without
+			// Ready infra nodes
+			return r.waitForReadyInfraNodes(ctx, replicas*2)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "wait-for-original-nodes-removed",
+		Description: "Wait for the original infra nodes to be removed, terminating their instances if they don't drain",
+		Do: func(ctx context.Context) error {
+			return r.waitForNodesRemoved(ctx, originalNodeSelector)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "create-new-machinepool",
+		Description: fmt.Sprintf("Create new machinepool %s", newMp.Name),
+		Do: func(ctx context.Context) error {
+			return r.createMachinePool(ctx, newMp)
+		},
+		Rollback: func(ctx context.Context) error {
+			return r.deleteMachinePool(ctx, newMp)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "wait-for-new-nodes",
+		Description: fmt.Sprintf("Wait for %d infra nodes to be Ready", replicas*2),
+		Do: func(ctx context.Context) error {
+			return r.waitForReadyInfraNodes(ctx, replicas*2)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "delete-temporary-machinepool",
+		Description: fmt.Sprintf("Delete temporary machinepool %s", tempMp.Name),
+		Do: func(ctx context.Context) error {
+			return r.deleteMachinePool(ctx, tempMp)
+		},
+		Rollback: func(ctx context.Context) error {
+			if err := r.createMachinePool(ctx, recreatableMachinePool(tempMp)); err != nil {
+				return err
+			}
+			// The new machinepool is deleted by the next rollback, which must not leave the cluster without Ready
+			// infra nodes
+			return r.waitForReadyInfraNodes(ctx, replicas*2)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "wait-for-temporary-nodes-removed",
+		Description: fmt.Sprintf("Wait for the infra node count to return to %d, terminating instances if they don't drain", replicas),
+		Do: func(ctx context.Context) error {
+			return r.waitForInfraNodeCount(ctx, replicas, tempNodeSelector)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "send-service-log",
+		Description: "Send a service log to notify the customer of the resize",
+		Do: func(ctx context.Context) error {
+			postCmd := generateServiceLog(tempMp, r.instanceType, r.justification, r.clusterId, r.ohss)
+			if err := postCmd.Run(); err != nil {
+				fmt.Println("Failed to generate service log. Please manually send a service log to the customer for the blocked egresses with:")
+				fmt.Printf("osdctl servicelog post %v -t %v -p %v\n",
+					r.clusterId, resizedInfraNodeServiceLogTemplate, strings.Join(postCmd.TemplateParams, " -p "))
+			}
+			return nil
+		},
+	})
+
+	return nil
+}
+
+// infraNodeSelectors returns selectors matching the original infra nodes and the temporary infra nodes respectively
+func infraNodeSelectors() (labels.Selector, labels.Selector, error) {
+	// requireInfra matches all infra nodes
 	requireInfra, err := labels.NewRequirement(infraNodeLabel, selection.Exists, nil)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// requireNotTempNode matches all nodes that do not have the temporaryInfraNodeLabel, created with the new (temporary) machine pool
 	requireNotTempNode, err := labels.NewRequirement(temporaryInfraNodeLabel, selection.DoesNotExist, nil)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// requireTempNode matches the opposite of above, all nodes that *do* have the temporaryInfraNodeLabel
 	requireTempNode, err := labels.NewRequirement(temporaryInfraNodeLabel, selection.Exists, nil)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// infraNode + notTempNode = original nodes
-	originalNodeSelector := selector.Add(*requireInfra, *requireNotTempNode)
+	originalNodeSelector := labels.NewSelector().Add(*requireInfra, *requireNotTempNode)
 
 	// infraNode + tempNode = temp nodes
-	tempNodeSelector := selector.Add(*requireInfra, *requireTempNode)
+	tempNodeSelector := labels.NewSelector().Add(*requireInfra, *requireTempNode)
+
+	return originalNodeSelector, tempNodeSelector, nil
+}
+
+// createMachinePool creates mp, tolerating it already existing from a previous run which failed partway
+func (r *Infra) createMachinePool(ctx context.Context, mp *hivev1.MachinePool) error {
+	instanceType, err := getInstanceType(mp)
+	if err != nil {
+		return fmt.Errorf("failed to parse instance type from machinepool: %v", err)
+	}
 
-	originalNodes := &corev1.NodeList{}
-	if err := r.client.List(ctx, originalNodes, &client.ListOptions{LabelSelector: originalNodeSelector}); err != nil {
+	log.Printf("creating machinepool %s, with instance type %s", mp.Name, instanceType)
+	if err := r.hiveAdmin.Create(ctx, mp); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			log.Printf("machinepool %s already exists", mp.Name)
+			return nil
+		}
 		return err
 	}
 
-	// Delete original machinepool
-	log.Printf("deleting original machinepool %s, with instance type %s", originalMp.Name, originalInstanceType)
-	if err := r.hiveAdmin.Delete(ctx, originalMp); err != nil {
+	return nil
+}
+
+// deleteMachinePool deletes mp and waits for it to be deleted, tolerating it already being deleted
+func (r *Infra) deleteMachinePool(ctx context.Context, mp *hivev1.MachinePool) error {
+	log.Printf("deleting machinepool %s", mp.Name)
+	if err := r.hiveAdmin.Delete(ctx, mp); err != nil && !apierrors.IsNotFound(err) {
 		return err
 	}
 
-	// Wait for original machinepool to delete
-	if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
-		mp := &hivev1.MachinePool{}
-		err := r.hive.Get(ctx, client.ObjectKey{Namespace: originalMp.Namespace, Name: originalMp.Name}, mp)
+	return wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
+		existing := &hivev1.MachinePool{}
+		err := r.hive.Get(ctx, client.ObjectKey{Namespace: mp.Namespace, Name: mp.Name}, existing)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
 				return true, nil
 			}
-			log.Printf("error retrieving machines list, continuing to wait: %s", err)
+			log.Printf("error retrieving machinepool, continuing to wait: %s", err)
 			return false, nil
 		}
 
-		log.Printf("original machinepool %s/%s still exists, continuing to wait", originalMp.Namespace, originalMp.Name)
+		log.Printf("machinepool %s/%s still exists, continuing to wait", mp.Namespace, mp.Name)
 		return false, nil
-	}); err != nil {
-		return err
-	}
-
-	// Wait for original nodes to delete
-	if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
-		// Re-check for originalNodes to see if they have been deleted
-		return skipError(wrapResult(r.nodesMatchExpectedCount(ctx, originalNodeSelector, 0)), "error matching expected count")
-	}); err != nil {
-		switch {
-		case errors.Is(err, wait.ErrWaitTimeout):
-			log.Printf("Warning: timed out waiting for nodes to drain: %v. Terminating backing cloud instances.", err.Error())
-
-			// Terminate the backing cloud instances if they are not removed by the 20 minute timeout
-			err := r.terminateCloudInstances(ctx, originalNodes)
-			if err != nil {
-				return err
-			}
-
-			if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
-				log.Printf("waiting for nodes to terminate")
-				return skipError(wrapResult(r.nodesMatchExpectedCount(ctx, originalNodeSelector, 0)), "error matching expected count")
-			}); err != nil {
-				if errors.Is(err, wait.ErrWaitTimeout) {
-					log.Printf("timed out waiting for nodes to terminate: %v.", err.Error())
-				}
-				return err
-			}
-		default:
-			return err
-		}
-	}
+	})
+}
 
-	// Create new permanent machinepool
-	log.Printf("creating new machinepool %s, with instance type %s", newMp.Name, instanceType)
-	if err := r.hiveAdmin.Create(ctx, newMp); err != nil {
+// waitForReadyInfraNodes waits for at least count infra nodes to be reporting Ready
+func (r *Infra) waitForReadyInfraNodes(ctx context.Context, count int) error {
+	selector, err := labels.Parse(infraNodeLabel)
+	if err != nil {
 		return err
 	}
 
-	// Wait for new permanent machines to become nodes
-	if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
+	return wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
 		nodes := &corev1.NodeList{}
-		selector, err := labels.Parse("node-role.kubernetes.io/infra=")
-		if err != nil {
-			// This should never happen, so we do not have to skip this error
-			return false, err
-		}
 
 		if err := r.client.List(ctx, nodes, &client.ListOptions{LabelSelector: selector}); err != nil {
 			log.Printf("error retrieving nodes list, continuing to wait: %s", err)
@@ -343,7 +473,7 @@ func (r *Infra) RunInfra(ctx context.Context) error {
 		}
 
 		readyNodes := 0
-		log.Printf("waiting for %d infra nodes to be reporting Ready", int(*originalMp.Spec.Replicas)*2)
+		log.Printf("waiting for %d infra nodes to be reporting Ready", count)
 		for _, node := range nodes.Items {
 			for _, cond := range node.Status.Conditions {
 				if cond.Type == corev1.NodeReady {
@@ -356,52 +486,38 @@ func (r *Infra) RunInfra(ctx context.Context) error {
 		}
 
 		switch {
-		case readyNodes >= int(*originalMp.Spec.Replicas)*2:
+		case readyNodes >= count:
 			return true, nil
 		default:
 			log.Printf("found %d infra nodes reporting Ready, continuing to wait", readyNodes)
 			return false, nil
 		}
-	}); err != nil {
-		return err
-	}
+	})
+}
 
-	tempNodes := &corev1.NodeList{}
-	if err := r.client.List(ctx, tempNodes, &client.ListOptions{LabelSelector: tempNodeSelector}); err != nil {
-		return err
+// waitForNodesRemoved waits for the nodes matching selector to be removed, terminating their backing cloud instances if
+// they aren't removed within the timeout
+func (r *Infra) waitForNodesRemoved(ctx context.Context, selector labels.Selector) error {
+	err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
+		return skipError(wrapResult(r.nodesMatchExpectedCount(ctx, selector, 0)), "error matching expected count")
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		log.Printf("Warning: timed out waiting for nodes to drain: %v. Terminating backing cloud instances.", err.Error())
+		return r.terminateRemainingNodes(ctx, selector)
 	}
 
-	// Delete temp machinepool
-	log.Printf("deleting temporary machinepool %s, with instance type %s", tempMp.Name, instanceType)
-	if err := r.hiveAdmin.Delete(ctx, tempMp); err != nil {
-		return err
-	}
-
-	// Wait for temporary machinepool to delete
-	if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
-		mp := &hivev1.MachinePool{}
-		err := r.hive.Get(ctx, client.ObjectKey{Namespace: tempMp.Namespace, Name: tempMp.Name}, mp)
-		if err != nil {
-			if apierrors.IsNotFound(err) {
-				return true, nil
-			}
-			log.Printf("error retrieving old machine details, continuing to wait: %s", err)
-			return false, nil
-		}
-
-		log.Printf("temporary machinepool %s/%s still exists, continuing to wait", tempMp.Namespace, tempMp.Name)
-		return false, nil
-	}); err != nil {
-		return err
-	}
+	return err
+}
 
-	// Wait for infra node count to return to normal
-	log.Printf("waiting for infra node count to return to: %d", int(*originalMp.Spec.Replicas))
-	if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
+// waitForInfraNodeCount waits for the infra node count to return to count, terminating the backing cloud instances of
+// the nodes matching selector if it doesn't within the timeout
+func (r *Infra) waitForInfraNodeCount(ctx context.Context, count int, selector labels.Selector) error {
+	log.Printf("waiting for infra node count to return to: %d", count)
+	err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
 		nodes := &corev1.NodeList{}
 		selector, err := labels.Parse("node-role.kubernetes.io/infra=")
 		if err != nil {
-			// This should never happen, so we do not have to skip this errorreturn false, err
+			// This should never happen, so we do not have to skip this error
 			return false, err
 		}
 
@@ -411,42 +527,42 @@ func (r *Infra) RunInfra(ctx context.Context) error {
 		}
 
 		switch len(nodes.Items) {
-		case int(*originalMp.Spec.Replicas):
+		case count:
 			log.Printf("found %d infra nodes, infra resize complete", len(nodes.Items))
 			return true, nil
 		default:
 			log.Printf("found %d infra nodes, continuing to wait", len(nodes.Items))
 			return false, nil
 		}
-	}); err != nil {
-		switch {
-		case errors.Is(err, wait.ErrWaitTimeout):
-			log.Printf("Warning: timed out waiting for nodes to drain: %v. Terminating backing cloud instances.", err.Error())
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) {
+		log.Printf("Warning: timed out waiting for nodes to drain: %v. Terminating backing cloud instances.", err.Error())
+		return r.terminateRemainingNodes(ctx, selector)
+	}
 
-			err := r.terminateCloudInstances(ctx, tempNodes)
-			if err != nil {
-				return err
-			}
+	return err
+}
 
-			if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
-				log.Printf("waiting for nodes to terminate")
-				return skipError(wrapResult(r.nodesMatchExpectedCount(ctx, tempNodeSelector, 0)), "error matching expected count")
-			}); err != nil {
-				if errors.Is(err, wait.ErrWaitTimeout) {
-					log.Printf("timed out waiting for nodes to terminate: %v.", err.Error())
-				}
-				return err
-			}
-		default:
-			return err
-		}
+// terminateRemainingNodes terminates the backing cloud instances of the nodes matching selector and waits for the nodes
+// to be removed
+func (r *Infra) terminateRemainingNodes(ctx context.Context, selector labels.Selector) error {
+	nodes := &corev1.NodeList{}
+	if err := r.client.List(ctx, nodes, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return err
+	}
+
+	if err := r.terminateCloudInstances(ctx, nodes); err != nil {
+		return err
 	}
 
-	postCmd := generateServiceLog(tempMp, r.instanceType, r.justification, r.clusterId, r.ohss)
-	if err := postCmd.Run(); err != nil {
-		fmt.Println("Failed to generate service log. Please manually send a service log to the customer for the blocked egresses with:")
-		fmt.Printf("osdctl servicelog post %v -t %v -p %v\n",
-			r.clusterId, resizedInfraNodeServiceLogTemplate, strings.Join(postCmd.TemplateParams, " -p "))
+	if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
+		log.Printf("waiting for nodes to terminate")
+		return skipError(wrapResult(r.nodesMatchExpectedCount(ctx, selector, 0)), "error matching expected count")
+	}); err != nil {
+		if errors.Is(err, wait.ErrWaitTimeout) {
+			log.Printf("timed out waiting for nodes to terminate: %v.", err.Error())
+		}
+		return err
 	}
 
 	return nil
@@ -496,17 +612,7 @@ func (r *Infra) embiggenMachinePool(mp *hivev1.MachinePool) (*hivev1.MachinePool
 		"custom-8-65536-ext": "custom-16-131072-ext",
 	}
 
-	newMp := &hivev1.MachinePool{}
-	mp.DeepCopyInto(newMp)
-
-	// Unset fields we want to be regenerated
-	newMp.CreationTimestamp = metav1.Time{}
-	newMp.Finalizers = []string{}
-	newMp.ResourceVersion = ""
-	newMp.Generation = 0
-	newMp.SelfLink = ""
-	newMp.UID = ""
-	newMp.Status = hivev1.MachinePoolStatus{}
+	newMp := recreatableMachinePool(mp)
 
 	// Update instance type sizing
 	if r.instanceType != "" {
@@ -535,6 +641,24 @@ func (r *Infra) embiggenMachinePool(mp *hivev1.MachinePool) (*hivev1.MachinePool
 	return newMp, nil
 }
 
+// recreatableMachinePool returns a copy of mp which can be created, e.g. to recreate a deleted machinepool
+func recreatableMachinePool(mp *hivev1.MachinePool) *hivev1.MachinePool {
+	newMp := &hivev1.MachinePool{}
+	mp.DeepCopyInto(newMp)
+
+	// Unset fields we want to be regenerated
+	newMp.CreationTimestamp = metav1.Time{}
+	newMp.DeletionTimestamp = nil
+	newMp.Finalizers = []string{}
+	newMp.ResourceVersion = ""
+	newMp.Generation = 0
+	newMp.SelfLink = ""
+	newMp.UID = ""
+	newMp.Status = hivev1.MachinePoolStatus{}
+
+	return newMp
+}
+
 func getInstanceType(mp *hivev1.MachinePool) (string, error) {
 	if mp.Spec.Platform.AWS != nil {
 		return mp.Spec.Platform.AWS.InstanceType, nil
@@ -7,6 +7,8 @@ import (
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
 	hivev1gcp "github.com/openshift/hive/apis/hive/v1/gcp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // newTestCluster assembles a *cmv1.Cluster while handling the error to help out with inline test-case generation
@@ -129,3 +131,51 @@ func TestConvertProviderIDtoInstanceID(t *testing.T) {
 		})
 	}
 }
+
+func TestResize_recreatableMachinePool(t *testing.T) {
+	now := metav1.Now()
+	mp := &hivev1.MachinePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "infra",
+			Namespace:         "uhc-production-1234",
+			ResourceVersion:   "42",
+			UID:               "uid",
+			Generation:        3,
+			CreationTimestamp: now,
+			DeletionTimestamp: &now,
+			Finalizers:        []string{"hive.openshift.io/remotemachineset"},
+		},
+		Spec: hivev1.MachinePoolSpec{Name: "infra"},
+	}
+
+	recreated := recreatableMachinePool(mp)
+
+	if recreated.Name != mp.Name || recreated.Namespace != mp.Namespace || recreated.Spec.Name != mp.Spec.Name {
+		t.Errorf("expected name and spec to be kept, got %v", recreated)
+	}
+	if recreated.ResourceVersion != "" || recreated.UID != "" || recreated.Generation != 0 ||
+		!recreated.CreationTimestamp.IsZero() || recreated.DeletionTimestamp != nil || len(recreated.Finalizers) != 0 {
+		t.Errorf("expected server-populated fields to be unset, got %v", recreated.ObjectMeta)
+	}
+	if mp.ResourceVersion != "42" {
+		t.Errorf("expected the original machinepool not to be modified")
+	}
+}
+
+func TestResize_infraNodeSelectors(t *testing.T) {
+	originalSelector, tempSelector, err := infraNodeSelectors()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	original := labels.Set{infraNodeLabel: ""}
+	temp := labels.Set{infraNodeLabel: "", temporaryInfraNodeLabel: ""}
+	worker := labels.Set{"node-role.kubernetes.io/worker": ""}
+
+	if !originalSelector.Matches(original) || originalSelector.Matches(temp) || originalSelector.Matches(worker) {
+		t.Errorf("expected original selector %s to only match original infra nodes", originalSelector)
+	}
+	if !tempSelector.Matches(temp) || tempSelector.Matches(original) || tempSelector.Matches(worker) {
+		t.Errorf("expected temporary selector %s to only match temporary infra nodes", tempSelector)
+	}
+}
@@ -0,0 +1,563 @@
+package resize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	controlPlaneRole = "control-plane"
+	infraRole        = "infra"
+
+	controlPlaneNodeLabel = "node-role.kubernetes.io/master"
+
+	// targetNodeUtilization is the highest share of a machine type's CPU or memory the busiest node may use for the
+	// machine type to be considered a fit, leaving headroom for spikes and for the workloads of a node being replaced
+	targetNodeUtilization = 0.6
+)
+
+// machineType is a machine type supported by OCM for a cloud provider
+type machineType struct {
+	Name         string
+	Category     string
+	Architecture string
+	CCSOnly      bool
+	VCPUs        int64
+	MemoryBytes  int64
+}
+
+func (m machineType) String() string {
+	return fmt.Sprintf("%s (%d vCPU, %s memory)", m.Name, m.VCPUs, formatBytes(m.MemoryBytes))
+}
+
+// sizingCloud queries the cloud provider a cluster runs in for what the sizing advisor needs to validate a machine type
+type sizingCloud interface {
+	// offered returns whether the machine type can be launched in the zone
+	offered(ctx context.Context, zone, machineType string) (bool, error)
+	// vCPUHeadroom returns how many more vCPUs of the machine type's family can be launched before hitting the quota
+	vCPUHeadroom(ctx context.Context, machineType string) (int64, error)
+	close()
+}
+
+// nodeUtilization is the CPU and memory used by the busiest node of a role
+type nodeUtilization struct {
+	Nodes       int
+	CPUCores    float64
+	MemoryBytes int64
+}
+
+// sizingRequest describes the nodes being resized
+type sizingRequest struct {
+	Role     string
+	Current  string
+	Replicas int64
+	// PeakVCPUs returns how many vCPUs more than the nodes use today are running at the peak of the resize
+	PeakVCPUs func(current, candidate int64) int64
+}
+
+// newControlPlaneSizingRequest describes a control plane resize, which replaces one machine at a time. A replacement
+// machine is created before the machine it replaces is deleted.
+func newControlPlaneSizingRequest(current string, replicas int64) sizingRequest {
+	return sizingRequest{
+		Role:     controlPlaneRole,
+		Current:  current,
+		Replicas: replicas,
+		PeakVCPUs: func(current, candidate int64) int64 {
+			return max(candidate, replicas*(candidate-current)+current)
+		},
+	}
+}
+
+// newInfraSizingRequest describes an infra resize, which runs both a temporary machinepool and the new machinepool of
+// the candidate type before the temporary one is deleted
+func newInfraSizingRequest(current string, replicas int64) sizingRequest {
+	return sizingRequest{
+		Role:     infraRole,
+		Current:  current,
+		Replicas: replicas,
+		PeakVCPUs: func(current, candidate int64) int64 {
+			return max(replicas*candidate, 2*replicas*candidate-replicas*current)
+		},
+	}
+}
+
+// machineTypeEvaluation is the result of checking whether a machine type is suitable for a resize
+type machineTypeEvaluation struct {
+	Type          machineType
+	MissingZones  []string
+	RequiredVCPUs int64
+	VCPUHeadroom  int64
+	// Fits is true if the busiest node would stay under the target utilization, or if the utilization is unknown
+	Fits bool
+	// Problems are the reasons the machine type cannot be used
+	Problems []string
+}
+
+// ok returns true if the machine type can be used and fits the current utilization
+func (e machineTypeEvaluation) ok() bool {
+	return len(e.Problems) == 0 && e.Fits
+}
+
+// sizingAdvisor validates machine types against the cloud provider and recommends the smallest supported machine type
+// which fits the current utilization of the nodes being resized
+type sizingAdvisor struct {
+	cloud   sizingCloud
+	client  client.Client
+	cluster *cmv1.Cluster
+	zones   []string
+	catalog []machineType
+	out     io.Writer
+
+	// utilization is loaded once, and is nil if node metrics are unavailable
+	utilization       *nodeUtilization
+	utilizationLoaded bool
+}
+
+// newSizingAdvisor returns an advisor for the cluster, querying its cloud provider with the credentials Backplane
+// provides for AWS, or the Application Default Credentials for GCP
+func newSizingAdvisor(ctx context.Context, conn *sdk.Connection, cluster *cmv1.Cluster, c client.Client, out io.Writer) (*sizingAdvisor, error) {
+	catalog, err := getMachineTypeCatalog(conn, cluster.CloudProvider().ID())
+	if err != nil {
+		return nil, err
+	}
+
+	var cloud sizingCloud
+	switch cluster.CloudProvider().ID() {
+	case "aws":
+		cloud, err = newAwsSizingCloud(conn, cluster)
+	case "gcp":
+		cloud, err = newGcpSizingCloud(ctx, conn, cluster)
+	default:
+		return nil, fmt.Errorf("cloud provider not supported: %s, only AWS and GCP are supported", cluster.CloudProvider().ID())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &sizingAdvisor{
+		cloud:   cloud,
+		client:  c,
+		cluster: cluster,
+		zones:   cluster.Nodes().AvailabilityZones(),
+		catalog: catalog,
+		out:     out,
+	}, nil
+}
+
+func (a *sizingAdvisor) close() {
+	a.cloud.close()
+}
+
+// getMachineTypeCatalog returns the machine types OCM supports for the cloud provider
+func getMachineTypeCatalog(conn *sdk.Connection, cloudProvider string) ([]machineType, error) {
+	requestSize := 100
+	request := conn.ClustersMgmt().V1().MachineTypes().List().Search(fmt.Sprintf("cloud_provider.id = '%s'", cloudProvider)).Size(requestSize)
+	response, err := request.Send()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list supported machine types: %w", err)
+	}
+
+	items := response.Items().Slice()
+	for response.Size() >= requestSize {
+		request.Page(response.Page() + 1)
+		response, err = request.Send()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list supported machine types: %w", err)
+		}
+		items = append(items, response.Items().Slice()...)
+	}
+
+	catalog := make([]machineType, 0, len(items))
+	for _, item := range items {
+		catalog = append(catalog, machineType{
+			Name:         item.ID(),
+			Category:     string(item.Category()),
+			Architecture: string(item.Architecture()),
+			CCSOnly:      item.CCSOnly(),
+			VCPUs:        int64(item.CPU().Value()),
+			MemoryBytes:  int64(item.Memory().Value()),
+		})
+	}
+
+	return catalog, nil
+}
+
+// lookup returns the supported machine type with the name
+func (a *sizingAdvisor) lookup(name string) (machineType, bool) {
+	for _, m := range a.catalog {
+		if m.Name == name {
+			return m, true
+		}
+	}
+
+	return machineType{}, false
+}
+
+// getUtilization returns the utilization of the busiest node of the role, or nil if node metrics are unavailable
+func (a *sizingAdvisor) getUtilization(ctx context.Context, role string) *nodeUtilization {
+	if a.utilizationLoaded {
+		return a.utilization
+	}
+	a.utilizationLoaded = true
+
+	utilization, err := getNodeUtilization(ctx, a.client, roleNodeLabel(role))
+	if err != nil {
+		fmt.Fprintf(a.out, "Warning: node metrics are unavailable, machine types will not be checked against the current utilization: %v\n", err)
+		return nil
+	}
+	a.utilization = utilization
+
+	return a.utilization
+}
+
+// roleNodeLabel returns the label selecting the nodes of the role
+func roleNodeLabel(role string) string {
+	if role == controlPlaneRole {
+		return controlPlaneNodeLabel
+	}
+
+	return infraNodeLabel
+}
+
+// getNodeUtilization returns the CPU and memory used by the busiest node with the label, according to the metrics API
+func getNodeUtilization(ctx context.Context, c client.Client, label string) (*nodeUtilization, error) {
+	metrics := &unstructured.UnstructuredList{}
+	metrics.SetGroupVersionKind(schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "NodeMetricsList"})
+	if err := c.List(ctx, metrics, client.HasLabels{label}); err != nil {
+		return nil, err
+	}
+	if len(metrics.Items) == 0 {
+		return nil, fmt.Errorf("no node metrics found for nodes labelled %s", label)
+	}
+
+	utilization := &nodeUtilization{Nodes: len(metrics.Items)}
+	for _, item := range metrics.Items {
+		cpu, _, err := unstructured.NestedString(item.Object, "usage", "cpu")
+		if err != nil {
+			return nil, err
+		}
+		memory, _, err := unstructured.NestedString(item.Object, "usage", "memory")
+		if err != nil {
+			return nil, err
+		}
+
+		cpuQuantity, err := resource.ParseQuantity(cpu)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CPU usage of node %s: %w", item.GetName(), err)
+		}
+		memoryQuantity, err := resource.ParseQuantity(memory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse memory usage of node %s: %w", item.GetName(), err)
+		}
+
+		utilization.CPUCores = max(utilization.CPUCores, float64(cpuQuantity.MilliValue())/1000)
+		utilization.MemoryBytes = max(utilization.MemoryBytes, memoryQuantity.Value())
+	}
+
+	return utilization, nil
+}
+
+// evaluate checks whether the machine type is supported, offered in every zone of the cluster, has enough quota
+// headroom for the resize, and fits the current utilization
+func (a *sizingAdvisor) evaluate(ctx context.Context, req sizingRequest, name string) (machineTypeEvaluation, error) {
+	candidate, found := a.lookup(name)
+	if !found {
+		return machineTypeEvaluation{Type: machineType{Name: name}, Problems: []string{"not a supported machine type"}}, nil
+	}
+
+	eval := machineTypeEvaluation{Type: candidate, Fits: true}
+	if candidate.CCSOnly && !a.cluster.CCS().Enabled() {
+		eval.Problems = append(eval.Problems, "only supported for CCS clusters")
+	}
+
+	for _, zone := range a.zones {
+		offered, err := a.cloud.offered(ctx, zone, name)
+		if err != nil {
+			return eval, fmt.Errorf("failed to check whether %s is offered in %s: %w", name, zone, err)
+		}
+		if !offered {
+			eval.MissingZones = append(eval.MissingZones, zone)
+		}
+	}
+	if len(eval.MissingZones) > 0 {
+		eval.Problems = append(eval.Problems, fmt.Sprintf("not offered in %s", strings.Join(eval.MissingZones, ", ")))
+	}
+
+	// When the current machine type is unknown, assume none of its vCPUs are freed up during the resize
+	var currentVCPUs int64
+	if current, found := a.lookup(req.Current); found {
+		currentVCPUs = current.VCPUs
+	}
+	eval.RequiredVCPUs = req.PeakVCPUs(currentVCPUs, candidate.VCPUs)
+	headroom, err := a.cloud.vCPUHeadroom(ctx, name)
+	if err != nil {
+		return eval, fmt.Errorf("failed to check the vCPU quota for %s: %w", name, err)
+	}
+	eval.VCPUHeadroom = headroom
+	if eval.RequiredVCPUs > eval.VCPUHeadroom {
+		eval.Problems = append(eval.Problems, fmt.Sprintf("needs %d more vCPUs of quota, only %d available", eval.RequiredVCPUs, eval.VCPUHeadroom))
+	}
+
+	if utilization := a.getUtilization(ctx, req.Role); utilization != nil {
+		eval.Fits = utilization.CPUCores <= targetNodeUtilization*float64(candidate.VCPUs) &&
+			float64(utilization.MemoryBytes) <= targetNodeUtilization*float64(candidate.MemoryBytes)
+	}
+
+	return eval, nil
+}
+
+// candidates returns the supported machine types larger than the current one, smallest first. Accelerated machine types
+// and machine types of another architecture are never recommended.
+func (a *sizingAdvisor) candidates(req sizingRequest) []machineType {
+	current, found := a.lookup(req.Current)
+
+	var candidates []machineType
+	for _, m := range a.catalog {
+		if m.Name == req.Current || m.Category == string(cmv1.MachineTypeCategoryAcceleratedComputing) {
+			continue
+		}
+		if m.CCSOnly && !a.cluster.CCS().Enabled() {
+			continue
+		}
+		if found {
+			if m.Architecture != current.Architecture || m.VCPUs < current.VCPUs || m.MemoryBytes < current.MemoryBytes {
+				continue
+			}
+			if m.VCPUs == current.VCPUs && m.MemoryBytes == current.MemoryBytes {
+				continue
+			}
+		}
+		candidates = append(candidates, m)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].VCPUs != candidates[j].VCPUs {
+			return candidates[i].VCPUs < candidates[j].VCPUs
+		}
+		if candidates[i].MemoryBytes != candidates[j].MemoryBytes {
+			return candidates[i].MemoryBytes < candidates[j].MemoryBytes
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+
+	return candidates
+}
+
+// recommend returns the smallest supported machine type which can be used and fits the current utilization, along with
+// the evaluations of the smaller machine types which were rejected
+func (a *sizingAdvisor) recommend(ctx context.Context, req sizingRequest) (*machineTypeEvaluation, []machineTypeEvaluation, error) {
+	var rejected []machineTypeEvaluation
+	for _, candidate := range a.candidates(req) {
+		eval, err := a.evaluate(ctx, req, candidate.Name)
+		if err != nil {
+			return nil, rejected, err
+		}
+		if eval.ok() {
+			return &eval, rejected, nil
+		}
+		rejected = append(rejected, eval)
+	}
+
+	return nil, rejected, fmt.Errorf("no supported machine type larger than %s can be used for the %s nodes", req.Current, req.Role)
+}
+
+// validate checks the machine type a resize targets, returning an error explaining why it cannot be used along with a
+// recommendation. A machine type which is usable but doesn't fit the current utilization only prints a warning.
+func (a *sizingAdvisor) validate(ctx context.Context, req sizingRequest, name string) error {
+	eval, err := a.evaluate(ctx, req, name)
+	if err != nil {
+		return err
+	}
+	if err := printMachineTypeEvaluations(a.out, a.getUtilization(ctx, req.Role), []machineTypeEvaluation{eval}); err != nil {
+		return err
+	}
+
+	if len(eval.Problems) == 0 {
+		if !eval.Fits {
+			fmt.Fprintf(a.out, "Warning: the busiest %s node would use more than %.0f%% of the CPU or memory of %s\n", req.Role, targetNodeUtilization*100, name)
+		}
+		return nil
+	}
+
+	msg := fmt.Sprintf("machine type %s cannot be used: %s", name, strings.Join(eval.Problems, "; "))
+	recommendation, _, err := a.recommend(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s, and %v", msg, err)
+	}
+
+	return fmt.Errorf("%s, the smallest machine type which can be used is %s", msg, recommendation.Type)
+}
+
+// printMachineTypeEvaluations prints the current utilization and a table of the evaluated machine types
+func printMachineTypeEvaluations(out io.Writer, utilization *nodeUtilization, evals []machineTypeEvaluation) error {
+	if utilization != nil {
+		fmt.Fprintf(out, "Busiest of %d nodes uses %.2f CPU cores and %s memory, machine types must fit it under %.0f%% utilization\n\n",
+			utilization.Nodes, utilization.CPUCores, formatBytes(utilization.MemoryBytes), targetNodeUtilization*100)
+	}
+
+	p := printer.NewTablePrinter(out, 20, 1, 3, ' ')
+	p.AddRow([]string{"MACHINE TYPE", "VCPU", "MEMORY", "QUOTA (NEEDED/AVAILABLE)", "FITS", "PROBLEMS"})
+	for _, eval := range evals {
+		problems := strings.Join(eval.Problems, "; ")
+		if problems == "" {
+			problems = "-"
+		}
+		p.AddRow([]string{
+			eval.Type.Name,
+			fmt.Sprintf("%d", eval.Type.VCPUs),
+			formatBytes(eval.Type.MemoryBytes),
+			fmt.Sprintf("%d/%d", eval.RequiredVCPUs, eval.VCPUHeadroom),
+			fmt.Sprintf("%t", eval.Fits),
+			problems,
+		})
+	}
+
+	return p.Flush()
+}
+
+// formatBytes formats a number of bytes as GiB
+func formatBytes(bytes int64) string {
+	return fmt.Sprintf("%.1fGiB", float64(bytes)/(1<<30))
+}
+
+// recommendOptions defines the struct for running the resize recommend command
+type recommendOptions struct {
+	clusterID   string
+	role        string
+	machineType string
+	out         io.Writer
+}
+
+func newCmdResizeRecommend() *cobra.Command {
+	ops := &recommendOptions{out: os.Stdout}
+	recommendCmd := &cobra.Command{
+		Use:   "recommend",
+		Short: "Recommend or validate a machine type to resize control plane or infra nodes to",
+		Long: `Recommend or validate a machine type to resize control plane or infra nodes to
+
+  Recommends the smallest machine type supported by OCM which is offered in every availability zone of the cluster, has
+  enough vCPU quota headroom for the resize, and keeps the busiest node's CPU and memory utilization, according to node
+  metrics, under 60%. Use --machine-type to validate a specific machine type instead.
+
+  AWS is queried with the credentials Backplane provides, and GCP with the Application Default Credentials.`,
+		Example: `
+  # Recommend a machine type to resize the control plane nodes to
+  osdctl cluster resize recommend -c "${CLUSTER_ID}" --role control-plane
+
+  # Check whether infra nodes can be resized to r5.2xlarge
+  osdctl cluster resize recommend -c "${CLUSTER_ID}" --role infra --machine-type r5.2xlarge`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ops.run(context.Background())
+		},
+	}
+	recommendCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "c", "", "The internal ID of the cluster to recommend a machine type for")
+	recommendCmd.Flags().StringVar(&ops.role, "role", controlPlaneRole, "The role of the nodes being resized, one of: control-plane, infra")
+	recommendCmd.Flags().StringVar(&ops.machineType, "machine-type", "", "(optional) A machine type to validate instead of recommending one")
+	recommendCmd.MarkFlagRequired("cluster-id")
+
+	return recommendCmd
+}
+
+func (o *recommendOptions) run(ctx context.Context) error {
+	if o.role != controlPlaneRole && o.role != infraRole {
+		return fmt.Errorf("invalid role %s, must be one of: %s, %s", o.role, controlPlaneRole, infraRole)
+	}
+
+	connection, err := utils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return errors.New("this command should not be used for HCP clusters")
+	}
+
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return err
+	}
+	c, err := k8s.New(cluster.ID(), client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	req, err := currentSizing(ctx, c, o.role)
+	if err != nil {
+		return err
+	}
+
+	advisor, err := newSizingAdvisor(ctx, connection, cluster, c, o.out)
+	if err != nil {
+		return err
+	}
+	defer advisor.close()
+
+	if o.machineType != "" {
+		if err := advisor.validate(ctx, req, o.machineType); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.out, "\n%s can be used for the %s nodes\n", o.machineType, o.role)
+		return nil
+	}
+
+	recommendation, rejected, err := advisor.recommend(ctx, req)
+	evals := rejected
+	if recommendation != nil {
+		evals = append(evals, *recommendation)
+	}
+	if printErr := printMachineTypeEvaluations(o.out, advisor.getUtilization(ctx, req.Role), evals); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.out, "\nRecommended machine type for the %s nodes: %s, currently %s\n", o.role, recommendation.Type, req.Current)
+	return nil
+}
+
+// currentSizing describes a resize of the nodes of the role from the machine type and number of nodes running today
+func currentSizing(ctx context.Context, c client.Client, role string) (sizingRequest, error) {
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes, client.HasLabels{roleNodeLabel(role)}); err != nil {
+		return sizingRequest{}, fmt.Errorf("failed to list %s nodes: %w", role, err)
+	}
+	if len(nodes.Items) == 0 {
+		return sizingRequest{}, fmt.Errorf("no %s nodes found", role)
+	}
+
+	current := nodes.Items[0].Labels[corev1.LabelInstanceTypeStable]
+	if current == "" {
+		return sizingRequest{}, fmt.Errorf("could not determine the machine type of node %s", nodes.Items[0].Name)
+	}
+
+	if role == controlPlaneRole {
+		return newControlPlaneSizingRequest(current, int64(len(nodes.Items))), nil
+	}
+	return newInfraSizingRequest(current, int64(len(nodes.Items))), nil
+}
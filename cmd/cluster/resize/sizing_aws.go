@@ -0,0 +1,165 @@
+package resize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+)
+
+// awsStandardInstancesQuotaCode is the quota code of "Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances"
+const awsStandardInstancesQuotaCode = "L-1216C47A"
+
+// awsInstanceQuotaCodes are the quota codes of the running On-Demand instances of the other instance families, keyed by
+// the family prefix of the instance type. The quotas are measured in vCPUs.
+var awsInstanceQuotaCodes = map[string]string{
+	"dl":  "L-6E869C2A",
+	"f":   "L-74FC7D96",
+	"g":   "L-DB2E81BA",
+	"hpc": "L-F7808C92",
+	"inf": "L-1945791B",
+	"p":   "L-417A185B",
+	"trn": "L-2C3B7624",
+	"vt":  "L-DB2E81BA",
+	"x":   "L-7295265B",
+}
+
+type awsSizingEC2Client interface {
+	ec2.DescribeInstanceTypeOfferingsAPIClient
+	ec2.DescribeInstancesAPIClient
+}
+
+type awsSizingQuotaClient interface {
+	GetServiceQuota(ctx context.Context, params *servicequotas.GetServiceQuotaInput, optFns ...func(*servicequotas.Options)) (*servicequotas.GetServiceQuotaOutput, error)
+}
+
+// awsSizingCloud implements sizingCloud with instance type offerings and the EC2 service quotas
+type awsSizingCloud struct {
+	ec2Client   awsSizingEC2Client
+	quotaClient awsSizingQuotaClient
+
+	// offerings caches the instance types offered in each availability zone
+	offerings map[string]map[string]bool
+}
+
+func newAwsSizingCloud(conn *sdk.Connection, cluster *cmv1.Cluster) (*awsSizingCloud, error) {
+	cfg, err := osdCloud.CreateAWSV2Config(conn, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsSizingCloud{
+		ec2Client:   ec2.NewFromConfig(cfg),
+		quotaClient: servicequotas.NewFromConfig(cfg),
+		offerings:   map[string]map[string]bool{},
+	}, nil
+}
+
+func (a *awsSizingCloud) offered(ctx context.Context, zone, machineType string) (bool, error) {
+	if offerings, ok := a.offerings[zone]; ok {
+		return offerings[machineType], nil
+	}
+
+	offerings := map[string]bool{}
+	paginator := ec2.NewDescribeInstanceTypeOfferingsPaginator(a.ec2Client, &ec2.DescribeInstanceTypeOfferingsInput{
+		LocationType: types.LocationTypeAvailabilityZone,
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("location"),
+				Values: []string{zone},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, offering := range page.InstanceTypeOfferings {
+			offerings[string(offering.InstanceType)] = true
+		}
+	}
+	a.offerings[zone] = offerings
+
+	return offerings[machineType], nil
+}
+
+// vCPUHeadroom returns the quota of the instance type's family minus the vCPUs of the instances of that family which
+// are already pending or running
+func (a *awsSizingCloud) vCPUHeadroom(ctx context.Context, machineType string) (int64, error) {
+	quotaCode, err := awsQuotaCode(machineType)
+	if err != nil {
+		return 0, err
+	}
+
+	quota, err := a.quotaClient.GetServiceQuota(ctx, &servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String("ec2"),
+		QuotaCode:   aws.String(quotaCode),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if quota.Quota == nil || quota.Quota.Value == nil {
+		return 0, fmt.Errorf("quota %s has no value", quotaCode)
+	}
+
+	var used int64
+	paginator := ec2.NewDescribeInstancesPaginator(a.ec2Client, &ec2.DescribeInstancesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []string{"pending", "running"},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if code, err := awsQuotaCode(string(instance.InstanceType)); err != nil || code != quotaCode {
+					continue
+				}
+				if instance.CpuOptions != nil {
+					used += int64(aws.ToInt32(instance.CpuOptions.CoreCount) * aws.ToInt32(instance.CpuOptions.ThreadsPerCore))
+				}
+			}
+		}
+	}
+
+	return int64(*quota.Quota.Value) - used, nil
+}
+
+func (a *awsSizingCloud) close() {}
+
+// awsQuotaCode returns the code of the service quota limiting the running On-Demand instances of the instance type
+func awsQuotaCode(instanceType string) (string, error) {
+	family, _, found := strings.Cut(instanceType, ".")
+	if !found {
+		return "", fmt.Errorf("invalid instance type %s", instanceType)
+	}
+	// The family prefix is the letters before the generation, e.g. "inf" for inf2.xlarge
+	prefix := family
+	if i := strings.IndexFunc(family, func(r rune) bool { return !unicode.IsLetter(r) }); i >= 0 {
+		prefix = family[:i]
+	}
+
+	if code, ok := awsInstanceQuotaCodes[prefix]; ok {
+		return code, nil
+	}
+	if prefix != "" && strings.ContainsRune("acdhimrtz", rune(prefix[0])) {
+		return awsStandardInstancesQuotaCode, nil
+	}
+
+	return "", fmt.Errorf("no known vCPU quota for instance type %s", instanceType)
+}
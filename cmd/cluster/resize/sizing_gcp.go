@@ -0,0 +1,130 @@
+package resize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"google.golang.org/api/iterator"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+)
+
+// gcpSizingCloud implements sizingCloud with the machine types of each zone and the regional CPU quotas
+type gcpSizingCloud struct {
+	machineTypesClient *compute.MachineTypesClient
+	regionsClient      *compute.RegionsClient
+	project            string
+	region             string
+
+	// families caches the machine type families offered in each zone
+	families map[string]map[string]bool
+	// quotas caches the regional quotas
+	quotas []*computepb.Quota
+}
+
+func newGcpSizingCloud(ctx context.Context, conn *sdk.Connection, cluster *cmv1.Cluster) (*gcpSizingCloud, error) {
+	project, err := osdCloud.GetGcpProjectID(conn, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &gcpSizingCloud{
+		project:  project,
+		region:   cluster.Region().ID(),
+		families: map[string]map[string]bool{},
+	}
+	if g.machineTypesClient, err = compute.NewMachineTypesRESTClient(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create GCP machine types client: %w", err)
+	}
+	if g.regionsClient, err = compute.NewRegionsRESTClient(ctx); err != nil {
+		g.close()
+		return nil, fmt.Errorf("failed to create GCP regions client: %w", err)
+	}
+
+	return g, nil
+}
+
+// offered checks whether the machine type's family is offered in the zone. Custom machine types aren't listed by GCP,
+// but can be created wherever their family is offered.
+func (g *gcpSizingCloud) offered(ctx context.Context, zone, machineType string) (bool, error) {
+	if families, ok := g.families[zone]; ok {
+		return families[gcpMachineFamily(machineType)], nil
+	}
+
+	families := map[string]bool{}
+	machineTypes := g.machineTypesClient.List(ctx, &computepb.ListMachineTypesRequest{
+		Project: g.project,
+		Zone:    zone,
+	})
+	for {
+		m, err := machineTypes.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		families[gcpMachineFamily(m.GetName())] = true
+	}
+	g.families[zone] = families
+
+	return families[gcpMachineFamily(machineType)], nil
+}
+
+// vCPUHeadroom returns the limit minus the usage of the regional CPU quota of the machine type's family
+func (g *gcpSizingCloud) vCPUHeadroom(ctx context.Context, machineType string) (int64, error) {
+	if g.quotas == nil {
+		region, err := g.regionsClient.Get(ctx, &computepb.GetRegionRequest{
+			Project: g.project,
+			Region:  g.region,
+		})
+		if err != nil {
+			return 0, err
+		}
+		g.quotas = region.GetQuotas()
+	}
+
+	metric := gcpCPUQuotaMetric(machineType)
+	for _, quota := range g.quotas {
+		if quota.GetMetric() == metric {
+			return int64(quota.GetLimit() - quota.GetUsage()), nil
+		}
+	}
+
+	return 0, fmt.Errorf("quota %s not found in region %s", metric, g.region)
+}
+
+func (g *gcpSizingCloud) close() {
+	if g.machineTypesClient != nil {
+		g.machineTypesClient.Close()
+	}
+	if g.regionsClient != nil {
+		g.regionsClient.Close()
+	}
+}
+
+// gcpMachineFamily returns the family of a machine type, e.g. "n2" for n2-standard-8 and n2-custom-8-65536. Custom
+// machine types without a family prefix, like custom-8-65536-ext, are N1 machine types.
+func gcpMachineFamily(machineType string) string {
+	family, _, _ := strings.Cut(machineType, "-")
+	if family == "custom" {
+		return "n1"
+	}
+
+	return family
+}
+
+// gcpCPUQuotaMetric returns the regional quota metric limiting the vCPUs of the machine type. N1, E2, and shared-core
+// machine types count against the CPUS quota, and the other families have a quota of their own.
+func gcpCPUQuotaMetric(machineType string) string {
+	switch family := gcpMachineFamily(machineType); family {
+	case "n1", "e2", "f1", "g1":
+		return "CPUS"
+	default:
+		return strings.ToUpper(family) + "_CPUS"
+	}
+}
@@ -0,0 +1,280 @@
+package resize
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const gib = int64(1 << 30)
+
+type fakeSizingCloud struct {
+	// notOffered are the machine types not offered, keyed by zone
+	notOffered map[string][]string
+	headroom   int64
+}
+
+func (f *fakeSizingCloud) offered(_ context.Context, zone, machineType string) (bool, error) {
+	for _, m := range f.notOffered[zone] {
+		if m == machineType {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (f *fakeSizingCloud) vCPUHeadroom(context.Context, string) (int64, error) {
+	return f.headroom, nil
+}
+
+func (f *fakeSizingCloud) close() {}
+
+var testCatalog = []machineType{
+	{Name: "m5.xlarge", Category: "general_purpose", Architecture: "amd64", VCPUs: 4, MemoryBytes: 16 * gib},
+	{Name: "m5.2xlarge", Category: "general_purpose", Architecture: "amd64", VCPUs: 8, MemoryBytes: 32 * gib},
+	{Name: "r5.xlarge", Category: "memory_optimized", Architecture: "amd64", VCPUs: 4, MemoryBytes: 32 * gib},
+	{Name: "m6g.2xlarge", Category: "general_purpose", Architecture: "arm64", VCPUs: 8, MemoryBytes: 32 * gib},
+	{Name: "g4dn.2xlarge", Category: "accelerated_computing", Architecture: "amd64", VCPUs: 8, MemoryBytes: 32 * gib},
+	{Name: "m5.4xlarge", Category: "general_purpose", Architecture: "amd64", VCPUs: 16, MemoryBytes: 64 * gib},
+	{Name: "m5.8xlarge", Category: "general_purpose", Architecture: "amd64", VCPUs: 32, MemoryBytes: 128 * gib, CCSOnly: true},
+}
+
+func newNodeMetrics(name, cpu, memory string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"usage": map[string]interface{}{"cpu": cpu, "memory": memory},
+	}}
+	u.SetAPIVersion("metrics.k8s.io/v1beta1")
+	u.SetKind("NodeMetrics")
+	u.SetName(name)
+	u.SetLabels(map[string]string{controlPlaneNodeLabel: ""})
+	return u
+}
+
+func newTestAdvisor(t *testing.T, cloud sizingCloud, ccs bool, objs ...client.Object) (*sizingAdvisor, *bytes.Buffer) {
+	cluster, err := cmv1.NewCluster().CCS(cmv1.NewCCS().Enabled(ccs)).Build()
+	if err != nil {
+		t.Fatalf("failed to build cluster: %s", err)
+	}
+
+	out := &bytes.Buffer{}
+	return &sizingAdvisor{
+		cloud:   cloud,
+		client:  fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(objs...).Build(),
+		cluster: cluster,
+		zones:   []string{"us-east-1a", "us-east-1b", "us-east-1c"},
+		catalog: testCatalog,
+		out:     out,
+	}, out
+}
+
+func TestSizingRequestPeakVCPUs(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       sizingRequest
+		current   int64
+		candidate int64
+		expected  int64
+	}{
+		{
+			name:      "control plane upsize replaces every machine",
+			req:       newControlPlaneSizingRequest("m5.xlarge", 3),
+			current:   4,
+			candidate: 8,
+			expected:  16,
+		},
+		{
+			name:      "control plane needs at least one surge machine",
+			req:       newControlPlaneSizingRequest("m5.2xlarge", 3),
+			current:   8,
+			candidate: 8,
+			expected:  8,
+		},
+		{
+			name:      "infra runs the temporary and new machinepools together",
+			req:       newInfraSizingRequest("r5.xlarge", 3),
+			current:   4,
+			candidate: 8,
+			expected:  36,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.req.PeakVCPUs(test.current, test.candidate); actual != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSizingAdvisorRecommend(t *testing.T) {
+	tests := []struct {
+		name     string
+		cloud    *fakeSizingCloud
+		ccs      bool
+		metrics  []client.Object
+		expected string
+		rejected []string
+		wantErr  bool
+	}{
+		{
+			name:     "smallest larger type without metrics",
+			cloud:    &fakeSizingCloud{headroom: 100},
+			expected: "r5.xlarge",
+		},
+		{
+			name:     "skips types not offered in every zone",
+			cloud:    &fakeSizingCloud{headroom: 100, notOffered: map[string][]string{"us-east-1c": {"r5.xlarge"}}},
+			expected: "m5.2xlarge",
+			rejected: []string{"r5.xlarge"},
+		},
+		{
+			name:     "skips types which don't fit the utilization",
+			cloud:    &fakeSizingCloud{headroom: 100},
+			metrics:  []client.Object{newNodeMetrics("master-0", "1500m", "12Gi"), newNodeMetrics("master-1", "4", "8Gi")},
+			expected: "m5.2xlarge",
+			rejected: []string{"r5.xlarge"},
+		},
+		{
+			name:     "CCS only types are only recommended for CCS clusters",
+			cloud:    &fakeSizingCloud{headroom: 100},
+			ccs:      true,
+			metrics:  []client.Object{newNodeMetrics("master-0", "12", "8Gi")},
+			expected: "m5.8xlarge",
+			rejected: []string{"r5.xlarge", "m5.2xlarge", "m5.4xlarge"},
+		},
+		{
+			name:    "no type has enough quota",
+			cloud:   &fakeSizingCloud{headroom: 2},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			advisor, _ := newTestAdvisor(t, test.cloud, test.ccs, test.metrics...)
+			recommendation, rejected, err := advisor.recommend(context.Background(), newControlPlaneSizingRequest("m5.xlarge", 3))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got recommendation %v", recommendation)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if recommendation.Type.Name != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, recommendation.Type.Name)
+			}
+			var rejectedNames []string
+			for _, eval := range rejected {
+				rejectedNames = append(rejectedNames, eval.Type.Name)
+			}
+			if strings.Join(rejectedNames, ",") != strings.Join(test.rejected, ",") {
+				t.Errorf("expected %v to be rejected, got %v", test.rejected, rejectedNames)
+			}
+		})
+	}
+}
+
+func TestSizingAdvisorValidate(t *testing.T) {
+	cloud := &fakeSizingCloud{headroom: 100, notOffered: map[string][]string{"us-east-1b": {"m5.2xlarge"}}}
+
+	tests := []struct {
+		name        string
+		machineType string
+		metrics     []client.Object
+		errContains []string
+		warning     bool
+	}{
+		{
+			name:        "usable type",
+			machineType: "m5.4xlarge",
+		},
+		{
+			name:        "unsupported type",
+			machineType: "m5.3xlarge",
+			errContains: []string{"not a supported machine type", "r5.xlarge"},
+		},
+		{
+			name:        "type not offered in a zone",
+			machineType: "m5.2xlarge",
+			errContains: []string{"not offered in us-east-1b", "r5.xlarge"},
+		},
+		{
+			name:        "usable type which doesn't fit the utilization only warns",
+			machineType: "r5.xlarge",
+			metrics:     []client.Object{newNodeMetrics("master-0", "3", "8Gi")},
+			warning:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			advisor, out := newTestAdvisor(t, cloud, false, test.metrics...)
+			err := advisor.validate(context.Background(), newControlPlaneSizingRequest("m5.xlarge", 3), test.machineType)
+			if len(test.errContains) == 0 && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			for _, s := range test.errContains {
+				if err == nil || !strings.Contains(err.Error(), s) {
+					t.Errorf("expected error containing %q, got %v", s, err)
+				}
+			}
+			if test.warning != strings.Contains(out.String(), "Warning: the busiest") {
+				t.Errorf("expected warning %t, got:\n%s", test.warning, out.String())
+			}
+		})
+	}
+}
+
+func TestAwsQuotaCode(t *testing.T) {
+	tests := map[string]string{
+		"m5.xlarge":     awsStandardInstancesQuotaCode,
+		"r5.2xlarge":    awsStandardInstancesQuotaCode,
+		"c6gn.large":    awsStandardInstancesQuotaCode,
+		"inf2.xlarge":   "L-1945791B",
+		"g4dn.2xlarge":  "L-DB2E81BA",
+		"x2iedn.xlarge": "L-7295265B",
+		"p4d.24xlarge":  "L-417A185B",
+	}
+
+	for instanceType, expected := range tests {
+		actual, err := awsQuotaCode(instanceType)
+		if err != nil {
+			t.Errorf("unexpected error for %s: %s", instanceType, err)
+		}
+		if actual != expected {
+			t.Errorf("expected %s for %s, got %s", expected, instanceType, actual)
+		}
+	}
+
+	if _, err := awsQuotaCode("u-6tb1.metal"); err == nil {
+		t.Errorf("expected an error for an instance type without a known quota")
+	}
+}
+
+func TestGcpCPUQuotaMetric(t *testing.T) {
+	tests := map[string]string{
+		"custom-8-65536-ext": "CPUS",
+		"n1-standard-8":      "CPUS",
+		"e2-standard-4":      "CPUS",
+		"n2-standard-8":      "N2_CPUS",
+		"n2d-custom-8-65536": "N2D_CPUS",
+		"c2-standard-16":     "C2_CPUS",
+	}
+
+	for machineType, expected := range tests {
+		if actual := gcpCPUQuotaMetric(machineType); actual != expected {
+			t.Errorf("expected %s for %s, got %s", expected, machineType, actual)
+		}
+	}
+}
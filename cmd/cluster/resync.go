@@ -3,33 +3,59 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"sync"
 	"time"
 
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	hiveinternalv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
-	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
-	twentyMinuteTimeout   = 20 * time.Minute
-	twentySecondIncrement = 20 * time.Second
+	twentyMinuteTimeout = 20 * time.Minute
 )
 
 type Resync struct {
-	hive      client.Client
-	clusterId string
+	clusterId      string
+	timeout        time.Duration
+	diagnosticsDir string
+	out            io.Writer
+	// clusterSync resyncs the clustersync on the hive shard of the cluster
+	clusterSync *ClusterSyncResync
+}
+
+// ClusterSyncResync deletes ClusterSyncs, for hive to recreate them and resync all the SyncSets and SelectorSyncSets
+// of their cluster, then watches them until they recover. It is safe for concurrent use.
+type ClusterSyncResync struct {
+	// Hive is the client of the hive shard
+	Hive client.WithWatch
+	// PodLogs streams the logs of a hive pod, for the diagnostic bundles
+	PodLogs func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error)
+	// Timeout is how long to wait for a ClusterSync to recover
+	Timeout time.Duration
+	// DiagnosticsDir is the directory the diagnostic bundles of the ClusterSyncs which don't recover are saved in
+	DiagnosticsDir string
+	// Out is where the condition transitions of the ClusterSyncs are printed
+	Out io.Writer
+
+	mu sync.Mutex
 }
 
 func newCmdResync() *cobra.Command {
-	r := Resync{}
+	r := Resync{out: os.Stdout}
 
 	resyncCmd := &cobra.Command{
 		Use:   "resync",
@@ -38,12 +64,19 @@ func newCmdResync() *cobra.Command {
 
   Normally, clusters are periodically synced by Hive every two hours at minimum. This command deletes a cluster's
   clustersync from its managing Hive cluster, causing the clustersync to be recreated in most circumstances and forcing
-  a resync of all SyncSets and SelectorSyncSets. The command will also wait for the clustersync to report its status
-  again (Success or Failure) before exiting.
+  a resync of all SyncSets and SelectorSyncSets. The command then watches the clustersync, printing its condition
+  transitions as they happen, until it reports it's no longer failing.
+
+  When the clustersync doesn't recover before the timeout, a diagnostic bundle is saved with the failure messages of
+  the failing SyncSets and SelectorSyncSets, the SyncSets generated for the cluster, the clustersync itself and the hive
+  controller logs about the cluster's namespace, to attach when escalating to the Hive team.
 `,
 		Example: `
   # Force a cluster resync by deleting its clustersync CustomResource
   osdctl cluster resync --cluster-id ${CLUSTER_ID}
+
+  # Wait up to an hour for the resync, and save the diagnostic bundle in /tmp if it fails
+  osdctl cluster resync --cluster-id ${CLUSTER_ID} --timeout 1h --diagnostics-dir /tmp
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return r.Run(context.Background())
@@ -51,22 +84,13 @@ func newCmdResync() *cobra.Command {
 	}
 
 	resyncCmd.Flags().StringVarP(&r.clusterId, "cluster-id", "C", "", "OCM internal/external cluster id or cluster name to delete the clustersync for.")
+	resyncCmd.Flags().DurationVar(&r.timeout, "timeout", twentyMinuteTimeout, "How long to wait for the clustersync to recover.")
+	resyncCmd.Flags().StringVar(&r.diagnosticsDir, "diagnostics-dir", ".", "Directory to save the diagnostic bundle in when the clustersync doesn't recover.")
 
 	return resyncCmd
 }
 
 func (r *Resync) New() error {
-	scheme := runtime.NewScheme()
-
-	// Register hiveinternalv1alpha1 for ClusterSync
-	if err := hiveinternalv1alpha1.AddToScheme(scheme); err != nil {
-		return err
-	}
-
-	if err := corev1.AddToScheme(scheme); err != nil {
-		return err
-	}
-
 	ocmClient, err := utils.CreateConnection()
 	if err != nil {
 		return err
@@ -83,28 +107,75 @@ func (r *Resync) New() error {
 		return err
 	}
 
-	hc, err := k8s.New(hive.ID(), client.Options{Scheme: scheme})
+	_, cfg, _, err := common.GetKubeConfigAndClient(hive.ID())
+	if err != nil {
+		return err
+	}
+	r.clusterSync, err = NewClusterSyncResync(cfg, r.timeout, r.diagnosticsDir, r.out)
 	if err != nil {
 		return err
 	}
-	r.hive = hc
 	log.Printf("ready to delete clustersync for cluster: %s/%s on hive: %s", cluster.ID(), cluster.Name(), hive.Name())
 
 	return nil
 }
 
+// NewClusterSyncResync returns a ClusterSyncResync connected to the hive shard with the REST config
+func NewClusterSyncResync(cfg *rest.Config, timeout time.Duration, diagnosticsDir string, out io.Writer) (*ClusterSyncResync, error) {
+	scheme := runtime.NewScheme()
+
+	// Register hiveinternalv1alpha1 for ClusterSync
+	if err := hiveinternalv1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	// Register hivev1 for the SyncSets of the diagnostic bundle
+	if err := hivev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	hive, err := client.NewWithWatch(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClusterSyncResync{
+		Hive: hive,
+		PodLogs: func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+			return clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+		},
+		Timeout:        timeout,
+		DiagnosticsDir: diagnosticsDir,
+		Out:            out,
+	}, nil
+}
+
 func (r *Resync) Run(ctx context.Context) error {
 	if err := r.New(); err != nil {
 		return fmt.Errorf("failed to initialize command: %v", err)
 	}
 
+	return r.resync(ctx)
+}
+
+// resync deletes the clustersync of the cluster and watches it until it recovers, saving a diagnostic bundle when it
+// doesn't
+func (r *Resync) resync(ctx context.Context) error {
 	ns := &corev1.NamespaceList{}
 	selector, err := labels.Parse(fmt.Sprintf("api.openshift.com/id=%s", r.clusterId))
 	if err != nil {
 		return err
 	}
 
-	if err := r.hive.List(ctx, ns, &client.ListOptions{LabelSelector: selector, Limit: 1}); err != nil {
+	if err := r.clusterSync.Hive.List(ctx, ns, &client.ListOptions{LabelSelector: selector, Limit: 1}); err != nil {
 		return err
 	}
 	if len(ns.Items) != 1 {
@@ -113,45 +184,156 @@ func (r *Resync) Run(ctx context.Context) error {
 
 	log.Printf("found namespace: %s", ns.Items[0].Name)
 	clustersyncs := &hiveinternalv1alpha1.ClusterSyncList{}
-	if err := r.hive.List(ctx, clustersyncs, &client.ListOptions{Namespace: ns.Items[0].Name}); err != nil {
+	if err := r.clusterSync.Hive.List(ctx, clustersyncs, &client.ListOptions{Namespace: ns.Items[0].Name}); err != nil {
 		return err
 	}
 	if len(clustersyncs.Items) != 1 {
 		return fmt.Errorf("expected 1 clustersync, found %d clustersyncs in namespace: %s", len(clustersyncs.Items), ns.Items[0].Name)
 	}
 
-	log.Printf("deleting clustersync: %s/%s", clustersyncs.Items[0].Namespace, clustersyncs.Items[0].Name)
-	if err := r.hive.Delete(ctx, &clustersyncs.Items[0]); err != nil {
-		return err
+	_, err = r.clusterSync.ResyncClusterSync(ctx, clustersyncs.Items[0].Namespace, clustersyncs.Items[0].Name)
+	return err
+}
+
+// ResyncClusterSync deletes a ClusterSync, for hive to recreate it and resync all the SyncSets and SelectorSyncSets of
+// the cluster, then watches the recreated ClusterSync, printing its condition transitions, until it reports it's no
+// longer failing. When it doesn't recover before the timeout, a diagnostic bundle is saved to escalate it to the Hive
+// team. The last ClusterSync seen is returned, when there is one, to tell why it's still failing.
+func (r *ClusterSyncResync) ResyncClusterSync(ctx context.Context, namespace, name string) (*hiveinternalv1alpha1.ClusterSync, error) {
+	deleted := &hiveinternalv1alpha1.ClusterSync{}
+	if err := r.Hive.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, deleted); err != nil {
+		return nil, err
 	}
 
-	log.Printf("waiting up to %s for clustersync to report status", twentyMinuteTimeout)
-	if err := wait.PollImmediate(twentySecondIncrement, twentyMinuteTimeout, func() (bool, error) {
+	log.Printf("deleting clustersync: %s/%s", namespace, name)
+	if err := r.Hive.Delete(ctx, deleted); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	log.Printf("watching clustersync: %s/%s for up to %s", namespace, name, r.Timeout)
+	lastSeen, err := r.watchClusterSync(ctx, deleted, start)
+	if err == nil {
+		log.Printf("clustersync: %s/%s recovered after %s", namespace, name, time.Since(start).Round(time.Second))
+		return lastSeen, nil
+	}
+
+	bundle, bundleErr := r.saveDiagnostics(ctx, deleted, lastSeen, start)
+	if bundleErr != nil {
+		log.Printf("failed to save the diagnostic bundle of clustersync %s/%s: %v", namespace, name, bundleErr)
+	} else {
+		r.printf("Diagnostic bundle saved to %s, attach it when escalating to the Hive team\n", bundle)
+	}
+	return lastSeen, err
+}
+
+// printf prints to Out, one line at a time when ClusterSyncs are resynced concurrently
+func (r *ClusterSyncResync) printf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Fprintf(r.Out, format, args...)
+}
+
+// watchClusterSync watches the clustersync recreated after deleting the old one, printing its condition transitions,
+// until it reports it's no longer failing. The last clustersync seen is returned, when there is one.
+func (r *ClusterSyncResync) watchClusterSync(ctx context.Context, deleted *hiveinternalv1alpha1.ClusterSync, start time.Time) (*hiveinternalv1alpha1.ClusterSync, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	var lastSeen *hiveinternalv1alpha1.ClusterSync
+	seen := map[hiveinternalv1alpha1.ClusterSyncConditionType]hiveinternalv1alpha1.ClusterSyncCondition{}
+	// observe tells if the clustersync recovered. The old clustersync, until it's gone, is ignored.
+	observe := func(clustersync *hiveinternalv1alpha1.ClusterSync) bool {
+		if clustersync.Name != deleted.Name || clustersync.UID == deleted.UID || clustersync.DeletionTimestamp != nil {
+			return false
+		}
+		lastSeen = clustersync
+		r.printTransitions(seen, clustersync, start)
+		return clusterSyncRecovered(clustersync)
+	}
+
+	timedOut := fmt.Errorf("timed out after %s waiting for clustersync %s/%s to recover", r.Timeout, deleted.Namespace, deleted.Name)
+	for {
+		// The clustersync may have been recreated before the watch starts
 		clustersync := &hiveinternalv1alpha1.ClusterSync{}
-		err := r.hive.Get(ctx, client.ObjectKey{Namespace: clustersyncs.Items[0].Namespace, Name: clustersyncs.Items[0].Name}, clustersync)
+		err := r.Hive.Get(ctx, client.ObjectKey{Namespace: deleted.Namespace, Name: deleted.Name}, clustersync)
+		switch {
+		case err == nil:
+			if observe(clustersync) {
+				return lastSeen, nil
+			}
+		case ctx.Err() != nil:
+			return lastSeen, timedOut
+		case !apierrors.IsNotFound(err):
+			return lastSeen, err
+		}
+
+		watcher, err := r.Hive.Watch(ctx, &hiveinternalv1alpha1.ClusterSyncList{}, client.InNamespace(deleted.Namespace), client.MatchingFields{"metadata.name": deleted.Name})
 		if err != nil {
-			if apierrors.IsNotFound(err) {
-				return false, nil
+			if ctx.Err() != nil {
+				return lastSeen, timedOut
 			}
-			return false, err
+			return lastSeen, err
+		}
+		recovered, err := watchUntilRecovered(ctx, watcher, observe)
+		watcher.Stop()
+		if recovered || err != nil {
+			return lastSeen, err
+		}
+		if ctx.Err() != nil {
+			return lastSeen, timedOut
 		}
+		// The watch was closed by the API server, start another one
+	}
+}
 
-		for _, condition := range clustersync.Status.Conditions {
-			if condition.Type == hiveinternalv1alpha1.ClusterSyncFailed {
-				log.Printf("clustersync: %s/%s has status: %s", clustersync.Namespace, clustersync.Name, condition.Reason)
-				if condition.Status == corev1.ConditionFalse {
-					return true, nil
-				}
-				log.Printf("clustersync: %s/%s has status: %s and message: %s, you may want to take a closer look", clustersync.Namespace, clustersync.Name, condition.Reason, condition.Message)
+// watchUntilRecovered observes the clustersyncs of the watch until one recovered, the context is done or the watch is
+// closed
+func watchUntilRecovered(ctx context.Context, watcher watch.Interface, observe func(*hiveinternalv1alpha1.ClusterSync) bool) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
 				return false, nil
 			}
+			switch event.Type {
+			case watch.Error:
+				return false, apierrors.FromObject(event.Object)
+			case watch.Deleted:
+				continue
+			}
+			if clustersync, ok := event.Object.(*hiveinternalv1alpha1.ClusterSync); ok && observe(clustersync) {
+				return true, nil
+			}
 		}
+	}
+}
 
-		log.Printf("clustersync: %s/%s has no status condition yet, continuing to wait", clustersync.Namespace, clustersync.Name)
-		return false, nil
-	}); err != nil {
-		return err
+// clusterSyncRecovered tells if the clustersync reports it's no longer failing
+func clusterSyncRecovered(clustersync *hiveinternalv1alpha1.ClusterSync) bool {
+	for _, condition := range clustersync.Status.Conditions {
+		if condition.Type == hiveinternalv1alpha1.ClusterSyncFailed {
+			return condition.Status == corev1.ConditionFalse
+		}
 	}
+	return false
+}
 
-	return nil
+// printTransitions prints the conditions of the clustersync which changed since they were last seen
+func (r *ClusterSyncResync) printTransitions(seen map[hiveinternalv1alpha1.ClusterSyncConditionType]hiveinternalv1alpha1.ClusterSyncCondition, clustersync *hiveinternalv1alpha1.ClusterSync, start time.Time) {
+	for _, condition := range clustersync.Status.Conditions {
+		previous, found := seen[condition.Type]
+		if found && previous.Status == condition.Status && previous.Reason == condition.Reason && previous.Message == condition.Message {
+			continue
+		}
+		seen[condition.Type] = condition
+
+		from := "-"
+		if found {
+			from = string(previous.Status)
+		}
+		r.printf("[%s] %s/%s %s: %s -> %s (%s) %s\n", time.Since(start).Round(time.Second), clustersync.Namespace, clustersync.Name, condition.Type, from, condition.Status, condition.Reason, condition.Message)
+	}
 }
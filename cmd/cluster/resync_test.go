@@ -0,0 +1,151 @@
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveinternalv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const testResyncNamespace = "uhc-production-1234"
+
+func testClusterSync(failed corev1.ConditionStatus, failureMessage string) *hiveinternalv1alpha1.ClusterSync {
+	clustersync := &hiveinternalv1alpha1.ClusterSync{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testResyncNamespace, Name: "test-cluster"},
+		Status: hiveinternalv1alpha1.ClusterSyncStatus{Conditions: []hiveinternalv1alpha1.ClusterSyncCondition{{
+			Type:    hiveinternalv1alpha1.ClusterSyncFailed,
+			Status:  failed,
+			Reason:  map[corev1.ConditionStatus]string{corev1.ConditionTrue: "Failure", corev1.ConditionFalse: "Success"}[failed],
+			Message: failureMessage,
+		}}},
+	}
+	if failureMessage != "" {
+		clustersync.Status.SelectorSyncSets = []hiveinternalv1alpha1.SyncStatus{{
+			Name:           "osd-config",
+			Result:         hiveinternalv1alpha1.FailureSyncSetResult,
+			FailureMessage: failureMessage,
+		}}
+	}
+	return clustersync
+}
+
+// withUID sets the UID the API server would set, to tell the deleted clustersync from the recreated one
+func withUID(clustersync *hiveinternalv1alpha1.ClusterSync, uid types.UID) *hiveinternalv1alpha1.ClusterSync {
+	clustersync.UID = uid
+	return clustersync
+}
+
+// newTestResync returns a Resync whose client recreates the deleted clustersync as failing, and updates it once the
+// clustersync is watched, when update is set
+func newTestResync(t *testing.T, out io.Writer, update *hiveinternalv1alpha1.ClusterSync) *Resync {
+	scheme := runtime.NewScheme()
+	require.NoError(t, hiveinternalv1alpha1.AddToScheme(scheme))
+	require.NoError(t, hivev1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	hive := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testResyncNamespace, Labels: map[string]string{"api.openshift.com/id": "1234"}}},
+		withUID(testClusterSync(corev1.ConditionTrue, "failed to apply resource 0: forbidden"), "deleted"),
+		&hivev1.SyncSet{ObjectMeta: metav1.ObjectMeta{Namespace: testResyncNamespace, Name: "ext-oauth"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: hiveNamespace, Name: "hive-clustersync-0", Labels: map[string]string{"control-plane": "clustersync"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "clustersync"}}},
+		},
+	).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if err := c.Delete(ctx, obj, opts...); err != nil {
+				return err
+			}
+			return c.Create(ctx, withUID(testClusterSync(corev1.ConditionTrue, "failed to apply resource 0: forbidden"), "recreated"))
+		},
+		Watch: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+			watcher, err := c.Watch(ctx, list, opts...)
+			if err == nil && update != nil {
+				current := &hiveinternalv1alpha1.ClusterSync{}
+				require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(update), current))
+				update.ResourceVersion = current.ResourceVersion
+				update.UID = current.UID
+				require.NoError(t, c.Update(ctx, update))
+			}
+			return watcher, err
+		},
+	}).Build()
+
+	return &Resync{clusterId: "1234", clusterSync: &ClusterSyncResync{
+		Hive:           hive,
+		Timeout:        100 * time.Millisecond,
+		DiagnosticsDir: t.TempDir(),
+		Out:            out,
+		PodLogs: func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(strings.Join([]string{
+				`time="2024-06-01T10:00:00Z" level=info msg="reconciling" namespace=uhc-production-5678`,
+				`time="2024-06-01T10:00:01Z" level=info msg="applying resources" namespace=uhc-production-1234`,
+				`time="2024-06-01T10:00:02Z" level=warning msg="failed to apply resource" namespace=uhc-production-1234`,
+			}, "\n"))), nil
+		},
+	}}
+}
+
+func TestResyncWatchesUntilRecovered(t *testing.T) {
+	out := &bytes.Buffer{}
+	r := newTestResync(t, out, testClusterSync(corev1.ConditionFalse, ""))
+
+	require.NoError(t, r.resync(context.Background()))
+	assert.Contains(t, out.String(), "Failed: - -> True (Failure) failed to apply resource 0: forbidden")
+	assert.Contains(t, out.String(), "Failed: True -> False (Success)")
+	assert.NotContains(t, out.String(), "Diagnostic bundle")
+}
+
+func TestResyncSavesDiagnostics(t *testing.T) {
+	out := &bytes.Buffer{}
+	r := newTestResync(t, out, nil)
+
+	err := r.resync(context.Background())
+	assert.ErrorContains(t, err, "timed out after 100ms waiting for clustersync uhc-production-1234/test-cluster to recover")
+	require.Contains(t, out.String(), "Diagnostic bundle saved to ")
+
+	bundle := strings.Split(strings.Split(out.String(), "Diagnostic bundle saved to ")[1], ",")[0]
+	file, err := os.Open(bundle)
+	require.NoError(t, err)
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+
+	files := map[string]string{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tarReader)
+		require.NoError(t, err)
+		files[header.Name[strings.Index(header.Name, "/")+1:]] = string(content)
+	}
+
+	assert.Contains(t, files["summary.txt"], "SelectorSyncSet osd-config: failed to apply resource 0: forbidden")
+	assert.Equal(t, "ext-oauth\n", files["syncsets.txt"])
+	assert.Contains(t, files["clustersync.yaml"], "osd-config")
+	logs := files["logs/hive-clustersync-0_clustersync.log"]
+	assert.Contains(t, logs, "failed to apply resource")
+	assert.NotContains(t, logs, "uhc-production-5678")
+}
@@ -0,0 +1,158 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveinternalv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/osdctl/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	hiveNamespace = "hive"
+	// hiveControllersSelector selects the hive-clustersync pods syncing the clusters and the hive-controllers pods
+	hiveControllersSelector = "control-plane in (clustersync,controller-manager)"
+)
+
+// saveDiagnostics saves a bundle to escalate a clustersync which didn't recover to the Hive team: the failure messages
+// of its SyncSets and SelectorSyncSets, the SyncSets generated for the cluster, the clustersync and the hive controller
+// logs about the namespace since the resync started. The path of the bundle is returned.
+func (r *ClusterSyncResync) saveDiagnostics(ctx context.Context, deleted, clustersync *hiveinternalv1alpha1.ClusterSync, start time.Time) (string, error) {
+	files := map[string][]byte{}
+	var problems []string
+	namespace := deleted.Namespace
+
+	var summary bytes.Buffer
+	fmt.Fprintf(&summary, "Namespace: %s\nClusterSync: %s\nResync started: %s\nTimeout: %s\n", namespace, deleted.Name, start.UTC().Format(time.RFC3339), r.Timeout)
+	if clustersync == nil {
+		fmt.Fprintln(&summary, "\nThe clustersync wasn't recreated")
+	} else {
+		fmt.Fprintln(&summary, "\nConditions:")
+		for _, condition := range clustersync.Status.Conditions {
+			fmt.Fprintf(&summary, "  %s=%s %s: %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+		fmt.Fprintln(&summary, "\nFailing resources:")
+		for _, failure := range clusterSyncFailures(clustersync) {
+			fmt.Fprintf(&summary, "  %s\n", failure)
+		}
+
+		data, err := yaml.Marshal(clustersync)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal the clustersync: %w", err)
+		}
+		files["clustersync.yaml"] = data
+	}
+
+	syncSets := &hivev1.SyncSetList{}
+	if err := r.Hive.List(ctx, syncSets, client.InNamespace(namespace)); err != nil {
+		problems = append(problems, fmt.Sprintf("failed to list the SyncSets: %v", err))
+	}
+	var names bytes.Buffer
+	for _, syncSet := range syncSets.Items {
+		fmt.Fprintln(&names, syncSet.Name)
+	}
+	files["syncsets.txt"] = names.Bytes()
+
+	logs, err := r.hiveControllerLogs(ctx, namespace, start)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	for name, data := range logs {
+		files[path.Join("logs", name)] = data
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintln(&summary, "\nProblems gathering the diagnostics:")
+		for _, problem := range problems {
+			fmt.Fprintf(&summary, "  %s\n", problem)
+		}
+	}
+	files["summary.txt"] = summary.Bytes()
+
+	name := fmt.Sprintf("resync-%s-%s", namespace, start.UTC().Format("20060102150405"))
+	bundle := filepath.Join(r.DiagnosticsDir, name+".tar.gz")
+	if err := utils.WriteTarball(bundle, name, files, start); err != nil {
+		return bundle, fmt.Errorf("failed to write the diagnostic bundle: %w", err)
+	}
+	return bundle, nil
+}
+
+// clusterSyncFailures returns the failure message of each failing SyncSet and SelectorSyncSet of the clustersync
+func clusterSyncFailures(clustersync *hiveinternalv1alpha1.ClusterSync) []string {
+	var failures []string
+	for _, status := range clustersync.Status.SelectorSyncSets {
+		if status.Result == hiveinternalv1alpha1.FailureSyncSetResult {
+			failures = append(failures, fmt.Sprintf("SelectorSyncSet %s: %s", status.Name, status.FailureMessage))
+		}
+	}
+	for _, status := range clustersync.Status.SyncSets {
+		if status.Result == hiveinternalv1alpha1.FailureSyncSetResult {
+			failures = append(failures, fmt.Sprintf("SyncSet %s: %s", status.Name, status.FailureMessage))
+		}
+	}
+	return failures
+}
+
+// hiveControllerLogs returns the lines about the namespace in the logs of the hive controllers since the resync
+// started, by pod and container
+func (r *ClusterSyncResync) hiveControllerLogs(ctx context.Context, namespace string, start time.Time) (map[string][]byte, error) {
+	if r.PodLogs == nil {
+		return nil, fmt.Errorf("the logs of the hive controllers can't be streamed")
+	}
+
+	pods := &corev1.PodList{}
+	selector, err := labels.Parse(hiveControllersSelector)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Hive.List(ctx, pods, client.InNamespace(hiveNamespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list the hive controller pods: %w", err)
+	}
+
+	logs := map[string][]byte{}
+	var failed []string
+	since := metav1.NewTime(start)
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			stream, err := r.PodLogs(ctx, pod.Namespace, pod.Name, &corev1.PodLogOptions{Container: container.Name, SinceTime: &since})
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s/%s: %v", pod.Name, container.Name, err))
+				continue
+			}
+
+			var lines bytes.Buffer
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				if strings.Contains(scanner.Text(), namespace) {
+					lines.Write(scanner.Bytes())
+					lines.WriteByte('\n')
+				}
+			}
+			stream.Close()
+			if err := scanner.Err(); err != nil {
+				failed = append(failed, fmt.Sprintf("%s/%s: %v", pod.Name, container.Name, err))
+			}
+			if lines.Len() > 0 {
+				logs[fmt.Sprintf("%s_%s.log", pod.Name, container.Name)] = lines.Bytes()
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return logs, fmt.Errorf("failed to get the logs of %s", strings.Join(failed, ", "))
+	}
+	return logs, nil
+}
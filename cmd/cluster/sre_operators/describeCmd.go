@@ -20,8 +20,13 @@ import (
 )
 
 type sreOperatorsDescribeOptions struct {
+	deep     bool
+	logLines int64
+	tarball  string
+
 	genericclioptions.IOStreams
 	kubeCli client.Client
+	logs    podLogsFunc
 }
 
 type sreOperatorDetails struct {
@@ -42,6 +47,12 @@ const (
 	sreOperatorsDescribeExample = `
 		# Describe SRE operators
 		$ osdctl cluster sre-operators describe <operator-name>
+
+		# Also gather the pods, log errors, events, CSV conditions, install plans and custom resources of the operator
+		$ osdctl cluster sre-operators describe <operator-name> --deep
+
+		# Bundle the deep report, with the full resources and logs, into a tarball
+		$ osdctl cluster sre-operators describe <operator-name> --tarball operator-report.tar.gz
 	`
 	sreOperatorsLongDescription = `
   Helps obtain various health information about a specified SRE operator within a cluster,
@@ -52,6 +63,12 @@ const (
 
   The command creates a Kubernetes client to access the current cluster context, and GitLab/GitHub
   clients to fetch the latest versions of each operator from its respective repository.
+
+  With --deep, the command also gathers what is needed to troubleshoot an unhealthy operator: the status
+  and restarts of the operator deployment's pods, the errors and reconcile errors in their recent logs
+  (and in the logs of the previous containers of restarted pods), the events in the namespace, the CSV
+  conditions, the install plans and the status conditions of the custom resources the operator owns.
+  With --tarball, the report is bundled with the gathered resources as YAML and the full logs.
 	`
 )
 
@@ -71,9 +88,16 @@ func newCmdDescribe(streams genericclioptions.IOStreams, client client.Client) *
 			util.CheckErr(opts.checks(cmd))
 			output, _ := opts.DescribeOperator(cmd, args[0])
 			util.CheckErr(opts.printText(output))
+			if opts.deep || opts.tarball != "" {
+				util.CheckErr(opts.describeDeep(context.Background(), args[0]))
+			}
 		},
 	}
 
+	describeCmd.Flags().BoolVar(&opts.deep, "deep", false, "Also gather the pods, log errors, events, CSV conditions, install plans and custom resources of the operator")
+	describeCmd.Flags().Int64Var(&opts.logLines, "log-lines", 500, "How many of the latest log lines of each operator container to gather")
+	describeCmd.Flags().StringVar(&opts.tarball, "tarball", "", "Bundle the deep report, the gathered resources and the logs into this gzipped tarball (implies --deep)")
+
 	return describeCmd
 }
 
@@ -199,11 +223,13 @@ func (ctx *sreOperatorsDescribeOptions) DescribeOperator(cmd *cobra.Command, ope
 		for _, item := range csvList.Items {
 			if strings.Contains(item.GetName(), operatorName) {
 				currentVersion = item.GetName()
-				csvStatus = item.Object["status"].(map[string]interface{})["phase"].(string)
-				conditions := item.Object["status"].(map[string]interface{})["conditions"]
-				lastCondition := conditions.([]interface{})[len(conditions.([]interface{}))-1].(map[string]interface{})
-				csvHealthPhase = lastCondition["phase"].(string)
-				csvHealthMessage = lastCondition["message"].(string) + ", reason: " + lastCondition["reason"].(string)
+				csvStatus, _, _ = unstructured.NestedString(item.Object, "status", "phase")
+				// An operator which failed to install may not have any conditions
+				if conditions := unstructuredConditions(item, "status", "conditions"); len(conditions) > 0 {
+					lastCondition := conditions[len(conditions)-1]
+					csvHealthPhase = lastCondition.Type
+					csvHealthMessage = lastCondition.Message + ", reason: " + lastCondition.Reason
+				}
 			}
 		}
 	}
@@ -214,8 +240,10 @@ func (ctx *sreOperatorsDescribeOptions) DescribeOperator(cmd *cobra.Command, ope
 	} else {
 		for _, item := range subList.Items {
 			if strings.Contains(item.GetName(), operatorName) {
-				operatorChannel = item.Object["spec"].(map[string]interface{})["channel"].(string)
-				subMessage = item.Object["status"].(map[string]interface{})["conditions"].([]interface{})[0].(map[string]interface{})["message"].(string)
+				operatorChannel, _, _ = unstructured.NestedString(item.Object, "spec", "channel")
+				if conditions := unstructuredConditions(item, "status", "conditions"); len(conditions) > 0 {
+					subMessage = conditions[0].Message
+				}
 			}
 		}
 	}
@@ -266,7 +294,16 @@ func (ctx *sreOperatorsDescribeOptions) DescribeOperator(cmd *cobra.Command, ope
 	currentCommit := extractCommit(currentVersion)
 	currentVersion = extractVersion(currentVersion)
 	op := sreOperatorDetails{
-		basic: sreOperator{operatorName, currentVersion, currentCommit, ExpectedVersion, expectedCommit, csvStatus, operatorChannel, repositoryUrl},
+		basic: sreOperator{
+			Name:           operatorName,
+			Current:        currentVersion,
+			CurrentCommit:  currentCommit,
+			Expected:       ExpectedVersion,
+			ExpectedCommit: expectedCommit,
+			Status:         csvStatus,
+			Channel:        operatorChannel,
+			RepositoryURL:  repositoryUrl,
+		},
 
 		CsvHealthPhase:   csvHealthPhase,
 		CsvHealthMessage: csvHealthMessage,
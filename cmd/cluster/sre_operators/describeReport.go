@@ -0,0 +1,549 @@
+package sre_operators
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// maxReportLogErrors is how many of the latest error lines of each container are shown in the report
+	maxReportLogErrors = 20
+	// maxReportEvents is how many of the latest events of the namespace are shown in the report
+	maxReportEvents = 20
+	// maxReportInstallPlans is how many of the latest install plans are shown in the report
+	maxReportInstallPlans = 3
+)
+
+var (
+	// operatorLogErrorRegexp matches the log lines of errors, both from structured logs and klog
+	operatorLogErrorRegexp = regexp.MustCompile(`(?i)"level":"(error|dpanic|panic|fatal)"|level=(error|fatal)|\b(error|panic|fatal)\b|^E\d{4} `)
+	// operatorReconcileErrorRegexp matches the errors controller-runtime logs when a reconcile fails
+	operatorReconcileErrorRegexp = regexp.MustCompile(`Reconciler error`)
+)
+
+// podLogsFunc streams the logs of a pod container
+type podLogsFunc func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error)
+
+// operatorCondition is a status condition of an OLM or operator resource. CSV conditions have a phase instead of a
+// type, which is used as the type.
+type operatorCondition struct {
+	Type               string
+	Status             string
+	Reason             string
+	Message            string
+	LastTransitionTime string
+}
+
+type operatorContainerReport struct {
+	Name            string
+	Previous        bool
+	ErrorLines      []string
+	ReconcileErrors int
+}
+
+type operatorPodReport struct {
+	Name            string
+	Phase           corev1.PodPhase
+	Ready           bool
+	Restarts        int32
+	LastTermination string
+	Containers      []operatorContainerReport
+}
+
+type installPlanReport struct {
+	Name       string
+	Phase      string
+	Approval   string
+	Approved   bool
+	CSVs       []string
+	Conditions []operatorCondition
+}
+
+type customResourceReport struct {
+	Kind       string
+	Namespace  string
+	Name       string
+	Conditions []operatorCondition
+}
+
+// operatorReport is the deep description of an operator, gathered to troubleshoot an unhealthy operator
+type operatorReport struct {
+	Operator      sreOperatorRef
+	GatheredAt    time.Time
+	Pods          []operatorPodReport
+	Events        []corev1.Event
+	CSVConditions []operatorCondition
+	InstallPlans  []installPlanReport
+	Resources     []customResourceReport
+
+	// The gathered resources and logs, which are bundled in the tarball
+	csv             *unstructured.Unstructured
+	subscription    *unstructured.Unstructured
+	installPlans    []unstructured.Unstructured
+	customResources []unstructured.Unstructured
+	pods            []corev1.Pod
+	logs            map[string][]byte
+	errors          []string
+}
+
+// podLogs returns a function streaming pod logs with the REST config of the describe client
+func (o *sreOperatorsDescribeOptions) podLogs() (podLogsFunc, error) {
+	var cfg *rest.Config
+	var err error
+	if c, ok := o.kubeCli.(interface{ RESTConfig() (*rest.Config, error) }); ok {
+		cfg, err = c.RESTConfig()
+	} else {
+		cfg, err = config.GetConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	return func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+		return clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	}, nil
+}
+
+// describeDeep gathers the deep report of the operator, then prints it and bundles it into the tarball if set
+func (o *sreOperatorsDescribeOptions) describeDeep(ctx context.Context, operatorName string) error {
+	operators, err := sreOperatorRefs(operatorName)
+	if err != nil {
+		return err
+	}
+	if o.logs == nil {
+		if o.logs, err = o.podLogs(); err != nil {
+			return err
+		}
+	}
+
+	report, err := o.gatherReport(ctx, operators[0])
+	if err != nil {
+		return err
+	}
+
+	text := &bytes.Buffer{}
+	if err := printOperatorReport(text, report); err != nil {
+		return err
+	}
+	if _, err := o.Out.Write(text.Bytes()); err != nil {
+		return err
+	}
+
+	if o.tarball == "" {
+		return nil
+	}
+	if err := writeOperatorReportTarball(o.tarball, report, text.Bytes()); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "\nThe report, resources and logs have been written to %s\n", o.tarball)
+	return nil
+}
+
+// gatherReport gathers the pods, logs, events, CSV, install plans and custom resources of the operator. Resources
+// which can't be gathered are recorded in the report rather than failing it, as an unhealthy operator may be missing
+// some of them.
+func (o *sreOperatorsDescribeOptions) gatherReport(ctx context.Context, operator sreOperatorRef) (*operatorReport, error) {
+	report := &operatorReport{Operator: operator, GatheredAt: time.Now().UTC(), logs: map[string][]byte{}}
+	inNamespace := client.InNamespace(operator.Namespace)
+
+	csvs := newOLMList("ClusterServiceVersionList")
+	if err := o.kubeCli.List(ctx, csvs, inNamespace); err != nil {
+		return nil, fmt.Errorf("failed to list the CSVs: %w", err)
+	}
+	for i := range csvs.Items {
+		if strings.Contains(csvs.Items[i].GetName(), operator.Name) {
+			report.csv = &csvs.Items[i]
+			report.CSVConditions = unstructuredConditions(csvs.Items[i], "status", "conditions")
+		}
+	}
+
+	subscriptions := newOLMList("SubscriptionList")
+	if err := o.kubeCli.List(ctx, subscriptions, inNamespace); err != nil {
+		report.addError("failed to list the subscriptions: %v", err)
+	}
+	for i := range subscriptions.Items {
+		if strings.Contains(subscriptions.Items[i].GetName(), operator.Name) {
+			report.subscription = &subscriptions.Items[i]
+		}
+	}
+
+	installPlans := newOLMList("InstallPlanList")
+	if err := o.kubeCli.List(ctx, installPlans, inNamespace); err != nil {
+		report.addError("failed to list the install plans: %v", err)
+	}
+	report.installPlans = installPlans.Items
+	sort.SliceStable(report.installPlans, func(i, j int) bool {
+		return report.installPlans[i].GetCreationTimestamp().Time.Before(report.installPlans[j].GetCreationTimestamp().Time)
+	})
+	for _, installPlan := range report.installPlans {
+		report.InstallPlans = append(report.InstallPlans, newInstallPlanReport(installPlan))
+	}
+
+	if report.csv != nil {
+		o.gatherCustomResources(ctx, report)
+	}
+
+	if err := o.gatherPods(ctx, report); err != nil {
+		report.addError("failed to gather the operator pods: %v", err)
+	}
+
+	events := &corev1.EventList{}
+	if err := o.kubeCli.List(ctx, events, inNamespace); err != nil {
+		report.addError("failed to list the events: %v", err)
+	}
+	report.Events = events.Items
+	sort.SliceStable(report.Events, func(i, j int) bool {
+		return eventTime(report.Events[i]).Before(eventTime(report.Events[j]))
+	})
+
+	return report, nil
+}
+
+// gatherPods gathers the status and logs of the pods of the operator deployment
+func (o *sreOperatorsDescribeOptions) gatherPods(ctx context.Context, report *operatorReport) error {
+	deployments := &appsv1.DeploymentList{}
+	if err := o.kubeCli.List(ctx, deployments, client.InNamespace(report.Operator.Namespace)); err != nil {
+		return err
+	}
+	var deployment *appsv1.Deployment
+	for i := range deployments.Items {
+		if strings.Contains(deployments.Items[i].Name, report.Operator.Name) {
+			deployment = &deployments.Items[i]
+		}
+	}
+	if deployment == nil || deployment.Spec.Selector == nil {
+		return fmt.Errorf("no deployment found for %s", report.Operator.Name)
+	}
+
+	pods := &corev1.PodList{}
+	if err := o.kubeCli.List(ctx, pods, client.InNamespace(report.Operator.Namespace), client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil {
+		return err
+	}
+	report.pods = pods.Items
+
+	for _, pod := range pods.Items {
+		podReport := operatorPodReport{Name: pod.Name, Phase: pod.Status.Phase, Ready: true}
+		for _, status := range pod.Status.ContainerStatuses {
+			podReport.Ready = podReport.Ready && status.Ready
+			podReport.Restarts += status.RestartCount
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				podReport.LastTermination = fmt.Sprintf("%s: %s (exit code %d) at %s", status.Name, terminated.Reason, terminated.ExitCode, terminated.FinishedAt.UTC().Format(time.RFC3339))
+			}
+
+			containerReport, err := o.gatherLogs(ctx, report, pod, status.Name, false)
+			if err != nil {
+				report.addError("failed to get the logs of %s/%s: %v", pod.Name, status.Name, err)
+			} else {
+				podReport.Containers = append(podReport.Containers, containerReport)
+			}
+			// The logs of the previous container hold the error which made it restart
+			if status.RestartCount > 0 {
+				containerReport, err := o.gatherLogs(ctx, report, pod, status.Name, true)
+				if err != nil {
+					report.addError("failed to get the previous logs of %s/%s: %v", pod.Name, status.Name, err)
+				} else {
+					podReport.Containers = append(podReport.Containers, containerReport)
+				}
+			}
+		}
+		report.Pods = append(report.Pods, podReport)
+	}
+	return nil
+}
+
+// gatherLogs tails the logs of the container, keeping them for the tarball and filtering them for errors
+func (o *sreOperatorsDescribeOptions) gatherLogs(ctx context.Context, report *operatorReport, pod corev1.Pod, container string, previous bool) (operatorContainerReport, error) {
+	containerReport := operatorContainerReport{Name: container, Previous: previous}
+	stream, err := o.logs(ctx, pod.Namespace, pod.Name, &corev1.PodLogOptions{Container: container, Previous: previous, TailLines: &o.logLines})
+	if err != nil {
+		return containerReport, err
+	}
+	defer stream.Close()
+
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return containerReport, err
+	}
+	name := pod.Name + "_" + container
+	if previous {
+		name += "_previous"
+	}
+	report.logs[name+".log"] = logs
+
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if operatorReconcileErrorRegexp.MatchString(line) {
+			containerReport.ReconcileErrors++
+		}
+		if operatorLogErrorRegexp.MatchString(line) {
+			containerReport.ErrorLines = append(containerReport.ErrorLines, line)
+		}
+	}
+	if len(containerReport.ErrorLines) > maxReportLogErrors {
+		containerReport.ErrorLines = containerReport.ErrorLines[len(containerReport.ErrorLines)-maxReportLogErrors:]
+	}
+	return containerReport, scanner.Err()
+}
+
+// gatherCustomResources gathers the status conditions of the custom resources owned by the operator, from the CRDs
+// its CSV declares
+func (o *sreOperatorsDescribeOptions) gatherCustomResources(ctx context.Context, report *operatorReport) {
+	owned, _, _ := unstructured.NestedSlice(report.csv.Object, "spec", "customresourcedefinitions", "owned")
+	for _, crd := range owned {
+		crd, ok := crd.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := crd["name"].(string)
+		version, _ := crd["version"].(string)
+		kind, _ := crd["kind"].(string)
+		_, group, found := strings.Cut(name, ".")
+		if !found || version == "" || kind == "" {
+			continue
+		}
+
+		resources := &unstructured.UnstructuredList{}
+		resources.SetGroupVersionKind(schema.GroupVersionKind{Group: group, Version: version, Kind: kind + "List"})
+		if err := o.kubeCli.List(ctx, resources); err != nil {
+			report.addError("failed to list the %s resources: %v", kind, err)
+			continue
+		}
+		for _, resource := range resources.Items {
+			report.customResources = append(report.customResources, resource)
+			report.Resources = append(report.Resources, customResourceReport{
+				Kind:       kind,
+				Namespace:  resource.GetNamespace(),
+				Name:       resource.GetName(),
+				Conditions: unstructuredConditions(resource, "status", "conditions"),
+			})
+		}
+	}
+}
+
+func (r *operatorReport) addError(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func newOLMList(kind string) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: "operators.coreos.com", Version: "v1alpha1", Kind: kind})
+	return list
+}
+
+func newInstallPlanReport(installPlan unstructured.Unstructured) installPlanReport {
+	report := installPlanReport{Name: installPlan.GetName()}
+	report.Phase, _, _ = unstructured.NestedString(installPlan.Object, "status", "phase")
+	report.Approval, _, _ = unstructured.NestedString(installPlan.Object, "spec", "approval")
+	report.Approved, _, _ = unstructured.NestedBool(installPlan.Object, "spec", "approved")
+	report.CSVs, _, _ = unstructured.NestedStringSlice(installPlan.Object, "spec", "clusterServiceVersionNames")
+	report.Conditions = unstructuredConditions(installPlan, "status", "conditions")
+	return report
+}
+
+// unstructuredConditions returns the conditions at the path of the object
+func unstructuredConditions(obj unstructured.Unstructured, fields ...string) []operatorCondition {
+	var conditions []operatorCondition
+	items, _, _ := unstructured.NestedSlice(obj.Object, fields...)
+	for _, item := range items {
+		c, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		str := func(key string) string {
+			value, _ := c[key].(string)
+			return value
+		}
+		condition := operatorCondition{
+			Type:               str("type"),
+			Status:             str("status"),
+			Reason:             str("reason"),
+			Message:            str("message"),
+			LastTransitionTime: str("lastTransitionTime"),
+		}
+		if condition.Type == "" {
+			condition.Type = str("phase")
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// eventTime returns when the event last happened
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// printOperatorReport prints the report in sections, with the latest of the events, install plans and log errors
+func printOperatorReport(w io.Writer, report *operatorReport) error {
+	fmt.Fprintf(w, "\n%sPods%s\n", Bold, RestoreColor)
+	if len(report.Pods) == 0 {
+		fmt.Fprintln(w, "No operator pods found")
+	} else {
+		p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+		p.AddRow([]string{"NAME", "PHASE", "READY", "RESTARTS", "LAST TERMINATION"})
+		for _, pod := range report.Pods {
+			p.AddRow([]string{pod.Name, string(pod.Phase), fmt.Sprint(pod.Ready), fmt.Sprint(pod.Restarts), pod.LastTermination})
+		}
+		if err := p.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "\n%sLog Errors%s\n", Bold, RestoreColor)
+	if len(report.logs) == 0 {
+		fmt.Fprintln(w, "No logs gathered")
+	}
+	for _, pod := range report.Pods {
+		for _, container := range pod.Containers {
+			name := pod.Name + "/" + container.Name
+			if container.Previous {
+				name += " (previous)"
+			}
+			fmt.Fprintf(w, "%s: %d error lines, %d reconcile errors\n", name, len(container.ErrorLines), container.ReconcileErrors)
+			for _, line := range container.ErrorLines {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%sEvents%s\n", Bold, RestoreColor)
+	events := report.Events
+	if len(events) > maxReportEvents {
+		events = events[len(events)-maxReportEvents:]
+	}
+	if len(events) == 0 {
+		fmt.Fprintln(w, "No events found")
+	} else {
+		p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+		p.AddRow([]string{"LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE"})
+		for _, event := range events {
+			p.AddRow([]string{
+				eventTime(event).UTC().Format(time.RFC3339), event.Type, event.Reason,
+				strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name, strings.TrimSpace(event.Message),
+			})
+		}
+		if err := p.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "\n%sCSV Conditions%s\n", Bold, RestoreColor)
+	if err := printOperatorConditions(w, report.CSVConditions); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%sInstall Plans%s\n", Bold, RestoreColor)
+	installPlans := report.InstallPlans
+	if len(installPlans) > maxReportInstallPlans {
+		installPlans = installPlans[len(installPlans)-maxReportInstallPlans:]
+	}
+	if len(installPlans) == 0 {
+		fmt.Fprintln(w, "No install plans found")
+	}
+	for _, installPlan := range installPlans {
+		fmt.Fprintf(w, "%s: phase %s, approval %s, approved %t, CSVs %s\n", installPlan.Name, installPlan.Phase, installPlan.Approval, installPlan.Approved, strings.Join(installPlan.CSVs, ", "))
+		for _, condition := range installPlan.Conditions {
+			if condition.Status != "True" || condition.Message != "" {
+				fmt.Fprintf(w, "  %s=%s %s: %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%sCustom Resources%s\n", Bold, RestoreColor)
+	if len(report.Resources) == 0 {
+		fmt.Fprintln(w, "No custom resources found")
+	}
+	for _, resource := range report.Resources {
+		name := resource.Name
+		if resource.Namespace != "" {
+			name = resource.Namespace + "/" + name
+		}
+		fmt.Fprintf(w, "%s %s:\n", resource.Kind, name)
+		for _, condition := range resource.Conditions {
+			fmt.Fprintf(w, "  %s=%s %s: %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+
+	if len(report.errors) > 0 {
+		fmt.Fprintf(w, "\n%sGathering Errors%s\n", Bold, RestoreColor)
+		for _, err := range report.errors {
+			fmt.Fprintln(w, err)
+		}
+	}
+	return nil
+}
+
+func printOperatorConditions(w io.Writer, conditions []operatorCondition) error {
+	if len(conditions) == 0 {
+		fmt.Fprintln(w, "No conditions found")
+		return nil
+	}
+	p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	p.AddRow([]string{"LAST TRANSITION", "PHASE", "REASON", "MESSAGE"})
+	for _, condition := range conditions {
+		p.AddRow([]string{condition.LastTransitionTime, condition.Type, condition.Reason, condition.Message})
+	}
+	return p.Flush()
+}
+
+// writeOperatorReportTarball bundles the printed report, the gathered resources as YAML and the container logs into
+// a gzipped tarball
+func writeOperatorReportTarball(file string, report *operatorReport, text []byte) error {
+	files := map[string][]byte{"report.txt": text}
+
+	resources := map[string]interface{}{
+		"csv.yaml":              report.csv,
+		"subscription.yaml":     report.subscription,
+		"installplans.yaml":     report.installPlans,
+		"custom-resources.yaml": report.customResources,
+		"pods.yaml":             report.pods,
+		"events.yaml":           report.Events,
+	}
+	for name, resource := range resources {
+		data, err := yaml.Marshal(resource)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+		files[name] = data
+	}
+	for name, logs := range report.logs {
+		files[path.Join("logs", name)] = logs
+	}
+
+	dir := fmt.Sprintf("%s-%s", report.Operator.Name, report.GatheredAt.Format("20060102150405"))
+	if err := utils.WriteTarball(file, dir, files, report.GatheredAt); err != nil {
+		return fmt.Errorf("failed to write the report tarball: %w", err)
+	}
+	return nil
+}
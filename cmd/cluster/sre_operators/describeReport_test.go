@@ -0,0 +1,177 @@
+package sre_operators
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testMUONamespace = "openshift-managed-upgrade-operator"
+
+func newTestOperatorReportOptions(out io.Writer, logs map[string]string) *sreOperatorsDescribeOptions {
+	labels := map[string]string{"name": "managed-upgrade-operator"}
+	csv := testOperatorObject("ClusterServiceVersion", testMUONamespace, "managed-upgrade-operator.v0.1.100-abcdef0", map[string]interface{}{
+		"spec": map[string]interface{}{"customresourcedefinitions": map[string]interface{}{"owned": []interface{}{
+			map[string]interface{}{"name": "upgradeconfigs.upgrade.managed.openshift.io", "version": "v1alpha1", "kind": "UpgradeConfig"},
+		}}},
+		"status": map[string]interface{}{"phase": "Failed", "conditions": []interface{}{
+			map[string]interface{}{"phase": "Installing", "reason": "InstallSucceeded", "message": "waiting for install components to report healthy"},
+			map[string]interface{}{"phase": "Failed", "reason": "ComponentUnhealthy", "message": "installing: deployment changed old hash"},
+		}},
+	})
+	installPlan := testOperatorObject("InstallPlan", testMUONamespace, "install-abcde", map[string]interface{}{
+		"spec": map[string]interface{}{"approval": "Automatic", "approved": true, "clusterServiceVersionNames": []interface{}{"managed-upgrade-operator.v0.1.100-abcdef0"}},
+		"status": map[string]interface{}{"phase": "Failed", "conditions": []interface{}{
+			map[string]interface{}{"type": "Installed", "status": "False", "reason": "InstallComponentFailed", "message": "error creating csv"},
+		}},
+	})
+	upgradeConfig := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Degraded", "status": "True", "reason": "ClusterVerificationFailed", "message": "cluster is not healthy"},
+		}},
+	}}
+	upgradeConfig.SetAPIVersion("upgrade.managed.openshift.io/v1alpha1")
+	upgradeConfig.SetKind("UpgradeConfig")
+	upgradeConfig.SetNamespace(testMUONamespace)
+	upgradeConfig.SetName("managed-upgrade-config")
+
+	objs := []client.Object{
+		csv, installPlan, upgradeConfig,
+		testSubscription(testMUONamespace, "managed-upgrade-operator"),
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "managed-upgrade-operator", Namespace: testMUONamespace},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "managed-upgrade-operator-1", Namespace: testMUONamespace, Labels: labels},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "managed-upgrade-operator",
+				RestartCount: 3,
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					Reason: "Error", ExitCode: 1, FinishedAt: metav1.NewTime(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)),
+				}},
+			}}},
+		},
+		// Pods of other deployments in the namespace aren't gathered
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other-1", Namespace: testMUONamespace}},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "event-2", Namespace: testMUONamespace},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "managed-upgrade-operator-1"},
+			Type:           "Warning", Reason: "BackOff", Message: "Back-off restarting failed container",
+			LastTimestamp: metav1.NewTime(time.Date(2024, 6, 1, 10, 5, 0, 0, time.UTC)),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "event-1", Namespace: testMUONamespace},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "managed-upgrade-operator-1"},
+			Type:           "Normal", Reason: "Pulled", Message: "Container image already present",
+			LastTimestamp: metav1.NewTime(time.Date(2024, 6, 1, 10, 1, 0, 0, time.UTC)),
+		},
+	}
+
+	return &sreOperatorsDescribeOptions{
+		logLines:  500,
+		IOStreams: genericclioptions.IOStreams{Out: out},
+		kubeCli:   newOperatorTestClient(objs...),
+		logs: func(ctx context.Context, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+			key := pod + "/" + opts.Container
+			if opts.Previous {
+				key += "/previous"
+			}
+			return io.NopCloser(strings.NewReader(logs[key])), nil
+		},
+	}
+}
+
+func TestGatherOperatorReport(t *testing.T) {
+	opts := newTestOperatorReportOptions(io.Discard, map[string]string{
+		"managed-upgrade-operator-1/managed-upgrade-operator": strings.Join([]string{
+			`{"level":"info","msg":"Reconciling UpgradeConfig"}`,
+			`{"level":"error","msg":"Reconciler error","controller":"upgradeconfig","error":"cluster is not healthy"}`,
+		}, "\n"),
+		"managed-upgrade-operator-1/managed-upgrade-operator/previous": `panic: runtime error: invalid memory address`,
+	})
+
+	report, err := opts.gatherReport(context.Background(), sreOperatorRef{Namespace: testMUONamespace, Name: "managed-upgrade-operator"})
+	require.NoError(t, err)
+	assert.Empty(t, report.errors)
+
+	require.Len(t, report.Pods, 1)
+	pod := report.Pods[0]
+	assert.Equal(t, int32(3), pod.Restarts)
+	assert.Equal(t, "managed-upgrade-operator: Error (exit code 1) at 2024-06-01T10:00:00Z", pod.LastTermination)
+	require.Len(t, pod.Containers, 2)
+	assert.Equal(t, 1, pod.Containers[0].ReconcileErrors)
+	assert.Len(t, pod.Containers[0].ErrorLines, 1)
+	assert.True(t, pod.Containers[1].Previous)
+	assert.Equal(t, []string{"panic: runtime error: invalid memory address"}, pod.Containers[1].ErrorLines)
+
+	require.Len(t, report.Events, 2)
+	assert.Equal(t, "BackOff", report.Events[1].Reason)
+
+	require.Len(t, report.CSVConditions, 2)
+	assert.Equal(t, operatorCondition{Type: "Failed", Reason: "ComponentUnhealthy", Message: "installing: deployment changed old hash"}, report.CSVConditions[1])
+
+	require.Len(t, report.InstallPlans, 1)
+	assert.Equal(t, "Failed", report.InstallPlans[0].Phase)
+	assert.Equal(t, []string{"managed-upgrade-operator.v0.1.100-abcdef0"}, report.InstallPlans[0].CSVs)
+
+	require.Len(t, report.Resources, 1)
+	assert.Equal(t, "UpgradeConfig", report.Resources[0].Kind)
+	assert.Equal(t, "Degraded", report.Resources[0].Conditions[0].Type)
+}
+
+func TestDescribeDeepTarball(t *testing.T) {
+	out := &bytes.Buffer{}
+	opts := newTestOperatorReportOptions(out, map[string]string{
+		"managed-upgrade-operator-1/managed-upgrade-operator": `E0601 10:00:00.000000       1 controller.go:329] "Reconciler error"`,
+	})
+	opts.tarball = filepath.Join(t.TempDir(), "report.tar.gz")
+
+	require.NoError(t, opts.describeDeep(context.Background(), "openshift-managed-upgrade-operator"))
+	assert.Contains(t, out.String(), "managed-upgrade-operator-1/managed-upgrade-operator: 1 error lines, 1 reconcile errors")
+	assert.Contains(t, out.String(), "Back-off restarting failed container")
+	assert.Contains(t, out.String(), "install-abcde: phase Failed")
+	assert.Contains(t, out.String(), "Installed=False InstallComponentFailed: error creating csv")
+	assert.Contains(t, out.String(), "UpgradeConfig openshift-managed-upgrade-operator/managed-upgrade-config:")
+
+	file, err := os.Open(opts.tarball)
+	require.NoError(t, err)
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+
+	files := map[string]string{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tarReader)
+		require.NoError(t, err)
+		files[header.Name[strings.Index(header.Name, "/")+1:]] = string(content)
+	}
+	assert.Contains(t, files["report.txt"], "CSV Conditions")
+	assert.Contains(t, files["csv.yaml"], "ComponentUnhealthy")
+	assert.Contains(t, files["custom-resources.yaml"], "ClusterVerificationFailed")
+	assert.Contains(t, files, "logs/managed-upgrade-operator-1_managed-upgrade-operator_previous.log")
+	assert.Contains(t, files["logs/managed-upgrade-operator-1_managed-upgrade-operator.log"], "Reconciler error")
+	assert.Contains(t, files, "events.yaml")
+}
@@ -0,0 +1,411 @@
+package sre_operators
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/xanzy/go-gitlab"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	sreOperatorsDriftExample = `
+	# Show the SRE operators which drifted from their expected version on the clusters matching a search query
+	$ osdctl cluster sre-operators drift --query "product.id = 'osd' and version.raw_id like '4.16%'"
+
+	# Show the drift of the clusters listed in a file
+	$ osdctl cluster sre-operators drift --clusters-file clusters.json
+
+	# Show the drift of a single operator, including the clusters where it's up to date
+	$ osdctl cluster sre-operators drift --clusters-file clusters.json --operator managed-upgrade-operator --all
+	`
+	sreOperatorsDriftDescription = `
+	Compares the SRE operators installed on many clusters against their expected versions, to find
+	the clusters which are stuck on an old version during a rollout.
+
+	The expected version of each operator is fetched from its repository once, then the installed CSV
+	versions and subscription health are gathered from the clusters concurrently. The report is a
+	matrix of cluster by operator, where each operator is either outdated, failed or missing.
+	Operators whose namespace doesn't exist on a cluster aren't deployed to it and are shown as '-'.
+
+	By default only the clusters and operators which drifted are shown, use --all to show every one.
+	A gitlab_access token is required to fetch the expected versions, and can be set within the
+	config file using the 'osdctl setup' command.
+	`
+)
+
+// The drift of an operator on a cluster, from the most to the least severe
+const (
+	driftMissing     = "missing"
+	driftFailed      = "failed"
+	driftOutdated    = "outdated"
+	driftOK          = "ok"
+	driftNotDeployed = "-"
+)
+
+type sreOperatorsDriftOptions struct {
+	query        []string
+	clustersFile string
+	operator     string
+	all          bool
+	concurrency  int
+
+	genericclioptions.IOStreams
+	clientForCluster func(ocmClient *sdk.Connection, clusterID string) (client.Client, error)
+}
+
+// sreOperatorRef is an SRE operator and the namespace it's installed in
+type sreOperatorRef struct {
+	Namespace string
+	Name      string
+}
+
+type operatorDrift struct {
+	State     string
+	Installed sreOperator
+}
+
+// String returns the state of the drift, with the installed version when it's not the expected one
+func (d operatorDrift) String() string {
+	switch d.State {
+	case driftOutdated:
+		return driftOutdated + " (" + d.Installed.Current + ")"
+	case driftFailed:
+		if d.Installed.Status != "" && d.Installed.Status != "Succeeded" {
+			return driftFailed + " (" + d.Installed.Status + ")"
+		}
+		return driftFailed + " (subscription)"
+	}
+	return d.State
+}
+
+type clusterDrift struct {
+	ClusterID   string
+	ClusterName string
+	Operators   map[string]operatorDrift
+	Error       error
+}
+
+func newCmdDrift(streams genericclioptions.IOStreams) *cobra.Command {
+	opts := &sreOperatorsDriftOptions{
+		IOStreams: streams,
+		clientForCluster: func(ocmClient *sdk.Connection, clusterID string) (client.Client, error) {
+			kubeCli, _, _, err := common.GetKubeConfigAndClientWithConn(ocmClient, clusterID)
+			return kubeCli, err
+		},
+	}
+
+	driftCmd := &cobra.Command{
+		Use:               "drift",
+		Short:             "Report the SRE operators which drifted from their expected version across many clusters",
+		Long:              sreOperatorsDriftDescription,
+		Example:           sreOperatorsDriftExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(opts.checks(cmd))
+			util.CheckErr(opts.run(context.Background()))
+		},
+	}
+
+	driftCmd.Flags().StringArrayVarP(&opts.query, "query", "q", []string{}, "Specify a search query (eg. -q \"name like foo\") for the clusters to compare")
+	driftCmd.Flags().StringVarP(&opts.clustersFile, "clusters-file", "c", "", `Read a list of clusters to compare. the format of the file is: {"clusters":["$CLUSTERID"]}`)
+	driftCmd.Flags().StringVar(&opts.operator, "operator", "", "Filter to only compare the specified operator")
+	driftCmd.Flags().BoolVar(&opts.all, "all", false, "Show every cluster and operator, including the ones which are up to date")
+	driftCmd.Flags().IntVar(&opts.concurrency, "concurrency", 10, "How many clusters to gather the installed operators from at once")
+
+	return driftCmd
+}
+
+func (o *sreOperatorsDriftOptions) checks(cmd *cobra.Command) error {
+	if len(o.query) == 0 && o.clustersFile == "" {
+		return util.UsageErrorf(cmd, "at least one of --query or --clusters-file is required")
+	}
+	if o.concurrency < 1 {
+		return util.UsageErrorf(cmd, "--concurrency must be at least 1")
+	}
+	return nil
+}
+
+func (o *sreOperatorsDriftOptions) run(ctx context.Context) error {
+	operators, err := sreOperatorRefs(o.operator)
+	if err != nil {
+		return err
+	}
+
+	gitlabAccess := viper.GetString("gitlab_access")
+	if gitlabAccess == "" {
+		return fmt.Errorf("gitlab access token not found, please ensure your gitlab access token is set in the .config/osdctl file in the format: 'gitlab_access: \"<TOKEN>\"'")
+	}
+	gitlabClient, err := gitlab.NewClient(gitlabAccess, gitlab.WithBaseURL("https://gitlab.cee.redhat.com/"))
+	if err != nil {
+		return fmt.Errorf("failed to create the gitlab client: %w", err)
+	}
+
+	ocmClient, err := utils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer ocmClient.Close()
+
+	clusters, err := o.findClusters(ocmClient)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.Out, "Fetching the expected version of %d operators\n", len(operators))
+	expected, expectedErrs := getExpectedVersions(operators, func(operatorName string) (string, error) {
+		version, _, _, err := fetchLatestVersion(gitlabClient, operatorName)
+		if err == nil && version == "" {
+			err = fmt.Errorf("no version found")
+		}
+		return version, err
+	})
+
+	fmt.Fprintf(o.Out, "Gathering the installed operators from %d clusters\n\n", len(clusters))
+	drifts := o.gatherDrift(ctx, ocmClient, clusters, operators, expected)
+
+	if err := o.printDrift(drifts, operators, expected, expectedErrs); err != nil {
+		return err
+	}
+	if len(expectedErrs) > 0 {
+		return fmt.Errorf("failed to fetch the expected version of %d operators", len(expectedErrs))
+	}
+	return nil
+}
+
+// findClusters returns the ready clusters matching the search queries and listed in the clusters file
+func (o *sreOperatorsDriftOptions) findClusters(ocmClient *sdk.Connection) ([]*cmv1.Cluster, error) {
+	filters := append([]string{}, o.query...)
+	if o.clustersFile != "" {
+		contents, err := os.ReadFile(o.clustersFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read file %s: %w", o.clustersFile, err)
+		}
+		clustersFile := servicelog.ClustersFile{}
+		if err := json.Unmarshal(contents, &clustersFile); err != nil {
+			return nil, fmt.Errorf("cannot parse file %s: %w", o.clustersFile, err)
+		}
+		if len(clustersFile.Clusters) == 0 {
+			return nil, fmt.Errorf("no clusters listed in file %s", o.clustersFile)
+		}
+		var queries []string
+		for _, cluster := range clustersFile.Clusters {
+			queries = append(queries, utils.GenerateQuery(cluster))
+		}
+		filters = append(filters, strings.Join(queries, " or "))
+	}
+
+	clusters, err := utils.ApplyFilters(ocmClient, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for clusters with provided filters (%v): %w", filters, err)
+	}
+
+	var ready []*cmv1.Cluster
+	for _, cluster := range clusters {
+		if cluster.State() != cmv1.ClusterStateReady {
+			fmt.Fprintf(o.ErrOut, "Skipping cluster %s (%s), which is %s\n", cluster.Name(), cluster.ID(), cluster.State())
+			continue
+		}
+		ready = append(ready, cluster)
+	}
+	if len(ready) == 0 {
+		return nil, fmt.Errorf("no ready clusters match the given filters (%v)", filters)
+	}
+	return ready, nil
+}
+
+// sreOperatorRefs returns the SRE operators, or only the one matching the filter when it's set
+func sreOperatorRefs(filter string) ([]sreOperatorRef, error) {
+	var operators []sreOperatorRef
+	for i, namespace := range listOfOperators {
+		if filter == "" || filter == namespace || filter == listOfOperatorNames[i] {
+			operators = append(operators, sreOperatorRef{Namespace: namespace, Name: listOfOperatorNames[i]})
+		}
+	}
+	if len(operators) == 0 {
+		return nil, fmt.Errorf("operator '%s' not found", filter)
+	}
+	return operators, nil
+}
+
+// getExpectedVersions fetches the expected version of every operator once, keyed by operator name. The operators whose
+// version couldn't be fetched are returned with their error instead.
+func getExpectedVersions(operators []sreOperatorRef, latestVersion func(operatorName string) (string, error)) (map[string]string, map[string]error) {
+	expected := make(map[string]string, len(operators))
+	errs := map[string]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, operator := range operators {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			version, err := latestVersion(name)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[name] = err
+				return
+			}
+			expected[name] = version
+		}(operator.Name)
+	}
+	wg.Wait()
+	return expected, errs
+}
+
+// gatherDrift compares the operators installed on the clusters against their expected versions, gathering up to
+// concurrency clusters at once
+func (o *sreOperatorsDriftOptions) gatherDrift(ctx context.Context, ocmClient *sdk.Connection, clusters []*cmv1.Cluster, operators []sreOperatorRef, expected map[string]string) []clusterDrift {
+	drifts := make([]clusterDrift, len(clusters))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, o.concurrency)
+
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func(i int, cluster *cmv1.Cluster) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			drifts[i] = clusterDrift{ClusterID: cluster.ID(), ClusterName: cluster.Name()}
+			kubeCli, err := o.clientForCluster(ocmClient, cluster.ID())
+			if err != nil {
+				drifts[i].Error = fmt.Errorf("failed to login: %w", err)
+				return
+			}
+			drifts[i].Operators, drifts[i].Error = getClusterDrift(ctx, kubeCli, operators, expected)
+		}(i, cluster)
+	}
+	wg.Wait()
+
+	sort.SliceStable(drifts, func(i, j int) bool { return drifts[i].ClusterName < drifts[j].ClusterName })
+	return drifts
+}
+
+// getClusterDrift returns the drift of every operator installed on the cluster, keyed by operator name
+func getClusterDrift(ctx context.Context, kubeCli client.Client, operators []sreOperatorRef, expected map[string]string) (map[string]operatorDrift, error) {
+	drifts := make(map[string]operatorDrift, len(operators))
+	for _, operator := range operators {
+		ns := &corev1.Namespace{}
+		if err := kubeCli.Get(ctx, client.ObjectKey{Name: operator.Namespace}, ns); err != nil {
+			if apierrors.IsNotFound(err) {
+				drifts[operator.Name] = operatorDrift{State: driftNotDeployed}
+				continue
+			}
+			return nil, fmt.Errorf("failed to get namespace %s: %w", operator.Namespace, err)
+		}
+
+		installed, found, err := getInstalledOperator(ctx, kubeCli, operator.Namespace, operator.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the installed %s: %w", operator.Name, err)
+		}
+		drifts[operator.Name] = getOperatorDrift(installed, found, expected[operator.Name])
+	}
+	return drifts, nil
+}
+
+// getOperatorDrift returns the most severe drift of the installed operator. An operator whose expected version
+// couldn't be fetched can't be outdated.
+func getOperatorDrift(installed sreOperator, found bool, expected string) operatorDrift {
+	drift := operatorDrift{State: driftOK, Installed: installed}
+	switch {
+	case !found:
+		drift.State = driftMissing
+	case installed.Status != "Succeeded" || len(installed.SubscriptionProblems) > 0:
+		drift.State = driftFailed
+	case expected != "" && installed.Current != expected:
+		drift.State = driftOutdated
+	}
+	return drift
+}
+
+// printDrift prints the expected versions, then the cluster by operator matrix of the drifted operators, the
+// clusters which couldn't be compared and the operators whose expected version couldn't be fetched
+func (o *sreOperatorsDriftOptions) printDrift(drifts []clusterDrift, operators []sreOperatorRef, expected map[string]string, expectedErrs map[string]error) error {
+	p := printer.NewTablePrinter(o.Out, 20, 1, 3, ' ')
+	p.AddRow([]string{"OPERATOR", "EXPECTED", "OUTDATED", "FAILED", "MISSING"})
+	var columns []string
+	for _, operator := range operators {
+		counts := map[string]int{}
+		for _, drift := range drifts {
+			counts[drift.Operators[operator.Name].State]++
+		}
+		version := expected[operator.Name]
+		if version == "" {
+			version = "unknown"
+		}
+		p.AddRow([]string{operator.Name, version, fmt.Sprint(counts[driftOutdated]), fmt.Sprint(counts[driftFailed]), fmt.Sprint(counts[driftMissing])})
+		if o.all || counts[driftOutdated]+counts[driftFailed]+counts[driftMissing] > 0 {
+			columns = append(columns, operator.Name)
+		}
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(o.Out)
+
+	var failed []clusterDrift
+	matrix := printer.NewTablePrinter(o.Out, 20, 1, 3, ' ')
+	matrix.AddRow(append([]string{"CLUSTER", "ID"}, columns...))
+	drifted := 0
+	for _, drift := range drifts {
+		if drift.Error != nil {
+			failed = append(failed, drift)
+			continue
+		}
+		row := []string{drift.ClusterName, drift.ClusterID}
+		clusterDrifted := false
+		for _, name := range columns {
+			operator := drift.Operators[name]
+			clusterDrifted = clusterDrifted || (operator.State != driftOK && operator.State != driftNotDeployed)
+			row = append(row, operator.String())
+		}
+		if clusterDrifted {
+			drifted++
+		}
+		if o.all || clusterDrifted {
+			matrix.AddRow(row)
+		}
+	}
+	if o.all || drifted > 0 {
+		if err := matrix.Flush(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "\n%d of %d clusters have drifted operators\n", drifted, len(drifts)-len(failed))
+
+	if len(failed) > 0 {
+		fmt.Fprintf(o.Out, "\nFailed to compare %d clusters:\n", len(failed))
+		for _, drift := range failed {
+			fmt.Fprintf(o.Out, "  %s (%s): %v\n", drift.ClusterName, drift.ClusterID, drift.Error)
+		}
+	}
+
+	if len(expectedErrs) > 0 {
+		fmt.Fprintf(o.Out, "\nFailed to fetch the expected version of %d operators:\n", len(expectedErrs))
+		for _, operator := range operators {
+			if err, ok := expectedErrs[operator.Name]; ok {
+				fmt.Fprintf(o.Out, "  %s: %v\n", operator.Name, err)
+			}
+		}
+	}
+	return nil
+}
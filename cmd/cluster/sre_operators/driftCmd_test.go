@@ -0,0 +1,166 @@
+package sre_operators
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testOperatorObject(kind, namespace, name string, fields map[string]interface{}) client.Object {
+	obj := &unstructured.Unstructured{Object: fields}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{Group: "operators.coreos.com", Version: "v1alpha1", Kind: kind})
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func testCSV(namespace, name, phase string) client.Object {
+	return testOperatorObject("ClusterServiceVersion", namespace, name, map[string]interface{}{
+		"status": map[string]interface{}{"phase": phase},
+	})
+}
+
+func testSubscription(namespace, name string, conditions ...interface{}) client.Object {
+	return testOperatorObject("Subscription", namespace, name, map[string]interface{}{
+		"spec":   map[string]interface{}{"channel": "production"},
+		"status": map[string]interface{}{"conditions": conditions},
+	})
+}
+
+// newOperatorTestClient returns a fake client which knows the OLM resources and the UpgradeConfig of the managed
+// upgrade operator
+func newOperatorTestClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	kinds := []schema.GroupVersionKind{
+		{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "ClusterServiceVersion"},
+		{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "Subscription"},
+		{Group: "operators.coreos.com", Version: "v1alpha1", Kind: "InstallPlan"},
+		{Group: "upgrade.managed.openshift.io", Version: "v1alpha1", Kind: "UpgradeConfig"},
+	}
+	for _, gvk := range kinds {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+var testDriftOperators = []sreOperatorRef{
+	{Namespace: "openshift-managed-upgrade-operator", Name: "managed-upgrade-operator"},
+	{Namespace: "openshift-must-gather-operator", Name: "must-gather-operator"},
+	{Namespace: "openshift-route-monitor-operator", Name: "route-monitor-operator"},
+	{Namespace: "aws-account-operator", Name: "aws-account-operator"},
+}
+
+func testDriftNamespace(name string) client.Object {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+func TestSreOperatorRefs(t *testing.T) {
+	operators, err := sreOperatorRefs("")
+	require.NoError(t, err)
+	assert.Len(t, operators, len(listOfOperators))
+
+	operators, err = sreOperatorRefs("openshift-velero")
+	require.NoError(t, err)
+	assert.Equal(t, []sreOperatorRef{{Namespace: "openshift-velero", Name: "managed-velero-operator"}}, operators)
+
+	_, err = sreOperatorRefs("unknown-operator")
+	assert.ErrorContains(t, err, "operator 'unknown-operator' not found")
+}
+
+func TestGetClusterDrift(t *testing.T) {
+	kubeCli := newOperatorTestClient(
+		testDriftNamespace("openshift-managed-upgrade-operator"),
+		testCSV("openshift-managed-upgrade-operator", "managed-upgrade-operator.v0.1.100-abcdef0", "Succeeded"),
+		testSubscription("openshift-managed-upgrade-operator", "managed-upgrade-operator"),
+		testDriftNamespace("openshift-must-gather-operator"),
+		testDriftNamespace("openshift-route-monitor-operator"),
+		testCSV("openshift-route-monitor-operator", "route-monitor-operator.v0.1.200-1234567", "Succeeded"),
+		testSubscription("openshift-route-monitor-operator", "route-monitor-operator", map[string]interface{}{
+			"type": "ResolutionFailed", "status": "True", "message": "constraints not satisfiable",
+		}),
+	)
+	expected := map[string]string{
+		"managed-upgrade-operator": "v0.1.120",
+		"route-monitor-operator":   "v0.1.200",
+	}
+
+	drifts, err := getClusterDrift(context.Background(), kubeCli, testDriftOperators, expected)
+	require.NoError(t, err)
+
+	assert.Equal(t, driftOutdated, drifts["managed-upgrade-operator"].State)
+	assert.Equal(t, "outdated (v0.1.100)", drifts["managed-upgrade-operator"].String())
+	assert.Equal(t, driftMissing, drifts["must-gather-operator"].State)
+	assert.Equal(t, driftFailed, drifts["route-monitor-operator"].State)
+	assert.Equal(t, []string{"ResolutionFailed: constraints not satisfiable"}, drifts["route-monitor-operator"].Installed.SubscriptionProblems)
+	assert.Equal(t, "failed (subscription)", drifts["route-monitor-operator"].String())
+	assert.Equal(t, driftNotDeployed, drifts["aws-account-operator"].State)
+}
+
+func TestGetOperatorDrift(t *testing.T) {
+	installed := sreOperator{Current: "v0.1.100", Status: "Succeeded"}
+	assert.Equal(t, driftOK, getOperatorDrift(installed, true, "v0.1.100").State)
+	// Without an expected version the operator can't be outdated
+	assert.Equal(t, driftOK, getOperatorDrift(installed, true, "").State)
+
+	installed.Status = "Installing"
+	assert.Equal(t, "failed (Installing)", getOperatorDrift(installed, true, "v0.1.120").String())
+}
+
+func TestGetExpectedVersions(t *testing.T) {
+	calls := make(chan string, len(testDriftOperators))
+	expected, errs := getExpectedVersions(testDriftOperators, func(operatorName string) (string, error) {
+		calls <- operatorName
+		if operatorName == "must-gather-operator" {
+			return "", fmt.Errorf("failed to list branches")
+		}
+		return "v0.1.1", nil
+	})
+	close(calls)
+
+	assert.Len(t, calls, len(testDriftOperators))
+	assert.Equal(t, "v0.1.1", expected["route-monitor-operator"])
+	assert.NotContains(t, expected, "must-gather-operator")
+	assert.Equal(t, map[string]error{"must-gather-operator": fmt.Errorf("failed to list branches")}, errs)
+}
+
+func TestPrintDrift(t *testing.T) {
+	out := &bytes.Buffer{}
+	opts := &sreOperatorsDriftOptions{IOStreams: genericclioptions.IOStreams{Out: out}}
+	drifts := []clusterDrift{
+		{ClusterName: "stuck", ClusterID: "id-1", Operators: map[string]operatorDrift{
+			"managed-upgrade-operator": {State: driftOutdated, Installed: sreOperator{Current: "v0.1.100"}},
+			"must-gather-operator":     {State: driftOK},
+		}},
+		{ClusterName: "current", ClusterID: "id-2", Operators: map[string]operatorDrift{
+			"managed-upgrade-operator": {State: driftOK},
+			"must-gather-operator":     {State: driftOK},
+		}},
+		{ClusterName: "unreachable", ClusterID: "id-3", Error: fmt.Errorf("failed to login")},
+	}
+
+	expectedErrs := map[string]error{"must-gather-operator": fmt.Errorf("failed to list branches")}
+	require.NoError(t, opts.printDrift(drifts, testDriftOperators[:2], map[string]string{"managed-upgrade-operator": "v0.1.120"}, expectedErrs))
+
+	assert.Contains(t, out.String(), "outdated (v0.1.100)")
+	assert.Contains(t, out.String(), "unknown")
+	assert.NotContains(t, out.String(), "id-2")
+	assert.Contains(t, out.String(), "1 of 2 clusters have drifted operators")
+	assert.Contains(t, out.String(), "unreachable (id-3): failed to login")
+	assert.Contains(t, out.String(), "must-gather-operator: failed to list branches")
+}
@@ -45,6 +45,8 @@ type sreOperator struct {
 	Status         string
 	Channel        string
 	RepositoryURL  string
+
+	SubscriptionProblems []string
 }
 
 const (
@@ -243,31 +245,6 @@ func (ctx *sreOperatorsListOptions) ListOperators(cmd *cobra.Command) ([]sreOper
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, workerLimit)
 
-	csv := &unstructured.Unstructured{}
-	csv.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   "operators.coreos.com",
-		Version: "v1alpha1",
-		Kind:    "ClusterServiceVersion",
-	})
-	csvList := &unstructured.UnstructuredList{}
-	csvList.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   "operators.coreos.com",
-		Version: "v1alpha1",
-		Kind:    "ClusterServiceVersionList",
-	})
-	sub := &unstructured.Unstructured{}
-	sub.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   "operators.coreos.com",
-		Version: "v1alpha1",
-		Kind:    "Subscription",
-	})
-	subList := &unstructured.UnstructuredList{}
-	subList.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   "operators.coreos.com",
-		Version: "v1alpha1",
-		Kind:    "SubscriptionList",
-	})
-
 	gitlabClient := &gitlab.Client{}
 	if !ctx.short {
 		gitlab_access := viper.GetString("gitlab_access")
@@ -307,35 +284,11 @@ func (ctx *sreOperatorsListOptions) ListOperators(cmd *cobra.Command) ([]sreOper
 				latestVersion[i], repositoryUrl, expectedCommit = getLatestVersion(gitlabClient, listOfOperatorNames[i])
 			}
 
-			csvListCopy := csvList.DeepCopy()
-			subListCopy := subList.DeepCopy()
-
-			if err := ctx.kubeCli.List(context.TODO(), csvListCopy, client.InNamespace(listOfOperators[i])); err != nil {
+			installed, found, err := getInstalledOperator(context.TODO(), ctx.kubeCli, listOfOperators[i], listOfOperatorNames[i])
+			if err != nil || !found {
 				return
-			} else {
-				for _, item := range csvListCopy.Items {
-					if strings.Contains(item.GetName(), listOfOperatorNames[i]) {
-						currentVersion[i] = item.GetName()
-						operatorStatus = item.Object["status"].(map[string]interface{})["phase"].(string)
-					}
-				}
-				if currentVersion[i] == "" {
-					return
-				}
-
-				if err := ctx.kubeCli.List(context.TODO(), subListCopy, client.InNamespace(listOfOperators[i])); err != nil {
-					return
-				} else {
-					for _, item := range subListCopy.Items {
-						if strings.Contains(item.GetName(), listOfOperatorNames[i]) {
-							operatorChannel = item.Object["spec"].(map[string]interface{})["channel"].(string)
-						}
-					}
-				}
-
-				currentCommit = extractCommit(currentVersion[i])
-				currentVersion[i] = extractVersion(currentVersion[i])
 			}
+			currentVersion[i], currentCommit, operatorStatus, operatorChannel = installed.Current, installed.CurrentCommit, installed.Status, installed.Channel
 
 			op := sreOperator{
 				Name:           listOfOperatorNames[i],
@@ -366,6 +319,76 @@ func (ctx *sreOperatorsListOptions) ListOperators(cmd *cobra.Command) ([]sreOper
 	return opList, nil
 }
 
+// getInstalledOperator returns the version, status and channel of the operator installed in the namespace, from its
+// CSV and subscription. found is false when no CSV of the operator is installed.
+func getInstalledOperator(ctx context.Context, kubeCli client.Client, namespace, operatorName string) (op sreOperator, found bool, err error) {
+	csvList := &unstructured.UnstructuredList{}
+	csvList.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "operators.coreos.com",
+		Version: "v1alpha1",
+		Kind:    "ClusterServiceVersionList",
+	})
+	subList := &unstructured.UnstructuredList{}
+	subList.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "operators.coreos.com",
+		Version: "v1alpha1",
+		Kind:    "SubscriptionList",
+	})
+
+	if err := kubeCli.List(ctx, csvList, client.InNamespace(namespace)); err != nil {
+		return op, false, err
+	}
+	csvName := ""
+	for _, item := range csvList.Items {
+		if strings.Contains(item.GetName(), operatorName) {
+			csvName = item.GetName()
+			op.Status, _, _ = unstructured.NestedString(item.Object, "status", "phase")
+		}
+	}
+	if csvName == "" {
+		return op, false, nil
+	}
+
+	if err := kubeCli.List(ctx, subList, client.InNamespace(namespace)); err != nil {
+		return op, false, err
+	}
+	for _, item := range subList.Items {
+		if strings.Contains(item.GetName(), operatorName) {
+			op.Channel, _, _ = unstructured.NestedString(item.Object, "spec", "channel")
+			op.SubscriptionProblems = subscriptionProblems(item)
+		}
+	}
+
+	op.Name = operatorName
+	op.Current = extractVersion(csvName)
+	op.CurrentCommit = extractCommit(csvName)
+	return op, true, nil
+}
+
+// subscriptionProblems returns the messages of the failure conditions set on the subscription
+func subscriptionProblems(sub unstructured.Unstructured) []string {
+	var problems []string
+	conditions, _, _ := unstructured.NestedSlice(sub.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["status"] != "True" {
+			continue
+		}
+		switch condition["type"] {
+		case "CatalogSourcesUnhealthy", "ResolutionFailed", "InstallPlanFailed", "InstallPlanMissing":
+			problem := fmt.Sprint(condition["type"])
+			if message, ok := condition["message"].(string); ok && message != "" {
+				problem += ": " + message
+			}
+			problems = append(problems, problem)
+		}
+	}
+	if state, _, _ := unstructured.NestedString(sub.Object, "status", "state"); state == "UpgradeFailed" {
+		problems = append(problems, "UpgradeFailed")
+	}
+	return problems
+}
+
 func extractVersion(input string) string {
 	// extract version from csv name
 	regex := regexp.MustCompile(`(?:.?)(v[0-9\.]+)(?:-.*)?`)
@@ -394,6 +417,16 @@ func extractCommit(input string) string {
 }
 
 func getLatestVersion(gitClient *gitlab.Client, operatorName string) (string, string, string) {
+	expectedVersion, repositoryUrl, expectedCommit, err := fetchLatestVersion(gitClient, operatorName)
+	if err != nil {
+		fmt.Println(operatorName, "-", err)
+		return "", "", ""
+	}
+	return expectedVersion, repositoryUrl, expectedCommit
+}
+
+// fetchLatestVersion returns the latest version of the operator, its repository and commit from GitLab
+func fetchLatestVersion(gitClient *gitlab.Client, operatorName string) (string, string, string, error) {
 
 	// Special case for deployment-validation-operator: version is stored in a text file
 	if operatorName == "deployment-validation-operator" {
@@ -402,21 +435,21 @@ func getLatestVersion(gitClient *gitlab.Client, operatorName string) (string, st
 
 		fileTxt, _, err := gitClient.RepositoryFiles.GetFile(repoLink, filePath, &gitlab.GetFileOptions{Ref: gitlab.Ptr("master")})
 		if err != nil {
-			fmt.Println(operatorName, "- Failed to obtain GitLab file: ", err)
-			return "", "", ""
+			return "", "", "", fmt.Errorf("failed to obtain GitLab file: %w", err)
 		}
 		decodedFileTxt, err := base64.StdEncoding.DecodeString(fileTxt.Content)
 		if err != nil {
-			fmt.Println(operatorName, "failed to decode file: ", err)
+			return "", "", "", fmt.Errorf("failed to decode file: %w", err)
 		}
 		line := strings.Split(string(decodedFileTxt), "\n")
 		for i := len(line) - 1; i >= 0; i-- {
 			if line[i] != "" {
 				expectedVersion := extractVersion("v" + line[i])
 				expectedCommit := extractCommit("v" + line[i])
-				return expectedVersion, "https://github.com/app-sre/deployment-validation-operator/", expectedCommit
+				return expectedVersion, "https://github.com/app-sre/deployment-validation-operator/", expectedCommit, nil
 			}
 		}
+		return "", "", "", fmt.Errorf("no version listed in %s", filePath)
 	}
 	// Special case for must-gather-operator
 	if operatorName == "must-gather-operator" {
@@ -425,8 +458,7 @@ func getLatestVersion(gitClient *gitlab.Client, operatorName string) (string, st
 		// Get the latest release branch
 		repositoryBranches, _, err := gitClient.Branches.ListBranches("service/saas-must-gather-operator-bundle", &gitlab.ListBranchesOptions{})
 		if err != nil {
-			fmt.Println("Failed to list branches: ", err)
-			return "", "", ""
+			return "", "", "", fmt.Errorf("failed to list branches: %w", err)
 		}
 		repositoryBranch := ""
 		highestVersion := 0.0
@@ -445,15 +477,14 @@ func getLatestVersion(gitClient *gitlab.Client, operatorName string) (string, st
 		}
 		fileYaml, _, err := gitClient.RepositoryFiles.GetFile(repoLink, filePath, &gitlab.GetFileOptions{Ref: gitlab.Ptr(repositoryBranch)})
 		if err != nil {
-			fmt.Println(operatorName, "- Failed to obtain GitLab file: ", err)
-			return "", "", ""
+			return "", "", "", fmt.Errorf("failed to obtain GitLab file: %w", err)
 		}
 		decodedYamlString, err := base64.StdEncoding.DecodeString(fileYaml.Content)
 		if err != nil {
-			fmt.Println(operatorName, "failed to decode file: ", err)
+			return "", "", "", fmt.Errorf("failed to decode file: %w", err)
 		}
 		expectedVersion := extractVersion(string(decodedYamlString))
-		return expectedVersion, "https://github.com/openshift/must-gather-operator/", extractCommit(string(decodedYamlString))
+		return expectedVersion, "https://github.com/openshift/must-gather-operator/", extractCommit(string(decodedYamlString)), nil
 	}
 	// Special case for observability-operator
 	if operatorName == "observability-operator" {
@@ -461,13 +492,12 @@ func getLatestVersion(gitClient *gitlab.Client, operatorName string) (string, st
 		filePath := "data/services/osd-operators/cicd/saas/saas-observability-operator.yaml"
 		fileYaml, _, err := gitClient.RepositoryFiles.GetFile(repoLink, filePath, &gitlab.GetFileOptions{Ref: gitlab.Ptr("master")})
 		if err != nil {
-			fmt.Println(operatorName, "- Failed to obtain GitLab file: ", err)
-			return "", "", ""
+			return "", "", "", fmt.Errorf("failed to obtain GitLab file: %w", err)
 		}
 		// decode base64
 		decodedYamlString, err := base64.StdEncoding.DecodeString(fileYaml.Content)
 		if err != nil {
-			fmt.Println(operatorName, "failed to decode file: ", err)
+			return "", "", "", fmt.Errorf("failed to decode file: %w", err)
 		}
 		yamlContent := string(decodedYamlString)
 		re := regexp.MustCompile(`hivep01ue1/cluster-scope.yml
@@ -475,12 +505,12 @@ func getLatestVersion(gitClient *gitlab.Client, operatorName string) (string, st
 		matches := re.FindStringSubmatch(yamlContent)
 
 		if len(matches) == 0 {
-			fmt.Println("Failed to extract version from observability-operator")
+			return "", "", "", fmt.Errorf("failed to extract version from %s", filePath)
 		}
 
 		version, sha := getObservabilityOperatorVersion(matches[1])
 
-		return version, "https://github.com/rhobs/observability-operator/", sha
+		return version, "https://github.com/rhobs/observability-operator/", sha, nil
 
 	}
 
@@ -489,21 +519,20 @@ func getLatestVersion(gitClient *gitlab.Client, operatorName string) (string, st
 
 	fileYaml, _, err := gitClient.RepositoryFiles.GetFile(repoLink, filePath, &gitlab.GetFileOptions{Ref: gitlab.Ptr(repositoryBranch)})
 	if err != nil {
-		fmt.Println(operatorName, "- Failed to obtain GitLab file: ", err)
-		return "", "", ""
+		return "", "", "", fmt.Errorf("failed to obtain GitLab file: %w", err)
 	}
 
 	// decode base64
 	decodedYamlString, err := base64.StdEncoding.DecodeString(fileYaml.Content)
 	if err != nil {
-		fmt.Println(operatorName, "failed to decode file: ", err)
+		return "", "", "", fmt.Errorf("failed to decode file: %w", err)
 	}
 
 	expectedVersion := extractVersion(string(decodedYamlString))
 
 	repositoryUrl := "https://github.com/openshift/" + operatorName
 
-	return expectedVersion, repositoryUrl, extractCommit(string(decodedYamlString))
+	return expectedVersion, repositoryUrl, extractCommit(string(decodedYamlString)), nil
 }
 
 func getObservabilityOperatorVersion(sha string) (string, string) {
@@ -16,6 +16,7 @@ func NewCmdSREOperators(streams genericclioptions.IOStreams, client client.Clien
 
 	sreOperatorsCmd.AddCommand(newCmdList(streams, client))
 	sreOperatorsCmd.AddCommand(newCmdDescribe(streams, client))
+	sreOperatorsCmd.AddCommand(newCmdDrift(streams))
 
 	return sreOperatorsCmd
 }
@@ -15,6 +15,7 @@ import (
 	hiveinternalv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
 	hypershiftv1beta1 "github.com/openshift/hypershift/api/hypershift/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -33,6 +34,7 @@ import (
 
 	"github.com/openshift/osdctl/internal/utils/globalflags"
 	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/openshift/osdctl/pkg/workflow"
 )
 
 const (
@@ -52,6 +54,16 @@ type transferOwnerOptions struct {
 	hypershift   bool
 	cluster      *cmv1.Cluster
 
+	workflow workflow.Options
+
+	// mgmtCluster is the management cluster of HCP clusters
+	mgmtCluster *cmv1.Cluster
+	// masterKubeCli and masterKubeClientSet are clients of the hive cluster of classic clusters, or of the service
+	// cluster of HCP clusters
+	masterKubeCli       client.Client
+	masterKubeClientSet *kubernetes.Clientset
+	targetClientSet     *kubernetes.Clientset
+
 	genericclioptions.IOStreams
 	GlobalOptions *globalflags.GlobalOptions
 }
@@ -59,8 +71,17 @@ type transferOwnerOptions struct {
 func newCmdTransferOwner(streams genericclioptions.IOStreams, globalOpts *globalflags.GlobalOptions) *cobra.Command {
 	ops := newTransferOwnerOptions(streams, globalOpts)
 	transferOwnerCmd := &cobra.Command{
-		Use:               "transfer-owner",
-		Short:             "Transfer cluster ownership to a new user (to be done by Region Lead)",
+		Use:   "transfer-owner",
+		Short: "Transfer cluster ownership to a new user (to be done by Region Lead)",
+		Long: `Transfer cluster ownership to a new user (to be done by Region Lead)
+
+  The transfer is run as a sequence of checkpointed steps. If a step fails, fix the problem and re-run the command with
+  --resume to continue from the failed step, or with --rollback to undo the completed steps.`,
+		Example: `  # Print the steps of the transfer
+  osdctl cluster transfer-owner -C ${CLUSTER_ID} --new-owner ${NEW_OWNER} --reason OHSS-1234 --plan
+
+  # Continue a transfer which failed partway
+  osdctl cluster transfer-owner -C ${CLUSTER_ID} --new-owner ${NEW_OWNER} --reason OHSS-1234 --resume`,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -72,6 +93,7 @@ func newCmdTransferOwner(streams genericclioptions.IOStreams, globalOpts *global
 	transferOwnerCmd.Flags().StringVar(&ops.newOwnerName, "new-owner", ops.newOwnerName, "The new owners username to transfer the cluster to")
 	transferOwnerCmd.Flags().BoolVarP(&ops.dryrun, "dry-run", "d", false, "Dry-run - show all changes but do not apply them")
 	transferOwnerCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
+	ops.workflow.AddFlags(transferOwnerCmd.Flags())
 
 	_ = transferOwnerCmd.MarkFlagRequired("cluster-id")
 	_ = transferOwnerCmd.MarkFlagRequired("new-owner")
@@ -130,7 +152,8 @@ func updatePullSecret(conn *sdk.Connection, kubeCli client.Client, clientset *ku
 
 	// Delete the secret
 	err := clientset.CoreV1().Secrets(hiveNamespace).Delete(context.TODO(), secretName, metav1.DeleteOptions{})
-	if err != nil {
+	// The secret may already have been deleted by a previous run which failed partway
+	if err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("failed to delete secret %v in namespacd %v: %w", secretName, hiveNamespace, err)
 	}
 
@@ -191,7 +214,8 @@ func awaitPullSecretSyncSet(hiveNamespace string, cdName string, kubeCli client.
 	}
 
 	err := kubeCli.Create(ctx, syncSet)
-	if err != nil {
+	// The SyncSet is left behind by a previous run which failed before it synced
+	if err != nil && !apierrors.IsAlreadyExists(err) {
 		return fmt.Errorf("failed to create SyncSet: %w", err)
 	}
 
@@ -425,6 +449,9 @@ func buildNewSecret(oldpullsecret, newpullsecret []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	if oldAuths.Auths == nil {
+		oldAuths.Auths = map[string]Auth{}
+	}
 	for k, v := range newAuths.Auths {
 		oldAuths.Auths[k] = v
 	}
@@ -437,7 +464,70 @@ func buildNewSecret(oldpullsecret, newpullsecret []byte) ([]byte, error) {
 	return auth, nil
 }
 
+// transferDetails are the owners and organizations involved in a transfer. They are recorded in the workflow state,
+// since the subscription no longer refers to the old owner once it has been patched.
+type transferDetails struct {
+	ExternalClusterID string `json:"externalClusterId"`
+	ClusterName       string `json:"clusterName"`
+	SubscriptionID    string `json:"subscriptionId"`
+	ConsoleURL        string `json:"consoleUrl"`
+	DisplayName       string `json:"displayName"`
+
+	OldOwnerAccountID           string `json:"oldOwnerAccountId"`
+	OldOwnerUsername            string `json:"oldOwnerUsername"`
+	OldOrganizationID           string `json:"oldOrganizationId"`
+	OldOrganizationEbsAccountID string `json:"oldOrganizationEbsAccountId"`
+
+	NewOwnerAccountID           string `json:"newOwnerAccountId"`
+	NewOwnerUsername            string `json:"newOwnerUsername"`
+	NewOrganizationID           string `json:"newOrganizationId"`
+	NewOrganizationEbsAccountID string `json:"newOrganizationEbsAccountId"`
+}
+
+func (d transferDetails) orgChanged() bool {
+	return d.OldOrganizationID != d.NewOrganizationID
+}
+
+// checkResumedWith returns an error if the transfer recorded by a previous run isn't the one requested by the flags,
+// since resuming it would silently continue the old transfer
+func (d transferDetails) checkResumedWith(externalClusterID, newOwner string) error {
+	if externalClusterID != "" && d.ExternalClusterID != "" && externalClusterID != d.ExternalClusterID {
+		return fmt.Errorf("the previous run transfers cluster %s, not %s", d.ExternalClusterID, externalClusterID)
+	}
+	if newOwner != d.NewOwnerUsername && newOwner != d.NewOwnerAccountID {
+		return fmt.Errorf("the previous run transfers the cluster to '%s', not '%s': re-run with --new-owner %s, or with --rollback to undo it first",
+			d.NewOwnerUsername, newOwner, d.NewOwnerUsername)
+	}
+	return nil
+}
+
+func (d transferDetails) serviceLogParameters(clusterID string) serviceLogParameters {
+	return serviceLogParameters{
+		ClusterID:             clusterID,
+		OldOwnerName:          d.OldOwnerUsername,
+		OldOwnerID:            d.OldOrganizationEbsAccountID,
+		NewOwnerName:          d.NewOwnerUsername,
+		NewOwnerID:            d.NewOrganizationEbsAccountID,
+		IsExternalOrgTransfer: d.orgChanged(),
+	}
+}
+
+const (
+	transferDetailsKey = "transferDetails"
+	// originalPullSecretKey records the cluster's pull secret before the transfer, to restore it on rollback
+	originalPullSecretKey = "originalPullSecret"
+
+	// pullSecretSyncPollInterval and pullSecretSyncTimeout bound waiting for the updated pull secret to reach the
+	// cluster, which takes a while through the ManifestWork of HCP clusters
+	pullSecretSyncPollInterval = 10 * time.Second
+	pullSecretSyncTimeout      = 5 * time.Minute
+)
+
 func (o *transferOwnerOptions) run() error {
+	if err := o.workflow.Validate(); err != nil {
+		return err
+	}
+
 	// Initiate Connections First
 
 	// Create an OCM client to talk to the cluster API
@@ -454,75 +544,102 @@ func (o *transferOwnerOptions) run() error {
 
 	// Gather all required data
 	cluster, err := utils.GetClusterAnyStatus(ocm, o.clusterID)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster information for cluster with ID %s: %w", o.clusterID, err)
+	}
 	o.cluster = cluster
 	o.clusterID = cluster.ID()
 
-	userDetails, err := ocm.AccountsMgmt().V1().Accounts().Account(o.newOwnerName).Get().Send()
-	userName, ok := userDetails.Body().GetUsername()
-	if !ok {
-		return fmt.Errorf("Failed to get username from new user id")
-	}
-
-	var mgmtCluster, svcCluster, hiveCluster, masterCluster *cmv1.Cluster
-
 	o.hypershift, err = utils.IsHostedCluster(o.clusterID)
 	if err != nil {
 		return fmt.Errorf("failed to check if the given cluster is HCP: %w", err)
 	}
 
-	// Find and setup all resources that are needed
-	if o.hypershift {
-		fmt.Println("Given cluster is HCP, start to proceed the HCP owner transfer")
-		mgmtCluster, err = utils.GetManagementCluster(o.clusterID)
-		svcCluster, err = utils.GetServiceCluster(o.clusterID)
-		if err != nil {
+	store, err := workflow.DefaultStore()
+	if err != nil {
+		return err
+	}
+	w, err := workflow.New(store, "transfer-owner", o.clusterID, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	details := &transferDetails{}
+	found, err := w.Value(transferDetailsKey, details)
+	if err != nil {
+		return err
+	}
+	if found {
+		if err := details.checkResumedWith(cluster.ExternalID(), o.newOwnerName); err != nil {
 			return err
 		}
-		masterCluster = svcCluster
 	} else {
-		fmt.Println("Given cluster is OSD/ROSA classic, start to proceed the classic owner transfer")
-		hiveCluster, err = utils.GetHiveCluster(o.clusterID)
-		if err != nil {
+		// Gather all required information
+		fmt.Println("Gathering all required information for the cluster transfer...")
+		if details, err = o.gatherTransferDetails(ocm); err != nil {
+			return err
+		}
+		if err := w.SetValue(transferDetailsKey, details); err != nil {
 			return err
 		}
-		masterCluster = hiveCluster
 	}
 
-	elevationReasons := []string{
-		o.reason,
-		fmt.Sprintf("Updating pull secret using osdctl to tranfert owner to %s", o.newOwnerName),
+	fmt.Printf("Transfer cluster: \t\t'%v' (%v)\n", details.ExternalClusterID, details.ClusterName)
+	fmt.Printf("from user \t\t\t'%v' to '%v'\n", details.OldOwnerAccountID, details.NewOwnerAccountID)
+	if details.orgChanged() {
+		fmt.Printf("with organization change from \t'%v' to '%v'\n", details.OldOrganizationID, details.NewOrganizationID)
+	}
+
+	if o.dryrun {
+		if err := w.Plan(); err != nil {
+			return err
+		}
+		fmt.Print("This is a dry run, nothing changed.\n")
+		return nil
 	}
 
-	// Gather all required information
-	fmt.Println("Gathering all required information for the cluster transfer...")
-	cluster, err = utils.GetCluster(ocm, o.clusterID)
+	// Rolling back the pull secret also needs the kube clients
+	if !o.workflow.Plan {
+		if err := o.initKubeClients(); err != nil {
+			return err
+		}
+	}
+
+	o.addSteps(w, ocm, *details)
+
+	return o.workflow.Execute(context.TODO(), w)
+}
+
+// gatherTransferDetails looks up the current and new owners of the cluster
+func (o *transferOwnerOptions) gatherTransferDetails(ocm *sdk.Connection) (*transferDetails, error) {
+	cluster, err := utils.GetCluster(ocm, o.clusterID)
 	if err != nil {
-		return fmt.Errorf("failed to get cluster information for cluster with ID %s: %w", o.clusterID, err)
+		return nil, fmt.Errorf("failed to get cluster information for cluster with ID %s: %w", o.clusterID, err)
 	}
 
 	externalClusterID, ok := cluster.GetExternalID()
 	if !ok {
-		return fmt.Errorf("cluster has no external id")
+		return nil, fmt.Errorf("cluster has no external id")
 	}
 
 	subscription, err := utils.GetSubscription(ocm, o.clusterID)
 	if err != nil {
-		return fmt.Errorf("could not get subscription: %w", err)
+		return nil, fmt.Errorf("could not get subscription: %w", err)
 	}
 
 	subscriptionID, ok := subscription.GetID()
 	if !ok {
-		return fmt.Errorf("Could not get subscription id")
+		return nil, fmt.Errorf("Could not get subscription id")
 	}
 
 	oldOwnerAccount, ok := subscription.GetCreator()
 	if !ok {
-		return fmt.Errorf("cluster has no owner account")
+		return nil, fmt.Errorf("cluster has no owner account")
 	}
 
 	oldOrganizationId, ok := subscription.GetOrganizationID()
 	if !ok {
-		return fmt.Errorf("old organization has no ID")
+		return nil, fmt.Errorf("old organization has no ID")
 	}
 
 	// We have to get the organization from the ID because it's not nested
@@ -530,207 +647,500 @@ func (o *transferOwnerOptions) run() error {
 	oldOrganization, err := utils.GetOrganization(ocm, subscriptionID)
 	if err != nil {
 		fmt.Printf("Error: %s", err)
-		return fmt.Errorf("could not get current owner organization")
+		return nil, fmt.Errorf("could not get current owner organization")
 	}
 
 	newAccount, err := utils.GetAccount(ocm, o.newOwnerName)
 	if err != nil {
-		return fmt.Errorf("could not get new owners account: %w", err)
+		return nil, fmt.Errorf("could not get new owners account: %w", err)
 	}
 
 	newOrganization, ok := newAccount.GetOrganization()
 	if !ok {
-		return fmt.Errorf("new account has no organization")
+		return nil, fmt.Errorf("new account has no organization")
 	}
 
 	newOrganizationId, ok := newOrganization.GetID()
 	if !ok {
-		return fmt.Errorf("new organization has no ID")
+		return nil, fmt.Errorf("new organization has no ID")
 	}
 
 	accountID, ok := newAccount.GetID()
 	if !ok {
-		return fmt.Errorf("account has no id")
+		return nil, fmt.Errorf("account has no id")
 	}
 
 	clusterConsole, ok := cluster.GetConsole()
 	if !ok {
-		return fmt.Errorf("cluster has no console url")
+		return nil, fmt.Errorf("cluster has no console url")
 	}
 
 	clusterURL, ok := clusterConsole.GetURL()
 	if !ok {
-		return fmt.Errorf("cluster has no console url")
+		return nil, fmt.Errorf("cluster has no console url")
 	}
 
 	displayName, ok := subscription.GetDisplayName()
 	if !ok {
-		return fmt.Errorf("subscription has no displayName")
+		return nil, fmt.Errorf("subscription has no displayName")
 	}
 
 	oldOwnerAccountID, ok := oldOwnerAccount.GetID()
 	if !ok {
-		return fmt.Errorf("cannot get old owner account id")
+		return nil, fmt.Errorf("cannot get old owner account id")
 	}
 
 	oldOwnerAccount, err = utils.GetAccount(ocm, oldOwnerAccountID)
 	if err != nil {
-		return fmt.Errorf("cannot get old owner account")
+		return nil, fmt.Errorf("cannot get old owner account")
 	}
 
 	oldOwnerUsername, ok := oldOwnerAccount.GetUsername()
 	if !ok {
-		return fmt.Errorf("cannot get old owner username")
+		return nil, fmt.Errorf("cannot get old owner username")
 	}
 
 	oldOrganizationEbsAccountID, ok := oldOrganization.GetEbsAccountID()
 	if !ok {
-		return fmt.Errorf("cannot get old org ebs id")
+		return nil, fmt.Errorf("cannot get old org ebs id")
 	}
 
 	newOwnerUsername, ok := newAccount.GetUsername()
 	if !ok {
-		return fmt.Errorf("cannot get new owner username")
+		return nil, fmt.Errorf("cannot get new owner username")
 	}
 
 	newOrganizationEbsAccountID, ok := newOrganization.GetEbsAccountID()
 	if !ok {
-		return fmt.Errorf("cannot get new org ebs id")
-	}
-
-	orgChanged := oldOrganizationId != newOrganizationId
+		return nil, fmt.Errorf("cannot get new org ebs id")
+	}
+
+	return &transferDetails{
+		ExternalClusterID:           externalClusterID,
+		ClusterName:                 cluster.Name(),
+		SubscriptionID:              subscriptionID,
+		ConsoleURL:                  clusterURL,
+		DisplayName:                 displayName,
+		OldOwnerAccountID:           oldOwnerAccountID,
+		OldOwnerUsername:            oldOwnerUsername,
+		OldOrganizationID:           oldOrganizationId,
+		OldOrganizationEbsAccountID: oldOrganizationEbsAccountID,
+		NewOwnerAccountID:           accountID,
+		NewOwnerUsername:            newOwnerUsername,
+		NewOrganizationID:           newOrganizationId,
+		NewOrganizationEbsAccountID: newOrganizationEbsAccountID,
+	}, nil
+}
 
-	// build common SL parameters struct
-	slParams := serviceLogParameters{
-		ClusterID:             o.clusterID,
-		OldOwnerName:          oldOwnerUsername,
-		OldOwnerID:            oldOrganizationEbsAccountID,
-		NewOwnerName:          newOwnerUsername,
-		NewOwnerID:            newOrganizationEbsAccountID,
-		IsExternalOrgTransfer: orgChanged,
-	}
+// initKubeClients creates the clients of the cluster being transferred and of the hive or service cluster managing it
+func (o *transferOwnerOptions) initKubeClients() error {
+	var masterCluster *cmv1.Cluster
+	var err error
 
-	// Send a SL saying we're about to start
-	fmt.Println("Notify the customer before ownership transfer commences. Sending service log.")
-	postCmd := generateServiceLog(slParams, SL_TRANSFER_INITIATED)
-	if err := postCmd.Run(); err != nil {
-		fmt.Println("Failed to POST customer service log. Please manually send a service log to notify the customer before ownership transfer commences:")
-		fmt.Printf("osdctl servicelog post %v -t %v -p %v\n",
-			o.clusterID, SL_TRANSFER_INITIATED, strings.Join(postCmd.TemplateParams, " -p "))
+	// Find and setup all resources that are needed
+	if o.hypershift {
+		fmt.Println("Given cluster is HCP, start to proceed the HCP owner transfer")
+		o.mgmtCluster, err = utils.GetManagementCluster(o.clusterID)
+		if err != nil {
+			return err
+		}
+		masterCluster, err = utils.GetServiceCluster(o.clusterID)
+		if err != nil {
+			return err
+		}
+	} else {
+		fmt.Println("Given cluster is OSD/ROSA classic, start to proceed the classic owner transfer")
+		masterCluster, err = utils.GetHiveCluster(o.clusterID)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Send internal SL to cluster with additional details in case we
-	// need them later. This prevents leaking PII to customers.
-	postCmd = generateInternalServiceLog(slParams)
-	fmt.Println("Internal SL Being Sent")
-	if err := postCmd.Run(); err != nil {
-		fmt.Println("Failed to POST internal service log. Please manually send a service log to persist details of the customer transfer before proceeding:")
-		fmt.Println(fmt.Sprintf("osdctl servicelog post -i -p MESSAGE=\"From user '%s' in Red Hat account %s => user '%s' in Red Hat account %s.\" %s", slParams.OldOwnerName, slParams.OldOwnerID, slParams.NewOwnerName, slParams.NewOwnerID, slParams.ClusterID))
+	elevationReasons := []string{
+		o.reason,
+		fmt.Sprintf("Updating pull secret using osdctl to tranfert owner to %s", o.newOwnerName),
 	}
 
-	masterKubeCli, _, masterKubeClientSet, err := common.GetKubeConfigAndClient(masterCluster.ID(), elevationReasons...)
+	o.masterKubeCli, _, o.masterKubeClientSet, err = common.GetKubeConfigAndClient(masterCluster.ID(), elevationReasons...)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve Kubernetes configuration and client for Hive cluster ID %s: %w", masterCluster.ID(), err)
 	}
 
-	// Fetch the pull secret with the given new username
-	response, err := ocm.AccountsMgmt().V1().AccessToken().Post().Impersonate(userName).Parameter("body", nil).Send()
+	_, _, o.targetClientSet, err = common.GetKubeConfigAndClient(o.clusterID, elevationReasons...)
 	if err != nil {
-		return fmt.Errorf("Can't send request: %w", err)
+		return fmt.Errorf("failed to retrieve Kubernetes configuration and client for cluster with ID %s: %w", o.clusterID, err)
 	}
 
-	auths, ok := response.Body().GetAuths()
-	if !ok {
-		return fmt.Errorf("Error validating pull secret structure. This shouldn't happen, so you might need to contact SDB")
-	}
-	authsMap := map[string]map[string]string{}
-	for k, auth := range auths {
-		authsMap[k] = map[string]string{
-			"auth":  auth.Auth(),
-			"email": auth.Email(),
-		}
-	}
+	return nil
+}
 
-	pullSecret, err := json.Marshal(map[string]map[string]map[string]string{
-		"auths": authsMap,
+// addSteps adds the steps of the transfer to w
+func (o *transferOwnerOptions) addSteps(w *workflow.Workflow, ocm *sdk.Connection, details transferDetails) {
+	slParams := details.serviceLogParameters(o.clusterID)
+
+	w.AddStep(workflow.Step{
+		Name:        "notify-transfer-start",
+		Description: "Send service logs notifying the customer that the transfer is starting",
+		Do: func(ctx context.Context) error {
+			// Send a SL saying we're about to start
+			fmt.Println("Notify the customer before ownership transfer commences. Sending service log.")
+			postCmd := generateServiceLog(slParams, SL_TRANSFER_INITIATED)
+			if err := postCmd.Run(); err != nil {
+				fmt.Println("Failed to POST customer service log. Please manually send a service log to notify the customer before ownership transfer commences:")
+				fmt.Printf("osdctl servicelog post %v -t %v -p %v\n",
+					o.clusterID, SL_TRANSFER_INITIATED, strings.Join(postCmd.TemplateParams, " -p "))
+			}
+
+			// Send internal SL to cluster with additional details in case we
+			// need them later. This prevents leaking PII to customers.
+			postCmd = generateInternalServiceLog(slParams)
+			fmt.Println("Internal SL Being Sent")
+			if err := postCmd.Run(); err != nil {
+				fmt.Println("Failed to POST internal service log. Please manually send a service log to persist details of the customer transfer before proceeding:")
+				fmt.Println(fmt.Sprintf("osdctl servicelog post -i -p MESSAGE=\"From user '%s' in Red Hat account %s => user '%s' in Red Hat account %s.\" %s", slParams.OldOwnerName, slParams.OldOwnerID, slParams.NewOwnerName, slParams.NewOwnerID, slParams.ClusterID))
+			}
+			return nil
+		},
 	})
-	if err != nil {
-		return fmt.Errorf("failed to marshal pull secret data: %w", err)
-	}
+	w.AddStep(workflow.Step{
+		Name:        "update-pull-secret",
+		Description: fmt.Sprintf("Update the cluster's pull secret to the one of %s", details.NewOwnerUsername),
+		Do: func(ctx context.Context) error {
+			pullSecret, err := newOwnerPullSecret(ocm, details.NewOwnerUsername)
+			if err != nil {
+				return err
+			}
 
-	// Print the pull secret
-	fmt.Println("Pull Secret:")
-	fmt.Println(string(pullSecret))
+			// Print the pull secret
+			fmt.Println("Pull Secret:")
+			fmt.Println(string(pullSecret))
 
-	// Ask the user if they would like to continue
-	var continueConfirmation string
-	fmt.Print("Do you want to continue? (yes/no): ")
-	_, err = fmt.Scanln(&continueConfirmation)
-	if err != nil {
-		return fmt.Errorf("failed to read user input: %w", err)
+			// Ask the user if they would like to continue
+			var continueConfirmation string
+			fmt.Print("Do you want to continue? (yes/no): ")
+			_, err = fmt.Scanln(&continueConfirmation)
+			if err != nil {
+				return fmt.Errorf("failed to read user input: %w", err)
+			}
+
+			// Check the user's response
+			if continueConfirmation != "yes" {
+				return fmt.Errorf("operation aborted by the user")
+			}
+
+			// A retried step must keep the pull secret recorded before it first ran, the cluster may already have
+			// the new owner's
+			var original []byte
+			recorded, err := w.Value(originalPullSecretKey, &original)
+			if err != nil {
+				return err
+			}
+			if !recorded {
+				original, err = readClusterPullSecret(o.targetClientSet)
+				if err != nil {
+					return err
+				}
+				if err := w.SetValue(originalPullSecretKey, original); err != nil {
+					return err
+				}
+			}
+
+			return o.applyPullSecret(ocm, pullSecret)
+		},
+		Verify: func(ctx context.Context) (bool, error) {
+			pullSecret, err := newOwnerPullSecret(ocm, details.NewOwnerUsername)
+			if err != nil {
+				return false, err
+			}
+			// The ManifestWork of HCP clusters takes a while to sync the pull secret to the cluster
+			mismatched, err := waitForClusterPullSecret(ctx, o.targetClientSet, pullSecret, pullSecretSyncPollInterval, pullSecretSyncTimeout)
+			if err != nil {
+				return false, err
+			}
+			if len(mismatched) > 0 {
+				fmt.Printf("The cluster pull secret still doesn't match the new owner's after %s for: %s\n", pullSecretSyncTimeout, strings.Join(mismatched, ", "))
+			}
+			return len(mismatched) == 0, nil
+		},
+		Rollback: func(ctx context.Context) error {
+			var original []byte
+			recorded, err := w.Value(originalPullSecretKey, &original)
+			if err != nil {
+				return err
+			}
+			if !recorded {
+				fmt.Println("The pull secret wasn't changed, nothing to restore")
+				return nil
+			}
+
+			if err := o.applyPullSecret(ocm, original); err != nil {
+				return fmt.Errorf("failed to restore the original pull secret: %w", err)
+			}
+			if !o.hypershift {
+				err := rolloutTelemeterClientPods(o.targetClientSet, "openshift-monitoring", "app.kubernetes.io/name=telemeter-client")
+				if err != nil {
+					return fmt.Errorf("failed to roll out Telemeter Client pods in namespace 'openshift-monitoring' with label selector 'app.kubernetes.io/name=telemeter-client': %w", err)
+				}
+			}
+			return nil
+		},
+	})
+	// Rollout the telemeterClient pod for non HCP clusters
+	if !o.hypershift {
+		w.AddStep(workflow.Step{
+			Name:        "rollout-telemeter-client",
+			Description: "Restart the telemeter client pods to pick up the new pull secret",
+			Do: func(ctx context.Context) error {
+				err := rolloutTelemeterClientPods(o.targetClientSet, "openshift-monitoring", "app.kubernetes.io/name=telemeter-client")
+				if err != nil {
+					return fmt.Errorf("failed to roll out Telemeter Client pods in namespace 'openshift-monitoring' with label selector 'app.kubernetes.io/name=telemeter-client': %w", err)
+				}
+				return nil
+			},
+		})
 	}
+	w.AddStep(workflow.Step{
+		Name:        "verify-pull-secret",
+		Description: "Verify the cluster's pull secret was updated",
+		Do: func(ctx context.Context) error {
+			pullSecret, err := newOwnerPullSecret(ocm, details.NewOwnerUsername)
+			if err != nil {
+				return err
+			}
+			if err := verifyClusterPullSecret(o.targetClientSet, string(pullSecret)); err != nil {
+				return fmt.Errorf("error verifying cluster pull secret: %w", err)
+			}
+			return nil
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "confirm-transfer",
+		Description: "Confirm the subscription is still owned by the old owner",
+		Do: func(ctx context.Context) error {
+			fmt.Printf("Transfer cluster: \t\t'%v' (%v)\n", details.ExternalClusterID, details.ClusterName)
+			fmt.Printf("from user \t\t\t'%v' to '%v'\n", details.OldOwnerAccountID, details.NewOwnerAccountID)
+			if !utils.ConfirmPrompt() {
+				return fmt.Errorf("operation aborted by the user")
+			}
 
-	// Check the user's response
-	if continueConfirmation != "yes" {
-		return fmt.Errorf("operation aborted by the user")
+			subscription, err := utils.GetSubscription(ocm, o.clusterID)
+			if err != nil {
+				return fmt.Errorf("could not get subscription: %w", err)
+			}
+			oldOwnerAccount, err := amv1.NewAccount().ID(details.OldOwnerAccountID).Build()
+			if err != nil {
+				return err
+			}
+			if !validateOldOwner(details.OldOrganizationID, subscription, oldOwnerAccount) {
+				fmt.Print("can't validate this is old owners cluster, this could be because of a previously failed run\n")
+				if !utils.ConfirmPrompt() {
+					return fmt.Errorf("operation aborted by the user")
+				}
+			}
+			return nil
+		},
+	})
+
+	// Validation done, now update everything
+
+	// org has to be patched before creator
+	if details.orgChanged() {
+		w.AddStep(workflow.Step{
+			Name:        "patch-subscription-organization",
+			Description: fmt.Sprintf("Patch the subscription's organization from %s to %s", details.OldOrganizationID, details.NewOrganizationID),
+			Do: func(ctx context.Context) error {
+				return patchSubscriptionOrganization(ocm, details.SubscriptionID, details.NewOrganizationID)
+			},
+			Verify: func(ctx context.Context) (bool, error) {
+				subscription, err := utils.GetSubscription(ocm, o.clusterID)
+				if err != nil {
+					return false, err
+				}
+				return subscription.OrganizationID() == details.NewOrganizationID, nil
+			},
+			Rollback: func(ctx context.Context) error {
+				return patchSubscriptionOrganization(ocm, details.SubscriptionID, details.OldOrganizationID)
+			},
+		})
 	}
+	w.AddStep(workflow.Step{
+		Name:        "patch-subscription-creator",
+		Description: fmt.Sprintf("Patch the subscription's creator from %s to %s", details.OldOwnerAccountID, details.NewOwnerAccountID),
+		Do: func(ctx context.Context) error {
+			return patchSubscriptionCreator(ocm, details.SubscriptionID, details.NewOwnerAccountID)
+		},
+		Verify: func(ctx context.Context) (bool, error) {
+			subscription, err := utils.GetSubscription(ocm, o.clusterID)
+			if err != nil {
+				return false, err
+			}
+			return subscription.Creator().ID() == details.NewOwnerAccountID, nil
+		},
+		Rollback: func(ctx context.Context) error {
+			return patchSubscriptionCreator(ocm, details.SubscriptionID, details.OldOwnerAccountID)
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "swap-role-binding",
+		Description: "Replace the old owner's ClusterOwner role binding with one for the new owner",
+		Do: func(ctx context.Context) error {
+			return swapClusterOwnerRoleBinding(ocm, details.SubscriptionID, details.NewOwnerAccountID)
+		},
+		Rollback: func(ctx context.Context) error {
+			return swapClusterOwnerRoleBinding(ocm, details.SubscriptionID, details.OldOwnerAccountID)
+		},
+	})
+	// If the organization id has changed, re-register the cluster with CS with the new organization id
+	if details.orgChanged() {
+		w.AddStep(workflow.Step{
+			Name:        "reregister-cluster",
+			Description: fmt.Sprintf("Re-register the cluster with organization %s", details.NewOrganizationID),
+			Do: func(ctx context.Context) error {
+				return reregisterCluster(ocm, details, details.NewOrganizationID)
+			},
+			Rollback: func(ctx context.Context) error {
+				return reregisterCluster(ocm, details, details.OldOrganizationID)
+			},
+		})
+	}
+	w.AddStep(workflow.Step{
+		Name:        "validate-transfer",
+		Description: "Validate the cluster and subscription records match",
+		Do: func(ctx context.Context) error {
+			if err := validateTransfer(ocm, o.clusterID, details.NewOrganizationID); err != nil {
+				return fmt.Errorf("error while validating transfer %w", err)
+			}
+			fmt.Print("Transfer complete\n")
+			return nil
+		},
+	})
+	w.AddStep(workflow.Step{
+		Name:        "notify-transfer-complete",
+		Description: "Send a service log notifying the customer that the transfer is complete",
+		Do: func(ctx context.Context) error {
+			fmt.Println("Notify the customer the ownership transfer is completed. Sending service log.")
+			postCmd := generateServiceLog(slParams, SL_TRANSFER_COMPLETE)
+			if err := postCmd.Run(); err != nil {
+				fmt.Println("Failed to POST service log. Please manually send a service log to notify the customer the ownership transfer is completed:")
+				fmt.Printf("osdctl servicelog post %v -t %v -p %v\n",
+					o.clusterID, SL_TRANSFER_COMPLETE, strings.Join(postCmd.TemplateParams, " -p "))
+			}
+			return nil
+		},
+	})
+}
 
+// applyPullSecret replaces the cluster's pull secret through the hive SyncSet of classic clusters, or the ManifestWork of
+// HCP clusters
+func (o *transferOwnerOptions) applyPullSecret(ocm *sdk.Connection, pullSecret []byte) error {
 	if o.hypershift {
-		err = updateManifestWork(ocm, masterKubeCli, o.clusterID, mgmtCluster.Name(), pullSecret)
-		if err != nil {
+		if err := updateManifestWork(ocm, o.masterKubeCli, o.clusterID, o.mgmtCluster.Name(), pullSecret); err != nil {
 			return fmt.Errorf("failed to update pull secret for service cluster with ID %s: %w", o.clusterID, err)
 		}
-	} else {
-		err = updatePullSecret(ocm, masterKubeCli, masterKubeClientSet, o.clusterID, pullSecret)
-		if err != nil {
-			return fmt.Errorf("failed to update pull secret for Hive cluster with ID %s: %w", o.clusterID, err)
-		}
+		return nil
 	}
-
-	_, _, targetClientSet, err := common.GetKubeConfigAndClient(o.clusterID, elevationReasons...)
-	if err != nil {
-		return fmt.Errorf("failed to retrieve Kubernetes configuration and client for cluster with ID %s: %w", o.clusterID, err)
+	if err := updatePullSecret(ocm, o.masterKubeCli, o.masterKubeClientSet, o.clusterID, pullSecret); err != nil {
+		return fmt.Errorf("failed to update pull secret for Hive cluster with ID %s: %w", o.clusterID, err)
 	}
+	return nil
+}
 
-	// Rollout the telemeterClient pod for non HCP clusters
-	if !o.hypershift {
-		err = rolloutTelemeterClientPods(targetClientSet, "openshift-monitoring", "app.kubernetes.io/name=telemeter-client")
+// readClusterPullSecret returns the dockerconfigjson of the cluster's openshift-config/pull-secret
+// waitForClusterPullSecret polls the cluster's pull secret until its auths match the expected pull secret's, returning
+// the registries still mismatched once the timeout expires
+func waitForClusterPullSecret(ctx context.Context, clientset kubernetes.Interface, expected []byte, pollInterval, timeout time.Duration) ([]string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		current, err := readClusterPullSecret(clientset)
+		if err != nil {
+			return nil, err
+		}
+		mismatched, err := mismatchedPullSecretAuths(current, expected)
 		if err != nil {
-			return fmt.Errorf("failed to roll out Telemeter Client pods in namespace 'openshift-monitoring' with label selector 'app.kubernetes.io/name=telemeter-client': %w", err)
+			return nil, err
+		}
+		if len(mismatched) == 0 || time.Now().After(deadline) {
+			return mismatched, nil
+		}
+		fmt.Printf("Waiting for the cluster pull secret to be synced: %s\n", strings.Join(mismatched, ", "))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
 		}
 	}
+}
 
-	err = verifyClusterPullSecret(targetClientSet, string(pullSecret))
+func readClusterPullSecret(clientset kubernetes.Interface) ([]byte, error) {
+	secret, err := clientset.CoreV1().Secrets("openshift-config").Get(context.TODO(), "pull-secret", metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("error verifying cluster pull secret: %w", err)
+		return nil, fmt.Errorf("failed to get pull secret: %w", err)
 	}
+	return secret.Data[".dockerconfigjson"], nil
+}
 
-	fmt.Printf("Transfer cluster: \t\t'%v' (%v)\n", externalClusterID, cluster.Name())
-	fmt.Printf("from user \t\t\t'%v' to '%v'\n", oldOwnerAccount.ID(), accountID)
-	if !utils.ConfirmPrompt() {
-		return nil
+// newOwnerPullSecret fetches the pull secret of the new owner
+func newOwnerPullSecret(ocm *sdk.Connection, username string) ([]byte, error) {
+	response, err := ocm.AccountsMgmt().V1().AccessToken().Post().Impersonate(username).Parameter("body", nil).Send()
+	if err != nil {
+		return nil, fmt.Errorf("Can't send request: %w", err)
 	}
 
-	ok = validateOldOwner(oldOrganizationId, subscription, oldOwnerAccount)
+	auths, ok := response.Body().GetAuths()
 	if !ok {
-		fmt.Print("can't validate this is old owners cluster, this could be because of a previously failed run\n")
-		if !utils.ConfirmPrompt() {
-			return nil
+		return nil, fmt.Errorf("Error validating pull secret structure. This shouldn't happen, so you might need to contact SDB")
+	}
+	authsMap := map[string]map[string]string{}
+	for k, auth := range auths {
+		authsMap[k] = map[string]string{
+			"auth":  auth.Auth(),
+			"email": auth.Email(),
 		}
 	}
 
-	subscriptionOrgPatch, err := amv1.NewSubscription().OrganizationID(newOrganizationId).Build()
+	pullSecret, err := json.Marshal(map[string]map[string]map[string]string{
+		"auths": authsMap,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pull secret data: %w", err)
+	}
+
+	return pullSecret, nil
+}
 
+func patchSubscriptionOrganization(ocm *sdk.Connection, subscriptionID, organizationID string) error {
+	subscriptionOrgPatch, err := amv1.NewSubscription().OrganizationID(organizationID).Build()
 	if err != nil {
 		return fmt.Errorf("can't create subscription organization patch: %w", err)
 	}
 
-	subscriptionCreatorPatchRequest, err := createSubscriptionCreatorPatchRequest(ocm, subscriptionID, accountID)
+	subscriptionClient := ocm.AccountsMgmt().V1().Subscriptions().Subscription(subscriptionID)
+	response, err := subscriptionClient.Update().Body(subscriptionOrgPatch).Send()
+	if err != nil || response.Status() != 200 {
+		return fmt.Errorf("request failed with status: %d, '%w'", response.Status(), err)
+	}
+	fmt.Printf("Patched organization on subscription\n")
+
+	return nil
+}
 
+func patchSubscriptionCreator(ocm *sdk.Connection, subscriptionID, accountID string) error {
+	subscriptionCreatorPatchRequest, err := createSubscriptionCreatorPatchRequest(ocm, subscriptionID, accountID)
 	if err != nil {
 		return fmt.Errorf("can't create subscription creator patch: %w", err)
 	}
 
+	patchRes, err := subscriptionCreatorPatchRequest.Send()
+	if err != nil || patchRes.Status() != 200 {
+		return fmt.Errorf("request failed with status: %d, '%w'", patchRes.Status(), err)
+	}
+	fmt.Printf("Patched creator on subscription\n")
+
+	return nil
+}
+
+// swapClusterOwnerRoleBinding replaces the subscription's ClusterOwner role binding with one for accountID
+func swapClusterOwnerRoleBinding(ocm *sdk.Connection, subscriptionID, accountID string) error {
 	newRoleBinding, err := amv1.
 		NewRoleBinding().
 		AccountID(accountID).
@@ -738,44 +1148,12 @@ func (o *transferOwnerOptions) run() error {
 		Type("Subscription").
 		RoleID("ClusterOwner").
 		Build()
-
 	if err != nil {
 		return fmt.Errorf("can't create new owners rolebinding %w", err)
 	}
 
-	if orgChanged {
-		fmt.Printf("with organization change from \t'%v' to '%v'\n", oldOrganizationId, newOrganizationId)
-	}
-
-	if o.dryrun {
-		fmt.Print("This is a dry run, nothing changed.\n")
-		return nil
-	}
-
-	// Validation done, now update everything
-
-	// org has to be patched before creator
-	if orgChanged {
-		subscriptionClient := ocm.AccountsMgmt().V1().Subscriptions().Subscription(subscriptionID)
-		response, err := subscriptionClient.Update().Body(subscriptionOrgPatch).Send()
-
-		if err != nil || response.Status() != 200 {
-			return fmt.Errorf("request failed with status: %d, '%w'", response.Status(), err)
-		}
-		fmt.Printf("Patched organization on subscription\n")
-	}
-
-	// patch creator on subscription
-	patchRes, err := subscriptionCreatorPatchRequest.Send()
-
-	if err != nil || patchRes.Status() != 200 {
-		return fmt.Errorf("request failed with status: %d, '%w'", patchRes.Status(), err)
-	}
-	fmt.Printf("Patched creator on subscription\n")
-
 	// delete old rolebinding but do not exit on fail could be a rerun
 	err = deleteOldRoleBinding(ocm, subscriptionID)
-
 	if err != nil {
 		fmt.Printf("can't delete old rolebinding %v \n", err)
 	}
@@ -795,34 +1173,21 @@ func (o *transferOwnerOptions) run() error {
 		return fmt.Errorf("request failed with status: %d, '%w'", postRes.Status(), err)
 	}
 
-	// If the organization id has changed, re-register the cluster with CS with the new organization id
-	if orgChanged {
-
-		request, err := createNewRegisterClusterRequest(ocm, externalClusterID, subscriptionID, newOrganizationId, clusterURL, displayName)
-		if err != nil {
-			return fmt.Errorf("can't create RegisterClusterRequest with CS, '%w'", err)
-		}
-
-		response, err := request.Send()
-		if err != nil || (response.Status() != 200 && response.Status() != 201) {
-			return fmt.Errorf("request failed with status: %d, '%w'", response.Status(), err)
-		}
-		fmt.Print("Re-registered cluster\n")
-	}
+	return nil
+}
 
-	err = validateTransfer(ocm, subscription.ClusterID(), newOrganizationId)
+// reregisterCluster re-registers the cluster with CS with the given organization id
+func reregisterCluster(ocm *sdk.Connection, details transferDetails, organizationID string) error {
+	request, err := createNewRegisterClusterRequest(ocm, details.ExternalClusterID, details.SubscriptionID, organizationID, details.ConsoleURL, details.DisplayName)
 	if err != nil {
-		return fmt.Errorf("error while validating transfer %w", err)
+		return fmt.Errorf("can't create RegisterClusterRequest with CS, '%w'", err)
 	}
-	fmt.Print("Transfer complete\n")
 
-	fmt.Println("Notify the customer the ownership transfer is completed. Sending service log.")
-	postCmd = generateServiceLog(slParams, SL_TRANSFER_COMPLETE)
-	if err := postCmd.Run(); err != nil {
-		fmt.Println("Failed to POST service log. Please manually send a service log to notify the customer the ownership transfer is completed:")
-		fmt.Printf("osdctl servicelog post %v -t %v -p %v\n",
-			o.clusterID, SL_TRANSFER_COMPLETE, strings.Join(postCmd.TemplateParams, " -p "))
+	response, err := request.Send()
+	if err != nil || (response.Status() != 200 && response.Status() != 201) {
+		return fmt.Errorf("request failed with status: %d, '%w'", response.Status(), err)
 	}
+	fmt.Print("Re-registered cluster\n")
 
 	return nil
 }
@@ -1,10 +1,17 @@
 package cluster
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
 )
 
 func TestValidateOldOwner(t *testing.T) {
@@ -55,3 +62,72 @@ func TestValidateOldOwner(t *testing.T) {
 		})
 	}
 }
+
+func TestTransferDetailsServiceLogParameters(t *testing.T) {
+	g := NewGomegaWithT(t)
+	details := transferDetails{
+		OldOwnerUsername:            "old-user",
+		OldOrganizationID:           "old-org",
+		OldOrganizationEbsAccountID: "111",
+		NewOwnerUsername:            "new-user",
+		NewOrganizationID:           "new-org",
+		NewOrganizationEbsAccountID: "222",
+	}
+
+	g.Expect(details.serviceLogParameters("cluster-id")).To(Equal(serviceLogParameters{
+		ClusterID:             "cluster-id",
+		OldOwnerName:          "old-user",
+		OldOwnerID:            "111",
+		NewOwnerName:          "new-user",
+		NewOwnerID:            "222",
+		IsExternalOrgTransfer: true,
+	}))
+
+	details.NewOrganizationID = details.OldOrganizationID
+	g.Expect(details.orgChanged()).To(BeFalse())
+}
+
+func TestTransferDetailsCheckResumedWith(t *testing.T) {
+	g := NewGomegaWithT(t)
+	details := transferDetails{
+		ExternalClusterID: "external-id",
+		NewOwnerAccountID: "new-account-id",
+		NewOwnerUsername:  "new-user",
+	}
+
+	g.Expect(details.checkResumedWith("external-id", "new-user")).To(Succeed())
+	g.Expect(details.checkResumedWith("external-id", "new-account-id")).To(Succeed())
+	g.Expect(details.checkResumedWith("external-id", "other-user")).To(MatchError(ContainSubstring("transfers the cluster to 'new-user', not 'other-user'")))
+	g.Expect(details.checkResumedWith("other-external-id", "new-user")).To(MatchError(ContainSubstring("transfers cluster external-id, not other-external-id")))
+}
+
+func TestWaitForClusterPullSecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+	oldSecret := []byte(`{"auths":{"cloud.openshift.com":{"auth":"old","email":"old@example.com"}}}`)
+	newSecret := []byte(`{"auths":{"cloud.openshift.com":{"auth":"new","email":"new@example.com"}}}`)
+
+	// The ManifestWork syncs the new pull secret on the third read
+	reads := 0
+	clientset := kfake.NewSimpleClientset()
+	clientset.PrependReactor("get", "secrets", func(action ktesting.Action) (bool, runtime.Object, error) {
+		reads++
+		data := oldSecret
+		if reads >= 3 {
+			data = newSecret
+		}
+		return true, &corev1.Secret{Data: map[string][]byte{".dockerconfigjson": data}}, nil
+	})
+
+	mismatched, err := waitForClusterPullSecret(context.TODO(), clientset, newSecret, time.Millisecond, time.Minute)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mismatched).To(BeEmpty())
+	g.Expect(reads).To(Equal(3))
+
+	// The mismatched registries are returned once the timeout expires
+	mismatched, err = waitForClusterPullSecret(context.TODO(), kfake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: "openshift-config"},
+		Data:       map[string][]byte{".dockerconfigjson": oldSecret},
+	}), newSecret, time.Millisecond, 10*time.Millisecond)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mismatched).To(ConsistOf("cloud.openshift.com"))
+}
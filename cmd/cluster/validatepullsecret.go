@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	sdk "github.com/openshift-online/ocm-sdk-go"
 	v1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
 	"github.com/openshift/osdctl/cmd/servicelog"
 	"github.com/openshift/osdctl/pkg/k8s"
@@ -21,6 +22,7 @@ type validatePullSecretOptions struct {
 	clusterID string
 	elevate   bool
 	reason    string
+	fix       bool
 }
 
 func newCmdValidatePullSecret() *cobra.Command {
@@ -31,6 +33,10 @@ func newCmdValidatePullSecret() *cobra.Command {
 		Long: `Checks if the pull secret email matches the owner email.
 
 This command will automatically login to the cluster to check the current pull-secret defined in 'openshift-config/pull-secret'
+
+With --fix, a pull secret which doesn't match or is broken is rebuilt from the owner's OCM access token instead of
+sending a service log. The redacted difference is shown for confirmation, then the pull secret is applied through hive
+(or the service cluster for HCP clusters) and verified on the cluster.
 `,
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
@@ -45,6 +51,7 @@ This command will automatically login to the cluster to check the current pull-s
 		fmt.Printf("Error marking cluster-id flag as required: %v\n", err)
 	}
 
+	validatePullSecretCmd.Flags().BoolVar(&ops.fix, "fix", false, "Rebuild the pull secret from OCM and apply it when it doesn't match, instead of sending a service log")
 	validatePullSecretCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for this command to be run (usually an OHSS or PD ticket), mandatory when using elevate")
 	_ = validatePullSecretCmd.MarkFlagRequired("reason")
 	return validatePullSecretCmd
@@ -55,8 +62,18 @@ func newValidatePullSecretOptions() *validatePullSecretOptions {
 }
 
 func (o *validatePullSecretOptions) run() error {
+	ocm, err := utils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if ocmCloseErr := ocm.Close(); ocmCloseErr != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Cannot close the ocm (possible memory leak): %q", ocmCloseErr)
+		}
+	}()
+
 	// get the pull secret in OCM
-	emailOCM, err, done := o.getPullSecretFromOCM()
+	emailOCM, err, done := o.getPullSecretFromOCM(ocm)
 	if err != nil {
 		return err
 	}
@@ -65,7 +82,13 @@ func (o *validatePullSecretOptions) run() error {
 	}
 
 	// get the pull secret in cluster
-	emailCluster, err, done := getPullSecretElevated(o.clusterID, o.reason)
+	emailCluster, err, done := getPullSecretElevated(o.clusterID, o.reason, !o.fix)
+	if done && o.fix {
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to read the cluster pull secret: %v\n", err)
+		}
+		return o.repair(ocm)
+	}
 	if err != nil {
 		return err
 	}
@@ -73,6 +96,10 @@ func (o *validatePullSecretOptions) run() error {
 		return nil
 	}
 
+	if emailOCM != emailCluster && o.fix {
+		_, _ = fmt.Fprintln(os.Stderr, "Pull secret email doesn't match OCM user email. Repairing the pull secret.")
+		return o.repair(ocm)
+	}
 	if emailOCM != emailCluster {
 		_, _ = fmt.Fprintln(os.Stderr, "Pull secret email doesn't match OCM user email. Sending service log.")
 		postCmd := servicelog.PostCmdOptions{
@@ -86,8 +113,18 @@ func (o *validatePullSecretOptions) run() error {
 	return nil
 }
 
+// repair rebuilds the cluster's pull secret from OCM
+func (o *validatePullSecretOptions) repair(ocm *sdk.Connection) error {
+	repairer, err := newPullSecretRepairer(ocm, o.clusterID, o.reason)
+	if err != nil {
+		return err
+	}
+	return repairer.repair()
+}
+
 // getPullSecretElevated gets the pull-secret in the cluster with backplane elevation.
-func getPullSecretElevated(clusterID string, reason string) (email string, err error, sentSL bool) {
+// A service log is sent when the pull-secret is broken if sendServiceLog is set.
+func getPullSecretElevated(clusterID string, reason string, sendServiceLog bool) (email string, err error, sentSL bool) {
 	kubeClient, err := k8s.NewAsBackplaneClusterAdmin(clusterID, client.Options{}, reason)
 	if err != nil {
 		return "", fmt.Errorf("failed to login to cluster as 'backplane-cluster-admin': %w", err), false
@@ -98,7 +135,7 @@ func getPullSecretElevated(clusterID string, reason string) (email string, err e
 		return "", err, false
 	}
 
-	clusterPullSecretEmail, err, done := getPullSecretEmail(clusterID, secret, true)
+	clusterPullSecretEmail, err, done := getPullSecretEmail(clusterID, secret, sendServiceLog)
 	if done {
 		return "", err, true
 	}
@@ -110,18 +147,8 @@ func getPullSecretElevated(clusterID string, reason string) (email string, err e
 // getPullSecretFromOCM gets the cluster owner email from OCM
 // it returns the email, error and done
 // done means a service log has been sent
-func (o *validatePullSecretOptions) getPullSecretFromOCM() (string, error, bool) {
+func (o *validatePullSecretOptions) getPullSecretFromOCM(ocm *sdk.Connection) (string, error, bool) {
 	fmt.Println("Getting email from OCM")
-	ocm, err := utils.CreateConnection()
-	if err != nil {
-		return "", err, false
-	}
-	defer func() {
-		if ocmCloseErr := ocm.Close(); ocmCloseErr != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Cannot close the ocm (possible memory leak): %q", ocmCloseErr)
-		}
-	}()
-
 	subscription, err := utils.GetSubscription(ocm, o.clusterID)
 	if err != nil {
 		return "", err, false
@@ -138,6 +165,9 @@ func (o *validatePullSecretOptions) getPullSecretFromOCM() (string, error, bool)
 		return "", err, false
 	}
 	if len(registryCredentials) == 0 {
+		if o.fix {
+			return "", fmt.Errorf("there is no pull secret in OCM to repair the cluster's pull secret from"), false
+		}
 		_, _ = fmt.Fprintln(os.Stderr, "There is no pull secret in OCM. Sending service log.")
 		postCmd := servicelog.PostCmdOptions{
 			Template:       "https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/update_pull_secret.json",
@@ -159,8 +189,9 @@ func getPullSecretEmail(clusterID string, secret *corev1.Secret, sendServiceLog
 	dockerConfigJsonBytes, found := secret.Data[".dockerconfigjson"]
 	if !found {
 		// Indicates issue w/ pull-secret, so we can stop evaluating and specify a more direct course of action
-		_, _ = fmt.Fprintln(os.Stderr, "Secret does not contain expected key '.dockerconfigjson'. Sending service log.")
+		_, _ = fmt.Fprintln(os.Stderr, "Secret does not contain expected key '.dockerconfigjson'")
 		if sendServiceLog {
+			fmt.Println("Sending service log")
 			postCmd := servicelog.PostCmdOptions{
 				Template:  "https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/pull_secret_change_breaking_upgradesync.json",
 				ClusterId: clusterID,
@@ -51,6 +51,7 @@ type validatePullSecretExtOptions struct {
 	verboseLevel   string            // Logging level
 	useAccessToken bool              // Flag to use OCM access token values for validations
 	useRegCreds    bool              // Flag to use OCM registry credentials values for validations
+	fix            bool              // Flag to rebuild the pull-secret from OCM when validations fail
 }
 
 const VPSExample string = `
@@ -59,6 +60,9 @@ const VPSExample string = `
 
 	# Exclude Access-Token, and Registry-Credential checks...
 	osdctl cluster validate-pull-secret-ext ${CLUSTER_ID} --reason "OSD-XYZ" --skip-access-token --skip-registry-creds
+
+	# Rebuild the cluster's pull secret from OCM when validations fail...
+	osdctl cluster validate-pull-secret-ext ${CLUSTER_ID} --reason "OSD-XYZ" --fix
 `
 
 func newCmdValidatePullSecretExt() *cobra.Command {
@@ -71,6 +75,10 @@ func newCmdValidatePullSecretExt() *cobra.Command {
 	registry_credential, and access token data stored in OCM.  
 	If this is being executed against a cluster which is not owned by the current OCM account, 
 	Region Lead permissions are required to view and validate the OCM AccessToken. 
+
+	With --fix, a pull-secret failing validations is rebuilt from the owner's OCM AccessToken instead of
+	prompting to send service logs. The redacted difference is shown for confirmation, then the pull-secret
+	is applied through hive (or the service cluster for HCP clusters) and verified on the cluster.
 `,
 		Example:           VPSExample,
 		Args:              cobra.ExactArgs(1),
@@ -84,6 +92,7 @@ func newCmdValidatePullSecretExt() *cobra.Command {
 	validatePullSecretCmd.Flags().StringVarP(&ops.verboseLevel, "log-level", "l", "info", "debug, info, warn, error. (default=info)")
 	validatePullSecretCmd.Flags().Bool("skip-registry-creds", false, "Exclude OCM Registry Credentials checks against cluster secret")
 	validatePullSecretCmd.Flags().Bool("skip-access-token", false, "Exclude OCM AccessToken checks against cluster secret")
+	validatePullSecretCmd.Flags().BoolVar(&ops.fix, "fix", false, "Rebuild the pull-secret from OCM and apply it when validations fail, instead of prompting to send service logs")
 
 	_ = validatePullSecretCmd.MarkFlagRequired("reason")
 	return validatePullSecretCmd
@@ -198,9 +207,13 @@ func (o *validatePullSecretExtOptions) run() error {
 	if err != nil {
 		return err
 	}
+	// Whether any validation failed, for --fix to repair the pull-secret afterwards
+	failed := false
+
 	// This validation prompts user to send or not send a service log...
 	err = o.validateAuthEmail(pullSecret, emailOCM, cloudAuthKey)
 	if err != nil {
+		failed = true
 		fmt.Printf("Error validating pull-secret auth['%s] email.\nErr:'%s'\nWould you like to continue with validations? ", cloudAuthKey, err)
 		if !utils.ConfirmPrompt() {
 			return err
@@ -225,6 +238,7 @@ func (o *validatePullSecretExtOptions) run() error {
 		// Iterate over registry credentials and compare against cluster's pull secret
 		err = o.checkRegistryCredsAgainstPullSecret(regCreds, pullSecret, emailOCM)
 		if err != nil {
+			failed = true
 			fmt.Printf("\nError validating registry credentials:%s'.\nWould you like to continue with validations? ", err)
 			if !utils.ConfirmPrompt() {
 				return err
@@ -258,12 +272,25 @@ func (o *validatePullSecretExtOptions) run() error {
 		// Iterate over access token auths and compare against cluster's pull secret
 		err = o.checkAccessTokenToPullSecret(accessToken, pullSecret)
 		if err != nil {
+			failed = true
 			fmt.Printf("\nError validating AccessToken:%s'.\nWould you like to continue with validations? ", err)
 			if !utils.ConfirmPrompt() {
 				return err
 			}
 		}
 	}
+
+	if o.fix && failed {
+		// Print the results which led to the repair first
+		fmt.Printf("\n\n")
+		o.results.Flush()
+		fmt.Println()
+		repairer, err := newPullSecretRepairer(o.ocm, o.clusterID, o.reason)
+		if err != nil {
+			return err
+		}
+		return repairer.repair()
+	}
 	return nil
 }
 
@@ -294,13 +321,15 @@ func (o *validatePullSecretExtOptions) validateAuthEmail(pullSecret *corev1.Secr
 			o.log.Errorf("Couldn't extract email address from pull secret for: '%s'"+
 				"This can mean the pull secret is misconfigured. Please verify the pull secret manually:\n"+
 				"	oc get secret -n openshift-config pull-secret -o json | jq -r '.data[\".dockerconfigjson\"]' | base64 -d", errAENF.auth)
-			sendPullSecretServiceLog(o.clusterID, err)
+			if !o.fix {
+				sendPullSecretServiceLog(o.clusterID, err)
+			}
 		}
-		if errors.Is(err, ErrSecretMissingDockerConfigJson) {
+		if errors.Is(err, ErrSecretMissingDockerConfigJson) && !o.fix {
 			sendPullSecretServiceLog(o.clusterID, err)
 		}
 		var errSANF *ErrorSecretAuthNotFound
-		if errors.As(err, &errSANF) {
+		if errors.As(err, &errSANF) && !o.fix {
 			sendPullSecretServiceLog(o.clusterID, err)
 		}
 		//Todo: Should this prompt for a service log for other errors too (such as fail to unmarshall)?
@@ -313,7 +342,9 @@ func (o *validatePullSecretExtOptions) validateAuthEmail(pullSecret *corev1.Secr
 		o.addResult("account.Email", authKey, pullSecret.Namespace, pullSecret.Name, "email", Fail)
 		err = fmt.Errorf("pull-secret auth:'%s', email:'%s' doesn't match user email from OCM:'%s'", cloudAuthKey, emailCluster, emailOCM)
 		o.log.Errorf("%s\n", err)
-		sendPullSecretMismatchServiceLog(o.clusterID, err)
+		if !o.fix {
+			sendPullSecretMismatchServiceLog(o.clusterID, err)
+		}
 		return err
 	}
 	o.addResult("account.Email", authKey, pullSecret.Namespace, pullSecret.Name, "email", Pass)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 
+	sdk "github.com/openshift-online/ocm-sdk-go"
 	bplogin "github.com/openshift/backplane-cli/cmd/ocm-backplane/login"
 	bpconfig "github.com/openshift/backplane-cli/pkg/cli/config"
 	"github.com/openshift/osdctl/pkg/utils"
@@ -43,6 +44,13 @@ func GetKubeConfigAndClient(clusterID string, elevationReasons ...string) (clien
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to create ocm client: %w", err)
 	}
+	defer ocmClient.Close()
+	return GetKubeConfigAndClientWithConn(ocmClient, clusterID, elevationReasons...)
+}
+
+// GetKubeConfigAndClientWithConn is GetKubeConfigAndClient using an existing OCM connection, for the commands which
+// log in to many clusters
+func GetKubeConfigAndClientWithConn(ocmClient *sdk.Connection, clusterID string, elevationReasons ...string) (client.Client, *rest.Config, *kubernetes.Clientset, error) {
 	cluster, err := utils.GetCluster(ocmClient, clusterID)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to retrieve cluster: %w", err)
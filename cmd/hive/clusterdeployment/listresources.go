@@ -2,7 +2,10 @@ package clusterdeployment
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	gcpv1alpha1 "github.com/openshift/gcp-project-operator/api/v1alpha1"
@@ -22,8 +25,25 @@ import (
 func newCmdListResources(streams genericclioptions.IOStreams, client client.Client) *cobra.Command {
 	l := newListResources(streams, client)
 	lrCmd := &cobra.Command{
-		Use:               "listresources",
-		Short:             "List all resources on a hive cluster related to a given cluster",
+		Use:   "listresources",
+		Short: "List all resources on a hive cluster related to a given cluster",
+		Long: `List all resources on a hive cluster related to a given cluster
+
+  The resources are found by following the links from the ClusterDeployment: AccountClaim, Account and IAM Secret on
+  AWS, ProjectClaim and ProjectReference on GCP. A link to a missing resource, or which doesn't resolve both ways, is
+  reported as a warning.
+
+  With --orphans, the resources left behind by deleted clusters are listed instead, as a report of the resources which
+  can be reclaimed: AccountClaims and ProjectClaims without a ClusterDeployment, Accounts still claimed by deleted
+  clusters and ProjectReferences without a ProjectClaim.
+`,
+		Example: `
+  # List the resources of a cluster
+  osdctl hive cd listresources -C ${CLUSTER_ID}
+
+  # List the orphaned resources of the hive shard, created more than a day ago
+  osdctl hive cd listresources --orphans --min-age 24h
+`,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(l.complete(cmd, args))
@@ -32,6 +52,8 @@ func newCmdListResources(streams genericclioptions.IOStreams, client client.Clie
 	}
 	lrCmd.Flags().StringVarP(&l.ClusterId, "cluster-id", "C", "", "Cluster ID")
 	lrCmd.Flags().BoolVarP(&l.ExternalResourcesOnly, "external", "e", false, "only list external resources (i.e. exclude resources in cluster namespace)")
+	lrCmd.Flags().BoolVar(&l.Orphans, "orphans", false, "List the orphaned resources of the hive shard instead of the resources of a cluster")
+	lrCmd.Flags().DurationVar(&l.MinAge, "min-age", time.Hour, "With --orphans, only list the resources older than this, to leave out clusters being installed")
 
 	return lrCmd
 }
@@ -44,6 +66,8 @@ type ListResources struct {
 	ClusterId             string
 	P                     Printer
 	ExternalResourcesOnly bool
+	Orphans               bool
+	MinAge                time.Duration
 	KubeCli               client.Client
 	Cmd                   *cobra.Command
 }
@@ -66,63 +90,126 @@ func newListResources(streams genericclioptions.IOStreams, client client.Client)
 func (l *ListResources) complete(cmd *cobra.Command, _ []string) error {
 	var err error
 
+	l.Cmd = cmd
+
+	if l.Orphans {
+		if l.ClusterId != "" {
+			return cmdutil.UsageErrorf(cmd, "--orphans can't be used with --cluster-id")
+		}
+		return nil
+	}
+
 	err = l.getClusterDeployment()
 	if err != nil {
 		return err
 	}
 
-	l.Cmd = cmd
-
 	return nil
 }
 
 func (l *ListResources) RunListResources() error {
+	if l.Orphans {
+		return l.RunListOrphans()
+	}
 	if l.ClusterId == "" {
 		return cmdutil.UsageErrorf(l.Cmd, "No cluster ID specified, use -C to set one")
 	}
 	l.P.AddRow([]string{"Group", "Version", "Kind", "Namespace", "Name"})
 	l.PrintRow(l.ClusterDeployment.ObjectMeta, l.ClusterDeployment.TypeMeta)
 
+	// A missing resource is reported as a broken link along with the resources found before it
 	if l.ClusterDeployment.Spec.Platform.AWS != nil {
-		accountClaim, err := l.getAccountClaim(l.ClusterDeployment)
-		if err != nil {
-			return err
-		}
-		l.PrintRow(accountClaim.ObjectMeta, accountClaim.TypeMeta)
-		account, err := l.getAccount(accountClaim)
-		if err != nil {
+		if err := l.warnBrokenLink(l.listAwsResources()); err != nil {
 			return err
 		}
-		l.PrintRow(account.ObjectMeta, account.TypeMeta)
-		iamSecret, err := l.getSecret(account)
-		if err != nil {
-			return err
-		}
-		l.PrintRow(iamSecret.ObjectMeta, iamSecret.TypeMeta)
 	}
 
 	if l.ClusterDeployment.Spec.Platform.GCP != nil {
-		projectClaim, err := l.getProjectClaim(l.ClusterDeployment)
-		if err != nil {
+		if err := l.warnBrokenLink(l.listGcpResources()); err != nil {
 			return err
 		}
-		l.PrintRow(projectClaim.ObjectMeta, projectClaim.TypeMeta)
-		projectReference, err := l.getProjectReference(projectClaim)
-		if err != nil {
-			return err
-		}
-		l.PrintRow(projectReference.ObjectMeta, projectReference.TypeMeta)
 	}
 
 	return l.P.Flush()
 }
 
+// listAwsResources lists the AccountClaim, Account and IAM Secret linked to the ClusterDeployment
+func (l *ListResources) listAwsResources() error {
+	accountClaim, err := l.getAccountClaim(l.ClusterDeployment)
+	if err != nil {
+		return err
+	}
+	l.PrintRow(accountClaim.ObjectMeta, accountClaim.TypeMeta)
+	account, err := l.getAccount(accountClaim)
+	if err != nil {
+		return err
+	}
+	l.PrintRow(account.ObjectMeta, account.TypeMeta)
+	iamSecret, err := l.getSecret(account)
+	if err != nil {
+		return err
+	}
+	l.PrintRow(iamSecret.ObjectMeta, iamSecret.TypeMeta)
+	if account.Spec.ClaimLink != "" && (account.Spec.ClaimLink != accountClaim.Name || (account.Spec.ClaimLinkNamespace != "" && account.Spec.ClaimLinkNamespace != accountClaim.Namespace)) {
+		l.PrintWarning("AccountClaim %s/%s links to Account %s, which is claimed by '%s/%s'", accountClaim.Namespace, accountClaim.Name, account.Name, account.Spec.ClaimLinkNamespace, account.Spec.ClaimLink)
+	}
+
+	return nil
+}
+
+// listGcpResources lists the ProjectClaim and ProjectReference linked to the ClusterDeployment
+func (l *ListResources) listGcpResources() error {
+	projectClaim, err := l.getProjectClaim(l.ClusterDeployment)
+	if err != nil {
+		return err
+	}
+	l.PrintRow(projectClaim.ObjectMeta, projectClaim.TypeMeta)
+	projectReference, err := l.getProjectReference(projectClaim)
+	if err != nil {
+		return err
+	}
+	l.PrintRow(projectReference.ObjectMeta, projectReference.TypeMeta)
+	if claimLink := projectReference.Spec.ProjectClaimCRLink; claimLink.Name != "" && (claimLink.Name != projectClaim.Name || claimLink.Namespace != projectClaim.Namespace) {
+		l.PrintWarning("ProjectClaim %s/%s links to ProjectReference %s/%s, which links to ProjectClaim '%s/%s'", projectClaim.Namespace, projectClaim.Name, projectReference.Namespace, projectReference.Name, claimLink.Namespace, claimLink.Name)
+	}
+
+	return nil
+}
+
+// brokenLinkError is returned when a resource linked to from another one doesn't exist
+type brokenLinkError struct {
+	msg string
+}
+
+func (e *brokenLinkError) Error() string {
+	return e.msg
+}
+
+func newBrokenLinkError(format string, a ...any) error {
+	return &brokenLinkError{msg: fmt.Sprintf(format, a...)}
+}
+
+// warnBrokenLink reports a broken link as a warning row, returning any other error
+func (l *ListResources) warnBrokenLink(err error) error {
+	var brokenLink *brokenLinkError
+	if errors.As(err, &brokenLink) {
+		l.PrintWarning("%s", brokenLink.msg)
+		return nil
+	}
+	return err
+}
+
 func (l *ListResources) PrintRow(m v1.ObjectMeta, t v1.TypeMeta) {
 	if m.Namespace != l.ClusterDeployment.Namespace || !l.ExternalResourcesOnly {
 		l.P.AddRow(createRow(m, t))
 	}
 }
 
+// PrintWarning reports a link between the resources which is missing or doesn't resolve both ways
+func (l *ListResources) PrintWarning(format string, a ...any) {
+	l.P.AddRow([]string{"WARNING", "", "", "", "broken link: " + fmt.Sprintf(format, a...)})
+}
+
 func (l *ListResources) getClusterDeployment() error {
 	var cds hivev1.ClusterDeploymentList
 	if err := l.KubeCli.List(context.TODO(), &cds, &client.ListOptions{}); err != nil {
@@ -163,10 +250,10 @@ func (l *ListResources) getAccountClaim(cd hivev1.ClusterDeployment) (awsv1alpha
 	}
 
 	if len(accountClaims.Items) < 1 {
-		return awsv1alpha1.AccountClaim{}, cmdutil.UsageErrorf(l.Cmd, "AccountClaim not found")
+		return awsv1alpha1.AccountClaim{}, newBrokenLinkError("no AccountClaim found in namespace %s", cd.Namespace)
 	}
 	if len(accountClaims.Items) > 1 {
-		return awsv1alpha1.AccountClaim{}, cmdutil.UsageErrorf(l.Cmd, "more than 1 AccountClaim found")
+		return awsv1alpha1.AccountClaim{}, newBrokenLinkError("more than 1 AccountClaim found in namespace %s", cd.Namespace)
 	}
 	return accountClaims.Items[0], nil
 }
@@ -182,7 +269,7 @@ func (l *ListResources) getAccount(claim awsv1alpha1.AccountClaim) (awsv1alpha1.
 			return acc, nil
 		}
 	}
-	return awsv1alpha1.Account{}, cmdutil.UsageErrorf(l.Cmd, "Account not found")
+	return awsv1alpha1.Account{}, newBrokenLinkError("AccountClaim %s/%s links to Account '%s', which doesn't exist", claim.Namespace, claim.Name, claim.Spec.AccountLink)
 }
 
 func (o *ListResources) getSecret(account awsv1alpha1.Account) (corev1.Secret, error) {
@@ -208,10 +295,10 @@ func (l *ListResources) getProjectClaim(cd hivev1.ClusterDeployment) (gcpv1alpha
 	}
 
 	if len(projectClaims.Items) < 1 {
-		return gcpv1alpha1.ProjectClaim{}, cmdutil.UsageErrorf(l.Cmd, "ProjectClaim not found")
+		return gcpv1alpha1.ProjectClaim{}, newBrokenLinkError("no ProjectClaim found in namespace %s", cd.Namespace)
 	}
 	if len(projectClaims.Items) > 1 {
-		return gcpv1alpha1.ProjectClaim{}, cmdutil.UsageErrorf(l.Cmd, "more than 1 ProjectClaim found")
+		return gcpv1alpha1.ProjectClaim{}, newBrokenLinkError("more than 1 ProjectClaim found in namespace %s", cd.Namespace)
 	}
 
 	return projectClaims.Items[0], nil
@@ -228,5 +315,5 @@ func (l *ListResources) getProjectReference(claim gcpv1alpha1.ProjectClaim) (gcp
 			return projectReference, nil
 		}
 	}
-	return gcpv1alpha1.ProjectReference{}, cmdutil.UsageErrorf(l.Cmd, "ProjectReference not found")
+	return gcpv1alpha1.ProjectReference{}, newBrokenLinkError("ProjectClaim %s/%s links to ProjectReference '%s/%s', which doesn't exist", claim.Namespace, claim.Name, claim.Spec.ProjectReferenceCRLink.Namespace, claim.Spec.ProjectReferenceCRLink.Name)
 }
@@ -148,3 +148,48 @@ func TestListResourcesAws(t *testing.T) {
 	g.Expect(err).NotTo(HaveOccurred())
 	r.finish()
 }
+
+func TestListResourcesAwsBrokenLink(t *testing.T) {
+
+	r := setupResources(t)
+	g := r.g
+	r.accounts.Items[0].Spec.ClaimLink = "other-account-claim"
+	r.accounts.Items[0].Spec.ClaimLinkNamespace = "other-cluster-namespace"
+
+	gomock.InOrder(
+		r.mockPrinter.EXPECT().AddRow(gomock.Any()), //title row
+		r.mockPrinter.EXPECT().AddRow([]string{"hive.openshift.io", "v1", "ClusterDeployment", r.cd.Namespace, r.cd.Name}),
+		r.mockClient.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(1, r.accountClaims),
+		r.mockPrinter.EXPECT().AddRow([]string{"aws.managed.openshift.io", "v1alpha1", "AccountClaim", r.cd.Namespace, "fake-account-claim"}),
+		r.mockClient.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(1, r.accounts),
+		r.mockPrinter.EXPECT().AddRow([]string{"aws.managed.openshift.io", "v1alpha1", "Account", "aws-account-operator", "fake-account"}),
+		r.mockPrinter.EXPECT().AddRow([]string{`""`, "v1", "Secret", "aws-account-operator", "fake-secret"}),
+		r.mockPrinter.EXPECT().AddRow([]string{"WARNING", "", "", "", "broken link: AccountClaim fake-cluster-namespace/fake-account-claim links to Account fake-account, which is claimed by 'other-cluster-namespace/other-account-claim'"}),
+		r.mockPrinter.EXPECT().Flush(),
+	)
+	err := r.l.RunListResources()
+
+	g.Expect(err).NotTo(HaveOccurred())
+	r.finish()
+}
+
+func TestListResourcesAwsMissingAccount(t *testing.T) {
+
+	r := setupResources(t)
+	g := r.g
+	r.accounts.Items[0].Name = "other-account"
+
+	gomock.InOrder(
+		r.mockPrinter.EXPECT().AddRow(gomock.Any()), //title row
+		r.mockPrinter.EXPECT().AddRow([]string{"hive.openshift.io", "v1", "ClusterDeployment", r.cd.Namespace, r.cd.Name}),
+		r.mockClient.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(1, r.accountClaims),
+		r.mockPrinter.EXPECT().AddRow([]string{"aws.managed.openshift.io", "v1alpha1", "AccountClaim", r.cd.Namespace, "fake-account-claim"}),
+		r.mockClient.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(1, r.accounts),
+		r.mockPrinter.EXPECT().AddRow([]string{"WARNING", "", "", "", "broken link: AccountClaim fake-cluster-namespace/fake-account-claim links to Account 'fake-account', which doesn't exist"}),
+		r.mockPrinter.EXPECT().Flush(),
+	)
+	err := r.l.RunListResources()
+
+	g.Expect(err).NotTo(HaveOccurred())
+	r.finish()
+}
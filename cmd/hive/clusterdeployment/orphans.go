@@ -0,0 +1,187 @@
+package clusterdeployment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
+	gcpv1alpha1 "github.com/openshift/gcp-project-operator/api/v1alpha1"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// orphanedResource is a resource left behind by a deleted cluster
+type orphanedResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Reason    string
+	// Reclaimable is the cloud resource which can be reclaimed, i.e. the AWS account or GCP project
+	Reclaimable string
+	Created     time.Time
+}
+
+// RunListOrphans lists the resources of the hive shard which don't belong to any ClusterDeployment anymore
+func (l *ListResources) RunListOrphans() error {
+	orphans, err := l.findOrphans(context.TODO(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	l.P.AddRow([]string{"Kind", "Namespace", "Name", "Reclaimable", "Age", "Reason"})
+	for _, orphan := range orphans {
+		l.P.AddRow([]string{
+			orphan.Kind,
+			orphan.Namespace,
+			orphan.Name,
+			orphan.Reclaimable,
+			duration.HumanDuration(time.Since(orphan.Created)),
+			orphan.Reason,
+		})
+	}
+	if err := l.P.Flush(); err != nil {
+		return err
+	}
+
+	reclaimable := 0
+	for _, orphan := range orphans {
+		if orphan.Reclaimable != "" {
+			reclaimable++
+		}
+	}
+	fmt.Fprintf(l.IOStreams.Out, "\n%d orphaned resources, %d cloud accounts or projects to reclaim\n", len(orphans), reclaimable)
+	return nil
+}
+
+// findOrphans returns the AccountClaims and ProjectClaims without a ClusterDeployment, the Accounts claimed by
+// deleted clusters and the ProjectReferences without a ProjectClaim. Resources younger than MinAge, or being deleted,
+// are left out.
+func (l *ListResources) findOrphans(ctx context.Context, now time.Time) ([]orphanedResource, error) {
+	var cds hivev1.ClusterDeploymentList
+	if err := l.KubeCli.List(ctx, &cds, &client.ListOptions{}); err != nil {
+		return nil, err
+	}
+	clusterNamespaces := map[string]bool{}
+	for _, cd := range cds.Items {
+		clusterNamespaces[cd.Namespace] = true
+	}
+
+	isCandidate := func(m v1.ObjectMeta) bool {
+		return m.DeletionTimestamp == nil && now.Sub(m.CreationTimestamp.Time) >= l.MinAge
+	}
+
+	var orphans []orphanedResource
+
+	// The AWS and GCP resources are only defined on the shards of their platform
+	var accountClaims awsv1alpha1.AccountClaimList
+	if err := l.KubeCli.List(ctx, &accountClaims, &client.ListOptions{}); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	claimsByAccount := map[string]awsv1alpha1.AccountClaim{}
+	for _, claim := range accountClaims.Items {
+		claimsByAccount[claim.Spec.AccountLink] = claim
+		if !clusterNamespaces[claim.Namespace] && isCandidate(claim.ObjectMeta) {
+			orphans = append(orphans, orphanedResource{
+				Kind:      "AccountClaim",
+				Namespace: claim.Namespace,
+				Name:      claim.Name,
+				Reason:    "no ClusterDeployment in namespace",
+				Created:   claim.CreationTimestamp.Time,
+			})
+		}
+	}
+
+	var accounts awsv1alpha1.AccountList
+	if err := l.KubeCli.List(ctx, &accounts, &client.ListOptions{Namespace: "aws-account-operator"}); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, account := range accounts.Items {
+		if !isCandidate(account.ObjectMeta) || (!account.Status.Claimed && account.Spec.ClaimLink == "") {
+			continue
+		}
+
+		reason := ""
+		claim, found := claimsByAccount[account.Name]
+		switch {
+		case !found:
+			reason = fmt.Sprintf("claimed by deleted AccountClaim '%s/%s'", account.Spec.ClaimLinkNamespace, account.Spec.ClaimLink)
+		case !clusterNamespaces[claim.Namespace]:
+			reason = fmt.Sprintf("claimed by AccountClaim %s/%s without ClusterDeployment", claim.Namespace, claim.Name)
+		default:
+			continue
+		}
+
+		reclaimable := "aws:" + account.Spec.AwsAccountID
+		if account.Spec.BYOC {
+			// BYOC accounts belong to the customer, they don't go back to the pool
+			reclaimable = ""
+			reason += " (BYOC)"
+		}
+		orphans = append(orphans, orphanedResource{
+			Kind:        "Account",
+			Namespace:   account.Namespace,
+			Name:        account.Name,
+			Reason:      reason,
+			Reclaimable: reclaimable,
+			Created:     account.CreationTimestamp.Time,
+		})
+	}
+
+	var projectClaims gcpv1alpha1.ProjectClaimList
+	if err := l.KubeCli.List(ctx, &projectClaims, &client.ListOptions{}); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	projectClaimKeys := map[string]bool{}
+	for _, claim := range projectClaims.Items {
+		projectClaimKeys[claim.Namespace+"/"+claim.Name] = true
+		if !clusterNamespaces[claim.Namespace] && isCandidate(claim.ObjectMeta) {
+			orphans = append(orphans, orphanedResource{
+				Kind:      "ProjectClaim",
+				Namespace: claim.Namespace,
+				Name:      claim.Name,
+				Reason:    "no ClusterDeployment in namespace",
+				Created:   claim.CreationTimestamp.Time,
+			})
+		}
+	}
+
+	var projectReferences gcpv1alpha1.ProjectReferenceList
+	if err := l.KubeCli.List(ctx, &projectReferences, &client.ListOptions{}); err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, reference := range projectReferences.Items {
+		claimLink := reference.Spec.ProjectClaimCRLink
+		if projectClaimKeys[claimLink.Namespace+"/"+claimLink.Name] || !isCandidate(reference.ObjectMeta) {
+			continue
+		}
+
+		reclaimable := ""
+		if reference.Spec.GCPProjectID != "" && !reference.Spec.CCS {
+			reclaimable = "gcp:" + reference.Spec.GCPProjectID
+		}
+		orphans = append(orphans, orphanedResource{
+			Kind:        "ProjectReference",
+			Namespace:   reference.Namespace,
+			Name:        reference.Name,
+			Reason:      fmt.Sprintf("no ProjectClaim '%s/%s'", claimLink.Namespace, claimLink.Name),
+			Reclaimable: reclaimable,
+			Created:     reference.CreationTimestamp.Time,
+		})
+	}
+
+	sort.SliceStable(orphans, func(i, j int) bool {
+		if orphans[i].Kind != orphans[j].Kind {
+			return orphans[i].Kind < orphans[j].Kind
+		}
+		if orphans[i].Namespace != orphans[j].Namespace {
+			return orphans[i].Namespace < orphans[j].Namespace
+		}
+		return orphans[i].Name < orphans[j].Name
+	})
+	return orphans, nil
+}
@@ -0,0 +1,93 @@
+package clusterdeployment
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
+	gcpv1alpha1 "github.com/openshift/gcp-project-operator/api/v1alpha1"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testOrphansNow = time.Date(2024, 6, 2, 10, 0, 0, 0, time.UTC)
+
+func testObjectMeta(namespace, name string, age time.Duration) v1.ObjectMeta {
+	return v1.ObjectMeta{Namespace: namespace, Name: name, CreationTimestamp: v1.NewTime(testOrphansNow.Add(-age))}
+}
+
+func newOrphansTestListResources(out *bytes.Buffer, objs ...client.Object) *ListResources {
+	scheme := runtime.NewScheme()
+	_ = hivev1.AddToScheme(scheme)
+	_ = awsv1alpha1.AddToScheme(scheme)
+	_ = gcpv1alpha1.AddToScheme(scheme)
+
+	return &ListResources{
+		IOStreams: genericclioptions.IOStreams{Out: out},
+		P:         printer.NewTablePrinter(out, 20, 1, 3, ' '),
+		Orphans:   true,
+		MinAge:    time.Hour,
+		KubeCli:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	day := 24 * time.Hour
+	l := newOrphansTestListResources(&bytes.Buffer{},
+		&hivev1.ClusterDeployment{ObjectMeta: testObjectMeta("uhc-production-live", "live", day)},
+		// A cluster and its resources
+		&awsv1alpha1.AccountClaim{ObjectMeta: testObjectMeta("uhc-production-live", "live", day), Spec: awsv1alpha1.AccountClaimSpec{AccountLink: "osd-creds-mgmt-live"}},
+		&awsv1alpha1.Account{ObjectMeta: testObjectMeta("aws-account-operator", "osd-creds-mgmt-live", day), Spec: awsv1alpha1.AccountSpec{ClaimLink: "live", ClaimLinkNamespace: "uhc-production-live"}},
+		// An AccountClaim whose cluster was deleted, and its Account
+		&awsv1alpha1.AccountClaim{ObjectMeta: testObjectMeta("uhc-production-deleted", "deleted", day), Spec: awsv1alpha1.AccountClaimSpec{AccountLink: "osd-creds-mgmt-deleted"}},
+		&awsv1alpha1.Account{ObjectMeta: testObjectMeta("aws-account-operator", "osd-creds-mgmt-deleted", day), Spec: awsv1alpha1.AccountSpec{AwsAccountID: "111111111111", ClaimLink: "deleted"}},
+		// An Account whose AccountClaim was deleted
+		&awsv1alpha1.Account{ObjectMeta: testObjectMeta("aws-account-operator", "osd-creds-mgmt-gone", day), Spec: awsv1alpha1.AccountSpec{AwsAccountID: "222222222222", ClaimLink: "gone", ClaimLinkNamespace: "uhc-production-gone"}},
+		// An unclaimed Account in the pool
+		&awsv1alpha1.Account{ObjectMeta: testObjectMeta("aws-account-operator", "osd-creds-mgmt-pool", day)},
+		// An AccountClaim of a cluster being installed
+		&awsv1alpha1.AccountClaim{ObjectMeta: testObjectMeta("uhc-production-new", "new", time.Minute)},
+		// A ProjectReference whose ProjectClaim was deleted
+		&gcpv1alpha1.ProjectReference{ObjectMeta: testObjectMeta("gcp-project-operator", "uhc-production-gcp-gcp", day), Spec: gcpv1alpha1.ProjectReferenceSpec{
+			GCPProjectID:       "o-1234567",
+			ProjectClaimCRLink: gcpv1alpha1.NamespacedName{Namespace: "uhc-production-gcp", Name: "gcp"},
+		}},
+	)
+
+	orphans, err := l.findOrphans(context.Background(), testOrphansNow)
+	require.NoError(t, err)
+	require.Len(t, orphans, 4)
+
+	assert.Equal(t, "Account", orphans[0].Kind)
+	assert.Equal(t, "osd-creds-mgmt-deleted", orphans[0].Name)
+	assert.Equal(t, "aws:111111111111", orphans[0].Reclaimable)
+	assert.Equal(t, "claimed by AccountClaim uhc-production-deleted/deleted without ClusterDeployment", orphans[0].Reason)
+	assert.Equal(t, "osd-creds-mgmt-gone", orphans[1].Name)
+	assert.Equal(t, "claimed by deleted AccountClaim 'uhc-production-gone/gone'", orphans[1].Reason)
+	assert.Equal(t, "AccountClaim", orphans[2].Kind)
+	assert.Equal(t, "uhc-production-deleted", orphans[2].Namespace)
+	assert.Equal(t, "ProjectReference", orphans[3].Kind)
+	assert.Equal(t, "gcp:o-1234567", orphans[3].Reclaimable)
+}
+
+func TestRunListOrphans(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := newOrphansTestListResources(out,
+		&awsv1alpha1.Account{ObjectMeta: testObjectMeta("aws-account-operator", "osd-creds-mgmt-byoc", 24*time.Hour), Spec: awsv1alpha1.AccountSpec{AwsAccountID: "333333333333", BYOC: true, ClaimLink: "byoc"}},
+	)
+
+	require.NoError(t, l.RunListResources())
+	assert.Contains(t, out.String(), "osd-creds-mgmt-byoc")
+	assert.Contains(t, out.String(), "(BYOC)")
+	assert.NotContains(t, out.String(), "aws:333333333333")
+	assert.Contains(t, out.String(), "1 orphaned resources, 0 cloud accounts or projects to reclaim")
+}
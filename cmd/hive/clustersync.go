@@ -14,7 +14,9 @@ import (
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	hiveapiv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/osdctl/cmd/cluster"
 	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
@@ -47,6 +49,20 @@ type clusterSyncFailuresOptions struct {
 	sortField              string
 	sortOrder              string
 
+	resync             bool
+	resyncKinds        []string
+	resyncErrorPattern string
+	resyncConcurrency  int
+	resyncTimeout      time.Duration
+	diagnosticsDir     string
+	confirm            func() bool
+	// clusterSyncResync resyncs the ClusterSyncs with --resync
+	clusterSyncResync *cluster.ClusterSyncResync
+
+	history     bool
+	historyDir  string
+	historyFile string
+
 	genericclioptions.IOStreams
 	kubeCli client.Client
 }
@@ -59,6 +75,22 @@ const (
   for clusters that are not in limited support or hibernating.
 
   Error messages are include in all output format except the text format.
+
+  With --resync, the failing ClusterSyncs are resynced instead of listed, as
+  'osdctl cluster resync' does: they're deleted for hive to recreate them, and
+  the command watches them, printing their condition transitions, until they
+  recover. A diagnostic bundle is saved for each ClusterSync which doesn't
+  recover before the timeout. The ClusterSyncs to resync can be
+  chosen by the kind of the resources failing to apply and by a pattern of the
+  error messages. Clusters in limited support or hibernating are left out
+  unless included with --limited-support and --hibernating.
+
+  With --history, the failures are recorded in a local history of the hive
+  shard, and the command reports when each failure was first and last seen
+  and how many times it flapped, per cluster and per SyncSet. It also reports
+  the SyncSets which started failing on clusters since the previous run, to
+  catch a bad SelectorSyncSet rollout early. Run it periodically to build up
+  the history.
 `
 	clusterSyncFailuresExample = `
   # List clustersync failures using the short version of the command
@@ -73,6 +105,15 @@ const (
 
   # List failures and error message for a single cluster
   $ osdctl hive csf -C <cluster-id>
+
+  # Resync the clusters failing to apply a ConfigMap or a Secret, 10 at a time
+  $ osdctl hive csf --resync --kind ConfigMap,Secret --concurrency 10
+
+  # Resync the clusters whose failures match an error pattern
+  $ osdctl hive csf --resync --error-pattern "context deadline exceeded"
+
+  # Record the failures and report their history
+  $ osdctl hive csf --history
 `
 )
 
@@ -81,6 +122,7 @@ func NewCmdClusterSyncFailures(streams genericclioptions.IOStreams, client clien
 	opts := &clusterSyncFailuresOptions{
 		IOStreams: streams,
 		kubeCli:   client,
+		confirm:   utils.ConfirmPrompt,
 	}
 	clusterSyncCmd := &cobra.Command{
 		Use:               "clustersync-failures [flags]",
@@ -103,6 +145,14 @@ func NewCmdClusterSyncFailures(streams genericclioptions.IOStreams, client clien
 	clusterSyncCmd.Flags().StringVar(&opts.sortField, "sort-by", "timestamp", "Sort the output by a specified field. Options: name, timestamp, failingsyncsets.")
 	clusterSyncCmd.Flags().StringVar(&opts.sortOrder, "order", "asc", "Set the sorting order. Options: asc, desc.")
 	clusterSyncCmd.Flags().StringVarP(&opts.clusterID, "cluster-id", "C", "", "Internal ID to list failing syncsets and relative errors for a specific cluster.")
+	clusterSyncCmd.Flags().BoolVar(&opts.resync, "resync", false, "Resync the failing ClusterSyncs and wait for them to recover, instead of listing them.")
+	clusterSyncCmd.Flags().StringSliceVar(&opts.resyncKinds, "kind", nil, "Only resync the ClusterSyncs failing to apply resources of these kinds, e.g. ConfigMap,Secret. Requires --resync.")
+	clusterSyncCmd.Flags().StringVar(&opts.resyncErrorPattern, "error-pattern", "", "Only resync the ClusterSyncs with a failure message matching this regular expression. Requires --resync.")
+	clusterSyncCmd.Flags().IntVar(&opts.resyncConcurrency, "concurrency", 5, "Number of ClusterSyncs to resync at the same time.")
+	clusterSyncCmd.Flags().BoolVar(&opts.history, "history", false, "Record the failures in the local history of the hive shard and report their first-seen and last-seen times and flap counts.")
+	clusterSyncCmd.Flags().StringVar(&opts.historyDir, "history-dir", "", "Directory of the failure history. Defaults to the osdctl directory in the user's cache directory.")
+	clusterSyncCmd.Flags().DurationVar(&opts.resyncTimeout, "timeout", 20*time.Minute, "How long to wait for each resynced ClusterSync to recover.")
+	clusterSyncCmd.Flags().StringVar(&opts.diagnosticsDir, "diagnostics-dir", ".", "Directory to save the diagnostic bundles of the resynced ClusterSyncs which don't recover in. Requires --resync.")
 
 	return clusterSyncCmd
 }
@@ -120,10 +170,51 @@ func (o *clusterSyncFailuresOptions) complete(cmd *cobra.Command, args []string)
 		return cmdutil.UsageErrorf(cmd, "invalid output field")
 	}
 
-	if _, err := config.GetConfig(); err != nil {
+	if !o.resync && (len(o.resyncKinds) > 0 || o.resyncErrorPattern != "") {
+		return cmdutil.UsageErrorf(cmd, "--kind and --error-pattern require --resync")
+	}
+	if !o.resync && cmd.Flags().Changed("diagnostics-dir") {
+		return cmdutil.UsageErrorf(cmd, "--diagnostics-dir requires --resync")
+	}
+
+	if o.resync {
+		if o.clusterID != "" {
+			return cmdutil.UsageErrorf(cmd, "--resync can't be used with --cluster-id, use 'osdctl cluster resync' to resync a single cluster")
+		}
+		if o.resyncConcurrency < 1 {
+			return cmdutil.UsageErrorf(cmd, "--concurrency must be at least 1")
+		}
+		if _, err := regexp.Compile(o.resyncErrorPattern); err != nil {
+			return cmdutil.UsageErrorf(cmd, "invalid --error-pattern: %v", err)
+		}
+	}
+
+	if o.history {
+		if o.resync || o.clusterID != "" {
+			return cmdutil.UsageErrorf(cmd, "--history can't be used with --resync or --cluster-id")
+		}
+		if o.output == "csv" {
+			return cmdutil.UsageErrorf(cmd, "--history supports the yaml, json and text output formats")
+		}
+	}
+
+	cfg, err := config.GetConfig()
+	if err != nil {
 		return cmdutil.UsageErrorf(cmd, "could not find KUBECONFIG, please make sure you are logged into an hive shard")
 	}
 
+	if o.history {
+		if o.historyFile, err = clusterSyncHistoryFile(o.historyDir, cfg.Host); err != nil {
+			return err
+		}
+	}
+
+	if o.resync {
+		if o.clusterSyncResync, err = cluster.NewClusterSyncResync(cfg, o.resyncTimeout, o.diagnosticsDir, o.IOStreams.Out); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -132,6 +223,14 @@ func (o *clusterSyncFailuresOptions) run() error {
 		return o.printFailingCluster()
 	}
 
+	if o.resync {
+		return o.resyncFailingClusterSyncs(context.TODO())
+	}
+
+	if o.history {
+		return o.runHistory(time.Now())
+	}
+
 	csList, err := o.listFailingClusterSyncs()
 	if err != nil {
 		return err
@@ -0,0 +1,340 @@
+package hive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	clusterSyncHistoryDirName = "clustersync-history"
+	// clusterSyncHistoryRetention is how long a recovered failure is kept in the history
+	clusterSyncHistoryRetention = 30 * 24 * time.Hour
+)
+
+var unsafeFileNameRegex = regexp.MustCompile(`[^a-zA-Z0-9.-]`)
+
+// clusterSyncHistory is the history of the ClusterSync failures of a hive shard, across the runs of --history
+type clusterSyncHistory struct {
+	LastRun time.Time `json:"lastRun"`
+	// Clusters are keyed by ClusterSync namespace
+	Clusters map[string]*failureRecord `json:"clusters"`
+	// SyncSets are keyed by ClusterSync namespace and SyncSet name
+	SyncSets map[string]*failureRecord `json:"syncSets"`
+}
+
+// failureRecord tracks how a cluster or a SyncSet of a cluster failed across the runs
+type failureRecord struct {
+	Namespace string    `json:"namespace" yaml:"namespace"`
+	Name      string    `json:"name" yaml:"name"`
+	FirstSeen time.Time `json:"firstSeen" yaml:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen" yaml:"lastSeen"`
+	// Failing tells if it was failing on the last run
+	Failing bool `json:"failing" yaml:"failing"`
+	// Flaps is the number of times it failed again after having recovered
+	Flaps int `json:"flaps" yaml:"flaps"`
+}
+
+// failureTrend is a failing cluster or SyncSet of the history report
+type failureTrend struct {
+	failureRecord `yaml:",inline"`
+	// New tells if it started failing since the previous run
+	New bool `json:"new" yaml:"new"`
+}
+
+// newlyFailingSyncSet is a SyncSet which started failing on clusters since the previous run
+type newlyFailingSyncSet struct {
+	Name            string `json:"name" yaml:"name"`
+	NewFailures     int    `json:"newFailures" yaml:"newFailures"`
+	FailingClusters int    `json:"failingClusters" yaml:"failingClusters"`
+}
+
+// clusterSyncHistoryReport is the outcome of a --history run
+type clusterSyncHistoryReport struct {
+	PreviousRun          *time.Time            `json:"previousRun,omitempty" yaml:"previousRun,omitempty"`
+	NewlyFailingSyncSets []newlyFailingSyncSet `json:"newlyFailingSyncSets" yaml:"newlyFailingSyncSets"`
+	Clusters             []failureTrend        `json:"clusters" yaml:"clusters"`
+	SyncSets             []failureTrend        `json:"syncSets" yaml:"syncSets"`
+}
+
+// clusterSyncHistoryFile returns the history file of the hive shard served at host, in dir or in the user's cache
+// directory
+func clusterSyncHistoryFile(dir, host string) (string, error) {
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine cache directory: %w", err)
+		}
+		dir = filepath.Join(cacheDir, "osdctl", clusterSyncHistoryDirName)
+	}
+
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return filepath.Join(dir, unsafeFileNameRegex.ReplaceAllString(host, "_")+".json"), nil
+}
+
+// loadClusterSyncHistory reads the history from path, an empty history is returned if there isn't one yet
+func loadClusterSyncHistory(path string) (*clusterSyncHistory, error) {
+	history := &clusterSyncHistory{}
+	data, err := os.ReadFile(path) //#nosec G304 -- path is built from the history directory
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read the clustersync history: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, history); err != nil {
+			return nil, fmt.Errorf("failed to parse the clustersync history %s: %w", path, err)
+		}
+	}
+
+	if history.Clusters == nil {
+		history.Clusters = map[string]*failureRecord{}
+	}
+	if history.SyncSets == nil {
+		history.SyncSets = map[string]*failureRecord{}
+	}
+	return history, nil
+}
+
+// saveClusterSyncHistory atomically replaces the history at path
+func saveClusterSyncHistory(path string, history *clusterSyncHistory) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to save the clustersync history: %w", err)
+	}
+
+	return nil
+}
+
+// record updates the history with the failures seen at now, and returns the keys of the clusters and SyncSets which
+// started failing since the previous run
+func (h *clusterSyncHistory) record(failing []failingClusterSync, now time.Time) (newClusters, newSyncSets map[string]bool) {
+	newClusters = map[string]bool{}
+	newSyncSets = map[string]bool{}
+	seenClusters := map[string]bool{}
+	seenSyncSets := map[string]bool{}
+
+	for _, fcs := range failing {
+		seenClusters[fcs.Namespace] = true
+		if recordFailure(h.Clusters, fcs.Namespace, fcs.Namespace, fcs.Name, now) {
+			newClusters[fcs.Namespace] = true
+		}
+
+		for _, syncSet := range strings.Fields(fcs.FailingSyncSets) {
+			key := fcs.Namespace + "/" + syncSet
+			seenSyncSets[key] = true
+			if recordFailure(h.SyncSets, key, fcs.Namespace, syncSet, now) {
+				newSyncSets[key] = true
+			}
+		}
+	}
+
+	recordRecoveries(h.Clusters, seenClusters, now)
+	recordRecoveries(h.SyncSets, seenSyncSets, now)
+	h.LastRun = now
+	return newClusters, newSyncSets
+}
+
+// recordFailure records the failure seen at now, and tells if it's new or failing again
+func recordFailure(records map[string]*failureRecord, key, namespace, name string, now time.Time) bool {
+	record, found := records[key]
+	if !found {
+		records[key] = &failureRecord{Namespace: namespace, Name: name, FirstSeen: now, LastSeen: now, Failing: true}
+		return true
+	}
+
+	started := !record.Failing
+	if started {
+		record.Flaps++
+	}
+	record.Failing = true
+	record.LastSeen = now
+	return started
+}
+
+// recordRecoveries marks the records not seen at now as recovered, and drops the ones recovered for longer than the
+// retention
+func recordRecoveries(records map[string]*failureRecord, seen map[string]bool, now time.Time) {
+	for key, record := range records {
+		if seen[key] {
+			continue
+		}
+		record.Failing = false
+		if now.Sub(record.LastSeen) > clusterSyncHistoryRetention {
+			delete(records, key)
+		}
+	}
+}
+
+// runHistory records the current failures in the history and prints the report
+func (o *clusterSyncFailuresOptions) runHistory(now time.Time) error {
+	failing, err := o.listFailingClusterSyncs()
+	if err != nil {
+		return err
+	}
+
+	history, err := loadClusterSyncHistory(o.historyFile)
+	if err != nil {
+		return err
+	}
+	var previousRun *time.Time
+	if !history.LastRun.IsZero() {
+		lastRun := history.LastRun
+		previousRun = &lastRun
+	}
+
+	newClusters, newSyncSets := history.record(failing, now)
+	if err := saveClusterSyncHistory(o.historyFile, history); err != nil {
+		return err
+	}
+
+	report := o.historyReport(history, failing, newClusters, newSyncSets)
+	report.PreviousRun = previousRun
+
+	switch o.output {
+	case "json":
+		return json.NewEncoder(o.IOStreams.Out).Encode(report)
+	case "yaml":
+		return yaml.NewEncoder(o.IOStreams.Out).Encode(report)
+	default:
+		return o.printHistoryReport(report)
+	}
+}
+
+// historyReport returns the failing clusters and SyncSets of the history, leaving out the clusters in limited support
+// or hibernating unless they're included
+func (o *clusterSyncFailuresOptions) historyReport(history *clusterSyncHistory, failing []failingClusterSync, newClusters, newSyncSets map[string]bool) *clusterSyncHistoryReport {
+	included := map[string]bool{}
+	for _, fcs := range failing {
+		if !o.includeLimitedSupport && fcs.LimitedSupport {
+			continue
+		}
+		if !o.includeHibernating && fcs.Hibernating {
+			continue
+		}
+		included[fcs.Namespace] = true
+	}
+
+	report := &clusterSyncHistoryReport{}
+	for key, record := range history.Clusters {
+		if record.Failing && included[record.Namespace] {
+			report.Clusters = append(report.Clusters, failureTrend{failureRecord: *record, New: newClusters[key]})
+		}
+	}
+
+	newlyFailing := map[string]*newlyFailingSyncSet{}
+	for key, record := range history.SyncSets {
+		if !record.Failing || !included[record.Namespace] {
+			continue
+		}
+		report.SyncSets = append(report.SyncSets, failureTrend{failureRecord: *record, New: newSyncSets[key]})
+
+		syncSet, found := newlyFailing[record.Name]
+		if !found {
+			syncSet = &newlyFailingSyncSet{Name: record.Name}
+			newlyFailing[record.Name] = syncSet
+		}
+		syncSet.FailingClusters++
+		if newSyncSets[key] {
+			syncSet.NewFailures++
+		}
+	}
+	for _, syncSet := range newlyFailing {
+		if syncSet.NewFailures > 0 {
+			report.NewlyFailingSyncSets = append(report.NewlyFailingSyncSets, *syncSet)
+		}
+	}
+
+	sortFailureTrends(report.Clusters)
+	sortFailureTrends(report.SyncSets)
+	sort.Slice(report.NewlyFailingSyncSets, func(i, j int) bool {
+		a, b := report.NewlyFailingSyncSets[i], report.NewlyFailingSyncSets[j]
+		if a.NewFailures != b.NewFailures {
+			return a.NewFailures > b.NewFailures
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// sortFailureTrends sorts the oldest failures first
+func sortFailureTrends(trends []failureTrend) {
+	sort.Slice(trends, func(i, j int) bool {
+		if !trends[i].FirstSeen.Equal(trends[j].FirstSeen) {
+			return trends[i].FirstSeen.Before(trends[j].FirstSeen)
+		}
+		if trends[i].Namespace != trends[j].Namespace {
+			return trends[i].Namespace < trends[j].Namespace
+		}
+		return trends[i].Name < trends[j].Name
+	})
+}
+
+// printHistoryReport prints the history report in text format
+func (o *clusterSyncFailuresOptions) printHistoryReport(report *clusterSyncHistoryReport) error {
+	out := o.IOStreams.Out
+	if report.PreviousRun == nil {
+		fmt.Fprintln(out, "No previous run recorded, the failures will be compared on the next run.")
+	} else {
+		fmt.Fprintf(out, "SyncSets which started failing since the previous run at %s:\n", report.PreviousRun.Format(time.RFC3339))
+		if len(report.NewlyFailingSyncSets) == 0 {
+			fmt.Fprintln(out, "None")
+		} else {
+			p := printer.NewTablePrinter(out, 20, 1, 3, ' ')
+			p.AddRow([]string{"SYNCSET", "NEW FAILURES", "FAILING CLUSTERS"})
+			for _, syncSet := range report.NewlyFailingSyncSets {
+				p.AddRow([]string{syncSet.Name, strconv.Itoa(syncSet.NewFailures), strconv.Itoa(syncSet.FailingClusters)})
+			}
+			if err := p.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Fprintln(out, "\nFailing clusters:")
+	if err := o.printFailureTrends(report.Clusters, "NAME"); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "\nFailing SyncSets:")
+	return o.printFailureTrends(report.SyncSets, "SYNCSET")
+}
+
+func (o *clusterSyncFailuresOptions) printFailureTrends(trends []failureTrend, nameHeader string) error {
+	p := printer.NewTablePrinter(o.IOStreams.Out, 20, 1, 3, ' ')
+	if !o.noHeaders {
+		p.AddRow([]string{"NAMESPACE", nameHeader, "FIRST SEEN", "LAST SEEN", "FLAPS", "NEW"})
+	}
+	for _, trend := range trends {
+		p.AddRow([]string{
+			trend.Namespace,
+			trend.Name,
+			trend.FirstSeen.Format(time.RFC3339),
+			trend.LastSeen.Format(time.RFC3339),
+			strconv.Itoa(trend.Flaps),
+			strconv.FormatBool(trend.New),
+		})
+	}
+	return p.Flush()
+}
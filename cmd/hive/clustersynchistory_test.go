@@ -0,0 +1,126 @@
+package hive
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClusterSyncHistoryFile(t *testing.T) {
+	path, err := clusterSyncHistoryFile("/tmp/history", "https://api.hivep01ue1.example.com:6443")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/history/api.hivep01ue1.example.com_6443.json", path)
+}
+
+func TestClusterSyncHistoryRecord(t *testing.T) {
+	history, err := loadClusterSyncHistory(filepath.Join(t.TempDir(), "missing.json"))
+	require.NoError(t, err)
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	newClusters, newSyncSets := history.record([]failingClusterSync{
+		{Namespace: "uhc-production-1", Name: "cluster-1", FailingSyncSets: "osd-config osd-rbac "},
+	}, start)
+	assert.Equal(t, map[string]bool{"uhc-production-1": true}, newClusters)
+	assert.Len(t, newSyncSets, 2)
+
+	// osd-rbac recovers
+	newClusters, newSyncSets = history.record([]failingClusterSync{
+		{Namespace: "uhc-production-1", Name: "cluster-1", FailingSyncSets: "osd-config "},
+	}, start.Add(time.Hour))
+	assert.Empty(t, newClusters)
+	assert.Empty(t, newSyncSets)
+	assert.False(t, history.SyncSets["uhc-production-1/osd-rbac"].Failing)
+
+	// osd-rbac fails again
+	_, newSyncSets = history.record([]failingClusterSync{
+		{Namespace: "uhc-production-1", Name: "cluster-1", FailingSyncSets: "osd-config osd-rbac "},
+	}, start.Add(2*time.Hour))
+	assert.Equal(t, map[string]bool{"uhc-production-1/osd-rbac": true}, newSyncSets)
+	rbac := history.SyncSets["uhc-production-1/osd-rbac"]
+	assert.Equal(t, 1, rbac.Flaps)
+	assert.Equal(t, start, rbac.FirstSeen)
+	assert.Equal(t, start.Add(2*time.Hour), rbac.LastSeen)
+	assert.Equal(t, 0, history.SyncSets["uhc-production-1/osd-config"].Flaps)
+
+	// Failures recovered for longer than the retention are dropped
+	history.record(nil, start.Add(2*time.Hour+clusterSyncHistoryRetention+time.Minute))
+	assert.Empty(t, history.Clusters)
+	assert.Empty(t, history.SyncSets)
+}
+
+func newHistoryTestOptions(t *testing.T, out *bytes.Buffer, failures map[string]string) *clusterSyncFailuresOptions {
+	scheme := runtime.NewScheme()
+	_ = hivev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	var objs []client.Object
+	for namespace, syncSet := range failures {
+		objs = append(objs, testFailingClusterSync(namespace, "failed to apply resource 0"))
+		objs[len(objs)-1].(*v1alpha1.ClusterSync).Status.SelectorSyncSets[0].Name = syncSet
+	}
+	objs = append(objs, &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "limited",
+		Namespace: "uhc-production-ls",
+		Labels:    map[string]string{"api.openshift.com/limited-support": "true"},
+	}})
+
+	return &clusterSyncFailuresOptions{
+		output:      "text",
+		historyFile: filepath.Join(t.TempDir(), "hive.json"),
+		IOStreams:   genericclioptions.IOStreams{Out: out},
+		kubeCli:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+	}
+}
+
+func TestRunHistory(t *testing.T) {
+	out := &bytes.Buffer{}
+	opts := newHistoryTestOptions(t, out, map[string]string{"uhc-production-1": "osd-config"})
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, opts.runHistory(start))
+	assert.Contains(t, out.String(), "No previous run recorded")
+	assert.Regexp(t, `uhc-production-1\s+uhc-production-1-sync\s+2024-06-01T10:00:00Z`, out.String())
+
+	// A bad SelectorSyncSet rollout makes other clusters fail
+	second := newHistoryTestOptions(t, out, map[string]string{
+		"uhc-production-1":  "osd-config",
+		"uhc-production-2":  "osd-rollout",
+		"uhc-production-3":  "osd-rollout",
+		"uhc-production-ls": "osd-rollout",
+	})
+	second.historyFile = opts.historyFile
+	second.output = "json"
+	out.Reset()
+	require.NoError(t, second.runHistory(start.Add(10*time.Minute)))
+
+	report := &clusterSyncHistoryReport{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), report))
+	require.NotNil(t, report.PreviousRun)
+	assert.Equal(t, start, report.PreviousRun.UTC())
+	// The cluster in limited support is left out
+	assert.Equal(t, []newlyFailingSyncSet{{Name: "osd-rollout", NewFailures: 2, FailingClusters: 2}}, report.NewlyFailingSyncSets)
+	require.Len(t, report.Clusters, 3)
+	assert.Equal(t, "uhc-production-1", report.Clusters[0].Namespace)
+	assert.False(t, report.Clusters[0].New)
+	assert.True(t, report.Clusters[1].New)
+
+	// The yaml report uses the same keys as the json one
+	second.output = "yaml"
+	out.Reset()
+	require.NoError(t, second.runHistory(start.Add(20*time.Minute)))
+	assert.Contains(t, out.String(), "newlyFailingSyncSets:")
+	assert.Regexp(t, `(?m)^\s*firstSeen: `, out.String())
+	assert.Regexp(t, `(?m)^\s*lastSeen: `, out.String())
+}
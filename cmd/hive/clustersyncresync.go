@@ -0,0 +1,259 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveapiv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/osdctl/pkg/printer"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	resyncRecovered     = "recovered"
+	resyncStillFailing  = "still failing"
+	resyncError         = "error"
+	unknownResourceKind = "unknown"
+)
+
+// failedResourceRegex matches the index of the resource, patch or secret mapping hive failed to apply in a SyncSet
+// failure message, e.g. "failed to apply resource 2: ..."
+var failedResourceRegex = regexp.MustCompile(`(?i)failed to apply (resource|patch|secret)[a-z ]* (\d+)`)
+
+// clusterSyncResync is the outcome of resyncing a ClusterSync
+type clusterSyncResync struct {
+	Namespace string
+	Name      string
+	Result    string
+	Duration  time.Duration
+	Message   string
+}
+
+// resyncFailingClusterSyncs resyncs the failing ClusterSyncs matching the kinds and error pattern, and prints the
+// outcome of each
+func (o *clusterSyncFailuresOptions) resyncFailingClusterSyncs(ctx context.Context) error {
+	failing, err := o.listFailingClusterSyncs()
+	if err != nil {
+		return err
+	}
+	if err := o.sortBy(failing); err != nil {
+		return err
+	}
+
+	selected, err := o.selectClusterSyncsToResync(ctx, failing)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		fmt.Fprintln(o.IOStreams.Out, "No failing ClusterSync matches, nothing to resync.")
+		return nil
+	}
+
+	fmt.Fprintf(o.IOStreams.Out, "The following %d ClusterSyncs will be deleted and resynced:\n", len(selected))
+	for _, cs := range selected {
+		fmt.Fprintf(o.IOStreams.Out, "  %s/%s\n", cs.Namespace, cs.Name)
+	}
+	if !o.confirm() {
+		return fmt.Errorf("operation aborted by the user")
+	}
+
+	outcomes := o.resyncClusterSyncs(ctx, selected)
+	if err := o.printResyncOutcomes(outcomes); err != nil {
+		return err
+	}
+
+	recovered := 0
+	for _, outcome := range outcomes {
+		if outcome.Result == resyncRecovered {
+			recovered++
+		}
+	}
+	fmt.Fprintf(o.IOStreams.Out, "\n%d of %d ClusterSyncs recovered\n", recovered, len(outcomes))
+	if recovered != len(outcomes) {
+		return fmt.Errorf("%d ClusterSyncs did not recover", len(outcomes)-recovered)
+	}
+	return nil
+}
+
+// selectClusterSyncsToResync returns the failing ClusterSyncs to resync: the ones which aren't filtered out for being
+// in limited support or hibernating, and have a failing SyncSet or SelectorSyncSet matching the kinds and error pattern
+func (o *clusterSyncFailuresOptions) selectClusterSyncsToResync(ctx context.Context, failing []failingClusterSync) ([]failingClusterSync, error) {
+	errorPattern, err := regexp.Compile(o.resyncErrorPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid error pattern: %w", err)
+	}
+
+	var selected []failingClusterSync
+	for _, fcs := range failing {
+		if !o.includeLimitedSupport && fcs.LimitedSupport {
+			continue
+		}
+		if !o.includeHibernating && fcs.Hibernating {
+			continue
+		}
+
+		cs := &hiveapiv1alpha1.ClusterSync{}
+		if err := o.kubeCli.Get(ctx, client.ObjectKey{Namespace: fcs.Namespace, Name: fcs.Name}, cs); err != nil {
+			return nil, fmt.Errorf("could not retrieve ClusterSync %s/%s: %v", fcs.Namespace, fcs.Name, err)
+		}
+
+		matches, err := o.clusterSyncMatches(ctx, cs, errorPattern)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			selected = append(selected, fcs)
+		}
+	}
+	return selected, nil
+}
+
+// clusterSyncMatches tells if one of the failing SyncSets or SelectorSyncSets of the ClusterSync fails with a message
+// matching the error pattern, on a resource of one of the kinds
+func (o *clusterSyncFailuresOptions) clusterSyncMatches(ctx context.Context, cs *hiveapiv1alpha1.ClusterSync, errorPattern *regexp.Regexp) (bool, error) {
+	for _, syncSets := range []struct {
+		selector bool
+		statuses []hiveapiv1alpha1.SyncStatus
+	}{{true, cs.Status.SelectorSyncSets}, {false, cs.Status.SyncSets}} {
+		for _, status := range syncSets.statuses {
+			if status.Result != hiveapiv1alpha1.FailureSyncSetResult {
+				continue
+			}
+			if !errorPattern.MatchString(status.FailureMessage) {
+				continue
+			}
+			if len(o.resyncKinds) == 0 {
+				return true, nil
+			}
+
+			kinds, err := o.failingResourceKinds(ctx, cs.Namespace, status, syncSets.selector)
+			if err != nil {
+				return false, err
+			}
+			for _, kind := range kinds {
+				if slices.ContainsFunc(o.resyncKinds, func(k string) bool { return strings.EqualFold(k, kind) }) {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// failingResourceKinds returns the kinds of the resources a SyncSet or SelectorSyncSet failed to apply. When the
+// failure message doesn't tell which resource failed, the kinds of all its resources are returned.
+func (o *clusterSyncFailuresOptions) failingResourceKinds(ctx context.Context, namespace string, status hiveapiv1alpha1.SyncStatus, selector bool) ([]string, error) {
+	var spec hivev1.SyncSetCommonSpec
+	if selector {
+		sss := &hivev1.SelectorSyncSet{}
+		if err := o.kubeCli.Get(ctx, client.ObjectKey{Name: status.Name}, sss); err != nil {
+			return nil, fmt.Errorf("could not retrieve SelectorSyncSet %s: %v", status.Name, err)
+		}
+		spec = sss.Spec.SyncSetCommonSpec
+	} else {
+		ss := &hivev1.SyncSet{}
+		if err := o.kubeCli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: status.Name}, ss); err != nil {
+			return nil, fmt.Errorf("could not retrieve SyncSet %s/%s: %v", namespace, status.Name, err)
+		}
+		spec = ss.Spec.SyncSetCommonSpec
+	}
+
+	resourceKinds := make([]string, 0, len(spec.Resources))
+	for _, resource := range spec.Resources {
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(resource.Raw, &typeMeta); err != nil || typeMeta.Kind == "" {
+			typeMeta.Kind = unknownResourceKind
+		}
+		resourceKinds = append(resourceKinds, typeMeta.Kind)
+	}
+	patchKinds := make([]string, 0, len(spec.Patches))
+	for _, patch := range spec.Patches {
+		patchKinds = append(patchKinds, patch.Kind)
+	}
+
+	if match := failedResourceRegex.FindStringSubmatch(status.FailureMessage); match != nil {
+		index, _ := strconv.Atoi(match[2])
+		switch strings.ToLower(match[1]) {
+		case "resource":
+			if index < len(resourceKinds) {
+				return []string{resourceKinds[index]}, nil
+			}
+		case "patch":
+			if index < len(patchKinds) {
+				return []string{patchKinds[index]}, nil
+			}
+		case "secret":
+			return []string{"Secret"}, nil
+		}
+	}
+
+	kinds := append(resourceKinds, patchKinds...)
+	if len(spec.Secrets) > 0 {
+		kinds = append(kinds, "Secret")
+	}
+	return kinds, nil
+}
+
+// resyncClusterSyncs resyncs the ClusterSyncs, at most resyncConcurrency at a time, and returns the outcome of each
+func (o *clusterSyncFailuresOptions) resyncClusterSyncs(ctx context.Context, clusterSyncs []failingClusterSync) []clusterSyncResync {
+	outcomes := make([]clusterSyncResync, len(clusterSyncs))
+	semaphore := make(chan struct{}, o.resyncConcurrency)
+	var wg sync.WaitGroup
+	for i, fcs := range clusterSyncs {
+		wg.Add(1)
+		go func(i int, fcs failingClusterSync) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			outcomes[i] = o.resyncClusterSync(ctx, fcs.Namespace, fcs.Name)
+		}(i, fcs)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// resyncClusterSync resyncs a ClusterSync and tells if it recovered
+func (o *clusterSyncFailuresOptions) resyncClusterSync(ctx context.Context, namespace, name string) clusterSyncResync {
+	outcome := clusterSyncResync{Namespace: namespace, Name: name}
+	start := time.Now()
+	lastSeen, err := o.clusterSyncResync.ResyncClusterSync(ctx, namespace, name)
+	outcome.Duration = time.Since(start).Round(time.Second)
+
+	switch {
+	case err == nil:
+		outcome.Result = resyncRecovered
+	case lastSeen == nil:
+		outcome.Result = resyncError
+		outcome.Message = err.Error()
+	default:
+		outcome.Result = resyncStillFailing
+		outcome.Message = err.Error()
+		for _, condition := range lastSeen.Status.Conditions {
+			if condition.Type == hiveapiv1alpha1.ClusterSyncFailed && condition.Status == v1.ConditionTrue {
+				outcome.Message = condition.Message
+			}
+		}
+	}
+	return outcome
+}
+
+// printResyncOutcomes prints the outcome of each resynced ClusterSync
+func (o *clusterSyncFailuresOptions) printResyncOutcomes(outcomes []clusterSyncResync) error {
+	p := printer.NewTablePrinter(o.IOStreams.Out, 20, 1, 3, ' ')
+	p.AddRow([]string{"NAMESPACE", "NAME", "RESULT", "DURATION", "MESSAGE"})
+	for _, outcome := range outcomes {
+		p.AddRow([]string{outcome.Namespace, outcome.Name, outcome.Result, outcome.Duration.String(), outcome.Message})
+	}
+	return p.Flush()
+}
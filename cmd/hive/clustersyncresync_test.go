@@ -0,0 +1,162 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/openshift/osdctl/cmd/cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func testSelectorSyncSet() *hivev1.SelectorSyncSet {
+	return &hivev1.SelectorSyncSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "osd-config"},
+		Spec: hivev1.SelectorSyncSetSpec{SyncSetCommonSpec: hivev1.SyncSetCommonSpec{
+			Resources: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config"}}`)},
+				{Raw: []byte(`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRole","metadata":{"name":"role"}}`)},
+			},
+			Patches: []hivev1.SyncObjectPatch{{APIVersion: "apps/v1", Kind: "Deployment", Name: "console"}},
+		}},
+	}
+}
+
+func testFailingClusterSync(namespace, failureMessage string) *v1alpha1.ClusterSync {
+	return &v1alpha1.ClusterSync{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace + "-sync", Namespace: namespace},
+		Status: v1alpha1.ClusterSyncStatus{
+			Conditions: []v1alpha1.ClusterSyncCondition{{
+				Type:   v1alpha1.ClusterSyncFailed,
+				Status: corev1.ConditionTrue,
+				Reason: "Failure",
+			}},
+			SelectorSyncSets: []v1alpha1.SyncStatus{{
+				Name:           "osd-config",
+				Result:         v1alpha1.FailureSyncSetResult,
+				FailureMessage: failureMessage,
+			}},
+		},
+	}
+}
+
+// newResyncTestOptions returns options whose client recreates the deleted ClusterSyncs with the failure status
+func newResyncTestOptions(t *testing.T, out *bytes.Buffer, failed corev1.ConditionStatus, objs ...client.Object) *clusterSyncFailuresOptions {
+	scheme := runtime.NewScheme()
+	_ = hivev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	kubeCli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if err := c.Delete(ctx, obj, opts...); err != nil {
+				return err
+			}
+			return c.Create(ctx, &v1alpha1.ClusterSync{
+				ObjectMeta: metav1.ObjectMeta{Name: obj.GetName(), Namespace: obj.GetNamespace(), UID: "recreated"},
+				Status: v1alpha1.ClusterSyncStatus{Conditions: []v1alpha1.ClusterSyncCondition{{
+					Type:    v1alpha1.ClusterSyncFailed,
+					Status:  failed,
+					Message: "SelectorSyncSet osd-config is failing",
+				}}},
+			})
+		},
+	}).Build()
+
+	return &clusterSyncFailuresOptions{
+		sortField:         "name",
+		sortOrder:         "asc",
+		resyncConcurrency: 2,
+		confirm:           func() bool { return true },
+		IOStreams:         genericclioptions.IOStreams{Out: out},
+		kubeCli:           kubeCli,
+		clusterSyncResync: &cluster.ClusterSyncResync{
+			Hive:           kubeCli,
+			Timeout:        100 * time.Millisecond,
+			DiagnosticsDir: t.TempDir(),
+			Out:            out,
+		},
+	}
+}
+
+func TestFailingResourceKinds(t *testing.T) {
+	opts := newResyncTestOptions(t, &bytes.Buffer{}, corev1.ConditionFalse, testSelectorSyncSet())
+
+	tests := []struct {
+		message  string
+		expected []string
+	}{
+		{"failed to apply resource 1: error when creating", []string{"ClusterRole"}},
+		{"Failed to apply patch 0: deployments.apps \"console\" not found", []string{"Deployment"}},
+		{"failed to apply secret mapping 0: secret not found", []string{"Secret"}},
+		{"failed to apply resource 5: out of range", []string{"ConfigMap", "ClusterRole", "Deployment"}},
+		{"context deadline exceeded", []string{"ConfigMap", "ClusterRole", "Deployment"}},
+	}
+	for _, test := range tests {
+		kinds, err := opts.failingResourceKinds(context.Background(), "", v1alpha1.SyncStatus{Name: "osd-config", FailureMessage: test.message}, true)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, kinds, test.message)
+	}
+
+	_, err := opts.failingResourceKinds(context.Background(), "uhc-production-1", v1alpha1.SyncStatus{Name: "missing"}, false)
+	assert.ErrorContains(t, err, "could not retrieve SyncSet uhc-production-1/missing")
+}
+
+func TestResyncFailingClusterSyncs(t *testing.T) {
+	out := &bytes.Buffer{}
+	opts := newResyncTestOptions(t, out, corev1.ConditionFalse,
+		testSelectorSyncSet(),
+		testFailingClusterSync("uhc-production-1", "failed to apply resource 0: configmaps is forbidden"),
+		testFailingClusterSync("uhc-production-2", "failed to apply resource 1: context deadline exceeded"),
+		testFailingClusterSync("uhc-production-3", "failed to apply patch 0: context deadline exceeded"),
+	)
+	opts.resyncKinds = []string{"configmap", "ClusterRole"}
+	opts.resyncErrorPattern = "forbidden|deadline"
+
+	require.NoError(t, opts.resyncFailingClusterSyncs(context.Background()))
+	assert.Contains(t, out.String(), "The following 2 ClusterSyncs will be deleted and resynced")
+	assert.Regexp(t, `uhc-production-1\s+uhc-production-1-sync\s+recovered`, out.String())
+	assert.Regexp(t, `uhc-production-2\s+uhc-production-2-sync\s+recovered`, out.String())
+	assert.NotContains(t, out.String(), "uhc-production-3")
+	assert.Contains(t, out.String(), "2 of 2 ClusterSyncs recovered")
+}
+
+func TestResyncFailingClusterSyncsStillFailing(t *testing.T) {
+	out := &bytes.Buffer{}
+	opts := newResyncTestOptions(t, out, corev1.ConditionTrue,
+		testSelectorSyncSet(),
+		testFailingClusterSync("uhc-production-1", "failed to apply resource 0: configmaps is forbidden"),
+	)
+
+	assert.ErrorContains(t, opts.resyncFailingClusterSyncs(context.Background()), "1 ClusterSyncs did not recover")
+	assert.Regexp(t, `uhc-production-1-sync\s+still failing\s+\S+\s+SelectorSyncSet osd-config is failing`, out.String())
+	assert.Contains(t, out.String(), "uhc-production-1/uhc-production-1-sync Failed: - -> True")
+	assert.Contains(t, out.String(), "Diagnostic bundle saved to "+filepath.Join(opts.clusterSyncResync.DiagnosticsDir, "resync-uhc-production-1-"))
+	assert.Contains(t, out.String(), "0 of 1 ClusterSyncs recovered")
+}
+
+func TestResyncFailingClusterSyncsAborted(t *testing.T) {
+	out := &bytes.Buffer{}
+	opts := newResyncTestOptions(t, out, corev1.ConditionFalse,
+		testFailingClusterSync("uhc-production-1", "failed to apply resource 0: configmaps is forbidden"),
+	)
+	opts.confirm = func() bool { return false }
+
+	assert.ErrorContains(t, opts.resyncFailingClusterSyncs(context.Background()), "operation aborted by the user")
+
+	cs := &v1alpha1.ClusterSync{}
+	require.NoError(t, opts.kubeCli.Get(context.Background(), client.ObjectKey{Namespace: "uhc-production-1", Name: "uhc-production-1-sync"}, cs))
+	assert.Equal(t, "Failure", cs.Status.Conditions[0].Reason)
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	sdk "github.com/openshift-online/ocm-sdk-go"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdCloud"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +22,11 @@ const (
 	awsResourceName     = "red-hat-sre-jumphost"
 	publicSubnetTagKey  = "kubernetes.io/role/elb"
 	privateSubnetTagKey = "kubernetes.io/role/internal-elb"
+	// expiresAtTagKey records when a jumphost is due to shut itself down, in RFC3339 format
+	expiresAtTagKey = "red-hat-sre-jumphost/expires-at"
+	// ownerTagKey records who created a jumphost
+	ownerTagKey        = "red-hat-sre-jumphost/owner"
+	defaultJumphostTTL = 8 * time.Hour
 )
 
 func NewCmdJumphost() *cobra.Command {
@@ -29,6 +38,7 @@ func NewCmdJumphost() *cobra.Command {
 	jumphost.AddCommand(
 		newCmdCreateJumphost(),
 		newCmdDeleteJumphost(),
+		newCmdListJumphost(),
 	)
 
 	return jumphost
@@ -40,8 +50,18 @@ type jumphostConfig struct {
 	subnetId  string
 	tags      []types.Tag
 
-	keyFilepath string
-	ec2PublicIp string
+	// owner is recorded on created jumphosts so forgotten ones can be traced back to someone
+	owner string
+	// ttl is how long a created jumphost lives before shutting itself down, 0 disables the shutdown
+	ttl time.Duration
+	// ssm skips the public IP and SSH ingress rule and connects via AWS Systems Manager Session Manager instead
+	ssm bool
+	// instanceProfile is the IAM instance profile attached to the jumphost, required by Session Manager
+	instanceProfile string
+
+	keyFilepath   string
+	ec2InstanceId string
+	ec2PublicIp   string
 }
 
 type jumphostAWSClient interface {
@@ -54,6 +74,16 @@ type jumphostAWSClient interface {
 	DeleteSecurityGroup(ctx context.Context, params *ec2.DeleteSecurityGroupInput, optFns ...func(options *ec2.Options)) (*ec2.DeleteSecurityGroupOutput, error)
 	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
 
+	AssociateRouteTable(ctx context.Context, params *ec2.AssociateRouteTableInput, optFns ...func(options *ec2.Options)) (*ec2.AssociateRouteTableOutput, error)
+	CreateRoute(ctx context.Context, params *ec2.CreateRouteInput, optFns ...func(options *ec2.Options)) (*ec2.CreateRouteOutput, error)
+	CreateRouteTable(ctx context.Context, params *ec2.CreateRouteTableInput, optFns ...func(options *ec2.Options)) (*ec2.CreateRouteTableOutput, error)
+	CreateSubnet(ctx context.Context, params *ec2.CreateSubnetInput, optFns ...func(options *ec2.Options)) (*ec2.CreateSubnetOutput, error)
+	DeleteRouteTable(ctx context.Context, params *ec2.DeleteRouteTableInput, optFns ...func(options *ec2.Options)) (*ec2.DeleteRouteTableOutput, error)
+	DeleteSubnet(ctx context.Context, params *ec2.DeleteSubnetInput, optFns ...func(options *ec2.Options)) (*ec2.DeleteSubnetOutput, error)
+	DescribeInternetGateways(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error)
+	DescribeRouteTables(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeRouteTablesOutput, error)
+	DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeVpcsOutput, error)
+
 	DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeImagesOutput, error)
 	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
 	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeInstancesOutput, error)
@@ -64,45 +94,42 @@ type jumphostAWSClient interface {
 	CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(options *ec2.Options)) (*ec2.CreateTagsOutput, error)
 }
 
-// initJumphostConfig initializes a jumphostConfig struct for use with jumphost commands.
-// Generally, this function should always be used as opposed to initializing the struct by hand.
-func initJumphostConfig(ctx context.Context, clusterId, subnetId string) (*jumphostConfig, error) {
+// resolveCluster fetches the cluster with the given id from OCM, returning a nil cluster when no id is provided.
+// The returned connection must be closed by the caller.
+func resolveCluster(clusterId string) (*sdk.Connection, *cmv1.Cluster, error) {
 	ocm, err := utils.CreateConnection()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if clusterId == "" {
+		return ocm, nil, nil
 	}
-	defer ocm.Close()
-
-	//cluster, err := utils.GetClusterAnyStatus(ocm, clusterId)
-	//if err != nil {
-	//	return nil, fmt.Errorf("failed to get OCM cluster info for %s: %s", clusterId, err)
-	//}
-
-	//if err := validateCluster(cluster); err != nil {
-	//	return nil, fmt.Errorf("cluster not supported yet - %s", err)
-	//}
-	//
-	//log.Printf("getting AWS credentials from backplane-api for %s (%s)", cluster.Name(), cluster.ID())
-	//cfg, err := osdCloud.CreateAWSV2Config(ctx, cluster.ID())
-	//if err != nil {
-	//	return nil, err
-	//}
-
-	// TODO: When --cluster-id is supported, only do this as a fallback
-	cfg, err := config.LoadDefaultConfig(ctx)
+
+	cluster, err := utils.GetClusterAnyStatus(ocm, clusterId)
 	if err != nil {
-		return nil, err
+		ocm.Close()
+		return nil, nil, fmt.Errorf("failed to get OCM cluster info for %s: %s", clusterId, err)
 	}
 
-	return &jumphostConfig{
-		awsClient: ec2.NewFromConfig(cfg),
-		subnetId:  subnetId,
+	return ocm, cluster, nil
+}
+
+// isGcpCluster returns true if the provided cluster runs on GCP
+func isGcpCluster(cluster *cmv1.Cluster) bool {
+	return cluster != nil && cluster.CloudProvider().ID() == "gcp"
+}
+
+// initJumphostConfig initializes a jumphostConfig struct for use with jumphost commands.
+// Generally, this function should always be used as opposed to initializing the struct by hand.
+// When a cluster is provided, AWS credentials for the cluster's account are obtained from backplane-api, otherwise
+// the default AWS credentials are used.
+func initJumphostConfig(ctx context.Context, ocm *sdk.Connection, cluster *cmv1.Cluster, subnetId string) (*jumphostConfig, error) {
+	j := &jumphostConfig{
+		cluster:  cluster,
+		subnetId: subnetId,
+		owner:    utils.GetCurrentOCMUsername(ocm),
 		tags: []types.Tag{
-			//{
-			//	// This tag will allow the uninstaller to clean up orphaned resources in worst-case scenarios
-			//	Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", cluster.InfraID())),
-			//	Value: aws.String("owned"),
-			//},
 			{
 				Key:   aws.String("red-hat-managed"),
 				Value: aws.String("true"),
@@ -112,11 +139,39 @@ func initJumphostConfig(ctx context.Context, clusterId, subnetId string) (*jumph
 				Value: aws.String("red-hat-sre-jumphost"),
 			},
 		},
-	}, nil
+	}
+
+	if cluster == nil {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		j.awsClient = ec2.NewFromConfig(cfg)
+
+		return j, nil
+	}
+
+	if err := validateCluster(cluster); err != nil {
+		return nil, fmt.Errorf("cluster not supported yet - %s", err)
+	}
+
+	log.Printf("getting AWS credentials from backplane-api for %s (%s)", cluster.Name(), cluster.ID())
+	cfg, err := osdCloud.CreateAWSV2Config(ocm, cluster)
+	if err != nil {
+		return nil, err
+	}
+	j.awsClient = ec2.NewFromConfig(cfg)
+
+	// This tag will allow the uninstaller to clean up orphaned resources in worst-case scenarios
+	j.tags = append(j.tags, types.Tag{
+		Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", cluster.InfraID())),
+		Value: aws.String("owned"),
+	})
+
+	return j, nil
 }
 
-// validateCluster is currently unused as the --cluster-id flag is not supported yet.
-// Eventually, it will gate the usage of the --cluster-id flag based on types of supported clusters.
+// validateCluster gates the usage of the --cluster-id flag for AWS jumphosts based on types of supported clusters.
 func validateCluster(cluster *cmv1.Cluster) error {
 	if cluster != nil {
 		if cluster.CloudProvider().ID() != "aws" {
@@ -149,3 +204,24 @@ func generateTagFilters(tags []types.Tag) []types.Filter {
 
 	return filters
 }
+
+// tagValue returns the value of the tag with the given key, or an empty string if there is no such tag
+func tagValue(tags []types.Tag, key string) string {
+	for _, tag := range tags {
+		if tag.Key != nil && *tag.Key == key && tag.Value != nil {
+			return *tag.Value
+		}
+	}
+
+	return ""
+}
+
+// jumphostExpiry returns when a jumphost is due to expire, and false if it was created without a TTL
+func jumphostExpiry(instance types.Instance) (time.Time, bool) {
+	expiresAt, err := time.Parse(time.RFC3339, tagValue(instance.Tags, expiresAtTagKey))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return expiresAt, true
+}
@@ -2,6 +2,7 @@ package jumphost
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -20,8 +21,11 @@ import (
 
 func newCmdCreateJumphost() *cobra.Command {
 	var (
-		clusterId string
-		subnetId  string
+		clusterId       string
+		subnetId        string
+		ttl             time.Duration
+		ssm             bool
+		instanceProfile string
 	)
 
 	create := &cobra.Command{
@@ -32,31 +36,55 @@ func newCmdCreateJumphost() *cobra.Command {
 
   This command automates the process of creating a jumphost in order to gain SSH
   access to a cluster's EC2 instances and should generally only be used as a last
-  resort when the cluster's API server is otherwise inaccessible. It requires valid
-  AWS credentials to be already set and a subnet ID in the associated AWS account.
-  The provided subnet ID must be a public subnet.
+  resort when the cluster's API server is otherwise inaccessible. It requires either
+  valid AWS credentials to be already set and a subnet ID in the associated AWS
+  account, or a cluster ID to get AWS credentials from backplane-api. The provided
+  subnet ID must be a public subnet. When only a cluster ID is provided, a public
+  subnet in the cluster's VPC is used, and if there is none a temporary public subnet
+  is created which is cleaned up by "osdctl jumphost delete".
+
+  For GCP clusters, a bastion VM without an external IP is created in the cluster's
+  VPC along with a firewall rule allowing SSH from Identity-Aware Proxy (IAP), so it
+  also works for private clusters. This uses the Application Default Credentials,
+  e.g. from "gcloud auth application-default login".
 
   When the cluster's API server is accessible, prefer "oc debug node".
 
+  The jumphost shuts itself down and is terminated once its --ttl has passed. With
+  --ssm, the jumphost gets no public IP or SSH ingress rule and is instead reached
+  through AWS Systems Manager Session Manager, which requires an IAM instance
+  profile allowing Session Manager (e.g. with the AmazonSSMManagedInstanceCore policy).
+  The jumphost must then reach Session Manager without a public IP: when only a
+  cluster ID is provided, a private subnet of the cluster's VPC routed through a NAT
+  gateway is used, otherwise provide a subnet with --subnet-id which can.
+
   Requires these permissions:
   {
     "Version": "2012-10-17",
     "Statement": [
       {
         "Action": [
+          "ec2:AssociateRouteTable",
           "ec2:AuthorizeSecurityGroupIngress",
           "ec2:CreateKeyPair",
+          "ec2:CreateRoute",
+          "ec2:CreateRouteTable",
           "ec2:CreateSecurityGroup",
+          "ec2:CreateSubnet",
           "ec2:CreateTags",
           "ec2:DeleteKeyPair",
           "ec2:DeleteSecurityGroup",
           "ec2:DescribeImages",
           "ec2:DescribeInstances",
+          "ec2:DescribeInternetGateways",
           "ec2:DescribeKeyPairs",
+          "ec2:DescribeRouteTables",
           "ec2:DescribeSecurityGroups",
           "ec2:DescribeSubnets",
+          "ec2:DescribeVpcs",
           "ec2:RunInstances",
-          "ec2:TerminateInstances"
+          "ec2:TerminateInstances",
+          "iam:PassRole"
         ],
         "Effect": "Allow",
         "Resource": "*"
@@ -66,28 +94,90 @@ func newCmdCreateJumphost() *cobra.Command {
 		Example: `
   # Create and delete a jumphost
   osdctl jumphost create --subnet-id public-subnet-id
-  osdctl jumphost delete --subnet-id public-subnet-id`,
+  osdctl jumphost delete --subnet-id public-subnet-id
+
+  # Create a jumphost in a public subnet of the cluster's VPC, or a bastion VM for GCP clusters
+  osdctl jumphost create --cluster-id ${CLUSTER_ID}
+  osdctl jumphost delete --cluster-id ${CLUSTER_ID}
+
+  # Create a jumphost that terminates itself after 2 hours
+  osdctl jumphost create --subnet-id public-subnet-id --ttl 2h
+
+  # Create a jumphost reachable through Session Manager instead of SSH
+  osdctl jumphost create --subnet-id subnet-id --ssm --instance-profile ssm-instance-profile`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			j, err := initJumphostConfig(context.TODO(), clusterId, subnetId)
+			if clusterId == "" && subnetId == "" {
+				return errors.New("one of --cluster-id or --subnet-id must be specified")
+			}
+			if ssm && instanceProfile == "" {
+				return errors.New("--instance-profile is required with --ssm")
+			}
+			if ttl < 0 {
+				return errors.New("--ttl must not be negative")
+			}
+
+			ocm, cluster, err := resolveCluster(clusterId)
+			if err != nil {
+				return err
+			}
+			defer ocm.Close()
+
+			if isGcpCluster(cluster) {
+				if ssm || subnetId != "" {
+					return errors.New("--ssm and --subnet-id are only supported for AWS clusters")
+				}
+
+				g, err := initGcpJumphostConfig(context.TODO(), ocm, cluster)
+				if err != nil {
+					return err
+				}
+				defer g.close()
+				g.ttl = ttl
+
+				return g.runCreate(context.TODO())
+			}
+
+			j, err := initJumphostConfig(context.TODO(), ocm, cluster, subnetId)
 			if err != nil {
 				return err
 			}
+			j.ttl = ttl
+			j.ssm = ssm
+			j.instanceProfile = instanceProfile
 
 			return j.runCreate(context.TODO())
 		},
 	}
 
-	// create.Flags().StringVarP(&clusterId, "cluster-id", "c", "", "OCM internal/external cluster id trying to access via a jumphost")
-	create.Flags().StringVar(&subnetId, "subnet-id", "", "public subnet id to create a jumphost in")
-	create.MarkFlagRequired("subnet-id")
+	create.Flags().StringVarP(&clusterId, "cluster-id", "c", "", "OCM internal/external cluster id to create a jumphost for, a public subnet in its VPC (or a private one with --ssm) is used if --subnet-id isn't specified")
+	create.Flags().StringVar(&subnetId, "subnet-id", "", "subnet id to create a jumphost in, a public one unless using --ssm")
+	create.Flags().DurationVar(&ttl, "ttl", defaultJumphostTTL, "how long the jumphost lives before shutting itself down and terminating, 0 to disable")
+	create.Flags().BoolVar(&ssm, "ssm", false, "connect via AWS Systems Manager Session Manager instead of SSH, the jumphost gets no public IP")
+	create.Flags().StringVar(&instanceProfile, "instance-profile", "", "IAM instance profile name to attach to the jumphost, required with --ssm")
 
 	return create
 }
 
 func (j *jumphostConfig) runCreate(ctx context.Context) error {
-	if err := j.createKeyPair(ctx); err != nil {
-		return err
+	if j.subnetId == "" {
+		// A Session Manager jumphost has no public IP, so it can't reach Session Manager from a public subnet
+		findSubnet := j.findOrCreatePublicSubnet
+		if j.ssm {
+			findSubnet = j.findPrivateSubnet
+		}
+		subnetId, err := findSubnet(ctx)
+		if err != nil {
+			return err
+		}
+		j.subnetId = subnetId
+	}
+
+	// Session Manager doesn't need an SSH key pair to reach the jumphost
+	if !j.ssm {
+		if err := j.createKeyPair(ctx); err != nil {
+			return err
+		}
 	}
 
 	securityGroupId, err := j.createSecurityGroup(ctx)
@@ -176,6 +266,11 @@ func (j *jumphostConfig) createSecurityGroup(ctx context.Context) (string, error
 	}
 	log.Printf("created security group: %s", *resp.GroupId)
 
+	if j.ssm {
+		// Session Manager only needs outbound access, which security groups allow by default
+		return *resp.GroupId, nil
+	}
+
 	if err := j.allowJumphostSshFromIp(ctx, *resp.GroupId); err != nil {
 		return *resp.GroupId, fmt.Errorf("failed to allow SSH to jumphost: %w", err)
 	}
@@ -194,7 +289,7 @@ func (j *jumphostConfig) createEc2Jumphost(ctx context.Context, securityGroupId
 		return err
 	}
 
-	resp, err := j.awsClient.RunInstances(ctx, &ec2.RunInstancesInput{
+	input := &ec2.RunInstancesInput{
 		MaxCount: aws.Int32(1),
 		MinCount: aws.Int32(1),
 		BlockDeviceMappings: []types.BlockDeviceMapping{
@@ -212,7 +307,7 @@ func (j *jumphostConfig) createEc2Jumphost(ctx context.Context, securityGroupId
 		KeyName:                           aws.String(awsResourceName),
 		NetworkInterfaces: []types.InstanceNetworkInterfaceSpecification{
 			{
-				AssociatePublicIpAddress: aws.Bool(true),
+				AssociatePublicIpAddress: aws.Bool(!j.ssm),
 				DeleteOnTermination:      aws.Bool(true),
 				DeviceIndex:              aws.Int32(0),
 				Groups:                   []string{securityGroupId},
@@ -222,13 +317,18 @@ func (j *jumphostConfig) createEc2Jumphost(ctx context.Context, securityGroupId
 		TagSpecifications: []types.TagSpecification{
 			{
 				ResourceType: types.ResourceTypeInstance,
-				Tags:         j.tags,
+				Tags:         j.instanceTags(time.Now()),
 			},
 		},
-		// TODO: It would be nice to add user data which shut down the EC2 instance after 8 hours.
-		// This would automatically terminate the EC2 instance in case we forget to clean up.
-		UserData: nil,
-	})
+		// Shutting down terminates the instance, so it is cleaned up even if we forget to
+		UserData: j.userData(),
+	}
+	if j.ssm {
+		input.KeyName = nil
+		input.IamInstanceProfile = &types.IamInstanceProfileSpecification{Name: aws.String(j.instanceProfile)}
+	}
+
+	resp, err := j.awsClient.RunInstances(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to create jumphost EC2 instace: %w", err)
 	}
@@ -246,17 +346,59 @@ func (j *jumphostConfig) createEc2Jumphost(ctx context.Context, securityGroupId
 		return fmt.Errorf("%s: terminated %s after timing out waiting for instance to be running", err, *resp.Instances[0].InstanceId)
 	}
 
+	j.ec2InstanceId = *resp.Instances[0].InstanceId
+	if j.ssm {
+		log.Printf("created EC2 jumphost: %s", j.ec2InstanceId)
+		return nil
+	}
+
 	describeInstancesResp, err := j.awsClient.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
 		InstanceIds: []string{*resp.Instances[0].InstanceId},
 	})
+	if err != nil {
+		return fmt.Errorf("failed to describe jumphost EC2 instance %s: %w", j.ec2InstanceId, err)
+	}
 
 	log.Printf("created EC2 jumphost: %s with public ip: %s", *describeInstancesResp.Reservations[0].Instances[0].InstanceId, *describeInstancesResp.Reservations[0].Instances[0].PublicIpAddress)
 	j.ec2PublicIp = *describeInstancesResp.Reservations[0].Instances[0].PublicIpAddress
 	return nil
 }
 
+// instanceTags returns the tags for a jumphost EC2 instance created at the given time, which in addition to the
+// common tags record its owner and expiry
+func (j *jumphostConfig) instanceTags(now time.Time) []types.Tag {
+	tags := append([]types.Tag{}, j.tags...)
+	if j.owner != "" {
+		tags = append(tags, types.Tag{Key: aws.String(ownerTagKey), Value: aws.String(j.owner)})
+	}
+	if j.ttl > 0 {
+		tags = append(tags, types.Tag{Key: aws.String(expiresAtTagKey), Value: aws.String(now.Add(j.ttl).UTC().Format(time.RFC3339))})
+	}
+
+	return tags
+}
+
+// userData returns base64 encoded user data which shuts the instance down once its TTL has passed
+func (j *jumphostConfig) userData() *string {
+	if j.ttl <= 0 {
+		return nil
+	}
+
+	// shutdown only accepts whole minutes, round up so the jumphost never expires early
+	minutes := int((j.ttl + time.Minute - 1) / time.Minute)
+	script := fmt.Sprintf("#!/bin/bash\nshutdown -h +%d\n", minutes)
+	return aws.String(base64.StdEncoding.EncodeToString([]byte(script)))
+}
+
 // assembleNextSteps returns a string with helpful next steps for connecting to the created jumphost
 func (j *jumphostConfig) assembleNextSteps() string {
+	if j.ssm {
+		if j.ec2InstanceId == "" {
+			return "could not determine EC2 instance id - please verify, but something likely went wrong"
+		}
+		return fmt.Sprintf("aws ssm start-session --target %s", j.ec2InstanceId)
+	}
+
 	if j.ec2PublicIp == "" {
 		return fmt.Sprintf("could not determine EC2 public ip - please verify, but something likely went wrong")
 	}
@@ -2,10 +2,12 @@ package jumphost
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -243,6 +245,19 @@ func TestAssembleNextSteps(t *testing.T) {
 			jumphost: &jumphostConfig{},
 			expected: "could not determine EC2 public ip - please verify, but something likely went wrong",
 		},
+		{
+			name: "ssm",
+			jumphost: &jumphostConfig{
+				ssm:           true,
+				ec2InstanceId: "i-12345",
+			},
+			expected: "aws ssm start-session --target i-12345",
+		},
+		{
+			name:     "ssm_missing_instance_id",
+			jumphost: &jumphostConfig{ssm: true},
+			expected: "could not determine EC2 instance id - please verify, but something likely went wrong",
+		},
 	}
 
 	for _, tt := range tests {
@@ -252,6 +267,40 @@ func TestAssembleNextSteps(t *testing.T) {
 	}
 }
 
+func TestInstanceTags(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	j := &jumphostConfig{
+		owner: "test-user",
+		ttl:   2 * time.Hour,
+		tags:  []types.Tag{{Key: aws.String("Name"), Value: aws.String("red-hat-sre-jumphost")}},
+	}
+
+	tags := j.instanceTags(now)
+	assert.Len(t, tags, 3)
+	assert.Equal(t, "test-user", tagValue(tags, ownerTagKey))
+	assert.Equal(t, "2024-01-01T14:00:00Z", tagValue(tags, expiresAtTagKey))
+	// The common tags are used as filters, so they must not pick up per-instance tags
+	assert.Len(t, j.tags, 1)
+
+	expiresAt, ok := jumphostExpiry(types.Instance{Tags: tags})
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(2*time.Hour), expiresAt)
+
+	j.ttl = 0
+	_, ok = jumphostExpiry(types.Instance{Tags: j.instanceTags(now)})
+	assert.False(t, ok)
+}
+
+func TestUserData(t *testing.T) {
+	j := &jumphostConfig{ttl: 90*time.Minute + time.Second}
+	script, err := base64.StdEncoding.DecodeString(*j.userData())
+	assert.NoError(t, err)
+	assert.Equal(t, "#!/bin/bash\nshutdown -h +91\n", string(script))
+
+	j.ttl = 0
+	assert.Nil(t, j.userData())
+}
+
 func TestCreateKeyPair(t *testing.T) {
 	tests := []struct {
 		name         string
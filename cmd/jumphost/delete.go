@@ -2,6 +2,7 @@ package jumphost
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -16,6 +17,7 @@ func newCmdDeleteJumphost() *cobra.Command {
 	var (
 		clusterId string
 		subnetId  string
+		expired   bool
 	)
 
 	create := &cobra.Command{
@@ -28,6 +30,9 @@ func newCmdDeleteJumphost() *cobra.Command {
   fails the customer should be notified as there will be leftover AWS resources
   in their account. This command is idempotent and safe to run over and over.
 
+  With --expired, all jumphosts whose --ttl has passed are terminated instead, along
+  with any security groups and key pairs which are no longer used by a jumphost.
+
   Requires these permissions:
   {
     "Version": "2012-10-17",
@@ -39,10 +44,13 @@ func newCmdDeleteJumphost() *cobra.Command {
           "ec2:CreateSecurityGroup",
           "ec2:CreateTags",
           "ec2:DeleteKeyPair",
+          "ec2:DeleteRouteTable",
           "ec2:DeleteSecurityGroup",
+          "ec2:DeleteSubnet",
           "ec2:DescribeImages",
           "ec2:DescribeInstances",
           "ec2:DescribeKeyPairs",
+          "ec2:DescribeRouteTables",
           "ec2:DescribeSecurityGroups",
           "ec2:DescribeSubnets",
           "ec2:RunInstances",
@@ -56,20 +64,58 @@ func newCmdDeleteJumphost() *cobra.Command {
 		Example: `
   # Create and delete a jumphost
   osdctl jumphost create --subnet-id public-subnet-id
-  osdctl jumphost delete --subnet-id public-subnet-id`,
+  osdctl jumphost delete --subnet-id public-subnet-id
+
+  # Delete a jumphost created with --cluster-id, including any temporary subnet
+  osdctl jumphost delete --cluster-id ${CLUSTER_ID}
+
+  # Clean up all expired jumphosts
+  osdctl jumphost delete --expired`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			j, err := initJumphostConfig(context.TODO(), clusterId, subnetId)
+			if subnetId != "" && expired {
+				return errors.New("--subnet-id and --expired are mutually exclusive")
+			}
+			if clusterId == "" && subnetId == "" && !expired {
+				return errors.New("one of --cluster-id, --subnet-id, or --expired must be specified")
+			}
+
+			ocm, cluster, err := resolveCluster(clusterId)
+			if err != nil {
+				return err
+			}
+			defer ocm.Close()
+
+			if isGcpCluster(cluster) {
+				if expired || subnetId != "" {
+					return errors.New("--expired and --subnet-id are only supported for AWS clusters")
+				}
+
+				g, err := initGcpJumphostConfig(context.TODO(), ocm, cluster)
+				if err != nil {
+					return err
+				}
+				defer g.close()
+
+				return g.runDelete(context.TODO())
+			}
+
+			j, err := initJumphostConfig(context.TODO(), ocm, cluster, subnetId)
 			if err != nil {
 				return err
 			}
 
+			if expired {
+				return j.runDeleteExpired(context.TODO(), time.Now())
+			}
+
 			return j.runDelete(context.TODO())
 		},
 	}
 
+	create.Flags().StringVarP(&clusterId, "cluster-id", "c", "", "OCM internal/external cluster id to search for and delete a jumphost for")
 	create.Flags().StringVar(&subnetId, "subnet-id", "", "subnet id to search for and delete a jumphost in")
-	create.MarkFlagRequired("subnet-id")
+	create.Flags().BoolVar(&expired, "expired", false, "delete all jumphosts whose TTL has passed instead of the one in --subnet-id")
 
 	return create
 }
@@ -87,6 +133,83 @@ func (j *jumphostConfig) runDelete(ctx context.Context) error {
 		return err
 	}
 
+	vpcId, err := j.jumphostVpcId(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The temporary subnet may still hold the jumphosts of other SREs
+	instances, err := j.listJumphosts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		if aws.ToString(instance.VpcId) == vpcId {
+			log.Printf("jumphost %s is still running in %s, keeping its temporary subnet", aws.ToString(instance.InstanceId), vpcId)
+			return nil
+		}
+	}
+
+	return j.deleteTemporarySubnet(ctx, vpcId)
+}
+
+// runDeleteExpired terminates all jumphosts which expired before now, then cleans up security groups and temporary
+// subnets in VPCs and the key pair once no jumphosts are left using them
+func (j *jumphostConfig) runDeleteExpired(ctx context.Context, now time.Time) error {
+	instances, err := j.listJumphosts(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		expiredIds    []string
+		expiredVpcIds []string
+		liveVpcIds    = map[string]bool{}
+	)
+	for _, instance := range instances {
+		if expiresAt, ok := jumphostExpiry(instance); ok && !expiresAt.After(now) {
+			expiredIds = append(expiredIds, *instance.InstanceId)
+			expiredVpcIds = append(expiredVpcIds, aws.ToString(instance.VpcId))
+			continue
+		}
+		liveVpcIds[aws.ToString(instance.VpcId)] = true
+	}
+
+	if len(expiredIds) == 0 {
+		log.Println("no expired EC2 instances found to terminate")
+		return nil
+	}
+
+	log.Printf("terminating expired EC2 instances: %v", expiredIds)
+	if _, err := j.awsClient.TerminateInstances(ctx, &ec2.TerminateInstancesInput{InstanceIds: expiredIds}); err != nil {
+		return err
+	}
+
+	log.Println("waiting for the EC2 instances to be in a terminated state")
+	waiter := ec2.NewInstanceTerminatedWaiter(j.awsClient)
+	if err := waiter.Wait(ctx, &ec2.DescribeInstancesInput{InstanceIds: expiredIds}, 5*time.Minute); err != nil {
+		return err
+	}
+
+	deleted := map[string]bool{}
+	for _, vpcId := range expiredVpcIds {
+		if vpcId == "" || liveVpcIds[vpcId] || deleted[vpcId] {
+			continue
+		}
+		if err := j.deleteSecurityGroupInVpc(ctx, vpcId); err != nil {
+			return err
+		}
+		if err := j.deleteTemporarySubnet(ctx, vpcId); err != nil {
+			return err
+		}
+		deleted[vpcId] = true
+	}
+
+	// All jumphosts share one key pair, so it can only be removed once none are left
+	if len(liveVpcIds) == 0 {
+		return j.deleteKeyPair(ctx)
+	}
+
 	return nil
 }
 
@@ -118,11 +241,17 @@ func (j *jumphostConfig) deleteKeyPair(ctx context.Context) error {
 // deleteSecurityGroup searches for security groups by the expected tag filter within the provided subnet's VPC and
 // deletes the first matching security group
 func (j *jumphostConfig) deleteSecurityGroup(ctx context.Context) error {
-	vpcId, err := j.findVpcId(ctx)
+	vpcId, err := j.jumphostVpcId(ctx)
 	if err != nil {
 		return err
 	}
 
+	return j.deleteSecurityGroupInVpc(ctx, vpcId)
+}
+
+// deleteSecurityGroupInVpc searches for security groups by the expected tag filter within the provided VPC and
+// deletes the first matching security group
+func (j *jumphostConfig) deleteSecurityGroupInVpc(ctx context.Context, vpcId string) error {
 	resp, err := j.awsClient.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
 		Filters: append(generateTagFilters(j.tags), []types.Filter{
 			{
@@ -158,7 +287,7 @@ func (j *jumphostConfig) deleteSecurityGroup(ctx context.Context) error {
 // deleteEc2Jumphost searches for EC2 instances by the expected tag filter within the provided subnet's VPC and
 // terminates the first matching EC2 instance
 func (j *jumphostConfig) deleteEc2Jumphost(ctx context.Context) error {
-	vpcId, err := j.findVpcId(ctx)
+	vpcId, err := j.jumphostVpcId(ctx)
 	if err != nil {
 		return err
 	}
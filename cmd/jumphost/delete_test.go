@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -279,3 +280,77 @@ func TestDeleteSecurityGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestRunDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	jumphost := func(id, vpcId string, expiresAt time.Time) types.Instance {
+		return types.Instance{
+			InstanceId: aws.String(id),
+			VpcId:      aws.String(vpcId),
+			Tags:       []types.Tag{{Key: aws.String(expiresAtTagKey), Value: aws.String(expiresAt.Format(time.RFC3339))}},
+		}
+	}
+	isList := mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool { return len(input.InstanceIds) == 0 })
+	isWait := mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool { return len(input.InstanceIds) > 0 })
+	terminated := &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{{Instances: []types.Instance{{State: &types.InstanceState{Name: types.InstanceStateNameTerminated}}}}},
+	}
+
+	tests := []struct {
+		name       string
+		instances  []types.Instance
+		setupMocks func(mockAWS *mockAWSClient)
+	}{
+		{
+			name:       "nothing_expired",
+			instances:  []types.Instance{jumphost("i-live", "vpc-1", now.Add(time.Hour))},
+			setupMocks: func(mockAWS *mockAWSClient) {},
+		},
+		{
+			name: "expired_with_live_jumphost_in_same_vpc",
+			instances: []types.Instance{
+				jumphost("i-expired", "vpc-1", now.Add(-time.Hour)),
+				jumphost("i-live", "vpc-1", now.Add(time.Hour)),
+			},
+			setupMocks: func(mockAWS *mockAWSClient) {
+				mockAWS.On("TerminateInstances", ctx, &ec2.TerminateInstancesInput{InstanceIds: []string{"i-expired"}}).Return(&ec2.TerminateInstancesOutput{}, nil).Once()
+				mockAWS.On("DescribeInstances", mock.Anything, isWait).Return(terminated, nil).Once()
+			},
+		},
+		{
+			name: "all_expired",
+			instances: []types.Instance{
+				jumphost("i-expired-1", "vpc-1", now.Add(-time.Hour)),
+				jumphost("i-expired-2", "vpc-1", now),
+			},
+			setupMocks: func(mockAWS *mockAWSClient) {
+				mockAWS.On("TerminateInstances", ctx, &ec2.TerminateInstancesInput{InstanceIds: []string{"i-expired-1", "i-expired-2"}}).Return(&ec2.TerminateInstancesOutput{}, nil).Once()
+				mockAWS.On("DescribeInstances", mock.Anything, isWait).Return(terminated, nil).Once()
+				mockAWS.On("DescribeSecurityGroups", ctx, mock.Anything).Return(&ec2.DescribeSecurityGroupsOutput{
+					SecurityGroups: []types.SecurityGroup{{GroupId: aws.String("sg-1"), GroupName: aws.String(awsResourceName)}},
+				}, nil).Once()
+				mockAWS.On("DeleteSecurityGroup", ctx, mock.Anything).Return(&ec2.DeleteSecurityGroupOutput{}, nil).Once()
+				mockAWS.On("DescribeSubnets", ctx, mock.MatchedBy(func(input *ec2.DescribeSubnetsInput) bool {
+					return hasFilter(input.Filters, "vpc-id", "vpc-1")
+				})).Return(&ec2.DescribeSubnetsOutput{}, nil).Once()
+				mockAWS.On("DescribeRouteTables", ctx, mock.Anything).Return(&ec2.DescribeRouteTablesOutput{}, nil).Once()
+				mockAWS.On("DescribeKeyPairs", ctx, mock.Anything).Return(&ec2.DescribeKeyPairsOutput{}, nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAWS := new(mockAWSClient)
+			mockAWS.On("DescribeInstances", ctx, isList).Return(&ec2.DescribeInstancesOutput{
+				Reservations: []types.Reservation{{Instances: tt.instances}},
+			}, nil).Once()
+			tt.setupMocks(mockAWS)
+
+			j := &jumphostConfig{awsClient: mockAWS}
+			assert.NoError(t, j.runDeleteExpired(ctx, now))
+			mockAWS.AssertExpectations(t)
+		})
+	}
+}
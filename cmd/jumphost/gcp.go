@@ -0,0 +1,289 @@
+package jumphost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/utils"
+	"google.golang.org/api/googleapi"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// iapSourceRange is the range Identity-Aware Proxy TCP forwarding connects from
+	// https://cloud.google.com/iap/docs/using-tcp-forwarding#create-firewall-rule
+	iapSourceRange = "35.235.240.0/20"
+	gcpMachineType = "e2-micro"
+	gcpImage       = "projects/rhel-cloud/global/images/family/rhel-9"
+)
+
+// gcpLabelValueInvalidChars matches characters which aren't allowed in GCP label values
+var gcpLabelValueInvalidChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// gcpJumphostConfig is the GCP counterpart of jumphostConfig. Instead of a public SSH jumphost, it manages a bastion VM
+// without an external IP which is reached by tunnelling SSH through Identity-Aware Proxy (IAP).
+type gcpJumphostConfig struct {
+	instancesClient *compute.InstancesClient
+	firewallsClient *compute.FirewallsClient
+	cluster         *cmv1.Cluster
+
+	// project is the GCP project the cluster's VMs are in
+	project string
+	// networkProject is the GCP project the cluster's VPC is in, which differs from project for shared VPCs
+	networkProject string
+	zone           string
+	network        string
+	subnetwork     string
+
+	owner string
+	ttl   time.Duration
+}
+
+// initGcpJumphostConfig initializes a gcpJumphostConfig for the provided GCP cluster using the Application Default
+// Credentials
+func initGcpJumphostConfig(ctx context.Context, ocm *sdk.Connection, cluster *cmv1.Cluster) (*gcpJumphostConfig, error) {
+	project, err := osdCloud.GetGcpProjectID(ocm, cluster)
+	if err != nil {
+		return nil, err
+	}
+	g := &gcpJumphostConfig{
+		cluster: cluster,
+		project: project,
+		owner:   utils.GetCurrentOCMUsername(ocm),
+	}
+
+	g.setNetwork()
+
+	if g.instancesClient, err = compute.NewInstancesRESTClient(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create GCP instances client: %w", err)
+	}
+	if g.firewallsClient, err = compute.NewFirewallsRESTClient(ctx); err != nil {
+		g.close()
+		return nil, fmt.Errorf("failed to create GCP firewalls client: %w", err)
+	}
+
+	return g, nil
+}
+
+// setNetwork determines the zone, VPC, and subnet to create the bastion in, preferring the cluster's BYOVPC settings
+// and falling back to the names the installer uses
+func (g *gcpJumphostConfig) setNetwork() {
+	if zones := g.cluster.Nodes().AvailabilityZones(); len(zones) > 0 {
+		g.zone = zones[0]
+	} else {
+		g.zone = fmt.Sprintf("%s-a", g.cluster.Region().ID())
+	}
+
+	network := g.cluster.GCPNetwork()
+	g.networkProject = g.project
+	if network.VPCProjectID() != "" {
+		g.networkProject = network.VPCProjectID()
+	}
+
+	g.network = network.VPCName()
+	if g.network == "" {
+		g.network = fmt.Sprintf("%s-network", g.cluster.InfraID())
+	}
+
+	g.subnetwork = network.ComputeSubnet()
+	if g.subnetwork == "" {
+		g.subnetwork = fmt.Sprintf("%s-worker-subnet", g.cluster.InfraID())
+	}
+}
+
+func (g *gcpJumphostConfig) close() {
+	if g.instancesClient != nil {
+		g.instancesClient.Close()
+	}
+	if g.firewallsClient != nil {
+		g.firewallsClient.Close()
+	}
+}
+
+// instanceName is the name of the bastion VM, scoped to the cluster since several clusters can share a project's VPC
+func (g *gcpJumphostConfig) instanceName() string {
+	return fmt.Sprintf("%s-%s", g.cluster.InfraID(), awsResourceName)
+}
+
+// firewallName is the name of the firewall rule allowing IAP to reach the bastion VM
+func (g *gcpJumphostConfig) firewallName() string {
+	return fmt.Sprintf("%s-iap", g.instanceName())
+}
+
+func (g *gcpJumphostConfig) runCreate(ctx context.Context) error {
+	if err := g.createIapFirewall(ctx); err != nil {
+		return err
+	}
+
+	if err := g.createBastion(ctx); err != nil {
+		return err
+	}
+
+	log.Println(g.assembleNextSteps())
+	return nil
+}
+
+func (g *gcpJumphostConfig) runDelete(ctx context.Context) error {
+	log.Printf("deleting bastion VM: %s", g.instanceName())
+	op, err := g.instancesClient.Delete(ctx, &computepb.DeleteInstanceRequest{
+		Project:  g.project,
+		Zone:     g.zone,
+		Instance: g.instanceName(),
+	})
+	if err := waitForGcpOperation(ctx, op, err); err != nil {
+		if !isGcpNotFound(err) {
+			return fmt.Errorf("failed to delete bastion VM: %w", err)
+		}
+		log.Println("no bastion VM found to delete")
+	}
+
+	log.Printf("deleting firewall rule: %s", g.firewallName())
+	op, err = g.firewallsClient.Delete(ctx, &computepb.DeleteFirewallRequest{
+		Project:  g.networkProject,
+		Firewall: g.firewallName(),
+	})
+	if err := waitForGcpOperation(ctx, op, err); err != nil {
+		if !isGcpNotFound(err) {
+			return fmt.Errorf("failed to delete firewall rule: %w", err)
+		}
+		log.Println("no firewall rule found to delete")
+	}
+
+	return nil
+}
+
+// createIapFirewall creates a firewall rule allowing SSH from IAP to VMs with the bastion's network tag
+func (g *gcpJumphostConfig) createIapFirewall(ctx context.Context) error {
+	op, err := g.firewallsClient.Insert(ctx, &computepb.InsertFirewallRequest{
+		Project:          g.networkProject,
+		FirewallResource: g.iapFirewall(),
+	})
+	if err := waitForGcpOperation(ctx, op, err); err != nil {
+		if isGcpAlreadyExists(err) {
+			log.Printf("firewall rule %s already exists", g.firewallName())
+			return nil
+		}
+		return fmt.Errorf("failed to create firewall rule: %w", err)
+	}
+	log.Printf("created firewall rule: %s", g.firewallName())
+
+	return nil
+}
+
+// createBastion creates the bastion VM and waits for it to be created
+func (g *gcpJumphostConfig) createBastion(ctx context.Context) error {
+	op, err := g.instancesClient.Insert(ctx, &computepb.InsertInstanceRequest{
+		Project:          g.project,
+		Zone:             g.zone,
+		InstanceResource: g.bastionInstance(),
+	})
+	if err := waitForGcpOperation(ctx, op, err); err != nil {
+		return fmt.Errorf("failed to create bastion VM: %w", err)
+	}
+	log.Printf("created bastion VM: %s", g.instanceName())
+
+	return nil
+}
+
+func (g *gcpJumphostConfig) iapFirewall() *computepb.Firewall {
+	return &computepb.Firewall{
+		Name:         proto.String(g.firewallName()),
+		Description:  proto.String(fmt.Sprintf("Allows SSH from IAP to %s", g.instanceName())),
+		Network:      proto.String(fmt.Sprintf("projects/%s/global/networks/%s", g.networkProject, g.network)),
+		Direction:    proto.String(computepb.Firewall_INGRESS.String()),
+		SourceRanges: []string{iapSourceRange},
+		TargetTags:   []string{g.instanceName()},
+		Allowed: []*computepb.Allowed{
+			{
+				IPProtocol: proto.String("tcp"),
+				Ports:      []string{"22"},
+			},
+		},
+	}
+}
+
+func (g *gcpJumphostConfig) bastionInstance() *computepb.Instance {
+	instance := &computepb.Instance{
+		Name:        proto.String(g.instanceName()),
+		MachineType: proto.String(fmt.Sprintf("zones/%s/machineTypes/%s", g.zone, gcpMachineType)),
+		Disks: []*computepb.AttachedDisk{
+			{
+				AutoDelete: proto.Bool(true),
+				Boot:       proto.Bool(true),
+				InitializeParams: &computepb.AttachedDiskInitializeParams{
+					SourceImage: proto.String(gcpImage),
+				},
+			},
+		},
+		// No access configs, so the bastion gets no external IP and is only reachable through IAP
+		NetworkInterfaces: []*computepb.NetworkInterface{
+			{
+				Subnetwork: proto.String(fmt.Sprintf("projects/%s/regions/%s/subnetworks/%s", g.networkProject, g.cluster.Region().ID(), g.subnetwork)),
+			},
+		},
+		Tags: &computepb.Tags{Items: []string{g.instanceName()}},
+		Labels: map[string]string{
+			"red-hat-managed": "true",
+			"owner":           gcpLabelValue(g.owner),
+		},
+		Metadata: &computepb.Metadata{
+			Items: []*computepb.Items{
+				{Key: proto.String("enable-oslogin"), Value: proto.String("TRUE")},
+			},
+		},
+	}
+
+	if g.ttl > 0 {
+		// GCP deletes the VM itself once it has run for the TTL, so it is cleaned up even if we forget to
+		instance.Scheduling = &computepb.Scheduling{
+			MaxRunDuration:            &computepb.Duration{Seconds: proto.Int64(int64(g.ttl.Seconds()))},
+			InstanceTerminationAction: proto.String(computepb.Scheduling_DELETE.String()),
+		}
+	}
+
+	return instance
+}
+
+func (g *gcpJumphostConfig) assembleNextSteps() string {
+	return fmt.Sprintf("gcloud compute ssh %s --project %s --zone %s --tunnel-through-iap", g.instanceName(), g.project, g.zone)
+}
+
+// gcpLabelValue converts s into a valid GCP label value
+func gcpLabelValue(s string) string {
+	value := gcpLabelValueInvalidChars.ReplaceAllString(strings.ToLower(s), "_")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+
+	return value
+}
+
+// waitForGcpOperation waits for an operation returned alongside err to complete, returning the first error
+func waitForGcpOperation(ctx context.Context, op *compute.Operation, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return op.Wait(ctx)
+}
+
+func isGcpNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+func isGcpAlreadyExists(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
+}
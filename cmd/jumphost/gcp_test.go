@@ -0,0 +1,63 @@
+package jumphost
+
+import (
+	"testing"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGcpJumphostConfig(t *testing.T, network *cmv1.GCPNetworkBuilder) *gcpJumphostConfig {
+	builder := cmv1.NewCluster().
+		InfraID("infra-id").
+		Region(cmv1.NewCloudRegion().ID("us-east1")).
+		Nodes(cmv1.NewClusterNodes().AvailabilityZones("us-east1-b"))
+	if network != nil {
+		builder = builder.GCPNetwork(network)
+	}
+	cluster, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := &gcpJumphostConfig{cluster: cluster, project: "cluster-project", owner: "Test.User@example.com"}
+	g.setNetwork()
+	return g
+}
+
+func TestGcpSetNetwork(t *testing.T) {
+	g := newTestGcpJumphostConfig(t, nil)
+	assert.Equal(t, "us-east1-b", g.zone)
+	assert.Equal(t, "cluster-project", g.networkProject)
+	assert.Equal(t, "infra-id-network", g.network)
+	assert.Equal(t, "infra-id-worker-subnet", g.subnetwork)
+
+	g = newTestGcpJumphostConfig(t, cmv1.NewGCPNetwork().VPCName("shared-vpc").VPCProjectID("host-project").ComputeSubnet("compute"))
+	assert.Equal(t, "host-project", g.networkProject)
+	assert.Equal(t, "shared-vpc", g.network)
+	assert.Equal(t, "compute", g.subnetwork)
+}
+
+func TestGcpBastionInstance(t *testing.T) {
+	g := newTestGcpJumphostConfig(t, nil)
+
+	instance := g.bastionInstance()
+	assert.Equal(t, "infra-id-red-hat-sre-jumphost", instance.GetName())
+	assert.Equal(t, "projects/cluster-project/regions/us-east1/subnetworks/infra-id-worker-subnet", instance.GetNetworkInterfaces()[0].GetSubnetwork())
+	assert.Empty(t, instance.GetNetworkInterfaces()[0].GetAccessConfigs())
+	assert.Equal(t, "test_user_example_com", instance.GetLabels()["owner"])
+	assert.Nil(t, instance.GetScheduling())
+
+	g.ttl = 2 * time.Hour
+	instance = g.bastionInstance()
+	assert.Equal(t, int64(7200), instance.GetScheduling().GetMaxRunDuration().GetSeconds())
+	assert.Equal(t, "DELETE", instance.GetScheduling().GetInstanceTerminationAction())
+
+	firewall := g.iapFirewall()
+	assert.Equal(t, "infra-id-red-hat-sre-jumphost-iap", firewall.GetName())
+	assert.Equal(t, []string{iapSourceRange}, firewall.GetSourceRanges())
+	assert.Equal(t, instance.GetTags().GetItems(), firewall.GetTargetTags())
+
+	assert.Equal(t, "gcloud compute ssh infra-id-red-hat-sre-jumphost --project cluster-project --zone us-east1-b --tunnel-through-iap", g.assembleNextSteps())
+}
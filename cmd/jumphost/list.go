@@ -0,0 +1,147 @@
+package jumphost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/spf13/cobra"
+)
+
+func newCmdListJumphost() *cobra.Command {
+	var clusterId string
+
+	list := &cobra.Command{
+		Use:          "list",
+		SilenceUsage: true,
+		Short:        "List jumphosts created by `osdctl jumphost create`",
+		Long: `List jumphosts created by "osdctl jumphost create"
+
+  Lists all jumphosts which have not been terminated yet in the current AWS account
+  and region, along with who created them, how old they are, and when they expire.
+  With --cluster-id, only jumphosts created for that cluster are listed.
+
+  Requires these permissions:
+  {
+    "Version": "2012-10-17",
+    "Statement": [
+      {
+        "Action": [
+          "ec2:DescribeInstances"
+        ],
+        "Effect": "Allow",
+        "Resource": "*"
+      }
+    ]
+  }`,
+		Example: `
+  # List jumphosts, e.g. to find ones that were forgotten
+  osdctl jumphost list`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ocm, cluster, err := resolveCluster(clusterId)
+			if err != nil {
+				return err
+			}
+			defer ocm.Close()
+
+			if isGcpCluster(cluster) {
+				return errors.New("listing GCP jumphosts is not supported, use \"gcloud compute instances list\" instead")
+			}
+
+			j, err := initJumphostConfig(context.TODO(), ocm, cluster, "")
+			if err != nil {
+				return err
+			}
+
+			instances, err := j.listJumphosts(context.TODO())
+			if err != nil {
+				return err
+			}
+
+			return printJumphosts(os.Stdout, instances, time.Now())
+		},
+	}
+
+	list.Flags().StringVarP(&clusterId, "cluster-id", "c", "", "OCM internal/external cluster id to list jumphosts for, using AWS credentials from backplane-api")
+
+	return list
+}
+
+// listJumphosts returns all jumphost EC2 instances matching the expected tag filter which have not been terminated
+func (j *jumphostConfig) listJumphosts(ctx context.Context) ([]types.Instance, error) {
+	input := &ec2.DescribeInstancesInput{
+		Filters: append(generateTagFilters(j.tags), types.Filter{
+			Name:   aws.String("instance-state-name"),
+			Values: []string{"pending", "running", "stopping", "stopped"},
+		}),
+	}
+
+	var instances []types.Instance
+	for {
+		resp, err := j.awsClient.DescribeInstances(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe EC2 instances: %w", err)
+		}
+
+		for _, reservation := range resp.Reservations {
+			instances = append(instances, reservation.Instances...)
+		}
+
+		if resp.NextToken == nil {
+			return instances, nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// printJumphosts prints a table of jumphost EC2 instances, with ages and expiries relative to now
+func printJumphosts(w io.Writer, instances []types.Instance, now time.Time) error {
+	if len(instances) == 0 {
+		_, err := fmt.Fprintln(w, "no jumphosts found")
+		return err
+	}
+
+	table := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	table.AddRow([]string{"INSTANCE ID", "STATE", "OWNER", "AGE", "EXPIRES", "PUBLIC IP", "PRIVATE IP", "VPC"})
+	for _, instance := range instances {
+		age := "unknown"
+		if instance.LaunchTime != nil {
+			age = now.Sub(*instance.LaunchTime).Round(time.Minute).String()
+		}
+
+		expires := "never"
+		if expiresAt, ok := jumphostExpiry(instance); ok {
+			if expiresAt.After(now) {
+				expires = fmt.Sprintf("in %s", expiresAt.Sub(now).Round(time.Minute))
+			} else {
+				expires = "expired"
+			}
+		}
+
+		state := ""
+		if instance.State != nil {
+			state = string(instance.State.Name)
+		}
+
+		table.AddRow([]string{
+			aws.ToString(instance.InstanceId),
+			state,
+			tagValue(instance.Tags, ownerTagKey),
+			age,
+			expires,
+			aws.ToString(instance.PublicIpAddress),
+			aws.ToString(instance.PrivateIpAddress),
+			aws.ToString(instance.VpcId),
+		})
+	}
+
+	return table.Flush()
+}
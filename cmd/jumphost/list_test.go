@@ -0,0 +1,72 @@
+package jumphost
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListJumphosts(t *testing.T) {
+	ctx := context.Background()
+	mockAWS := new(mockAWSClient)
+	mockAWS.On("DescribeInstances", ctx, mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool { return input.NextToken == nil })).
+		Return(&ec2.DescribeInstancesOutput{
+			Reservations: []types.Reservation{{Instances: []types.Instance{{InstanceId: aws.String("i-1")}}}},
+			NextToken:    aws.String("next"),
+		}, nil).Once()
+	mockAWS.On("DescribeInstances", ctx, mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool { return input.NextToken != nil })).
+		Return(&ec2.DescribeInstancesOutput{
+			Reservations: []types.Reservation{{Instances: []types.Instance{{InstanceId: aws.String("i-2")}}}},
+		}, nil).Once()
+
+	j := &jumphostConfig{awsClient: mockAWS, tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String(awsResourceName)}}}
+	instances, err := j.listJumphosts(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, instances, 2)
+	mockAWS.AssertExpectations(t)
+
+	mockAWS = new(mockAWSClient)
+	mockAWS.On("DescribeInstances", ctx, mock.Anything).Return((*ec2.DescribeInstancesOutput)(nil), fmt.Errorf("describe_error"))
+	j.awsClient = mockAWS
+	_, err = j.listJumphosts(ctx)
+	assert.ErrorContains(t, err, "describe_error")
+}
+
+func TestPrintJumphosts(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	instances := []types.Instance{
+		{
+			InstanceId:      aws.String("i-live"),
+			State:           &types.InstanceState{Name: types.InstanceStateNameRunning},
+			LaunchTime:      aws.Time(now.Add(-time.Hour)),
+			PublicIpAddress: aws.String("1.2.3.4"),
+			Tags: []types.Tag{
+				{Key: aws.String(ownerTagKey), Value: aws.String("test-user")},
+				{Key: aws.String(expiresAtTagKey), Value: aws.String(now.Add(2 * time.Hour).Format(time.RFC3339))},
+			},
+		},
+		{
+			InstanceId: aws.String("i-expired"),
+			Tags:       []types.Tag{{Key: aws.String(expiresAtTagKey), Value: aws.String(now.Add(-time.Hour).Format(time.RFC3339))}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, printJumphosts(buf, instances, now))
+	assert.Contains(t, buf.String(), "test-user")
+	assert.Contains(t, buf.String(), "1h0m0s")
+	assert.Contains(t, buf.String(), "in 2h0m0s")
+	assert.Contains(t, buf.String(), "expired")
+
+	buf.Reset()
+	assert.NoError(t, printJumphosts(buf, nil, now))
+	assert.Equal(t, "no jumphosts found\n", buf.String())
+}
@@ -0,0 +1,371 @@
+package jumphost
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const (
+	// temporarySubnetPrefixLength is the size of the subnet created when a cluster's VPC has no public subnet, which
+	// only ever needs to hold a single jumphost
+	temporarySubnetPrefixLength = 28
+	// temporarySubnetTagKey marks the subnet and route table created by createTemporaryPublicSubnet, so that only those
+	// are deleted along with the jumphost
+	temporarySubnetTagKey = "red-hat-sre-jumphost/temporary-subnet"
+)
+
+// findOrCreatePublicSubnet returns a public subnet in the cluster's VPC to create a jumphost in, creating a temporary
+// one if the VPC has no public subnets
+func (j *jumphostConfig) findOrCreatePublicSubnet(ctx context.Context) (string, error) {
+	vpcId, subnets, routeTables, err := j.describeClusterVpcSubnets(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if public := publicSubnetIds(subnets, routeTables); len(public) > 0 {
+		log.Printf("found public subnet %s in vpc %s", public[0], vpcId)
+		return public[0], nil
+	}
+
+	log.Printf("no public subnets found in vpc %s, creating a temporary one", vpcId)
+	return j.createTemporaryPublicSubnet(ctx, vpcId, subnets)
+}
+
+// findPrivateSubnet returns a private subnet in the cluster's VPC to create a Session Manager jumphost in. Without a
+// public IP, the jumphost reaches the Session Manager endpoints through the subnet's NAT gateway.
+func (j *jumphostConfig) findPrivateSubnet(ctx context.Context) (string, error) {
+	vpcId, subnets, routeTables, err := j.describeClusterVpcSubnets(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	private := natSubnetIds(subnets, routeTables)
+	if len(private) == 0 {
+		return "", fmt.Errorf("no private subnets with a NAT gateway found in vpc %s, specify a subnet the jumphost can reach Session Manager from with --subnet-id", vpcId)
+	}
+
+	log.Printf("found private subnet %s in vpc %s", private[0], vpcId)
+	return private[0], nil
+}
+
+// describeClusterVpcSubnets returns the id of the cluster's VPC along with its subnets and route tables
+func (j *jumphostConfig) describeClusterVpcSubnets(ctx context.Context) (string, []types.Subnet, []types.RouteTable, error) {
+	vpcId, err := j.findClusterVpcId(ctx)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	subnetsResp, err := j.awsClient.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcId}}},
+	})
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to describe subnets in vpc %s: %w", vpcId, err)
+	}
+
+	routeTablesResp, err := j.awsClient.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []types.Filter{{Name: aws.String("vpc-id"), Values: []string{vpcId}}},
+	})
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to describe route tables in vpc %s: %w", vpcId, err)
+	}
+
+	return vpcId, subnetsResp.Subnets, routeTablesResp.RouteTables, nil
+}
+
+// findClusterVpcId returns the id of the VPC the cluster is installed in, determined by the cluster's BYOVPC subnets
+// or the subnets tagged with the cluster's infra id
+func (j *jumphostConfig) findClusterVpcId(ctx context.Context) (string, error) {
+	if j.cluster == nil {
+		return "", errors.New("could not determine VPC; a subnet id or cluster id must be provided")
+	}
+
+	input := &ec2.DescribeSubnetsInput{}
+	if subnetIds := j.cluster.AWS().SubnetIDs(); len(subnetIds) > 0 {
+		input.SubnetIds = subnetIds
+	} else {
+		input.Filters = []types.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []string{fmt.Sprintf("kubernetes.io/cluster/%s", j.cluster.InfraID())},
+			},
+		}
+	}
+
+	resp, err := j.awsClient.DescribeSubnets(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe subnets for cluster %s: %w", j.cluster.ID(), err)
+	}
+
+	if len(resp.Subnets) == 0 {
+		return "", fmt.Errorf("found 0 subnets belonging to cluster %s", j.cluster.ID())
+	}
+
+	return *resp.Subnets[0].VpcId, nil
+}
+
+// jumphostVpcId returns the id of the VPC jumphost resources are searched for in, preferring the provided subnet's
+// VPC and falling back to the cluster's VPC
+func (j *jumphostConfig) jumphostVpcId(ctx context.Context) (string, error) {
+	if j.subnetId == "" && j.cluster != nil {
+		return j.findClusterVpcId(ctx)
+	}
+
+	return j.findVpcId(ctx)
+}
+
+// publicSubnetIds returns the sorted ids of subnets whose route table, either explicitly associated or the VPC's main
+// route table, has a default route to an internet gateway
+func publicSubnetIds(subnets []types.Subnet, routeTables []types.RouteTable) []string {
+	return subnetIdsRoutedBy(subnets, routeTables, hasInternetGatewayDefaultRoute)
+}
+
+// natSubnetIds returns the sorted ids of subnets whose route table has a default route to a NAT gateway
+func natSubnetIds(subnets []types.Subnet, routeTables []types.RouteTable) []string {
+	return subnetIdsRoutedBy(subnets, routeTables, hasNatGatewayDefaultRoute)
+}
+
+// subnetIdsRoutedBy returns the sorted ids of subnets whose route table, either explicitly associated or the VPC's main
+// route table, matches
+func subnetIdsRoutedBy(subnets []types.Subnet, routeTables []types.RouteTable, matches func(types.RouteTable) bool) []string {
+	var mainRouteTable *types.RouteTable
+	explicit := map[string]*types.RouteTable{}
+	for i, rt := range routeTables {
+		for _, assoc := range rt.Associations {
+			if aws.ToBool(assoc.Main) {
+				mainRouteTable = &routeTables[i]
+			}
+			if assoc.SubnetId != nil {
+				explicit[*assoc.SubnetId] = &routeTables[i]
+			}
+		}
+	}
+
+	var ids []string
+	for _, subnet := range subnets {
+		rt, ok := explicit[aws.ToString(subnet.SubnetId)]
+		if !ok {
+			rt = mainRouteTable
+		}
+
+		if rt != nil && matches(*rt) {
+			ids = append(ids, *subnet.SubnetId)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// hasInternetGatewayDefaultRoute returns true if the route table has a route to 0.0.0.0/0 through an internet gateway
+func hasInternetGatewayDefaultRoute(rt types.RouteTable) bool {
+	for _, route := range rt.Routes {
+		if route.GatewayId != nil && strings.HasPrefix(*route.GatewayId, "igw-") {
+			// Some routes don't use CIDR blocks as targets, so this needs to be checked
+			if aws.ToString(route.DestinationCidrBlock) == "0.0.0.0/0" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasNatGatewayDefaultRoute returns true if the route table has a route to 0.0.0.0/0 through a NAT gateway
+func hasNatGatewayDefaultRoute(rt types.RouteTable) bool {
+	for _, route := range rt.Routes {
+		if route.NatGatewayId != nil && aws.ToString(route.DestinationCidrBlock) == "0.0.0.0/0" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// createTemporaryPublicSubnet creates a small subnet in unused VPC address space, along with a route table routing it
+// through the VPC's internet gateway. Both are tagged so that they are cleaned up by "osdctl jumphost delete".
+func (j *jumphostConfig) createTemporaryPublicSubnet(ctx context.Context, vpcId string, subnets []types.Subnet) (string, error) {
+	igwResp, err := j.awsClient.DescribeInternetGateways(ctx, &ec2.DescribeInternetGatewaysInput{
+		Filters: []types.Filter{{Name: aws.String("attachment.vpc-id"), Values: []string{vpcId}}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe internet gateways in vpc %s: %w", vpcId, err)
+	}
+	if len(igwResp.InternetGateways) == 0 {
+		return "", fmt.Errorf("vpc %s has no internet gateway, a public subnet can't be created - please provide --subnet-id", vpcId)
+	}
+
+	vpcResp, err := j.awsClient.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{vpcId}})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe vpc %s: %w", vpcId, err)
+	}
+	if len(vpcResp.Vpcs) == 0 {
+		return "", fmt.Errorf("found 0 vpcs matching %s", vpcId)
+	}
+
+	var used []string
+	for _, subnet := range subnets {
+		used = append(used, aws.ToString(subnet.CidrBlock))
+	}
+
+	var cidr string
+	for _, assoc := range vpcResp.Vpcs[0].CidrBlockAssociationSet {
+		if cidr, err = findFreeSubnetCidr(aws.ToString(assoc.CidrBlock), used, temporarySubnetPrefixLength); err == nil {
+			break
+		}
+	}
+	if cidr == "" {
+		return "", fmt.Errorf("failed to find free address space in vpc %s: %w", vpcId, err)
+	}
+
+	subnetResp, err := j.awsClient.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+		CidrBlock: aws.String(cidr),
+		VpcId:     aws.String(vpcId),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeSubnet,
+				Tags:         j.temporarySubnetTags(),
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary subnet: %w", err)
+	}
+	subnetId := *subnetResp.Subnet.SubnetId
+	log.Printf("created temporary subnet: %s (%s)", subnetId, cidr)
+
+	rtResp, err := j.awsClient.CreateRouteTable(ctx, &ec2.CreateRouteTableInput{
+		VpcId: aws.String(vpcId),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeRouteTable,
+				Tags:         j.temporarySubnetTags(),
+			},
+		},
+	})
+	if err != nil {
+		return subnetId, fmt.Errorf("failed to create route table for temporary subnet: %w", err)
+	}
+	routeTableId := *rtResp.RouteTable.RouteTableId
+	log.Printf("created route table: %s", routeTableId)
+
+	if _, err := j.awsClient.CreateRoute(ctx, &ec2.CreateRouteInput{
+		RouteTableId:         aws.String(routeTableId),
+		DestinationCidrBlock: aws.String("0.0.0.0/0"),
+		GatewayId:            igwResp.InternetGateways[0].InternetGatewayId,
+	}); err != nil {
+		return subnetId, fmt.Errorf("failed to create default route in %s: %w", routeTableId, err)
+	}
+
+	if _, err := j.awsClient.AssociateRouteTable(ctx, &ec2.AssociateRouteTableInput{
+		RouteTableId: aws.String(routeTableId),
+		SubnetId:     aws.String(subnetId),
+	}); err != nil {
+		return subnetId, fmt.Errorf("failed to associate route table %s with subnet %s: %w", routeTableId, subnetId, err)
+	}
+
+	return subnetId, nil
+}
+
+// findFreeSubnetCidr returns the last block of the given prefix length within vpcCidr that doesn't overlap with any of
+// the used CIDRs. Searching from the end of the range keeps clear of the blocks the installer allocates from the start.
+func findFreeSubnetCidr(vpcCidr string, used []string, prefixLength int) (string, error) {
+	_, vpcNet, err := net.ParseCIDR(vpcCidr)
+	if err != nil {
+		return "", fmt.Errorf("invalid vpc cidr %q: %w", vpcCidr, err)
+	}
+
+	vpcOnes, bits := vpcNet.Mask.Size()
+	if bits != 32 {
+		return "", fmt.Errorf("only IPv4 vpc cidrs are supported, got %s", vpcCidr)
+	}
+	if prefixLength < vpcOnes || prefixLength > 32 {
+		return "", fmt.Errorf("a /%d subnet doesn't fit in %s", prefixLength, vpcCidr)
+	}
+
+	var usedNets []*net.IPNet
+	for _, cidr := range used {
+		if _, n, err := net.ParseCIDR(cidr); err == nil {
+			usedNets = append(usedNets, n)
+		}
+	}
+
+	base := binary.BigEndian.Uint32(vpcNet.IP.To4())
+	size := uint32(1) << (32 - prefixLength)
+	for i := int64(1)<<(prefixLength-vpcOnes) - 1; i >= 0; i-- {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, base+uint32(i)*size)
+		candidate := &net.IPNet{IP: ip, Mask: net.CIDRMask(prefixLength, 32)}
+
+		overlaps := false
+		for _, n := range usedNets {
+			if n.Contains(candidate.IP) || candidate.Contains(n.IP) {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			return candidate.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("no free /%d block in %s", prefixLength, vpcCidr)
+}
+
+// temporarySubnetTags returns the tags of the subnet and route table created by createTemporaryPublicSubnet
+func (j *jumphostConfig) temporarySubnetTags() []types.Tag {
+	tags := make([]types.Tag, 0, len(j.tags)+1)
+	tags = append(tags, j.tags...)
+	return append(tags, types.Tag{
+		Key:   aws.String(temporarySubnetTagKey),
+		Value: aws.String("true"),
+	})
+}
+
+// deleteTemporarySubnet searches for the subnets and route tables created by createTemporaryPublicSubnet within the
+// provided VPC by the expected tag filter and deletes them
+func (j *jumphostConfig) deleteTemporarySubnet(ctx context.Context, vpcId string) error {
+	filters := append(generateTagFilters(j.temporarySubnetTags()), types.Filter{
+		Name:   aws.String("vpc-id"),
+		Values: []string{vpcId},
+	})
+
+	subnetsResp, err := j.awsClient.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+		Filters: filters,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe subnets: %w", err)
+	}
+
+	for _, subnet := range subnetsResp.Subnets {
+		log.Printf("deleting temporary subnet: %s", *subnet.SubnetId)
+		if _, err := j.awsClient.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{SubnetId: subnet.SubnetId}); err != nil {
+			return fmt.Errorf("failed to delete subnet: %w", err)
+		}
+	}
+
+	routeTablesResp, err := j.awsClient.DescribeRouteTables(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: filters,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe route tables: %w", err)
+	}
+
+	for _, rt := range routeTablesResp.RouteTables {
+		log.Printf("deleting route table: %s", *rt.RouteTableId)
+		if _, err := j.awsClient.DeleteRouteTable(ctx, &ec2.DeleteRouteTableInput{RouteTableId: rt.RouteTableId}); err != nil {
+			return fmt.Errorf("failed to delete route table: %w", err)
+		}
+	}
+
+	return nil
+}
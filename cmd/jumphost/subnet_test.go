@@ -0,0 +1,289 @@
+package jumphost
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *mockAWSClient) DescribeRouteTables(ctx context.Context, params *ec2.DescribeRouteTablesInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeRouteTablesOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.DescribeRouteTablesOutput), args.Error(1)
+}
+
+func (m *mockAWSClient) DescribeInternetGateways(ctx context.Context, params *ec2.DescribeInternetGatewaysInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeInternetGatewaysOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.DescribeInternetGatewaysOutput), args.Error(1)
+}
+
+func (m *mockAWSClient) DescribeVpcs(ctx context.Context, params *ec2.DescribeVpcsInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.DescribeVpcsOutput), args.Error(1)
+}
+
+func (m *mockAWSClient) CreateSubnet(ctx context.Context, params *ec2.CreateSubnetInput, optFns ...func(options *ec2.Options)) (*ec2.CreateSubnetOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.CreateSubnetOutput), args.Error(1)
+}
+
+func (m *mockAWSClient) CreateRouteTable(ctx context.Context, params *ec2.CreateRouteTableInput, optFns ...func(options *ec2.Options)) (*ec2.CreateRouteTableOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.CreateRouteTableOutput), args.Error(1)
+}
+
+func (m *mockAWSClient) CreateRoute(ctx context.Context, params *ec2.CreateRouteInput, optFns ...func(options *ec2.Options)) (*ec2.CreateRouteOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.CreateRouteOutput), args.Error(1)
+}
+
+func (m *mockAWSClient) AssociateRouteTable(ctx context.Context, params *ec2.AssociateRouteTableInput, optFns ...func(options *ec2.Options)) (*ec2.AssociateRouteTableOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.AssociateRouteTableOutput), args.Error(1)
+}
+
+func (m *mockAWSClient) DeleteSubnet(ctx context.Context, params *ec2.DeleteSubnetInput, optFns ...func(options *ec2.Options)) (*ec2.DeleteSubnetOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.DeleteSubnetOutput), args.Error(1)
+}
+
+func (m *mockAWSClient) DeleteRouteTable(ctx context.Context, params *ec2.DeleteRouteTableInput, optFns ...func(options *ec2.Options)) (*ec2.DeleteRouteTableOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*ec2.DeleteRouteTableOutput), args.Error(1)
+}
+
+func TestPublicSubnetIds(t *testing.T) {
+	subnets := []types.Subnet{
+		{SubnetId: aws.String("subnet-private")},
+		{SubnetId: aws.String("subnet-public-b")},
+		{SubnetId: aws.String("subnet-public-a")},
+		{SubnetId: aws.String("subnet-main")},
+	}
+	igwRoute := types.Route{GatewayId: aws.String("igw-1"), DestinationCidrBlock: aws.String("0.0.0.0/0")}
+	natRoute := types.Route{NatGatewayId: aws.String("nat-1"), DestinationCidrBlock: aws.String("0.0.0.0/0")}
+
+	routeTables := []types.RouteTable{
+		{
+			Associations: []types.RouteTableAssociation{{SubnetId: aws.String("subnet-private")}},
+			Routes:       []types.Route{natRoute},
+		},
+		{
+			Associations: []types.RouteTableAssociation{{SubnetId: aws.String("subnet-public-a")}, {SubnetId: aws.String("subnet-public-b")}},
+			Routes:       []types.Route{igwRoute},
+		},
+		{
+			Associations: []types.RouteTableAssociation{{Main: aws.Bool(true)}},
+			Routes:       []types.Route{natRoute},
+		},
+	}
+	assert.Equal(t, []string{"subnet-public-a", "subnet-public-b"}, publicSubnetIds(subnets, routeTables))
+
+	// Subnets without an explicit association use the main route table
+	routeTables[2].Routes = []types.Route{igwRoute}
+	assert.Equal(t, []string{"subnet-main", "subnet-public-a", "subnet-public-b"}, publicSubnetIds(subnets, routeTables))
+
+	assert.Empty(t, publicSubnetIds(subnets, nil))
+}
+
+func TestNatSubnetIds(t *testing.T) {
+	subnets := []types.Subnet{
+		{SubnetId: aws.String("subnet-private")},
+		{SubnetId: aws.String("subnet-public")},
+		{SubnetId: aws.String("subnet-isolated")},
+	}
+	routeTables := []types.RouteTable{
+		{
+			Associations: []types.RouteTableAssociation{{SubnetId: aws.String("subnet-private")}},
+			Routes:       []types.Route{{NatGatewayId: aws.String("nat-1"), DestinationCidrBlock: aws.String("0.0.0.0/0")}},
+		},
+		{
+			Associations: []types.RouteTableAssociation{{SubnetId: aws.String("subnet-public")}},
+			Routes:       []types.Route{{GatewayId: aws.String("igw-1"), DestinationCidrBlock: aws.String("0.0.0.0/0")}},
+		},
+		{
+			Associations: []types.RouteTableAssociation{{Main: aws.Bool(true)}},
+			Routes:       []types.Route{{GatewayId: aws.String("local"), DestinationCidrBlock: aws.String("10.0.0.0/16")}},
+		},
+	}
+	assert.Equal(t, []string{"subnet-private"}, natSubnetIds(subnets, routeTables))
+}
+
+func TestFindFreeSubnetCidr(t *testing.T) {
+	tests := []struct {
+		name        string
+		vpcCidr     string
+		used        []string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "empty_vpc",
+			vpcCidr:  "10.0.0.0/16",
+			expected: "10.0.255.240/28",
+		},
+		{
+			name:     "skips_used_blocks",
+			vpcCidr:  "10.0.0.0/16",
+			used:     []string{"10.0.0.0/17", "10.0.255.224/27"},
+			expected: "10.0.255.208/28",
+		},
+		{
+			name:        "full_vpc",
+			vpcCidr:     "10.0.0.0/24",
+			used:        []string{"10.0.0.0/24"},
+			expectError: true,
+		},
+		{
+			name:        "invalid_cidr",
+			vpcCidr:     "not-a-cidr",
+			expectError: true,
+		},
+		{
+			name:        "ipv6",
+			vpcCidr:     "2600:1f18::/56",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cidr, err := findFreeSubnetCidr(tt.vpcCidr, tt.used, temporarySubnetPrefixLength)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, cidr)
+		})
+	}
+}
+
+func TestFindOrCreatePublicSubnet(t *testing.T) {
+	ctx := context.Background()
+	cluster, err := cmv1.NewCluster().ID("cluster-id").InfraID("infra-id").AWS(cmv1.NewAWS()).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	isClusterSubnets := mock.MatchedBy(func(input *ec2.DescribeSubnetsInput) bool {
+		return len(input.Filters) == 1 && *input.Filters[0].Name == "tag-key"
+	})
+	isVpcSubnets := mock.MatchedBy(func(input *ec2.DescribeSubnetsInput) bool {
+		return len(input.Filters) == 1 && *input.Filters[0].Name == "vpc-id"
+	})
+
+	t.Run("existing_public_subnet", func(t *testing.T) {
+		mockAWS := new(mockAWSClient)
+		mockAWS.On("DescribeSubnets", ctx, isClusterSubnets).Return(&ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{{SubnetId: aws.String("subnet-private"), VpcId: aws.String("vpc-1")}},
+		}, nil).Once()
+		mockAWS.On("DescribeSubnets", ctx, isVpcSubnets).Return(&ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{{SubnetId: aws.String("subnet-private")}, {SubnetId: aws.String("subnet-public")}},
+		}, nil).Once()
+		mockAWS.On("DescribeRouteTables", ctx, mock.Anything).Return(&ec2.DescribeRouteTablesOutput{
+			RouteTables: []types.RouteTable{{
+				Associations: []types.RouteTableAssociation{{SubnetId: aws.String("subnet-public")}},
+				Routes:       []types.Route{{GatewayId: aws.String("igw-1"), DestinationCidrBlock: aws.String("0.0.0.0/0")}},
+			}},
+		}, nil).Once()
+
+		j := &jumphostConfig{awsClient: mockAWS, cluster: cluster}
+		subnetId, err := j.findOrCreatePublicSubnet(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "subnet-public", subnetId)
+		mockAWS.AssertExpectations(t)
+	})
+
+	t.Run("creates_temporary_subnet", func(t *testing.T) {
+		mockAWS := new(mockAWSClient)
+		mockAWS.On("DescribeSubnets", ctx, isClusterSubnets).Return(&ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{{SubnetId: aws.String("subnet-private"), VpcId: aws.String("vpc-1")}},
+		}, nil).Once()
+		mockAWS.On("DescribeSubnets", ctx, isVpcSubnets).Return(&ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{{SubnetId: aws.String("subnet-private"), CidrBlock: aws.String("10.0.0.0/17")}},
+		}, nil).Once()
+		mockAWS.On("DescribeRouteTables", ctx, mock.Anything).Return(&ec2.DescribeRouteTablesOutput{}, nil).Once()
+		mockAWS.On("DescribeInternetGateways", ctx, mock.Anything).Return(&ec2.DescribeInternetGatewaysOutput{
+			InternetGateways: []types.InternetGateway{{InternetGatewayId: aws.String("igw-1")}},
+		}, nil).Once()
+		mockAWS.On("DescribeVpcs", ctx, mock.Anything).Return(&ec2.DescribeVpcsOutput{
+			Vpcs: []types.Vpc{{CidrBlockAssociationSet: []types.VpcCidrBlockAssociation{{CidrBlock: aws.String("10.0.0.0/16")}}}},
+		}, nil).Once()
+		mockAWS.On("CreateSubnet", ctx, mock.MatchedBy(func(input *ec2.CreateSubnetInput) bool {
+			return *input.CidrBlock == "10.0.255.240/28" && *input.VpcId == "vpc-1" &&
+				tagValue(input.TagSpecifications[0].Tags, temporarySubnetTagKey) == "true"
+		})).Return(&ec2.CreateSubnetOutput{Subnet: &types.Subnet{SubnetId: aws.String("subnet-temporary")}}, nil).Once()
+		mockAWS.On("CreateRouteTable", ctx, mock.Anything).Return(&ec2.CreateRouteTableOutput{
+			RouteTable: &types.RouteTable{RouteTableId: aws.String("rtb-1")},
+		}, nil).Once()
+		mockAWS.On("CreateRoute", ctx, mock.MatchedBy(func(input *ec2.CreateRouteInput) bool {
+			return *input.GatewayId == "igw-1" && *input.RouteTableId == "rtb-1"
+		})).Return(&ec2.CreateRouteOutput{}, nil).Once()
+		mockAWS.On("AssociateRouteTable", ctx, mock.Anything).Return(&ec2.AssociateRouteTableOutput{}, nil).Once()
+
+		j := &jumphostConfig{awsClient: mockAWS, cluster: cluster}
+		subnetId, err := j.findOrCreatePublicSubnet(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "subnet-temporary", subnetId)
+		mockAWS.AssertExpectations(t)
+	})
+
+	t.Run("no_internet_gateway", func(t *testing.T) {
+		mockAWS := new(mockAWSClient)
+		mockAWS.On("DescribeSubnets", ctx, isClusterSubnets).Return(&ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{{SubnetId: aws.String("subnet-private"), VpcId: aws.String("vpc-1")}},
+		}, nil).Once()
+		mockAWS.On("DescribeSubnets", ctx, isVpcSubnets).Return(&ec2.DescribeSubnetsOutput{}, nil).Once()
+		mockAWS.On("DescribeRouteTables", ctx, mock.Anything).Return(&ec2.DescribeRouteTablesOutput{}, nil).Once()
+		mockAWS.On("DescribeInternetGateways", ctx, mock.Anything).Return(&ec2.DescribeInternetGatewaysOutput{}, nil).Once()
+
+		j := &jumphostConfig{awsClient: mockAWS, cluster: cluster}
+		_, err := j.findOrCreatePublicSubnet(ctx)
+		assert.ErrorContains(t, err, "has no internet gateway")
+	})
+
+	t.Run("no_cluster", func(t *testing.T) {
+		j := &jumphostConfig{awsClient: new(mockAWSClient)}
+		_, err := j.findOrCreatePublicSubnet(ctx)
+		assert.Error(t, err)
+	})
+}
+
+// hasFilter returns whether the filters match the name with the value
+func hasFilter(filters []types.Filter, name, value string) bool {
+	for _, filter := range filters {
+		if aws.ToString(filter.Name) == name && len(filter.Values) == 1 && filter.Values[0] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDeleteTemporarySubnet(t *testing.T) {
+	ctx := context.Background()
+	isTemporaryInVpc := func(filters []types.Filter) bool {
+		return hasFilter(filters, "tag:"+temporarySubnetTagKey, "true") && hasFilter(filters, "vpc-id", "vpc-1")
+	}
+
+	mockAWS := new(mockAWSClient)
+	mockAWS.On("DescribeSubnets", ctx, mock.MatchedBy(func(input *ec2.DescribeSubnetsInput) bool {
+		return isTemporaryInVpc(input.Filters)
+	})).Return(&ec2.DescribeSubnetsOutput{
+		Subnets: []types.Subnet{{SubnetId: aws.String("subnet-temporary")}},
+	}, nil).Once()
+	mockAWS.On("DeleteSubnet", ctx, &ec2.DeleteSubnetInput{SubnetId: aws.String("subnet-temporary")}).Return(&ec2.DeleteSubnetOutput{}, nil).Once()
+	mockAWS.On("DescribeRouteTables", ctx, mock.MatchedBy(func(input *ec2.DescribeRouteTablesInput) bool {
+		return isTemporaryInVpc(input.Filters)
+	})).Return(&ec2.DescribeRouteTablesOutput{
+		RouteTables: []types.RouteTable{{RouteTableId: aws.String("rtb-temporary")}},
+	}, nil).Once()
+	mockAWS.On("DeleteRouteTable", ctx, &ec2.DeleteRouteTableInput{RouteTableId: aws.String("rtb-temporary")}).Return(&ec2.DeleteRouteTableOutput{}, nil).Once()
+
+	j := &jumphostConfig{awsClient: mockAWS, tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String(awsResourceName)}}}
+	assert.NoError(t, j.deleteTemporarySubnet(ctx, "vpc-1"))
+	mockAWS.AssertExpectations(t)
+}
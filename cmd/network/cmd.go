@@ -18,6 +18,8 @@ func NewCmdNetwork(streams genericclioptions.IOStreams, client *k8s.LazyClient)
 	}
 
 	netCmd.AddCommand(newCmdPacketCapture(streams, client))
+	netCmd.AddCommand(newCmdPcapSummary(streams))
+	netCmd.AddCommand(newCmdProbe(streams))
 	netCmd.AddCommand(NewCmdValidateEgress())
 	return netCmd
 }
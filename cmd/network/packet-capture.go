@@ -8,7 +8,9 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
@@ -36,6 +38,14 @@ const (
 	nodeLabelValue           = ""
 	packetCaptureDurationSec = 60
 	singlePod                = false
+	captureOutputPath        = "/tmp/capture-output"
+	hostnameLabelKey         = "kubernetes.io/hostname"
+	// tcpdump's -C flag counts file sizes in units of 1,000,000 bytes
+	tcpdumpFileSizeUnit = 1000000
+	// netnsPath is where CRI-O keeps the network namespaces of the pods of a node
+	netnsPath = "/var/run/netns"
+	// podVethInterface is the interface captured on for a single pod, resolved to the pod's veth by podVethCommand
+	podVethInterface = `"$POD_VETH"`
 )
 
 // newCmdPacketCapture implements the packet-capture command to run a packet capture
@@ -60,6 +70,15 @@ func newCmdPacketCapture(streams genericclioptions.IOStreams, client *k8s.LazyCl
 	packetCaptureCmd.Flags().StringVarP(&ops.nodeLabelValue, "node-label-value", "", nodeLabelValue, "Node label value")
 	packetCaptureCmd.Flags().BoolVarP(&ops.singlePod, "single-pod", "", singlePod, "toggle deployment as single pod (default: deploy a daemonset)")
 	packetCaptureCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usualy an OHSS or PD ticket)")
+	packetCaptureCmd.Flags().StringVar(&ops.filter, "filter", "", "BPF filter expression passed to tcpdump, e.g. 'tcp port 443 and host 10.0.0.1'")
+	packetCaptureCmd.Flags().StringVar(&ops.pod, "pod", "", "Only capture the traffic of the given pod, in the format <namespace>/<name>. Implies --single-pod on the pod's node")
+	packetCaptureCmd.Flags().StringSliceVar(&ops.nodes, "nodes", nil, "Names of the nodes to capture on, overrides --node-label-key and --node-label-value")
+	packetCaptureCmd.Flags().IntVar(&ops.fileSizeMB, "file-size", 0, "Rotate capture files after they reach this size in MB. Requires --files")
+	packetCaptureCmd.Flags().IntVar(&ops.files, "files", 0, "Number of capture files to keep in the ring buffer. Requires --file-size")
+	packetCaptureCmd.Flags().Int64Var(&ops.maxBytes, "max-bytes", 0, "Maximum number of bytes captured per node, 0 for no limit")
+	packetCaptureCmd.Flags().BoolVar(&ops.analyze, "analyze", false, "Print a summary of each capture file once it has been copied, see 'osdctl network pcap-summary'")
+	packetCaptureCmd.MarkFlagsMutuallyExclusive("pod", "nodes")
+	packetCaptureCmd.MarkFlagsRequiredTogether("file-size", "files")
 
 	ops.startTime = time.Now()
 	return packetCaptureCmd
@@ -75,6 +94,18 @@ type packetCaptureOptions struct {
 	singlePod        bool
 	captureInterface string
 	reason           string
+	filter           string
+	pod              string
+	nodes            []string
+	fileSizeMB       int
+	files            int
+	maxBytes         int64
+	analyze          bool
+
+	// targetNode, targetPodIP and targetHostNetwork are resolved from pod when capturing a single pod's traffic
+	targetNode        string
+	targetPodIP       string
+	targetHostNetwork bool
 
 	genericclioptions.IOStreams
 	kubeCli   *k8s.LazyClient
@@ -93,14 +124,87 @@ func (o *packetCaptureOptions) complete(cmd *cobra.Command, _ []string) error {
 		// This action requires elevation
 		o.kubeCli.Impersonate("backplane-cluster-admin", o.reason, fmt.Sprintf("Elevation required to capture network"))
 	}
+	return o.validate()
+}
+
+func (o *packetCaptureOptions) validate() error {
+	if o.pod != "" {
+		if parts := strings.Split(o.pod, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("--pod must be in the format <namespace>/<name>, got %q", o.pod)
+		}
+		if len(o.nodes) > 0 {
+			return fmt.Errorf("--pod and --nodes cannot be used together")
+		}
+	}
+
+	if o.singlePod && len(o.nodes) > 1 {
+		return fmt.Errorf("--single-pod can only capture on one node, got %d", len(o.nodes))
+	}
+
+	if o.fileSizeMB < 0 || o.files < 0 || o.maxBytes < 0 {
+		return fmt.Errorf("--file-size, --files, and --max-bytes cannot be negative")
+	}
+
+	if (o.fileSizeMB > 0) != (o.files > 0) {
+		return fmt.Errorf("--file-size and --files must be specified together")
+	}
+
+	if o.maxBytes > 0 && o.ringBuffer() && int64(o.fileSizeMB)*int64(o.files)*tcpdumpFileSizeUnit > o.maxBytes {
+		return fmt.Errorf("a ring buffer of %d files of %dMB exceeds --max-bytes %d", o.files, o.fileSizeMB, o.maxBytes)
+	}
+
 	return nil
 }
 
+// ringBuffer returns whether captures rotate through a fixed number of files rather than a single capture file
+func (o *packetCaptureOptions) ringBuffer() bool {
+	return o.files > 0 && o.fileSizeMB > 0
+}
+
 func (o *packetCaptureOptions) run() error {
+	if o.pod != "" {
+		log.Println("Resolving the node of the pod to capture")
+		if err := resolveTargetPod(o); err != nil {
+			return err
+		}
+		o.singlePod = true
+	}
+
+	var err error
 	if o.singlePod {
-		return o.runPod()
+		err = o.runPod()
+	} else {
+		err = o.runDaemonSet()
+	}
+	if err != nil || !o.analyze {
+		return err
 	}
-	return o.runDaemonSet()
+
+	return analyzeCapturedFiles(o)
+}
+
+// analyzeCapturedFiles prints a summary of every capture file copied by this run
+func analyzeCapturedFiles(o *packetCaptureOptions) error {
+	timestamp := o.startTime.UTC().Format("20060102T150405")
+	// Single capture files are named <node>-<timestamp>.pcap, ring buffers are copied to a <node>-<timestamp> directory
+	var files []string
+	for _, pattern := range []string{
+		filepath.Join(outputDir, "*-"+timestamp+".pcap"),
+		filepath.Join(outputDir, "*-"+timestamp, "capture.pcap*"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		files = append(files, matches...)
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no capture files found in %s to analyze", outputDir)
+	}
+
+	summaryOps := &pcapSummaryOptions{top: pcapSummaryTopDefault, output: "text", IOStreams: o.IOStreams}
+	return summaryOps.run(files)
 }
 
 func (o *packetCaptureOptions) runDaemonSet() error {
@@ -240,17 +344,8 @@ func desiredPacketCaptureDaemonSet(o *packetCaptureOptions, key types.Namespaced
 	ds.Namespace = key.Namespace
 
 	ds.Spec.Selector = ls
-	ds.Spec.Template.Spec.NodeSelector = map[string]string{
-		o.nodeLabelKey: o.nodeLabelValue,
-	}
 	ds.Spec.Template.Labels = ls.MatchLabels
-	ds.Spec.Template.Spec.Tolerations = []corev1.Toleration{
-		{
-			Effect:   "NoSchedule",
-			Key:      o.nodeLabelKey,
-			Operator: "Exists",
-		},
-	}
+	setCaptureScheduling(o, &ds.Spec.Template.Spec)
 	ds.Spec.Template.Spec.Volumes = []corev1.Volume{
 		{
 			Name: "capture-output",
@@ -265,7 +360,7 @@ func desiredPacketCaptureDaemonSet(o *packetCaptureOptions, key types.Namespaced
 			Name:            "init-capture",
 			Image:           packetCaptureImage,
 			ImagePullPolicy: corev1.PullIfNotPresent,
-			Command:         []string{"/bin/bash", "-c", "tcpdump -G " + strconv.Itoa(o.duration) + " -W 1 -w /tmp/capture-output/capture.pcap -i " + o.captureInterface + " -nn -s0; sync"},
+			Command:         []string{"/bin/bash", "-c", captureCommand(o)},
 			SecurityContext: &corev1.SecurityContext{Privileged: &t},
 			VolumeMounts: []corev1.VolumeMount{
 				{
@@ -296,13 +391,157 @@ func desiredPacketCaptureDaemonSet(o *packetCaptureOptions, key types.Namespaced
 	return ds
 }
 
+// setCaptureScheduling places capture pods on the pod's node when capturing a single pod, on the named nodes when
+// --nodes is specified, or otherwise on every node matching the node label
+func setCaptureScheduling(o *packetCaptureOptions, spec *corev1.PodSpec) {
+	switch {
+	case o.targetNode != "":
+		spec.NodeName = o.targetNode
+		spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	case len(o.nodes) > 0:
+		spec.Affinity = &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{
+						{
+							MatchExpressions: []corev1.NodeSelectorRequirement{
+								{
+									Key:      hostnameLabelKey,
+									Operator: corev1.NodeSelectorOpIn,
+									Values:   o.nodes,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	default:
+		spec.NodeSelector = map[string]string{
+			o.nodeLabelKey: o.nodeLabelValue,
+		}
+		spec.Tolerations = []corev1.Toleration{
+			{
+				Effect:   "NoSchedule",
+				Key:      o.nodeLabelKey,
+				Operator: "Exists",
+			},
+		}
+	}
+}
+
+// captureOnVeth returns whether the capture is on the target pod's veth, which only carries the pod's traffic
+func (o *packetCaptureOptions) captureOnVeth() bool {
+	return o.targetPodIP != "" && !o.targetHostNetwork
+}
+
+// captureFilter returns the BPF filter to pass to tcpdump, restricted to the target pod's IP if it is on the host
+// network
+func captureFilter(o *packetCaptureOptions) string {
+	switch {
+	case o.captureOnVeth():
+		return o.filter
+	case o.targetPodIP != "" && o.filter != "":
+		return fmt.Sprintf("host %s and (%s)", o.targetPodIP, o.filter)
+	case o.targetPodIP != "":
+		return "host " + o.targetPodIP
+	default:
+		return o.filter
+	}
+}
+
+// captureCommand returns the shell command run by the init-capture container.
+// By default a single file is captured for the duration, a ring buffer rotates through --files files of --file-size MB
+// and --max-bytes truncates a single capture file at the given size.
+func captureCommand(o *packetCaptureOptions) string {
+	filter := ""
+	if f := captureFilter(o); f != "" {
+		filter = " " + shellQuote(f)
+	}
+	duration := strconv.Itoa(o.duration)
+	captureFile := captureOutputPath + "/capture.pcap"
+	iface := o.captureInterface
+	if iface == "any" {
+		// tcpdump 4.99 and later default to LINUX_SLL2 on the "any" interface, which fewer tools than LINUX_SLL decode
+		iface += " -y LINUX_SLL"
+	}
+	prefix := ""
+	if o.captureOnVeth() {
+		prefix = podVethCommand(o.targetPodIP) + "; "
+	}
+
+	switch {
+	case o.ringBuffer():
+		return prefix + "timeout " + duration + " tcpdump -C " + strconv.Itoa(o.fileSizeMB) + " -W " + strconv.Itoa(o.files) +
+			" -w " + captureFile + " -i " + iface + " -nn -s0" + filter + "; sync"
+	case o.maxBytes > 0:
+		return prefix + "timeout " + duration + " tcpdump -U -w - -i " + iface + " -nn -s0" + filter +
+			" | head -c " + strconv.FormatInt(o.maxBytes, 10) + " > " + captureFile + "; sync"
+	default:
+		return prefix + "tcpdump -G " + duration + " -W 1 -w " + captureFile + " -i " + iface + " -nn -s0" + filter + "; sync"
+	}
+}
+
+// podVethCommand returns the shell commands setting POD_VETH to the host side of the veth of the pod with the given
+// IP. The pod's network namespace is the one whose eth0 has the IP, and `ip -o link` shows the ifindex of the veth
+// peering with eth0 after its @if, as in /sys/class/net/eth0/iflink.
+func podVethCommand(podIP string) string {
+	return `for NETNS in ` + netnsPath + `/*; do ` +
+		`if nsenter --net="$NETNS" ip -o addr show dev eth0 2>/dev/null | grep -qF ` + shellQuote(" "+podIP+"/") + `; then ` +
+		`IFLINK=$(nsenter --net="$NETNS" ip -o link show dev eth0 | sed -n 's/^[0-9]*: eth0@if\([0-9]*\):.*/\1/p'); break; fi; done; ` +
+		`POD_VETH=$(ip -o link | sed -n "s/^${IFLINK:-none}: \([^@:]*\).*/\1/p"); ` +
+		`if [ -z "$POD_VETH" ]; then echo ` + shellQuote("failed to find the veth of pod "+podIP) + ` >&2; exit 1; fi; ` +
+		`echo "capturing on $POD_VETH"`
+}
+
+// shellQuote wraps s in single quotes so it is passed to bash as a single argument
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// resolveTargetPod looks up the node and IP of the pod to capture
+func resolveTargetPod(o *packetCaptureOptions) error {
+	parts := strings.SplitN(o.pod, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("--pod must be in the format <namespace>/<name>, got %q", o.pod)
+	}
+
+	pod := &corev1.Pod{}
+	if err := o.kubeCli.Get(context.TODO(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, pod); err != nil {
+		return fmt.Errorf("failed to get pod %s: %v", o.pod, err)
+	}
+
+	if pod.Spec.NodeName == "" {
+		return fmt.Errorf("pod %s is not scheduled to a node", o.pod)
+	}
+	if pod.Status.PodIP == "" {
+		return fmt.Errorf("pod %s has no IP assigned yet", o.pod)
+	}
+	if pod.Spec.HostNetwork {
+		log.Printf("Pod %s uses the host network, all traffic to and from %s on node %s will be captured\n", o.pod, pod.Status.PodIP, pod.Spec.NodeName)
+	}
+
+	o.targetNode = pod.Spec.NodeName
+	o.targetPodIP = pod.Status.PodIP
+	o.targetHostNetwork = pod.Spec.HostNetwork
+	log.Printf("Capturing traffic of pod %s (%s) on node %s\n", o.pod, o.targetPodIP, o.targetNode)
+	return nil
+}
+
 func copyFilesFromPod(o *packetCaptureOptions, pod *corev1.Pod) error {
 	err := os.MkdirAll(outputDir, 0750)
 	if err != nil {
 		return err
 	}
-	fileName := fmt.Sprintf("%s-%s.pcap", pod.Spec.NodeName, o.startTime.UTC().Format("20060102T150405"))
-	cmd := exec.Command("oc", "cp", pod.Namespace+"/"+pod.Name+":/tmp/capture-output/capture.pcap", outputDir+"/"+fileName, "--as", "backplane-cluster-admin") //#nosec G204 -- Subprocess launched with a potential tainted input or cmd arguments
+	src := pod.Namespace + "/" + pod.Name + ":" + captureOutputPath + "/capture.pcap"
+	dst := outputDir + "/" + fmt.Sprintf("%s-%s.pcap", pod.Spec.NodeName, o.startTime.UTC().Format("20060102T150405"))
+	if o.ringBuffer() {
+		// tcpdump numbers each file of the ring buffer, so copy the whole directory
+		src = pod.Namespace + "/" + pod.Name + ":" + captureOutputPath
+		dst = outputDir + "/" + fmt.Sprintf("%s-%s", pod.Spec.NodeName, o.startTime.UTC().Format("20060102T150405"))
+	}
+	cmd := exec.Command("oc", "cp", src, dst, "--as", "backplane-cluster-admin") //#nosec G204 -- Subprocess launched with a potential tainted input or cmd arguments
 	var stdBuffer bytes.Buffer
 	mw := io.MultiWriter(os.Stdout, &stdBuffer)
 
@@ -393,16 +632,7 @@ func desiredPacketCapturePod(o *packetCaptureOptions, key types.NamespacedName)
 	capturePod.Name = key.Name
 	capturePod.Namespace = key.Namespace
 	capturePod.Labels = ls.MatchLabels
-	capturePod.Spec.NodeSelector = map[string]string{
-		o.nodeLabelKey: o.nodeLabelValue,
-	}
-	capturePod.Spec.Tolerations = []corev1.Toleration{
-		{
-			Effect:   "NoSchedule",
-			Key:      o.nodeLabelKey,
-			Operator: "Exists",
-		},
-	}
+	setCaptureScheduling(o, &capturePod.Spec)
 	capturePod.Spec.Volumes = []corev1.Volume{
 		{
 			Name: "capture-output",
@@ -417,7 +647,7 @@ func desiredPacketCapturePod(o *packetCaptureOptions, key types.NamespacedName)
 			Name:            "init-capture",
 			Image:           packetCaptureImage,
 			ImagePullPolicy: corev1.PullIfNotPresent,
-			Command:         []string{"/bin/bash", "-c", "tcpdump -G " + strconv.Itoa(o.duration) + " -W 1 -w /tmp/capture-output/capture.pcap -i " + o.captureInterface + " -nn -s0; sync"},
+			Command:         []string{"/bin/bash", "-c", captureCommand(o)},
 			SecurityContext: &corev1.SecurityContext{Privileged: &t},
 			VolumeMounts: []corev1.VolumeMount{
 				{
@@ -444,6 +674,23 @@ func desiredPacketCapturePod(o *packetCaptureOptions, key types.NamespacedName)
 			},
 		},
 	}
+	if o.captureOnVeth() {
+		// The pod's veth is resolved from its network namespace
+		hostPathType := corev1.HostPathDirectory
+		propagation := corev1.MountPropagationHostToContainer
+		capturePod.Spec.Volumes = append(capturePod.Spec.Volumes, corev1.Volume{
+			Name: "netns",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: netnsPath, Type: &hostPathType},
+			},
+		})
+		capturePod.Spec.InitContainers[0].VolumeMounts = append(capturePod.Spec.InitContainers[0].VolumeMounts, corev1.VolumeMount{
+			Name:             "netns",
+			MountPath:        netnsPath,
+			ReadOnly:         true,
+			MountPropagation: &propagation,
+		})
+	}
 	return capturePod
 }
 
@@ -517,6 +764,17 @@ func waitForPacketCapturePod(o *packetCaptureOptions, capturePod *corev1.Pod) er
 }
 
 func setCaptureInterface(o *packetCaptureOptions) error {
+	// A pod's traffic is best seen on its veth before encapsulation. Pods on the host network have no veth of their
+	// own, so their traffic is captured on all interfaces filtered by their IP.
+	if o.captureOnVeth() {
+		o.captureInterface = podVethInterface
+		return nil
+	}
+	if o.targetPodIP != "" {
+		o.captureInterface = "any"
+		return nil
+	}
+
 	networkConfig := &configv1.Network{}
 	if err := o.kubeCli.Get(context.Background(), client.ObjectKey{Name: "cluster"}, networkConfig); err != nil {
 		return fmt.Errorf("failed to determine the network type: %s", err)
@@ -130,6 +130,15 @@ func TestDesiredPacketCapturePod(t *testing.T) {
 	assert.Equal(t, map[string]string{"app": ops.name}, pod.Labels)
 	assert.Equal(t, map[string]string{ops.nodeLabelKey: ops.nodeLabelValue}, pod.Spec.NodeSelector)
 	assert.True(t, pod.Spec.HostNetwork)
+	assert.Len(t, pod.Spec.Volumes, 1)
+
+	// Capturing a pod's traffic on its veth needs the network namespaces of the node
+	ops.targetPodIP = "10.128.0.5"
+	ops.captureInterface = podVethInterface
+	pod = desiredPacketCapturePod(ops, key)
+	assert.Equal(t, netnsPath, pod.Spec.Volumes[1].HostPath.Path)
+	assert.Equal(t, netnsPath, pod.Spec.InitContainers[0].VolumeMounts[1].MountPath)
+	assert.Contains(t, pod.Spec.InitContainers[0].Command[2], `-i "$POD_VETH"`)
 }
 
 func TestDeletePacketCapturePod(t *testing.T) {
@@ -842,3 +851,147 @@ type LazyClient struct {
 func (m *MockKubeClient) ToLazyClient() *k8s.LazyClient {
 	return k8s.LazyClientMock(m)
 }
+
+func TestPacketCaptureValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *packetCaptureOptions
+		wantErr bool
+	}{
+		{name: "defaults", opts: &packetCaptureOptions{}},
+		{name: "valid_pod", opts: &packetCaptureOptions{pod: "ns/name"}},
+		{name: "invalid_pod", opts: &packetCaptureOptions{pod: "name"}, wantErr: true},
+		{name: "pod_and_nodes", opts: &packetCaptureOptions{pod: "ns/name", nodes: []string{"node-1"}}, wantErr: true},
+		{name: "single_pod_multiple_nodes", opts: &packetCaptureOptions{singlePod: true, nodes: []string{"node-1", "node-2"}}, wantErr: true},
+		{name: "ring_buffer", opts: &packetCaptureOptions{fileSizeMB: 10, files: 5}},
+		{name: "file_size_without_files", opts: &packetCaptureOptions{fileSizeMB: 10}, wantErr: true},
+		{name: "ring_buffer_within_max_bytes", opts: &packetCaptureOptions{fileSizeMB: 10, files: 5, maxBytes: 50000000}},
+		{name: "ring_buffer_exceeds_max_bytes", opts: &packetCaptureOptions{fileSizeMB: 10, files: 5, maxBytes: 49999999}, wantErr: true},
+		{name: "negative_max_bytes", opts: &packetCaptureOptions{maxBytes: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCaptureCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *packetCaptureOptions
+		want string
+	}{
+		{
+			name: "default",
+			opts: &packetCaptureOptions{duration: 60, captureInterface: "genev_sys_6081"},
+			want: "tcpdump -G 60 -W 1 -w /tmp/capture-output/capture.pcap -i genev_sys_6081 -nn -s0; sync",
+		},
+		{
+			name: "filter",
+			opts: &packetCaptureOptions{duration: 60, captureInterface: "eth0", filter: "tcp port 443"},
+			want: "tcpdump -G 60 -W 1 -w /tmp/capture-output/capture.pcap -i eth0 -nn -s0 'tcp port 443'; sync",
+		},
+		{
+			name: "pod_and_filter",
+			opts: &packetCaptureOptions{duration: 60, captureInterface: podVethInterface, filter: "port 53", targetPodIP: "10.128.0.5"},
+			want: podVethCommand("10.128.0.5") + `; tcpdump -G 60 -W 1 -w /tmp/capture-output/capture.pcap -i "$POD_VETH" -nn -s0 'port 53'; sync`,
+		},
+		{
+			name: "host_network_pod_and_filter",
+			opts: &packetCaptureOptions{duration: 60, captureInterface: "any", filter: "port 53", targetPodIP: "10.0.0.5", targetHostNetwork: true},
+			want: "tcpdump -G 60 -W 1 -w /tmp/capture-output/capture.pcap -i any -y LINUX_SLL -nn -s0 'host 10.0.0.5 and (port 53)'; sync",
+		},
+		{
+			name: "ring_buffer",
+			opts: &packetCaptureOptions{duration: 300, captureInterface: "eth0", fileSizeMB: 100, files: 5},
+			want: "timeout 300 tcpdump -C 100 -W 5 -w /tmp/capture-output/capture.pcap -i eth0 -nn -s0; sync",
+		},
+		{
+			name: "max_bytes",
+			opts: &packetCaptureOptions{duration: 60, captureInterface: "eth0", maxBytes: 1024},
+			want: "timeout 60 tcpdump -U -w - -i eth0 -nn -s0 | head -c 1024 > /tmp/capture-output/capture.pcap; sync",
+		},
+		{
+			name: "filter_with_quote",
+			opts: &packetCaptureOptions{duration: 60, captureInterface: "eth0", filter: "host 'a'"},
+			want: `tcpdump -G 60 -W 1 -w /tmp/capture-output/capture.pcap -i eth0 -nn -s0 'host '\''a'\'''; sync`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, captureCommand(tt.opts))
+		})
+	}
+}
+
+func TestSetCaptureScheduling(t *testing.T) {
+	spec := &corev1.PodSpec{}
+	setCaptureScheduling(&packetCaptureOptions{targetNode: "node-1"}, spec)
+	assert.Equal(t, "node-1", spec.NodeName)
+	assert.Nil(t, spec.NodeSelector)
+
+	spec = &corev1.PodSpec{}
+	setCaptureScheduling(&packetCaptureOptions{nodes: []string{"node-1", "node-2"}, nodeLabelKey: nodeLabelKey}, spec)
+	assert.Nil(t, spec.NodeSelector)
+	terms := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, []string{"node-1", "node-2"}, terms[0].MatchExpressions[0].Values)
+
+	spec = &corev1.PodSpec{}
+	setCaptureScheduling(&packetCaptureOptions{nodeLabelKey: nodeLabelKey}, spec)
+	assert.Equal(t, map[string]string{nodeLabelKey: ""}, spec.NodeSelector)
+	assert.Nil(t, spec.Affinity)
+}
+
+func TestResolveTargetPod(t *testing.T) {
+	scheduledPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test-ns"},
+		Spec:       corev1.PodSpec{NodeName: "node-1"},
+		Status:     corev1.PodStatus{PodIP: "10.128.0.5"},
+	}
+	hostNetworkPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "host", Namespace: "test-ns"},
+		Spec:       corev1.PodSpec{NodeName: "node-1", HostNetwork: true},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.5"},
+	}
+	pendingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "test-ns"},
+	}
+
+	tests := []struct {
+		name     string
+		pod      string
+		wantNode string
+		wantIP   string
+		wantVeth bool
+		wantErr  bool
+	}{
+		{name: "scheduled", pod: "test-ns/app", wantNode: "node-1", wantIP: "10.128.0.5", wantVeth: true},
+		{name: "host_network", pod: "test-ns/host", wantNode: "node-1", wantIP: "10.0.0.5"},
+		{name: "not_scheduled", pod: "test-ns/pending", wantErr: true},
+		{name: "not_found", pod: "test-ns/missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithObjects(scheduledPod, hostNetworkPod, pendingPod).Build()
+			opts := &packetCaptureOptions{pod: tt.pod, kubeCli: k8s.LazyClientInit(fakeClient)}
+			err := resolveTargetPod(opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNode, opts.targetNode)
+			assert.Equal(t, tt.wantIP, opts.targetPodIP)
+			assert.Equal(t, tt.wantVeth, opts.captureOnVeth())
+		})
+	}
+}
@@ -0,0 +1,512 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	pcapSummaryTopDefault = 10
+	// pcapngMagic is the block type of a pcapng Section Header Block, used to tell pcapng and pcap files apart
+	pcapngMagic = 0x0a0d0d0a
+	// linkTypeLinuxSLL2 is the link type of captures on the "any" interface by tcpdump 4.99 and later, which gopacket
+	// doesn't decode
+	linkTypeLinuxSLL2     = 276
+	linuxSLL2HeaderLength = 20
+)
+
+// newCmdPcapSummary implements the pcap-summary command to summarize packet captures without Wireshark
+func newCmdPcapSummary(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &pcapSummaryOptions{IOStreams: streams}
+	pcapSummaryCmd := &cobra.Command{
+		Use:   "pcap-summary <file>...",
+		Short: "Summarize packet capture files",
+		Long: `Summarize one or more pcap or pcapng files, such as those downloaded by packet-capture.
+
+  The summary reports the top talkers, TCP resets and retransmissions, failed DNS queries (NXDOMAIN/SERVFAIL),
+  TLS SNI hostnames, and TCP connection attempts that never got a reply.`,
+		Example: `
+  # Summarize the captures downloaded by packet-capture
+  osdctl network pcap-summary capture-output/*.pcap
+
+  # Show the top 20 talkers of every capture as a JSON array
+  osdctl network pcap-summary capture-output/*.pcap --top 20 -o json`,
+		Args:              cobra.MinimumNArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(ops.run(args))
+		},
+	}
+
+	pcapSummaryCmd.Flags().IntVar(&ops.top, "top", pcapSummaryTopDefault, "Number of entries to show per section")
+	pcapSummaryCmd.Flags().StringVarP(&ops.output, "output", "o", "text", "Output format. Options: text, json (an array with the summary of each file)")
+
+	return pcapSummaryCmd
+}
+
+// pcapSummaryOptions defines the struct for running pcap-summary command
+type pcapSummaryOptions struct {
+	top    int
+	output string
+
+	genericclioptions.IOStreams
+}
+
+func (o *pcapSummaryOptions) run(files []string) error {
+	if o.output != "text" && o.output != "json" {
+		return fmt.Errorf("unsupported output format %q, must be one of: text, json", o.output)
+	}
+
+	// The JSON output is a single array of the summaries, so it stays one document for many files
+	summaries := make([]*PcapSummary, 0, len(files))
+	for _, file := range files {
+		summary, err := summarizePcapFile(file)
+		if err != nil {
+			return err
+		}
+
+		if o.output == "json" {
+			summaries = append(summaries, summary.Top(o.top))
+			continue
+		}
+		summary.Print(o.Out, o.top)
+	}
+
+	if o.output == "json" {
+		enc := json.NewEncoder(o.Out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	}
+	return nil
+}
+
+// PcapSummary is an aggregate view of the traffic in a packet capture
+type PcapSummary struct {
+	File                 string           `json:"file"`
+	Packets              int              `json:"packets"`
+	Bytes                int              `json:"bytes"`
+	TopTalkers           []PcapTalker     `json:"topTalkers"`
+	TCPResets            int              `json:"tcpResets"`
+	TCPRetransmissions   int              `json:"tcpRetransmissions"`
+	FailedDNSQueries     []PcapDNSFailure `json:"failedDnsQueries"`
+	TLSServerNames       []PcapCount      `json:"tlsServerNames"`
+	UnansweredConnection []PcapCount      `json:"unansweredConnections"`
+}
+
+// PcapTalker is the traffic sent between two hosts, in either direction
+type PcapTalker struct {
+	Hosts   string `json:"hosts"`
+	Packets int    `json:"packets"`
+	Bytes   int    `json:"bytes"`
+}
+
+// PcapDNSFailure is a DNS query name and the failing response code it got
+type PcapDNSFailure struct {
+	Query        string `json:"query"`
+	ResponseCode string `json:"responseCode"`
+	Count        int    `json:"count"`
+}
+
+// PcapCount is a generic named counter
+type PcapCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// tcpSegmentKey identifies a TCP segment within a flow, seeing the same key twice means it was retransmitted
+type tcpSegmentKey struct {
+	flow gopacket.Flow
+	tcp  gopacket.Flow
+	seq  uint32
+	len  int
+}
+
+// pcapAggregator accumulates statistics while reading packets
+type pcapAggregator struct {
+	summary      *PcapSummary
+	talkers      map[string]*PcapTalker
+	segments     map[tcpSegmentKey]bool
+	dnsFailures  map[string]*PcapDNSFailure
+	serverNames  map[string]int
+	pendingSyns  map[string]string
+	answeredSyns map[string]bool
+}
+
+func newPcapAggregator(file string) *pcapAggregator {
+	return &pcapAggregator{
+		summary:      &PcapSummary{File: file},
+		talkers:      map[string]*PcapTalker{},
+		segments:     map[tcpSegmentKey]bool{},
+		dnsFailures:  map[string]*PcapDNSFailure{},
+		serverNames:  map[string]int{},
+		pendingSyns:  map[string]string{},
+		answeredSyns: map[string]bool{},
+	}
+}
+
+// summarizePcapFile reads a pcap or pcapng file and summarizes its traffic
+func summarizePcapFile(path string) (*PcapSummary, error) {
+	f, err := os.Open(path) //#nosec G304 -- path is provided by the user
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	summary, err := summarizePcap(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize %s: %w", path, err)
+	}
+	summary.File = filepath.Base(path)
+
+	return summary, nil
+}
+
+// summarizePcap reads packets in pcap or pcapng format from r and summarizes them
+func summarizePcap(r io.Reader) (*PcapSummary, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture header: %w", err)
+	}
+
+	var source gopacket.PacketDataSource
+	var linkType layers.LinkType
+	if binary.LittleEndian.Uint32(magic) == pcapngMagic {
+		ngReader, err := pcapgo.NewNgReader(br, pcapgo.DefaultNgReaderOptions)
+		if err != nil {
+			return nil, err
+		}
+		source, linkType = ngReader, ngReader.LinkType()
+	} else {
+		reader, err := pcapgo.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		source, linkType = reader, reader.LinkType()
+	}
+
+	agg := newPcapAggregator("")
+	packets := gopacket.NewPacketSource(source, packetDecoder(linkType))
+	packets.DecodeOptions = gopacket.DecodeOptions{Lazy: true, NoCopy: true}
+	for {
+		packet, err := packets.NextPacket()
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// Captures truncated by --max-bytes end with a partial packet
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		agg.add(packet)
+	}
+
+	return agg.finish(), nil
+}
+
+// packetDecoder returns the decoder of the packets captured with the link type
+func packetDecoder(linkType layers.LinkType) gopacket.Decoder {
+	// gopacket's link types are a single byte, so pcapgo reports LINUX_SLL2 truncated to an unassigned link type
+	if linkType == layers.LinkType(linkTypeLinuxSLL2&0xff) {
+		return gopacket.DecodeFunc(decodeLinuxSLL2)
+	}
+	return linkType
+}
+
+// decodeLinuxSLL2 skips the LINUX_SLL2 header and decodes its payload by the protocol type, an EtherType, in its
+// first two bytes
+func decodeLinuxSLL2(data []byte, p gopacket.PacketBuilder) error {
+	if len(data) < linuxSLL2HeaderLength {
+		return fmt.Errorf("LINUX_SLL2 header of %d bytes is too short", len(data))
+	}
+	return layers.EthernetType(binary.BigEndian.Uint16(data[0:2])).Decode(data[linuxSLL2HeaderLength:], p)
+}
+
+func (a *pcapAggregator) add(packet gopacket.Packet) {
+	a.summary.Packets++
+	a.summary.Bytes += packet.Metadata().Length
+
+	network := packet.NetworkLayer()
+	if network == nil {
+		return
+	}
+	netFlow := network.NetworkFlow()
+
+	// Key talkers independently of direction
+	src, dst := netFlow.Src().String(), netFlow.Dst().String()
+	if dst < src {
+		src, dst = dst, src
+	}
+	hosts := src + " <-> " + dst
+	talker, ok := a.talkers[hosts]
+	if !ok {
+		talker = &PcapTalker{Hosts: hosts}
+		a.talkers[hosts] = talker
+	}
+	talker.Packets++
+	talker.Bytes += packet.Metadata().Length
+
+	if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
+		a.addTCP(netFlow, tcpLayer.(*layers.TCP))
+	}
+
+	if dnsLayer := packet.Layer(layers.LayerTypeDNS); dnsLayer != nil {
+		a.addDNS(dnsLayer.(*layers.DNS))
+	}
+}
+
+func (a *pcapAggregator) addTCP(netFlow gopacket.Flow, tcp *layers.TCP) {
+	if tcp.RST {
+		a.summary.TCPResets++
+	}
+
+	endpoint := func(flow gopacket.Flow, port layers.TCPPort, dst bool) string {
+		host := flow.Src().String()
+		if dst {
+			host = flow.Dst().String()
+		}
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		return fmt.Sprintf("%s:%d", host, port)
+	}
+	conn := endpoint(netFlow, tcp.SrcPort, false) + "->" + endpoint(netFlow, tcp.DstPort, true)
+
+	switch {
+	case tcp.SYN && !tcp.ACK:
+		a.pendingSyns[conn] = endpoint(netFlow, tcp.DstPort, true)
+	case tcp.SYN && tcp.ACK, tcp.RST:
+		// Any SYN-ACK or RST from the destination counts as a reply to the connection attempt
+		reverse := endpoint(netFlow, tcp.DstPort, true) + "->" + endpoint(netFlow, tcp.SrcPort, false)
+		a.answeredSyns[reverse] = true
+	}
+
+	if len(tcp.Payload) > 0 || tcp.SYN || tcp.FIN {
+		key := tcpSegmentKey{flow: netFlow, tcp: tcp.TransportFlow(), seq: tcp.Seq, len: len(tcp.Payload)}
+		if a.segments[key] {
+			// SYN retransmissions to an unresponsive destination are reported as unanswered connections instead
+			if !tcp.SYN {
+				a.summary.TCPRetransmissions++
+			}
+		}
+		a.segments[key] = true
+	}
+
+	if serverName := tlsServerName(tcp.Payload); serverName != "" {
+		a.serverNames[serverName]++
+	}
+}
+
+func (a *pcapAggregator) addDNS(dns *layers.DNS) {
+	if !dns.QR {
+		return
+	}
+	if dns.ResponseCode != layers.DNSResponseCodeNXDomain && dns.ResponseCode != layers.DNSResponseCodeServFail {
+		return
+	}
+
+	for _, question := range dns.Questions {
+		key := string(question.Name) + "/" + dns.ResponseCode.String()
+		failure, ok := a.dnsFailures[key]
+		if !ok {
+			failure = &PcapDNSFailure{Query: string(question.Name), ResponseCode: dns.ResponseCode.String()}
+			a.dnsFailures[key] = failure
+		}
+		failure.Count++
+	}
+}
+
+func (a *pcapAggregator) finish() *PcapSummary {
+	for _, talker := range a.talkers {
+		a.summary.TopTalkers = append(a.summary.TopTalkers, *talker)
+	}
+	sort.Slice(a.summary.TopTalkers, func(i, j int) bool {
+		if a.summary.TopTalkers[i].Bytes != a.summary.TopTalkers[j].Bytes {
+			return a.summary.TopTalkers[i].Bytes > a.summary.TopTalkers[j].Bytes
+		}
+		return a.summary.TopTalkers[i].Hosts < a.summary.TopTalkers[j].Hosts
+	})
+
+	for _, failure := range a.dnsFailures {
+		a.summary.FailedDNSQueries = append(a.summary.FailedDNSQueries, *failure)
+	}
+	sort.Slice(a.summary.FailedDNSQueries, func(i, j int) bool {
+		if a.summary.FailedDNSQueries[i].Count != a.summary.FailedDNSQueries[j].Count {
+			return a.summary.FailedDNSQueries[i].Count > a.summary.FailedDNSQueries[j].Count
+		}
+		return a.summary.FailedDNSQueries[i].Query < a.summary.FailedDNSQueries[j].Query
+	})
+
+	a.summary.TLSServerNames = sortedCounts(a.serverNames)
+
+	unanswered := map[string]int{}
+	for conn, destination := range a.pendingSyns {
+		if !a.answeredSyns[conn] {
+			unanswered[destination]++
+		}
+	}
+	a.summary.UnansweredConnection = sortedCounts(unanswered)
+
+	return a.summary
+}
+
+func sortedCounts(counts map[string]int) []PcapCount {
+	result := make([]PcapCount, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, PcapCount{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// Top returns a copy of the summary with each section limited to n entries
+func (s *PcapSummary) Top(n int) *PcapSummary {
+	limited := *s
+	if n <= 0 {
+		return &limited
+	}
+	if len(limited.TopTalkers) > n {
+		limited.TopTalkers = limited.TopTalkers[:n]
+	}
+	if len(limited.FailedDNSQueries) > n {
+		limited.FailedDNSQueries = limited.FailedDNSQueries[:n]
+	}
+	if len(limited.TLSServerNames) > n {
+		limited.TLSServerNames = limited.TLSServerNames[:n]
+	}
+	if len(limited.UnansweredConnection) > n {
+		limited.UnansweredConnection = limited.UnansweredConnection[:n]
+	}
+
+	return &limited
+}
+
+// Print writes a human-readable summary with at most n entries per section
+func (s *PcapSummary) Print(w io.Writer, n int) {
+	top := s.Top(n)
+	fmt.Fprintf(w, "Summary of %s: %d packets, %d bytes\n", s.File, s.Packets, s.Bytes)
+	fmt.Fprintf(w, "TCP resets: %d, TCP retransmissions: %d\n", s.TCPResets, s.TCPRetransmissions)
+
+	fmt.Fprintf(w, "\nTop talkers:\n")
+	for _, talker := range top.TopTalkers {
+		fmt.Fprintf(w, "  %-60s %8d packets %12d bytes\n", talker.Hosts, talker.Packets, talker.Bytes)
+	}
+
+	fmt.Fprintf(w, "\nConnection attempts with no reply:\n")
+	printPcapCounts(w, top.UnansweredConnection)
+
+	fmt.Fprintf(w, "\nFailed DNS queries:\n")
+	if len(top.FailedDNSQueries) == 0 {
+		fmt.Fprintf(w, "  none\n")
+	}
+	for _, failure := range top.FailedDNSQueries {
+		fmt.Fprintf(w, "  %-60s %-10s %8d\n", failure.Query, failure.ResponseCode, failure.Count)
+	}
+
+	fmt.Fprintf(w, "\nTLS server names:\n")
+	printPcapCounts(w, top.TLSServerNames)
+	fmt.Fprintln(w)
+}
+
+func printPcapCounts(w io.Writer, counts []PcapCount) {
+	if len(counts) == 0 {
+		fmt.Fprintf(w, "  none\n")
+	}
+	for _, count := range counts {
+		fmt.Fprintf(w, "  %-60s %8d\n", count.Name, count.Count)
+	}
+}
+
+// tlsServerName returns the SNI hostname if payload starts with a TLS ClientHello, otherwise an empty string.
+// Only ClientHellos that fit in a single TCP segment are parsed, which covers the vast majority of them.
+func tlsServerName(payload []byte) string {
+	// TLS record header: content type (22 = handshake), version (2), length (2)
+	if len(payload) < 5 || payload[0] != 22 || payload[1] != 3 {
+		return ""
+	}
+	data := payload[5:]
+
+	// Handshake header: type (1 = ClientHello), length (3)
+	if len(data) < 4 || data[0] != 1 {
+		return ""
+	}
+	data = data[4:]
+
+	// Client version (2), random (32)
+	if len(data) < 34 {
+		return ""
+	}
+	data = data[34:]
+
+	// Session ID, cipher suites and compression methods are variable length
+	skip := func(lenBytes int) bool {
+		if len(data) < lenBytes {
+			return false
+		}
+		n := 0
+		for i := 0; i < lenBytes; i++ {
+			n = n<<8 | int(data[i])
+		}
+		if len(data) < lenBytes+n {
+			return false
+		}
+		data = data[lenBytes+n:]
+		return true
+	}
+	if !skip(1) || !skip(2) || !skip(1) {
+		return ""
+	}
+
+	if len(data) < 2 {
+		return ""
+	}
+	extensionsLen := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+	if len(data) > extensionsLen {
+		data = data[:extensionsLen]
+	}
+
+	for len(data) >= 4 {
+		extType := binary.BigEndian.Uint16(data)
+		extLen := int(binary.BigEndian.Uint16(data[2:]))
+		data = data[4:]
+		if len(data) < extLen {
+			return ""
+		}
+		// server_name extension: list length (2), name type (1, 0 = host_name), name length (2), name
+		if extType == 0 {
+			ext := data[:extLen]
+			if len(ext) < 5 || ext[2] != 0 {
+				return ""
+			}
+			nameLen := int(binary.BigEndian.Uint16(ext[3:]))
+			if len(ext) < 5+nameLen {
+				return ""
+			}
+			return string(ext[5 : 5+nameLen])
+		}
+		data = data[extLen:]
+	}
+
+	return ""
+}
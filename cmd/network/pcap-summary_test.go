@@ -0,0 +1,214 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// testClientHello returns a minimal TLS ClientHello record carrying the given SNI hostname
+func testClientHello(serverName string) []byte {
+	sni := []byte{0, 0}
+	binary.BigEndian.PutUint16(sni, uint16(len(serverName)+3))
+	sni = append(sni, 0)
+	sni = binary.BigEndian.AppendUint16(sni, uint16(len(serverName)))
+	sni = append(sni, serverName...)
+
+	extensions := binary.BigEndian.AppendUint16(nil, 0)
+	extensions = binary.BigEndian.AppendUint16(extensions, uint16(len(sni)))
+	extensions = append(extensions, sni...)
+
+	hello := []byte{3, 3}
+	hello = append(hello, make([]byte, 32)...) // random
+	hello = append(hello, 0)                   // session id
+	hello = append(hello, 0, 2, 0x13, 0x01)    // cipher suites
+	hello = append(hello, 1, 0)                // compression methods
+	hello = binary.BigEndian.AppendUint16(hello, uint16(len(extensions)))
+	hello = append(hello, extensions...)
+
+	handshake := []byte{1, 0, byte(len(hello) >> 8), byte(len(hello))}
+	handshake = append(handshake, hello...)
+
+	record := []byte{22, 3, 1}
+	record = binary.BigEndian.AppendUint16(record, uint16(len(handshake)))
+	return append(record, handshake...)
+}
+
+type testPacketWriter struct {
+	t *testing.T
+	w *pcapgo.Writer
+}
+
+func (tw testPacketWriter) write(ls ...gopacket.SerializableLayer) {
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	for _, l := range ls {
+		if tcp, ok := l.(*layers.TCP); ok {
+			for _, other := range ls {
+				if ip, ok := other.(*layers.IPv4); ok {
+					_ = tcp.SetNetworkLayerForChecksum(ip)
+				}
+			}
+		}
+		if udp, ok := l.(*layers.UDP); ok {
+			for _, other := range ls {
+				if ip, ok := other.(*layers.IPv4); ok {
+					_ = udp.SetNetworkLayerForChecksum(ip)
+				}
+			}
+		}
+	}
+	if err := gopacket.SerializeLayers(buf, opts, ls...); err != nil {
+		tw.t.Fatal(err)
+	}
+	data := buf.Bytes()
+	ci := gopacket.CaptureInfo{Timestamp: time.Unix(0, 0), CaptureLength: len(data), Length: len(data)}
+	if err := tw.w.WritePacket(ci, data); err != nil {
+		tw.t.Fatal(err)
+	}
+}
+
+func newTestCapture(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	w := pcapgo.NewWriter(buf)
+	if err := w.WriteFileHeader(65536, layers.LinkTypeEthernet); err != nil {
+		t.Fatal(err)
+	}
+	tw := testPacketWriter{t: t, w: w}
+
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ipv4 := func(src, dst string, proto layers.IPProtocol) *layers.IPv4 {
+		return &layers.IPv4{Version: 4, TTL: 64, Protocol: proto, SrcIP: net.ParseIP(src), DstIP: net.ParseIP(dst)}
+	}
+
+	// A SYN to 10.0.0.9:443 that is retransmitted and never answered
+	for i := 0; i < 2; i++ {
+		tw.write(eth, ipv4("10.0.0.1", "10.0.0.9", layers.IPProtocolTCP), &layers.TCP{SrcPort: 40000, DstPort: 443, SYN: true, Seq: 100})
+	}
+
+	// A handshake with 10.0.0.2:443, a ClientHello that is retransmitted, then a reset
+	tw.write(eth, ipv4("10.0.0.1", "10.0.0.2", layers.IPProtocolTCP), &layers.TCP{SrcPort: 40001, DstPort: 443, SYN: true, Seq: 200})
+	tw.write(eth, ipv4("10.0.0.2", "10.0.0.1", layers.IPProtocolTCP), &layers.TCP{SrcPort: 443, DstPort: 40001, SYN: true, ACK: true, Seq: 900, Ack: 201})
+	hello := gopacket.Payload(testClientHello("api.example.com"))
+	for i := 0; i < 2; i++ {
+		tw.write(eth, ipv4("10.0.0.1", "10.0.0.2", layers.IPProtocolTCP), &layers.TCP{SrcPort: 40001, DstPort: 443, ACK: true, PSH: true, Seq: 201, Ack: 901}, hello)
+	}
+	tw.write(eth, ipv4("10.0.0.2", "10.0.0.1", layers.IPProtocolTCP), &layers.TCP{SrcPort: 443, DstPort: 40001, RST: true, Seq: 901})
+
+	// An NXDOMAIN response
+	tw.write(eth, ipv4("10.0.0.53", "10.0.0.1", layers.IPProtocolUDP), &layers.UDP{SrcPort: 53, DstPort: 50000}, &layers.DNS{
+		ID:           1,
+		QR:           true,
+		ResponseCode: layers.DNSResponseCodeNXDomain,
+		Questions:    []layers.DNSQuestion{{Name: []byte("missing.example.com"), Type: layers.DNSTypeA, Class: layers.DNSClassIN}},
+	})
+
+	return buf.Bytes()
+}
+
+func TestSummarizePcap(t *testing.T) {
+	summary, err := summarizePcap(bytes.NewReader(newTestCapture(t)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 8, summary.Packets)
+	assert.Equal(t, 1, summary.TCPResets)
+	assert.Equal(t, 1, summary.TCPRetransmissions)
+	assert.Equal(t, []PcapCount{{Name: "10.0.0.9:443", Count: 1}}, summary.UnansweredConnection)
+	assert.Equal(t, []PcapCount{{Name: "api.example.com", Count: 2}}, summary.TLSServerNames)
+	assert.Equal(t, []PcapDNSFailure{{Query: "missing.example.com", ResponseCode: "Non-Existent Domain", Count: 1}}, summary.FailedDNSQueries)
+	assert.Equal(t, "10.0.0.1 <-> 10.0.0.2", summary.TopTalkers[0].Hosts)
+	assert.Equal(t, 5, summary.TopTalkers[0].Packets)
+	assert.Len(t, summary.Top(1).TopTalkers, 1)
+}
+
+func TestSummarizePcap_Truncated(t *testing.T) {
+	capture := newTestCapture(t)
+	// Captures limited by --max-bytes end part way through a packet
+	summary, err := summarizePcap(bytes.NewReader(capture[:len(capture)-10]))
+	assert.NoError(t, err)
+	assert.Equal(t, 7, summary.Packets)
+
+	_, err = summarizePcap(bytes.NewReader([]byte("not a pcap")))
+	assert.Error(t, err)
+}
+
+func TestSummarizePcap_LinuxSLL2(t *testing.T) {
+	// pcapgo can't write link types above 255, so write the file header by hand
+	fileHeader := []byte{0xd4, 0xc3, 0xb2, 0xa1, 2, 0, 4, 0}
+	fileHeader = append(fileHeader, make([]byte, 8)...)
+	fileHeader = binary.LittleEndian.AppendUint32(fileHeader, 65536)
+	fileHeader = binary.LittleEndian.AppendUint32(fileHeader, linkTypeLinuxSLL2)
+	buf := bytes.NewBuffer(fileHeader)
+	w := pcapgo.NewWriter(buf)
+
+	// Captures on the "any" interface by tcpdump 4.99 and later have a LINUX_SLL2 header instead of an ethernet one
+	packet := gopacket.NewSerializeBuffer()
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.2")}
+	tcp := &layers.TCP{SrcPort: 40000, DstPort: 443, RST: true}
+	_ = tcp.SetNetworkLayerForChecksum(ip)
+	if err := gopacket.SerializeLayers(packet, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ip, tcp); err != nil {
+		t.Fatal(err)
+	}
+	header := make([]byte, linuxSLL2HeaderLength)
+	binary.BigEndian.PutUint16(header[0:2], uint16(layers.EthernetTypeIPv4))
+	binary.BigEndian.PutUint32(header[4:8], 2)  // interface index
+	binary.BigEndian.PutUint16(header[8:10], 1) // ARPHRD_ETHER
+	header[11] = 6                              // link-layer address length
+	data := append(header, packet.Bytes()...)
+	if err := w.WritePacket(gopacket.CaptureInfo{Timestamp: time.Unix(0, 0), CaptureLength: len(data), Length: len(data)}, data); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := summarizePcap(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Packets)
+	assert.Equal(t, 1, summary.TCPResets)
+	assert.Equal(t, "10.0.0.1 <-> 10.0.0.2", summary.TopTalkers[0].Hosts)
+}
+
+func TestTLSServerName(t *testing.T) {
+	assert.Equal(t, "api.example.com", tlsServerName(testClientHello("api.example.com")))
+	assert.Equal(t, "", tlsServerName([]byte("GET / HTTP/1.1\r\n")))
+	assert.Equal(t, "", tlsServerName(testClientHello("api.example.com")[:20]))
+}
+
+func TestPcapSummaryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.pcap")
+	if err := os.WriteFile(path, newTestCapture(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			ops := &pcapSummaryOptions{top: 5, output: format, IOStreams: genericclioptions.IOStreams{Out: out}}
+			assert.NoError(t, ops.run([]string{path}))
+			assert.Contains(t, out.String(), "missing.example.com")
+		})
+	}
+
+	out := &bytes.Buffer{}
+	ops := &pcapSummaryOptions{top: 5, output: "json", IOStreams: genericclioptions.IOStreams{Out: out}}
+	assert.NoError(t, ops.run([]string{path, path}))
+	var summaries []PcapSummary
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &summaries))
+	assert.Len(t, summaries, 2)
+
+	ops = &pcapSummaryOptions{output: "yaml"}
+	assert.Error(t, ops.run([]string{path}))
+}
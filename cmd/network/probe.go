@@ -0,0 +1,460 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+)
+
+const (
+	probeName        = "sre-network-probe"
+	probeNamespace   = "default"
+	probeTimeoutSec  = 5
+	probeResultToken = "PROBE_RESULT"
+	hostResolvConf   = "/host/etc/resolv.conf"
+)
+
+var (
+	// defaultProbeTargets are always checked in addition to the cluster's API and OAuth endpoints
+	defaultProbeTargets = []string{"quay.io:443", "registry.redhat.io:443", "registry.access.redhat.com:443"}
+	// probeMTUSizes are the packet sizes tried, largest first, when discovering the path MTU
+	probeMTUSizes = []int{9001, 8901, 1500, 1400, 1200}
+)
+
+// newCmdProbe implements the probe command to test DNS and connectivity from inside the cluster
+func newCmdProbe(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &probeOptions{IOStreams: streams}
+	probeCmd := &cobra.Command{
+		Use:   "probe",
+		Short: "Probe DNS and connectivity from pods on cluster nodes",
+		Long: `Probe DNS and connectivity from short-lived pods on cluster nodes.
+
+  A privileged pod is launched on each selected node (all worker nodes by default) which checks:
+
+  * DNS resolution of every target through CoreDNS and through the node's resolver
+  * TCP reachability of every target, and TLS reachability with its certificate verified
+  * the path MTU towards the first target
+  * reachability of every target through the cluster-wide proxy, if one is configured
+
+  The cluster's API and OAuth endpoints and Red Hat registries are always probed, additional targets can be added
+  with --target. Results are reported per node, and the command fails if any check failed.`,
+		Example: `
+  # Probe the default targets from every worker node
+  osdctl network probe --cluster-id my-cluster --reason OHSS-1234
+
+  # Probe a customer endpoint from two specific nodes and print the results as JSON
+  osdctl network probe --cluster-id my-cluster --reason OHSS-1234 --nodes ip-10-0-1-1.ec2.internal,ip-10-0-1-2.ec2.internal --target db.example.com:5432 -o json`,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(ops.complete())
+			cmdutil.CheckErr(ops.run(context.Background()))
+		},
+	}
+
+	probeCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "C", "", "The internal/external ID of the cluster to probe")
+	probeCmd.Flags().StringVar(&ops.reason, "reason", "", "The reason for this command, which requires elevation, to be run (usually an OHSS or PD ticket)")
+	probeCmd.Flags().StringSliceVar(&ops.nodes, "nodes", nil, "Names of the nodes to probe from, defaults to all worker nodes")
+	probeCmd.Flags().StringArrayVar(&ops.targets, "target", nil, "Additional host:port to probe, can be specified multiple times")
+	probeCmd.Flags().StringVarP(&ops.namespace, "namespace", "n", probeNamespace, "Namespace to run the probe pods in")
+	probeCmd.Flags().IntVar(&ops.timeout, "timeout", probeTimeoutSec, "Timeout (in seconds) for each individual check")
+	probeCmd.Flags().StringVarP(&ops.output, "output", "o", "table", "Output format. Options: table, json")
+	_ = probeCmd.MarkFlagRequired("cluster-id")
+	_ = probeCmd.MarkFlagRequired("reason")
+
+	return probeCmd
+}
+
+// probeOptions defines the struct for running the probe command
+type probeOptions struct {
+	clusterID string
+	reason    string
+	nodes     []string
+	targets   []string
+	namespace string
+	timeout   int
+	output    string
+
+	// httpsProxy is the cluster-wide proxy probed through, if the cluster has one
+	httpsProxy string
+
+	genericclioptions.IOStreams
+	kubeCli   client.Client
+	clientset kubernetes.Interface
+}
+
+// ProbeResult is the outcome of a single check against a single target from a node
+type ProbeResult struct {
+	Node   string `json:"node"`
+	Check  string `json:"check"`
+	Target string `json:"target"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (o *probeOptions) complete() error {
+	if o.output != "table" && o.output != "json" {
+		return fmt.Errorf("unsupported output format %q, must be one of: table, json", o.output)
+	}
+	if o.timeout <= 0 {
+		return fmt.Errorf("--timeout must be greater than 0")
+	}
+	for _, target := range o.targets {
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			return fmt.Errorf("invalid --target %q, must be in the format host:port: %v", target, err)
+		}
+	}
+
+	ocmClient, err := utils.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetClusterAnyStatus(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	o.targets = append(clusterProbeTargets(cluster), o.targets...)
+	if cluster.Proxy() != nil && !cluster.Proxy().Empty() {
+		o.httpsProxy = cluster.Proxy().HTTPSProxy()
+		if o.httpsProxy == "" {
+			o.httpsProxy = cluster.Proxy().HTTPProxy()
+		}
+	}
+
+	o.kubeCli, _, o.clientset, err = common.GetKubeConfigAndClient(cluster.ID(), o.reason)
+	return err
+}
+
+// clusterProbeTargets returns the cluster's API and OAuth endpoints followed by the default targets
+func clusterProbeTargets(cluster *cmv1.Cluster) []string {
+	var targets []string
+	if apiURL, err := url.Parse(cluster.API().URL()); err == nil && apiURL.Hostname() != "" {
+		port := apiURL.Port()
+		if port == "" {
+			port = "443"
+		}
+		targets = append(targets, net.JoinHostPort(apiURL.Hostname(), port))
+	}
+	if consoleURL, err := url.Parse(cluster.Console().URL()); err == nil && strings.HasPrefix(consoleURL.Hostname(), "console-openshift-console.") {
+		// The OAuth server shares the console's ingress domain
+		targets = append(targets, net.JoinHostPort(strings.Replace(consoleURL.Hostname(), "console-openshift-console.", "oauth-openshift.", 1), "443"))
+	}
+
+	return append(targets, defaultProbeTargets...)
+}
+
+func (o *probeOptions) run(ctx context.Context) error {
+	nodes, err := o.probeNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteStaleProbePods(ctx, o); err != nil {
+		return err
+	}
+
+	log.Printf("Ensuring network probe pods on %d node(s)\n", len(nodes))
+	pods, err := ensureProbePods(ctx, o, nodes)
+	// Always clean up any pods that were created, even if creating others failed
+	defer func() {
+		log.Println("Deleting network probe pods")
+		for _, pod := range pods {
+			if err := o.kubeCli.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+				log.Printf("failed to delete pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+			}
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	log.Println("Waiting for network probe pods to complete")
+	var results []ProbeResult
+	for _, pod := range pods {
+		if err := waitForProbePod(ctx, o, pod); err != nil {
+			results = append(results, ProbeResult{Node: pod.Spec.NodeName, Check: "pod", Passed: false, Detail: err.Error()})
+			continue
+		}
+		nodeResults, err := collectProbeResults(ctx, o, pod)
+		if err != nil {
+			results = append(results, ProbeResult{Node: pod.Spec.NodeName, Check: "pod", Passed: false, Detail: err.Error()})
+			continue
+		}
+		results = append(results, nodeResults...)
+	}
+
+	if err := printProbeResults(o.Out, o.output, results); err != nil {
+		return err
+	}
+	if failed := failedProbeResults(results); failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// probeNodes returns the nodes to probe from, all worker nodes unless --nodes is specified
+func (o *probeOptions) probeNodes(ctx context.Context) ([]string, error) {
+	if len(o.nodes) > 0 {
+		return o.nodes, nil
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := o.kubeCli.List(ctx, nodes, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{nodeLabelKey: nodeLabelValue}),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list worker nodes: %v", err)
+	}
+	if len(nodes.Items) == 0 {
+		return nil, fmt.Errorf("no worker nodes found, specify nodes to probe from with --nodes")
+	}
+
+	names := make([]string, len(nodes.Items))
+	for i := range nodes.Items {
+		names[i] = nodes.Items[i].Name
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// deleteStaleProbePods deletes the probe pods left behind by previous runs which were interrupted, i.e. the finished
+// ones and the ones which have been running longer than a probe can. The pods of concurrent runs are left alone.
+func deleteStaleProbePods(ctx context.Context, o *probeOptions) error {
+	pods := &corev1.PodList{}
+	if err := o.kubeCli.List(ctx, pods, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{"app": probeName}),
+		Namespace:     o.namespace,
+	}); err != nil {
+		return fmt.Errorf("failed to list network probe pods: %v", err)
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		finished := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+		if !finished && time.Since(pod.CreationTimestamp.Time) < probePodTimeout(o) {
+			continue
+		}
+		log.Printf("Deleting stale network probe pod %s/%s\n", pod.Namespace, pod.Name)
+		if err := o.kubeCli.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete stale pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ensureProbePods creates a probe pod on each node, returning the pods that were created
+func ensureProbePods(ctx context.Context, o *probeOptions, nodes []string) ([]*corev1.Pod, error) {
+	pods := make([]*corev1.Pod, 0, len(nodes))
+	for _, node := range nodes {
+		pod := desiredProbePod(o, node)
+		if err := o.kubeCli.Create(ctx, pod); err != nil {
+			return pods, fmt.Errorf("failed to create pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
+
+// desiredProbePod returns a pod that runs the probe script once on the given node. Its name is generated so that
+// concurrent runs and pods left behind by previous runs don't clash.
+func desiredProbePod(o *probeOptions, node string) *corev1.Pod {
+	t := true
+	hostPathFile := corev1.HostPathFile
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: probeName + "-",
+			Namespace:    o.namespace,
+			Labels:       map[string]string{"app": probeName},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:      node,
+			RestartPolicy: corev1.RestartPolicyNever,
+			Tolerations:   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Volumes: []corev1.Volume{
+				{
+					Name: "host-resolv-conf",
+					VolumeSource: corev1.VolumeSource{
+						HostPath: &corev1.HostPathVolumeSource{Path: "/etc/resolv.conf", Type: &hostPathFile},
+					},
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            "probe",
+					Image:           packetCaptureImage,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/bin/bash", "-c", probeScript(o)},
+					SecurityContext: &corev1.SecurityContext{Privileged: &t},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "host-resolv-conf",
+							MountPath: hostResolvConf,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return pod
+}
+
+// probeScript returns the bash script run by each probe pod. Every check prints a single line:
+// PROBE_RESULT <check> <target> <pass|fail> <detail>
+func probeScript(o *probeOptions) string {
+	timeout := strconv.Itoa(o.timeout)
+	var sb strings.Builder
+	sb.WriteString(`result() { echo "` + probeResultToken + ` $1 $2 $3 ${4:-}"; }` + "\n")
+	sb.WriteString(`node_ns=$(awk '/^nameserver/ {print $2; exit}' ` + hostResolvConf + ")\n")
+
+	for _, target := range o.targets {
+		host, port, _ := net.SplitHostPort(target)
+		h, p, t := shellQuote(host), shellQuote(port), shellQuote(target)
+
+		// DNS through CoreDNS, i.e. the pod's own resolver, and through the node's upstream resolver
+		sb.WriteString(`if ip=$(dig +short +time=` + timeout + ` +tries=1 ` + h + ` | grep -v '\.$' | head -1) && [ -n "$ip" ]; then result dns-coredns ` + t + ` pass "$ip"; else result dns-coredns ` + t + ` fail "no answer"; fi` + "\n")
+		sb.WriteString(`if ip=$(dig +short +time=` + timeout + ` +tries=1 @"$node_ns" ` + h + ` | grep -v '\.$' | head -1) && [ -n "$ip" ]; then result dns-node ` + t + ` pass "$ip via $node_ns"; else result dns-node ` + t + ` fail "no answer via $node_ns"; fi` + "\n")
+		sb.WriteString(`if timeout ` + timeout + ` bash -c "</dev/tcp/"` + h + `"/"` + p + ` 2>/dev/null; then result tcp ` + t + ` pass; else result tcp ` + t + ` fail "connection failed"; fi` + "\n")
+		// curl exits with 60 when the certificate can't be verified, e.g. when a firewall intercepts TLS
+		sb.WriteString(`if out=$(curl -s -o /dev/null -w '%{http_code}' --connect-timeout ` + timeout + ` --max-time ` + timeout + ` https://` + t + `/ 2>&1); then result tls ` + t + ` pass "HTTP $out"; else rc=$?; if [ $rc -eq 60 ]; then result tls ` + t + ` fail "certificate verification failed"; else result tls ` + t + ` fail "curl exit $rc"; fi; fi` + "\n")
+		// Proxies commonly intercept TLS with their own CA, so only reachability is checked through them
+		if o.httpsProxy != "" {
+			sb.WriteString(`if out=$(curl -sk -o /dev/null -w '%{http_code}' --proxy ` + shellQuote(o.httpsProxy) + ` --connect-timeout ` + timeout + ` --max-time ` + timeout + ` https://` + t + `/ 2>&1); then result proxy ` + t + ` pass "HTTP $out"; else result proxy ` + t + ` fail "curl exit $?"; fi` + "\n")
+		}
+	}
+
+	if len(o.targets) > 0 {
+		host, _, _ := net.SplitHostPort(o.targets[0])
+		sizes := make([]string, len(probeMTUSizes))
+		for i, size := range probeMTUSizes {
+			sizes[i] = strconv.Itoa(size)
+		}
+		// Don't fragment pings of decreasing size, 28 bytes are taken by the IP and ICMP headers
+		sb.WriteString(`mtu=""; for size in ` + strings.Join(sizes, " ") + `; do if ping -M do -c 1 -W ` + timeout + ` -s $((size - 28)) ` + shellQuote(host) + ` >/dev/null 2>&1; then mtu=$size; break; fi; done` + "\n")
+		sb.WriteString(`if [ -n "$mtu" ]; then result mtu ` + shellQuote(host) + ` pass "$mtu"; else result mtu ` + shellQuote(host) + ` fail "no ICMP reply at any size"; fi` + "\n")
+	}
+
+	return sb.String()
+}
+
+// probePodTimeout returns how long a probe pod can take to finish running
+func probePodTimeout(o *probeOptions) time.Duration {
+	// Each target runs up to 4 or 5 checks, plus the MTU discovery, all bounded by the timeout
+	return time.Duration(o.timeout*(5*len(o.targets)+len(probeMTUSizes)))*time.Second + 5*time.Minute
+}
+
+// waitForProbePod waits for the probe pod to finish running
+func waitForProbePod(ctx context.Context, o *probeOptions, pod *corev1.Pod) error {
+	return wait.PollUntilContextTimeout(ctx, 5*time.Second, probePodTimeout(o), true, func(ctx context.Context) (bool, error) {
+		tmp := &corev1.Pod{}
+		if err := o.kubeCli.Get(ctx, client.ObjectKeyFromObject(pod), tmp); err != nil {
+			return false, err
+		}
+		switch tmp.Status.Phase {
+		case corev1.PodSucceeded:
+			return true, nil
+		case corev1.PodFailed:
+			return false, fmt.Errorf("probe pod %s/%s on %s failed: %s", tmp.Namespace, tmp.Name, tmp.Spec.NodeName, tmp.Status.Message)
+		default:
+			return false, nil
+		}
+	})
+}
+
+// collectProbeResults reads the results from the probe pod's logs
+func collectProbeResults(ctx context.Context, o *probeOptions, pod *corev1.Pod) ([]ProbeResult, error) {
+	stream, err := o.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	defer stream.Close()
+
+	return parseProbeResults(pod.Spec.NodeName, stream)
+}
+
+// parseProbeResults parses the PROBE_RESULT lines printed by the probe script
+func parseProbeResults(node string, r io.Reader) ([]ProbeResult, error) {
+	var results []ProbeResult
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 5)
+		if len(fields) < 4 || fields[0] != probeResultToken {
+			continue
+		}
+		result := ProbeResult{Node: node, Check: fields[1], Target: fields[2], Passed: fields[3] == "pass"}
+		if len(fields) == 5 {
+			result.Detail = strings.TrimSpace(fields[4])
+		}
+		results = append(results, result)
+	}
+
+	return results, scanner.Err()
+}
+
+func printProbeResults(w io.Writer, format string, results []ProbeResult) error {
+	if w == nil {
+		w = os.Stdout
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	table := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	table.AddRow([]string{"NODE", "CHECK", "TARGET", "RESULT", "DETAIL"})
+	for _, result := range results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		table.AddRow([]string{result.Node, result.Check, result.Target, status, result.Detail})
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%d of %d checks failed\n", failedProbeResults(results), len(results))
+	return nil
+}
+
+// failedProbeResults returns the number of checks which failed
+func failedProbeResults(results []ProbeResult) int {
+	var failed int
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+	return failed
+}
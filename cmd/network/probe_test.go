@@ -0,0 +1,148 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClusterProbeTargets(t *testing.T) {
+	cluster := newTestCluster(t, cmv1.NewCluster().
+		API(cmv1.NewClusterAPI().URL("https://api.test.abcd.p1.openshiftapps.com:6443")).
+		Console(cmv1.NewClusterConsole().URL("https://console-openshift-console.apps.test.abcd.p1.openshiftapps.com")))
+
+	assert.Equal(t, append([]string{
+		"api.test.abcd.p1.openshiftapps.com:6443",
+		"oauth-openshift.apps.test.abcd.p1.openshiftapps.com:443",
+	}, defaultProbeTargets...), clusterProbeTargets(cluster))
+
+	assert.Equal(t, defaultProbeTargets, clusterProbeTargets(newTestCluster(t, cmv1.NewCluster())))
+}
+
+func TestProbeScript(t *testing.T) {
+	o := &probeOptions{targets: []string{"quay.io:443", "db.example.com:5432"}, timeout: 3}
+	script := probeScript(o)
+	assert.Contains(t, script, "result dns-coredns 'quay.io:443'")
+	assert.Contains(t, script, "result mtu 'quay.io'")
+	assert.NotContains(t, script, "result proxy")
+	assert.NotContains(t, script, "curl -sk -o /dev/null -w '%{http_code}' --connect-timeout 3 --max-time 3 https://'quay.io:443'/")
+	assert.Contains(t, script, `result tls 'quay.io:443' fail "certificate verification failed"`)
+
+	o.httpsProxy = "http://proxy.example.com:3128"
+	assert.Contains(t, probeScript(o), "--proxy 'http://proxy.example.com:3128'")
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		cmd := exec.Command("bash", "-n", "-c", probeScript(o))
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+}
+
+func TestParseProbeResults(t *testing.T) {
+	logs := strings.Join([]string{
+		"some unrelated output",
+		"PROBE_RESULT dns-coredns quay.io:443 pass 1.2.3.4",
+		"PROBE_RESULT tcp quay.io:443 fail connection failed",
+		"PROBE_RESULT tls quay.io:443 pass ",
+	}, "\n")
+
+	results, err := parseProbeResults("node-1", strings.NewReader(logs))
+	assert.NoError(t, err)
+	assert.Equal(t, []ProbeResult{
+		{Node: "node-1", Check: "dns-coredns", Target: "quay.io:443", Passed: true, Detail: "1.2.3.4"},
+		{Node: "node-1", Check: "tcp", Target: "quay.io:443", Passed: false, Detail: "connection failed"},
+		{Node: "node-1", Check: "tls", Target: "quay.io:443", Passed: true},
+	}, results)
+}
+
+func TestProbeNodes(t *testing.T) {
+	worker := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-b", Labels: map[string]string{nodeLabelKey: ""}}}
+	worker2 := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-a", Labels: map[string]string{nodeLabelKey: ""}}}
+	master := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "master", Labels: map[string]string{"node-role.kubernetes.io/master": ""}}}
+
+	o := &probeOptions{kubeCli: fake.NewClientBuilder().WithObjects(worker, worker2, master).Build()}
+	nodes, err := o.probeNodes(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"worker-a", "worker-b"}, nodes)
+
+	o.nodes = []string{"master"}
+	nodes, err = o.probeNodes(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"master"}, nodes)
+
+	o = &probeOptions{kubeCli: fake.NewClientBuilder().Build()}
+	_, err = o.probeNodes(context.TODO())
+	assert.Error(t, err)
+}
+
+func TestEnsureProbePods(t *testing.T) {
+	o := &probeOptions{namespace: "test-ns", targets: []string{"quay.io:443"}, timeout: 1, kubeCli: fake.NewClientBuilder().Build()}
+	pods, err := ensureProbePods(context.TODO(), o, []string{"node-1", "node-2"})
+	assert.NoError(t, err)
+	assert.Len(t, pods, 2)
+	assert.Equal(t, "node-2", pods[1].Spec.NodeName)
+	assert.Equal(t, corev1.RestartPolicyNever, pods[1].Spec.RestartPolicy)
+
+	// Pod names are generated, so a second run doesn't clash with the pods of the first
+	second, err := ensureProbePods(context.TODO(), o, []string{"node-1"})
+	assert.NoError(t, err)
+	assert.Len(t, second, 1)
+	assert.NotEqual(t, pods[0].Name, second[0].Name)
+	assert.True(t, strings.HasPrefix(second[0].Name, probeName+"-"))
+}
+
+func TestDeleteStaleProbePods(t *testing.T) {
+	labels := map[string]string{"app": probeName}
+	finished := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: probeName + "-finished", Namespace: "test-ns", Labels: labels, CreationTimestamp: metav1.Now()},
+		Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	stuck := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: probeName + "-stuck", Namespace: "test-ns", Labels: labels, CreationTimestamp: metav1.NewTime(time.Now().Add(-24 * time.Hour))},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	running := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: probeName + "-running", Namespace: "test-ns", Labels: labels, CreationTimestamp: metav1.Now()},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	other := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "test-ns"},
+		Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	o := &probeOptions{namespace: "test-ns", timeout: 1, kubeCli: fake.NewClientBuilder().WithObjects(finished, stuck, running, other).Build()}
+
+	assert.NoError(t, deleteStaleProbePods(context.TODO(), o))
+
+	pods := &corev1.PodList{}
+	assert.NoError(t, o.kubeCli.List(context.TODO(), pods))
+	var names []string
+	for _, pod := range pods.Items {
+		names = append(names, pod.Name)
+	}
+	assert.ElementsMatch(t, []string{probeName + "-running", "other"}, names)
+}
+
+func TestPrintProbeResults(t *testing.T) {
+	results := []ProbeResult{
+		{Node: "node-1", Check: "tcp", Target: "quay.io:443", Passed: true},
+		{Node: "node-1", Check: "tls", Target: "quay.io:443", Passed: false, Detail: "curl exit 28"},
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, printProbeResults(buf, "table", results))
+	assert.Contains(t, buf.String(), "1 of 2 checks failed")
+	assert.Equal(t, 1, failedProbeResults(results))
+
+	buf.Reset()
+	assert.NoError(t, printProbeResults(buf, "json", results))
+	assert.Contains(t, buf.String(), `"detail": "curl exit 28"`)
+}
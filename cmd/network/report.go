@@ -0,0 +1,358 @@
+package network
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/osd-network-verifier/pkg/output"
+	onv "github.com/openshift/osd-network-verifier/pkg/verifier"
+)
+
+const (
+	reportFormatJSON     = "json"
+	reportFormatJUnit    = "junit"
+	reportFormatMarkdown = "markdown"
+
+	egressOutputText = "text"
+	// egressOutputJSON prints the JSON report to stdout instead of the text summaries
+	egressOutputJSON = reportFormatJSON
+
+	egressReportDirName    = "egress-reports"
+	egressReportTimeLayout = "20060102T150405Z"
+)
+
+var egressReportFormats = []string{reportFormatJSON, reportFormatJUnit, reportFormatMarkdown}
+
+var egressOutputFormats = []string{egressOutputText, egressOutputJSON}
+
+// EgressReport is a structured record of a single verify-egress run across one or more subnets
+type EgressReport struct {
+	ClusterId string               `json:"clusterId,omitempty"`
+	Platform  string               `json:"platform"`
+	Probe     string               `json:"probe"`
+	Timestamp time.Time            `json:"timestamp"`
+	Results   []EgressSubnetResult `json:"results"`
+}
+
+// EgressSubnetResult is the outcome of running the network verifier against a single subnet
+type EgressSubnetResult struct {
+	SubnetId         string   `json:"subnetId"`
+	SecurityGroupIds []string `json:"securityGroupIds,omitempty"`
+	Passed           bool     `json:"passed"`
+	// BlockedUrls contains every egress URL the probe failed to reach, all other URLs for the platform were reachable
+	BlockedUrls []string `json:"blockedUrls,omitempty"`
+	Exceptions  []string `json:"exceptions,omitempty"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// EgressReportDiff describes what changed for a subnet between two reports
+type EgressReportDiff struct {
+	SubnetId      string
+	NewlyBlocked  []string
+	NewlyAllowed  []string
+	PreviouslyRan bool
+}
+
+// newEgressSubnetResult converts the network verifier's output for an input into an EgressSubnetResult
+func newEgressSubnetResult(input *onv.ValidateEgressInput, out *output.Output) EgressSubnetResult {
+	result := EgressSubnetResult{
+		SubnetId:         input.SubnetID,
+		SecurityGroupIds: input.AWS.SecurityGroupIDs,
+		Passed:           out.IsSuccessful(),
+	}
+
+	for _, failure := range out.GetEgressURLFailures() {
+		result.BlockedUrls = append(result.BlockedUrls, failure.EgressURL())
+	}
+	sort.Strings(result.BlockedUrls)
+
+	_, exceptions, errs := out.Parse()
+	for _, exception := range exceptions {
+		result.Exceptions = append(result.Exceptions, exception.Error())
+	}
+	for _, err := range errs {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	return result
+}
+
+// Failed returns the number of subnets with blocked egress URLs. Subnets which only failed because of verifier
+// exceptions or errors aren't counted.
+func (r *EgressReport) Failed() int {
+	var failed int
+	for _, result := range r.Results {
+		if !result.Passed && len(result.BlockedUrls) > 0 {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// Write renders the report in the requested format to w
+func (r *EgressReport) Write(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case reportFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case reportFormatJUnit:
+		return r.writeJUnit(w)
+	case reportFormatMarkdown:
+		return r.writeMarkdown(w)
+	default:
+		return fmt.Errorf("unsupported report format %q, must be one of: %s", format, strings.Join(egressReportFormats, ", "))
+	}
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// writeJUnit renders one test suite per subnet, with a failing test case per blocked URL and an erroring test case
+// per verifier exception or error. A subnet that passed is represented by a single passing test case.
+func (r *EgressReport) writeJUnit(w io.Writer) error {
+	suites := junitTestSuites{}
+	for _, result := range r.Results {
+		suite := junitTestSuite{
+			Name:      fmt.Sprintf("verify-egress %s", result.SubnetId),
+			Timestamp: r.Timestamp.UTC().Format(time.RFC3339),
+		}
+
+		for _, url := range result.BlockedUrls {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      url,
+				ClassName: result.SubnetId,
+				Failure:   &junitMessage{Message: "egress blocked", Body: fmt.Sprintf("%s is not reachable from %s", url, result.SubnetId)},
+			})
+			suite.Failures++
+		}
+		for _, msg := range append(append([]string{}, result.Exceptions...), result.Errors...) {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "verifier",
+				ClassName: result.SubnetId,
+				Error:     &junitMessage{Message: msg},
+			})
+			suite.Errors++
+		}
+		if len(suite.TestCases) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      "all egress URLs reachable",
+				ClassName: result.SubnetId,
+			})
+		}
+		suite.Tests = len(suite.TestCases)
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (r *EgressReport) writeMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Egress verification report\n\n")
+	if r.ClusterId != "" {
+		fmt.Fprintf(&sb, "- Cluster: `%s`\n", r.ClusterId)
+	}
+	fmt.Fprintf(&sb, "- Platform: `%s`\n", r.Platform)
+	fmt.Fprintf(&sb, "- Probe: `%s`\n", r.Probe)
+	fmt.Fprintf(&sb, "- Time: %s\n\n", r.Timestamp.UTC().Format(time.RFC3339))
+
+	fmt.Fprintf(&sb, "| Subnet | Security groups | Result | Blocked URLs |\n")
+	fmt.Fprintf(&sb, "|---|---|---|---|\n")
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %d |\n", result.SubnetId, strings.Join(result.SecurityGroupIds, ", "), status, len(result.BlockedUrls))
+	}
+
+	for _, result := range r.Results {
+		if result.Passed {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## %s\n\n", result.SubnetId)
+		for _, url := range result.BlockedUrls {
+			fmt.Fprintf(&sb, "- blocked: %s\n", url)
+		}
+		for _, exception := range result.Exceptions {
+			fmt.Fprintf(&sb, "- exception: %s\n", exception)
+		}
+		for _, err := range result.Errors {
+			fmt.Fprintf(&sb, "- error: %s\n", err)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// defaultEgressReportDir returns the directory verify-egress reports are stored in when --report-dir is not specified
+func defaultEgressReportDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "osdctl", egressReportDirName), nil
+}
+
+// saveEgressReport stores a JSON copy of the report under dir, keyed by cluster ID and timestamp
+func saveEgressReport(dir string, report *EgressReport) (string, error) {
+	if report.ClusterId == "" {
+		return "", fmt.Errorf("reports can only be stored for a cluster, --cluster-id is required")
+	}
+
+	clusterDir := filepath.Join(dir, report.ClusterId)
+	if err := os.MkdirAll(clusterDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create report directory %s: %w", clusterDir, err)
+	}
+
+	path := filepath.Join(clusterDir, report.Timestamp.UTC().Format(egressReportTimeLayout)+".json")
+	f, err := os.Create(path) //#nosec G304 -- path is built from the report directory and timestamp
+	if err != nil {
+		return "", fmt.Errorf("failed to create report %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := report.Write(f, reportFormatJSON); err != nil {
+		return "", fmt.Errorf("failed to write report %s: %w", path, err)
+	}
+
+	return path, nil
+}
+
+// loadLastEgressReport returns the most recently stored report for a cluster, or nil if none has been stored yet
+func loadLastEgressReport(dir, clusterId string) (*EgressReport, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, clusterId))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	// The timestamp layout sorts lexically
+	sort.Strings(names)
+
+	path := filepath.Join(dir, clusterId, names[len(names)-1])
+	data, err := os.ReadFile(path) //#nosec G304 -- path is built from the report directory
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EgressReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+
+	return report, nil
+}
+
+// compareEgressReports returns per-subnet differences in blocked URLs between a previous and current report
+func compareEgressReports(previous, current *EgressReport) []EgressReportDiff {
+	previousBlocked := map[string]map[string]bool{}
+	for _, result := range previous.Results {
+		previousBlocked[result.SubnetId] = map[string]bool{}
+		for _, url := range result.BlockedUrls {
+			previousBlocked[result.SubnetId][url] = true
+		}
+	}
+
+	diffs := make([]EgressReportDiff, 0, len(current.Results))
+	for _, result := range current.Results {
+		diff := EgressReportDiff{SubnetId: result.SubnetId}
+		blocked, ok := previousBlocked[result.SubnetId]
+		diff.PreviouslyRan = ok
+
+		currentBlocked := map[string]bool{}
+		for _, url := range result.BlockedUrls {
+			currentBlocked[url] = true
+			if !blocked[url] {
+				diff.NewlyBlocked = append(diff.NewlyBlocked, url)
+			}
+		}
+		for url := range blocked {
+			if !currentBlocked[url] {
+				diff.NewlyAllowed = append(diff.NewlyAllowed, url)
+			}
+		}
+		sort.Strings(diff.NewlyBlocked)
+		sort.Strings(diff.NewlyAllowed)
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs
+}
+
+// printEgressReportDiff prints a human-readable summary of the changes since the previous report
+func printEgressReportDiff(w io.Writer, previous *EgressReport, diffs []EgressReportDiff) {
+	fmt.Fprintf(w, "Changes since the previous run at %s:\n", previous.Timestamp.UTC().Format(time.RFC3339))
+	for _, diff := range diffs {
+		if !diff.PreviouslyRan {
+			fmt.Fprintf(w, "  %s: not verified in the previous run\n", diff.SubnetId)
+			continue
+		}
+		if len(diff.NewlyBlocked) == 0 && len(diff.NewlyAllowed) == 0 {
+			fmt.Fprintf(w, "  %s: no changes\n", diff.SubnetId)
+			continue
+		}
+		fmt.Fprintf(w, "  %s: %d newly blocked, %d newly reachable\n", diff.SubnetId, len(diff.NewlyBlocked), len(diff.NewlyAllowed))
+		for _, url := range diff.NewlyBlocked {
+			fmt.Fprintf(w, "    - blocked: %s\n", url)
+		}
+		for _, url := range diff.NewlyAllowed {
+			fmt.Fprintf(w, "    + reachable: %s\n", url)
+		}
+	}
+}
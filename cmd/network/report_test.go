@@ -0,0 +1,137 @@
+package network
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/openshift/osd-network-verifier/pkg/output"
+	onv "github.com/openshift/osd-network-verifier/pkg/verifier"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEgressReport(timestamp time.Time, blocked map[string][]string) *EgressReport {
+	report := &EgressReport{
+		ClusterId: "test-cluster",
+		Platform:  "aws-classic",
+		Probe:     "curl",
+		Timestamp: timestamp,
+	}
+	for subnet, urls := range blocked {
+		report.Results = append(report.Results, EgressSubnetResult{
+			SubnetId:    subnet,
+			Passed:      len(urls) == 0,
+			BlockedUrls: urls,
+		})
+	}
+
+	return report
+}
+
+func TestNewEgressSubnetResult(t *testing.T) {
+	out := &output.Output{}
+	out.SetEgressFailures([]string{"https://b.example.com", "https://a.example.com"})
+	input := &onv.ValidateEgressInput{SubnetID: "subnet-123"}
+	input.AWS.SecurityGroupIDs = []string{"sg-123"}
+
+	got := newEgressSubnetResult(input, out)
+	assert.Equal(t, "subnet-123", got.SubnetId)
+	assert.Equal(t, []string{"sg-123"}, got.SecurityGroupIds)
+	assert.False(t, got.Passed)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, got.BlockedUrls)
+
+	got = newEgressSubnetResult(input, &output.Output{})
+	assert.True(t, got.Passed)
+	assert.Empty(t, got.BlockedUrls)
+}
+
+func TestEgressReport_Failed(t *testing.T) {
+	report := newTestEgressReport(time.Now(), map[string][]string{
+		"subnet-blocked": {"https://a.example.com"},
+		"subnet-passed":  nil,
+	})
+	report.Results = append(report.Results, EgressSubnetResult{SubnetId: "subnet-errored", Errors: []string{"failed to create instance"}})
+
+	assert.Equal(t, 1, report.Failed())
+}
+
+func TestEgressReport_Write(t *testing.T) {
+	report := newTestEgressReport(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), map[string][]string{
+		"subnet-123": {"https://a.example.com"},
+	})
+
+	tests := []struct {
+		format    string
+		contains  string
+		wantError bool
+	}{
+		{format: "json", contains: `"blockedUrls": [`},
+		{format: "markdown", contains: "| subnet-123 |  | FAIL | 1 |"},
+		{format: "junit", contains: `<failure message="egress blocked">`},
+		{format: "yaml", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := report.Write(buf, tt.format)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, buf.String(), tt.contains)
+			if tt.format == "junit" {
+				assert.NoError(t, xml.Unmarshal(buf.Bytes(), &junitTestSuites{}))
+			}
+		})
+	}
+}
+
+func TestSaveAndLoadLastEgressReport(t *testing.T) {
+	dir := t.TempDir()
+
+	previous, err := loadLastEgressReport(dir, "test-cluster")
+	assert.NoError(t, err)
+	assert.Nil(t, previous)
+
+	older := newTestEgressReport(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), map[string][]string{"subnet-123": nil})
+	newer := newTestEgressReport(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), map[string][]string{"subnet-123": {"https://a.example.com"}})
+	for _, report := range []*EgressReport{newer, older} {
+		_, err := saveEgressReport(dir, report)
+		assert.NoError(t, err)
+	}
+
+	previous, err = loadLastEgressReport(dir, "test-cluster")
+	assert.NoError(t, err)
+	assert.True(t, newer.Timestamp.Equal(previous.Timestamp))
+	assert.Equal(t, newer.Results, previous.Results)
+
+	_, err = saveEgressReport(dir, &EgressReport{})
+	assert.Error(t, err)
+}
+
+func TestCompareEgressReports(t *testing.T) {
+	previous := newTestEgressReport(time.Now(), map[string][]string{
+		"subnet-123": {"https://a.example.com", "https://b.example.com"},
+	})
+	current := newTestEgressReport(time.Now(), map[string][]string{
+		"subnet-123": {"https://b.example.com", "https://c.example.com"},
+		"subnet-456": nil,
+	})
+
+	diffs := compareEgressReports(previous, current)
+	got := map[string]EgressReportDiff{}
+	for _, diff := range diffs {
+		got[diff.SubnetId] = diff
+	}
+
+	assert.Equal(t, EgressReportDiff{
+		SubnetId:      "subnet-123",
+		NewlyBlocked:  []string{"https://c.example.com"},
+		NewlyAllowed:  []string{"https://a.example.com"},
+		PreviouslyRan: true,
+	}, got["subnet-123"])
+	assert.False(t, got["subnet-456"].PreviouslyRan)
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -92,6 +95,18 @@ type EgressVerification struct {
 	GcpProjectID string
 	// VpcName is the VPC where the verifier will run
 	VpcName string
+	// Output is the format of the results printed to stdout, either text or json
+	Output string
+	// ReportFormat is an optional format (json, junit, markdown) to render a structured report of the run in
+	ReportFormat string
+	// ReportFile is the path to write the rendered report to, required by ReportFormat
+	ReportFile string
+	// SaveReport stores the report per cluster for later comparison
+	SaveReport bool
+	// ReportDir is where reports are stored per cluster for later comparison, defaults to the user's cache directory
+	ReportDir string
+	// CompareLast prints the changes in blocked egresses since the last stored report for the cluster
+	CompareLast bool
 }
 
 func NewCmdValidateEgress() *cobra.Command {
@@ -128,6 +143,15 @@ func NewCmdValidateEgress() *cobra.Command {
   # Override automatic selection of the list of endpoints to check
   osdctl network verify-egress --cluster-id my-rosa-cluster --platform hostedcluster
 
+  # Write a JUnit report of the results
+  osdctl network verify-egress --cluster-id my-rosa-cluster --report-format junit --report-file egress.xml
+
+  # Print the results as a JSON report
+  osdctl network verify-egress --cluster-id my-rosa-cluster -o json
+
+  # Show which egresses became blocked or reachable since the previous saved run against the cluster, and save this one
+  osdctl network verify-egress --cluster-id my-rosa-cluster --compare-last --save-report
+
   # (Not recommended) Run against a specific VPC, without specifying cluster-id
   <export environment variables like AWS_ACCESS_KEY_ID or use aws configure>
   osdctl network verify-egress --subnet-id subnet-abcdefg123 --security-group sg-abcdefgh123 --region us-east-1`,
@@ -135,7 +159,15 @@ func NewCmdValidateEgress() *cobra.Command {
 			if e.Version {
 				printVersion()
 			}
-			e.Run(context.Background())
+			report, err := e.Run(context.Background())
+			cmdutil.CheckErr(err)
+			// Failing to render or store the report must not prevent notifying about the blocked egresses
+			reportErr := e.handleReport(report)
+			e.NotifyFailures(report)
+			cmdutil.CheckErr(reportErr)
+			if report.Failed() > 0 {
+				os.Exit(1)
+			}
 		},
 	}
 
@@ -154,6 +186,12 @@ func NewCmdValidateEgress() *cobra.Command {
 	validateEgressCmd.Flags().StringVar(&e.CpuArchName, "cpu-arch", "x86", "(optional) compute instance CPU architecture. E.g., 'x86' or 'arm'")
 	validateEgressCmd.Flags().StringVar(&e.GcpProjectID, "gcp-project-id", "", "(optional) the GCP project ID to run verification for")
 	validateEgressCmd.Flags().StringVar(&e.VpcName, "vpc", "", "(optional) VPC name for cases where it can't be fetched from OCM")
+	validateEgressCmd.Flags().StringVarP(&e.Output, "output", "o", egressOutputText, fmt.Sprintf("(optional) format of the results printed to stdout. One of: %s", strings.Join(egressOutputFormats, ", ")))
+	validateEgressCmd.Flags().StringVar(&e.ReportFormat, "report-format", "", fmt.Sprintf("(optional) render a structured report of the results to --report-file. One of: %s", strings.Join(egressReportFormats, ", ")))
+	validateEgressCmd.Flags().StringVar(&e.ReportFile, "report-file", "", "(optional) path to write the report to. Requires --report-format")
+	validateEgressCmd.Flags().BoolVar(&e.SaveReport, "save-report", false, "(optional) store the report in --report-dir, for --compare-last to compare later runs against. Requires --cluster-id")
+	validateEgressCmd.Flags().StringVar(&e.ReportDir, "report-dir", "", "(optional) directory reports are stored in per cluster by --save-report, defaults to the user cache directory")
+	validateEgressCmd.Flags().BoolVar(&e.CompareLast, "compare-last", false, "(optional) show which egresses changed since the previous run saved with --save-report. Requires --cluster-id")
 
 	// If a cluster-id is specified, don't allow the foot-gun of overriding region
 	validateEgressCmd.MarkFlagsMutuallyExclusive("cluster-id", "region")
@@ -168,34 +206,34 @@ type networkVerifier interface {
 
 // Run parses the EgressVerification input, typically sets values automatically using the ClusterId, and runs
 // osd-network-verifier's egress check to validate firewall prerequisites for ROSA.
+// The returned EgressReport contains the result for every subnet checked, blocked egresses are not treated as an error.
 // Docs: https://docs.openshift.com/rosa/rosa_install_access_delete_clusters/rosa_getting_started_iam/rosa-aws-prereqs.html#osd-aws-privatelink-firewall-prerequisites_prerequisites
-func (e *EgressVerification) Run(ctx context.Context) {
+func (e *EgressVerification) Run(ctx context.Context) (*EgressReport, error) {
 	// Setup the logger
 	builder := logging.NewGoLoggerBuilder().Debug(e.Debug)
 	logger, err := builder.Build()
 	if err != nil {
-		log.Fatalf("network verification failed to build logger: %s", err)
+		return nil, fmt.Errorf("network verification failed to build logger: %w", err)
 	}
 	e.log = logger
 
 	if err := e.validateInput(); err != nil {
-		log.Fatalf("network verification failed to validate input: %s", err)
+		return nil, fmt.Errorf("network verification failed to validate input: %w", err)
 	}
 
 	e.cpuArch = cpu.ArchitectureByName(e.CpuArchName)
 	if e.CpuArchName != "" && !e.cpuArch.IsValid() {
-		log.Fatalf("%s is not a valid CPU architecture", e.CpuArchName)
+		return nil, fmt.Errorf("%s is not a valid CPU architecture", e.CpuArchName)
 	}
 
 	// If no ClusterId is provided, fetch from OCM
-	err = e.fetchCluster(ctx)
-	if err != nil {
-		log.Fatal(err)
+	if err := e.fetchCluster(ctx); err != nil {
+		return nil, err
 	}
 
 	platform, err := e.getPlatform()
 	if err != nil {
-		log.Fatalf("error getting platform: %s", err)
+		return nil, fmt.Errorf("error getting platform: %w", err)
 	}
 
 	var inputs []*onv.ValidateEgressInput
@@ -205,74 +243,110 @@ func (e *EgressVerification) Run(ctx context.Context) {
 	case cloud.AWSHCP, cloud.AWSHCPZeroEgress, cloud.AWSClassic:
 		cfg, err := e.setupForAws(ctx)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		verifier, err = onvAwsClient.NewAwsVerifierFromConfig(*cfg, e.log)
 		if err != nil {
-			log.Fatalf("failed to assemble osd-network-verifier client: %s", err)
+			return nil, fmt.Errorf("failed to assemble osd-network-verifier client: %w", err)
 		}
 
 		inputs, err = e.generateAWSValidateEgressInput(ctx, platform)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 	case cloud.GCPClassic:
 		credentials, err := e.setupForGcp(ctx)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		verifier, err = onvGcpClient.NewGcpVerifier(credentials, e.Debug)
 		if err != nil {
-			log.Fatalf("failed to assemble osd-network-verifier client: %s", err)
+			return nil, fmt.Errorf("failed to assemble osd-network-verifier client: %w", err)
 		}
 
 		inputs, err = e.generateGcpValidateEgressInput(ctx, platform)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 	default:
-		log.Fatalf("unsupported platform: %s", platform)
+		return nil, fmt.Errorf("unsupported platform: %s", platform)
+	}
+
+	report := &EgressReport{
+		ClusterId: e.ClusterId,
+		Platform:  platform.String(),
+		Probe:     strings.ToLower(e.Probe),
+		Timestamp: time.Now().UTC(),
+	}
+	if e.cluster != nil {
+		report.ClusterId = e.cluster.ID()
 	}
 
 	e.log.Info(ctx, "Preparing to check %+v subnet(s) with network verifier.", len(inputs))
-	var failures int
 	for i := range inputs {
 		e.log.Info(ctx, "running network verifier for subnet  %+v, security group %+v", inputs[i].SubnetID, inputs[i].AWS.SecurityGroupIDs)
 		out := onv.ValidateEgress(verifier, *inputs[i])
-		out.Summary(e.Debug)
-		// Prompt putting the cluster into LS if egresses crucial for monitoring (PagerDuty/DMS) are blocked.
-		// Prompt sending a service log instead for other blocked egresses.
-		if !out.IsSuccessful() && len(out.GetEgressURLFailures()) > 0 {
-			failures++
-			postCmd := generateServiceLog(out, e.ClusterId)
-			blockedUrl := strings.Join(postCmd.TemplateParams, ",")
-			if (strings.Contains(blockedUrl, "deadmanssnitch") || strings.Contains(blockedUrl, "pagerduty")) && e.cluster.State() == "ready" {
-				fmt.Println("PagerDuty and/or DMS outgoing traffic is blocked, resulting in a loss of observability. As a result, Red Hat can no longer guarantee SLAs and the cluster should be put in limited support")
-				pCmd := lsupport.Post{Template: LimitedSupportTemplate}
-				if err := pCmd.Run(e.ClusterId); err != nil {
-					fmt.Printf("failed to post limited support reason: %v", err)
-				}
-			} else if err := postCmd.Run(); err != nil {
-				fmt.Println("Failed to generate service log. Please manually send a service log to the customer for the blocked egresses with:")
-				fmt.Printf("osdctl servicelog post %v -t %v -p %v\n", e.ClusterId, blockedEgressTemplateUrl, strings.Join(postCmd.TemplateParams, " -p "))
+		// The JSON report is the only thing printed to stdout with -o json
+		if e.Output != egressOutputJSON {
+			out.Summary(e.Debug)
+		}
+		report.Results = append(report.Results, newEgressSubnetResult(inputs[i], out))
+	}
+
+	return report, nil
+}
+
+// NotifyFailures is an optional post-processing step for a report returned by Run.
+// It prompts putting the cluster into limited support if egresses crucial for monitoring (PagerDuty/DMS) are blocked,
+// and prompts sending a service log instead for other blocked egresses.
+// The prompts print to stdout, so with -o json they're skipped and the commands to run are printed to stderr instead.
+func (e *EgressVerification) NotifyFailures(report *EgressReport) {
+	for _, result := range report.Results {
+		if len(result.BlockedUrls) == 0 {
+			continue
+		}
+
+		postCmd := generateServiceLogForUrls(result.BlockedUrls, e.ClusterId)
+		blockedUrl := strings.Join(postCmd.TemplateParams, ",")
+		monitoringBlocked := (strings.Contains(blockedUrl, "deadmanssnitch") || strings.Contains(blockedUrl, "pagerduty")) && e.cluster.State() == "ready"
+		if e.Output == egressOutputJSON {
+			if monitoringBlocked {
+				fmt.Fprintf(os.Stderr, "PagerDuty and/or DMS outgoing traffic is blocked from %s, put the cluster in limited support with:\n", result.SubnetId)
+				fmt.Fprintf(os.Stderr, "osdctl cluster support post %v -t %v\n", e.ClusterId, LimitedSupportTemplate)
+				continue
 			}
+			fmt.Fprintf(os.Stderr, "Egresses are blocked from %s, send a service log to the customer with:\n", result.SubnetId)
+			fmt.Fprintf(os.Stderr, "osdctl servicelog post %v -t %v -p %v\n", e.ClusterId, blockedEgressTemplateUrl, strings.Join(postCmd.TemplateParams, " -p "))
+			continue
 		}
-		if failures > 0 {
-			os.Exit(1)
+
+		if monitoringBlocked {
+			fmt.Println("PagerDuty and/or DMS outgoing traffic is blocked, resulting in a loss of observability. As a result, Red Hat can no longer guarantee SLAs and the cluster should be put in limited support")
+			pCmd := lsupport.Post{Template: LimitedSupportTemplate}
+			if err := pCmd.Run(e.ClusterId); err != nil {
+				fmt.Printf("failed to post limited support reason: %v", err)
+			}
+		} else if err := postCmd.Run(); err != nil {
+			fmt.Println("Failed to generate service log. Please manually send a service log to the customer for the blocked egresses with:")
+			fmt.Printf("osdctl servicelog post %v -t %v -p %v\n", e.ClusterId, blockedEgressTemplateUrl, strings.Join(postCmd.TemplateParams, " -p "))
 		}
 	}
 }
 
 func generateServiceLog(out *output.Output, clusterId string) servicelog.PostCmdOptions {
 	failures := out.GetEgressURLFailures()
-	if len(failures) > 0 {
-		egressUrls := make([]string, len(failures))
-		for i, failure := range failures {
-			egressUrls[i] = failure.EgressURL()
-		}
+	egressUrls := make([]string, len(failures))
+	for i, failure := range failures {
+		egressUrls[i] = failure.EgressURL()
+	}
 
+	return generateServiceLogForUrls(egressUrls, clusterId)
+}
+
+func generateServiceLogForUrls(egressUrls []string, clusterId string) servicelog.PostCmdOptions {
+	if len(egressUrls) > 0 {
 		return servicelog.PostCmdOptions{
 			Template:       blockedEgressTemplateUrl,
 			ClusterId:      clusterId,
@@ -282,6 +356,68 @@ func generateServiceLog(out *output.Output, clusterId string) servicelog.PostCmd
 	return servicelog.PostCmdOptions{}
 }
 
+// handleReport prints or renders the report as requested, compares it against the previously stored report for the
+// cluster, and stores it for future comparisons if requested
+func (e *EgressVerification) handleReport(report *EgressReport) error {
+	// Keep stdout for the JSON report with -o json
+	messages := io.Writer(os.Stdout)
+	if e.Output == egressOutputJSON {
+		messages = os.Stderr
+		if err := report.Write(os.Stdout, reportFormatJSON); err != nil {
+			return err
+		}
+	}
+
+	if e.ReportFormat != "" {
+		f, err := os.Create(e.ReportFile)
+		if err != nil {
+			return fmt.Errorf("failed to create report file %s: %w", e.ReportFile, err)
+		}
+		defer f.Close()
+		if err := report.Write(f, e.ReportFormat); err != nil {
+			return err
+		}
+		fmt.Fprintf(messages, "Report written to %s\n", e.ReportFile)
+	}
+
+	// Reports are only stored per cluster, there's nothing to key a manually specified VPC on
+	if report.ClusterId == "" || (!e.CompareLast && !e.SaveReport) {
+		return nil
+	}
+
+	dir := e.ReportDir
+	if dir == "" {
+		var err error
+		dir, err = defaultEgressReportDir()
+		if err != nil {
+			return fmt.Errorf("failed to determine report directory, consider specifying --report-dir: %w", err)
+		}
+	}
+
+	if e.CompareLast {
+		previous, err := loadLastEgressReport(dir, report.ClusterId)
+		if err != nil {
+			return fmt.Errorf("failed to load previous report: %w", err)
+		}
+		if previous == nil {
+			fmt.Fprintf(messages, "No previous report found for %s in %s, nothing to compare against\n", report.ClusterId, dir)
+		} else {
+			printEgressReportDiff(messages, previous, compareEgressReports(previous, report))
+		}
+	}
+
+	if !e.SaveReport {
+		return nil
+	}
+	path, err := saveEgressReport(dir, report)
+	if err != nil {
+		return err
+	}
+	e.log.Debug(context.Background(), "stored report at %s", path)
+
+	return nil
+}
+
 // getPlatform returns a cloud.Platform struct corresponding to the cluster's cloud platform
 // reported by OCM or to the e.platformName override string specified by the user
 func (e *EgressVerification) getPlatform() (cloud.Platform, error) {
@@ -508,7 +644,7 @@ func (e *EgressVerification) fetchCluster(ctx context.Context) error {
 	if e.ClusterId != "" {
 		ocmClient, err := utils.CreateConnection()
 		if err != nil {
-			log.Fatalf("error creating OCM connection: %s", err)
+			return fmt.Errorf("error creating OCM connection: %w", err)
 		}
 		defer ocmClient.Close()
 
@@ -523,7 +659,7 @@ func (e *EgressVerification) fetchCluster(ctx context.Context) error {
 		case "rosa", "osd", "osdtrial":
 			break
 		default:
-			log.Fatalf("only supports rosa, osd, and osdtrial, got %s", e.cluster.Product().ID())
+			return fmt.Errorf("only supports rosa, osd, and osdtrial, got %s", e.cluster.Product().ID())
 		}
 	}
 
@@ -537,6 +673,30 @@ func (e *EgressVerification) validateInput() error {
 			"--subnet-id foo --subnet-id bar")
 	}
 
+	if e.ReportFormat != "" && !slices.Contains(egressReportFormats, strings.ToLower(e.ReportFormat)) {
+		return fmt.Errorf("unsupported --report-format %q, must be one of: %s", e.ReportFormat, strings.Join(egressReportFormats, ", "))
+	}
+
+	if e.Output != "" && !slices.Contains(egressOutputFormats, e.Output) {
+		return fmt.Errorf("unsupported --output %q, must be one of: %s", e.Output, strings.Join(egressOutputFormats, ", "))
+	}
+
+	if e.ReportFile != "" && e.ReportFormat == "" {
+		return fmt.Errorf("--report-file requires --report-format")
+	}
+
+	if e.ReportFormat != "" && e.ReportFile == "" {
+		return fmt.Errorf("--report-format requires --report-file, use -o json to print the report to stdout")
+	}
+
+	if e.CompareLast && e.ClusterId == "" {
+		return fmt.Errorf("--compare-last requires --cluster-id")
+	}
+
+	if e.SaveReport && e.ClusterId == "" {
+		return fmt.Errorf("--save-report requires --cluster-id")
+	}
+
 	return nil
 }
 
@@ -71,6 +71,34 @@ func TestEgressVerification_ValidateInput(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "valid_json_output",
+			ev: &EgressVerification{
+				Output: egressOutputJSON,
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid_output",
+			ev: &EgressVerification{
+				Output: "junit",
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid_report_format_without_file",
+			ev: &EgressVerification{
+				ReportFormat: reportFormatJUnit,
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid_save_report_without_cluster",
+			ev: &EgressVerification{
+				SaveReport: true,
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -688,3 +716,36 @@ func Test_egressVerification_setupForAwsVerification(t *testing.T) {
 		})
 	}
 }
+
+func TestEgressVerification_RunInvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   *EgressVerification
+	}{
+		{
+			name: "comma_separated_subnets",
+			ev:   &EgressVerification{SubnetIds: []string{"subnet-123,subnet-456"}},
+		},
+		{
+			name: "invalid_cpu_arch",
+			ev:   &EgressVerification{CpuArchName: "sparc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := tt.ev.Run(context.Background())
+			assert.Error(t, err)
+			assert.Nil(t, report)
+		})
+	}
+}
+
+func TestGenerateServiceLogForUrls(t *testing.T) {
+	got := generateServiceLogForUrls([]string{"https://test1.com", "https://test2.com"}, "test-cluster")
+	assert.Equal(t, blockedEgressTemplateUrl, got.Template)
+	assert.Equal(t, "test-cluster", got.ClusterId)
+	assert.Equal(t, []string{"URLS=https://test1.com,https://test2.com"}, got.TemplateParams)
+
+	assert.Equal(t, servicelog.PostCmdOptions{}, generateServiceLogForUrls(nil, "test-cluster"))
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/pmezard/go-difflib/difflib"
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 
 	"gopkg.in/yaml.v3"
@@ -248,7 +249,37 @@ func GetCurrentPackageTagFromAppInterface(saasFile string) (string, error) {
 	return currentPackageTag, nil
 }
 
+// GetPackageRepoFromAppInterface returns the repository of the package deployed by the saas file
+func GetPackageRepoFromAppInterface(saasFile string) (string, error) {
+	saasData, err := os.ReadFile(saasFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file '%s': %w", saasFile, err)
+	}
+
+	service := Service{}
+	err = yaml.Unmarshal(saasData, &service)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal service definition: %w", err)
+	}
+
+	for _, resourceTemplate := range service.ResourceTemplates {
+		if strings.Contains(resourceTemplate.Name, "package") && resourceTemplate.URL != "" {
+			return resourceTemplate.URL, nil
+		}
+	}
+	return "", fmt.Errorf("package repo not found in %s", saasFile)
+}
+
 func (a AppInterface) UpdateAppInterface(serviceName, saasFile, currentGitHash, promotionGitHash, branchName string) error {
+	if err := a.CreateBranch(branchName); err != nil {
+		return err
+	}
+
+	return a.UpdateSaasFileRef(saasFile, currentGitHash, promotionGitHash)
+}
+
+// CreateBranch (re)creates the branch from master and checks it out
+func (a AppInterface) CreateBranch(branchName string) error {
 	cmd := exec.Command("git", "checkout", "master")
 	cmd.Dir = a.GitDirectory
 	err := cmd.Run()
@@ -270,22 +301,24 @@ func (a AppInterface) UpdateAppInterface(serviceName, saasFile, currentGitHash,
 		return fmt.Errorf("failed to create branch %s: %v, does it already exist? If so, please delete it with `git branch -D %s` first", branchName, err, branchName)
 	}
 
+	return nil
+}
+
+// UpdateSaasFileRef replaces the current git hash of the saas file with the promoted one, in the canary targets when
+// there are some
+func (a AppInterface) UpdateSaasFileRef(saasFile, currentGitHash, promotionGitHash string) error {
 	// Update the hash in the SAAS file
 	fileContent, err := os.ReadFile(saasFile)
 	if err != nil {
 		return fmt.Errorf("failed to read file %s: %v", saasFile, err)
 	}
-	var newContent string
 
-	// If canary targets are set up in saas, replace the hash only in canary targets in the file content
-	// Otherwise proceed to promoting to all prod hives.
-	newContent, err, canaryTargetsSetUp := replaceTargetSha(string(fileContent), canaryStr, promotionGitHash)
+	newContent, canaryTargetsSetUp, err := saasFileWithRef(string(fileContent), currentGitHash, promotionGitHash)
 	if err != nil {
-		return fmt.Errorf("error modifying YAML: %v", err)
+		return err
 	}
 	if !canaryTargetsSetUp {
 		fmt.Println("canary targets not set, continuing to replace all occurrences of sha.")
-		newContent = strings.ReplaceAll(string(fileContent), currentGitHash, promotionGitHash)
 	}
 
 	err = os.WriteFile(saasFile, []byte(newContent), 0644)
@@ -296,6 +329,63 @@ func (a AppInterface) UpdateAppInterface(serviceName, saasFile, currentGitHash,
 	return nil
 }
 
+// SaasFileRefDiff returns the diff UpdateSaasFileRef applies to the saas file
+func (a AppInterface) SaasFileRefDiff(saasFile, currentGitHash, promotionGitHash string) (string, error) {
+	fileContent, err := os.ReadFile(saasFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %v", saasFile, err)
+	}
+
+	newContent, _, err := saasFileWithRef(string(fileContent), currentGitHash, promotionGitHash)
+	if err != nil {
+		return "", err
+	}
+
+	return a.saasFileDiff(saasFile, string(fileContent), newContent)
+}
+
+// saasFileWithRef returns the content of the saas file with the promoted git hash, and whether canary targets are set
+// up
+func saasFileWithRef(fileContent, currentGitHash, promotionGitHash string) (string, bool, error) {
+	// If canary targets are set up in saas, replace the hash only in canary targets in the file content
+	// Otherwise proceed to promoting to all prod hives.
+	newContent, err, canaryTargetsSetUp := replaceTargetSha(fileContent, canaryStr, promotionGitHash)
+	if err != nil {
+		return "", false, fmt.Errorf("error modifying YAML: %v", err)
+	}
+	if !canaryTargetsSetUp {
+		newContent = strings.ReplaceAll(fileContent, currentGitHash, promotionGitHash)
+	}
+
+	return newContent, canaryTargetsSetUp, nil
+}
+
+// PackageTagDiff returns the diff UpdatePackageTag applies to the saas file
+func (a AppInterface) PackageTagDiff(saasFile, oldTag, promotionTag string) (string, error) {
+	fileContent, err := os.ReadFile(saasFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %v", saasFile, err)
+	}
+
+	return a.saasFileDiff(saasFile, string(fileContent), strings.ReplaceAll(string(fileContent), oldTag, promotionTag))
+}
+
+// saasFileDiff returns the unified diff between the contents of the saas file, with its path in app-interface
+func (a AppInterface) saasFileDiff(saasFile, oldContent, newContent string) (string, error) {
+	name, err := filepath.Rel(a.GitDirectory, saasFile)
+	if err != nil || strings.HasPrefix(name, "..") {
+		name = saasFile
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(oldContent),
+		B:        difflib.SplitLines(newContent),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+}
+
 func (a AppInterface) UpdatePackageTag(saasFile, oldTag, promotionTag, branchName string) error {
 	cmd := exec.Command("git", "checkout", "master")
 	cmd.Dir = a.GitDirectory
@@ -328,17 +418,24 @@ func (a AppInterface) UpdatePackageTag(saasFile, oldTag, promotionTag, branchNam
 }
 
 func (a AppInterface) CommitSaasFile(saasFile, commitMessage string) error {
+	return a.CommitSaasFiles([]string{saasFile}, commitMessage)
+}
+
+// CommitSaasFiles commits the changes of the saas files in a single commit
+func (a AppInterface) CommitSaasFiles(saasFiles []string, commitMessage string) error {
 	// Commit the change
-	cmd := exec.Command("git", "add", saasFile)
-	cmd.Dir = a.GitDirectory
-	err := cmd.Run()
-	if err != nil {
-		return fmt.Errorf("failed to add file %s: %v", saasFile, err)
+	for _, saasFile := range saasFiles {
+		cmd := exec.Command("git", "add", saasFile)
+		cmd.Dir = a.GitDirectory
+		err := cmd.Run()
+		if err != nil {
+			return fmt.Errorf("failed to add file %s: %v", saasFile, err)
+		}
 	}
 
-	cmd = exec.Command("git", "commit", "-m", commitMessage)
+	cmd := exec.Command("git", "commit", "-m", commitMessage)
 	cmd.Dir = a.GitDirectory
-	err = cmd.Run()
+	err := cmd.Run()
 	if err != nil {
 		return fmt.Errorf("failed to commit changes: %v", err)
 	}
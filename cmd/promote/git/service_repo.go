@@ -2,54 +2,218 @@ package git
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
 )
 
-func CheckoutAndCompareGitHash(gitURL, gitHash, currentGitHash string) (string, string, error) {
-	tempDir, err := ioutil.TempDir("", "")
-	if err != nil {
-		return "", "", fmt.Errorf("failed to create temporary directory: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+const (
+	// The fields and the commits of the changelog are separated by the ASCII unit and record separators
+	changelogFieldSeparator  = "\x1f"
+	changelogCommitSeparator = "\x1e"
+	changelogFormat          = "--format=%H%x1f%an%x1f%s%x1f%b%x1e"
+)
 
-	err = os.Chdir(tempDir)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to change directory to temporary directory: %v", err)
-	}
+var (
+	jiraKeyRegex = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[0-9]+\b`)
+	// notJiraProjects are the prefixes matching the Jira key format which aren't Jira projects
+	notJiraProjects = map[string]bool{"UTF": true, "SHA": true, "RFC": true, "ISO": true, "CVE": true}
+)
 
-	cmd := exec.Command("git", "clone", gitURL, "source-dir")
-	err = cmd.Run()
-	if err != nil {
-		return "", "", fmt.Errorf("failed to clone git repository: %v", err)
-	}
+// Changelog is the content of a promotion: the commits and the files changed between the deployed and promoted refs
+type Changelog struct {
+	Commits []ChangelogCommit
+	Files   []ChangedFile
+}
+
+// ChangelogCommit is a commit of the changelog, with the Jira keys referenced by its message
+type ChangelogCommit struct {
+	Hash     string
+	Author   string
+	Subject  string
+	JiraKeys []string
+}
+
+// ChangedFile is a file changed by the promotion, with its git status (A, M, D, R...)
+type ChangedFile struct {
+	Status string
+	Path   string
+}
 
-	err = os.Chdir("source-dir")
+func CheckoutAndCompareGitHash(gitURL, gitHash, currentGitHash string) (string, *Changelog, error) {
+	sourceDir, cleanup, err := cloneServiceRepo(gitURL)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to change directory to source-dir: %v", err)
+		return "", nil, err
 	}
+	defer cleanup()
 
 	if gitHash == "" {
 		fmt.Printf("No git hash provided. Using HEAD.\n")
-		cmd = exec.Command("git", "rev-parse", "HEAD")
+		cmd := exec.Command("git", "rev-parse", "HEAD")
+		cmd.Dir = sourceDir
 		output, err := cmd.Output()
 		if err != nil {
-			return "", "", fmt.Errorf("failed to get git hash: %v", err)
+			return "", nil, fmt.Errorf("failed to get git hash: %v", err)
 		}
 		gitHash = strings.TrimSpace(string(output))
 		fmt.Printf("The head githash is %s\n", gitHash)
 	}
 
 	if currentGitHash == gitHash {
-		return "", "", fmt.Errorf("git hash %s is already at HEAD", gitHash)
-	} else {
-		cmd := exec.Command("git", "log", "--no-merges", fmt.Sprintf("%s..%s", currentGitHash, gitHash))
-		commitLog, err := cmd.Output()
-		if err != nil {
-			return "", "", err
+		return "", nil, fmt.Errorf("git hash %s is already at HEAD", gitHash)
+	}
+
+	changelog, err := readChangelog(sourceDir, currentGitHash, gitHash)
+	if err != nil {
+		return "", nil, err
+	}
+	return gitHash, changelog, nil
+}
+
+// GetChangelog clones the service repository and returns the changelog between the refs
+func GetChangelog(gitURL, fromRef, toRef string) (*Changelog, error) {
+	sourceDir, cleanup, err := cloneServiceRepo(gitURL)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return readChangelog(sourceDir, fromRef, toRef)
+}
+
+// cloneServiceRepo clones the service repository in a temporary directory, removed by the returned cleanup function
+func cloneServiceRepo(gitURL string) (string, func(), error) {
+	tempDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+
+	sourceDir := filepath.Join(tempDir, "source-dir")
+	cmd := exec.Command("git", "clone", gitURL, sourceDir)
+	err = cmd.Run()
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to clone git repository: %v", err)
+	}
+
+	return sourceDir, cleanup, nil
+}
+
+// readChangelog returns the commits, without the merges, and the files changed between the refs of the repository
+func readChangelog(sourceDir, fromRef, toRef string) (*Changelog, error) {
+	cmd := exec.Command("git", "log", "--no-merges", changelogFormat, fmt.Sprintf("%s..%s", fromRef, toRef))
+	cmd.Dir = sourceDir
+	commitLog, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the commits between %s and %s: %v", fromRef, toRef, err)
+	}
+
+	cmd = exec.Command("git", "diff", "--name-status", fromRef, toRef)
+	cmd.Dir = sourceDir
+	changedFiles, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the files changed between %s and %s: %v", fromRef, toRef, err)
+	}
+
+	return &Changelog{
+		Commits: parseChangelogCommits(string(commitLog)),
+		Files:   parseChangedFiles(string(changedFiles)),
+	}, nil
+}
+
+// parseChangelogCommits parses the output of git log with the changelog format
+func parseChangelogCommits(output string) []ChangelogCommit {
+	var commits []ChangelogCommit
+	for _, record := range strings.Split(output, changelogCommitSeparator) {
+		fields := strings.SplitN(strings.TrimSpace(record), changelogFieldSeparator, 4)
+		if len(fields) < 3 {
+			continue
+		}
+		message := fields[2]
+		if len(fields) == 4 {
+			message += "\n" + fields[3]
+		}
+		commits = append(commits, ChangelogCommit{
+			Hash:     fields[0],
+			Author:   fields[1],
+			Subject:  fields[2],
+			JiraKeys: jiraKeys(message),
+		})
+	}
+	return commits
+}
+
+// parseChangedFiles parses the output of git diff --name-status, renamed and copied files are reported with their
+// new path
+func parseChangedFiles(output string) []ChangedFile {
+	var files []ChangedFile
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		files = append(files, ChangedFile{Status: fields[0][:1], Path: fields[len(fields)-1]})
+	}
+	return files
+}
+
+// jiraKeys returns the Jira keys referenced by the message, in order of appearance
+func jiraKeys(message string) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, key := range jiraKeyRegex.FindAllString(message, -1) {
+		if seen[key] || notJiraProjects[key[:strings.Index(key, "-")]] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// JiraKeys returns the Jira keys referenced by the commits of the changelog, in order of appearance
+func (c *Changelog) JiraKeys() []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, commit := range c.Commits {
+		for _, key := range commit.JiraKeys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// String renders the changelog to be reviewed, and used as description of the promotion MR
+func (c *Changelog) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Changelog (%d commits, %d files changed):\n\n", len(c.Commits), len(c.Files))
+	for _, commit := range c.Commits {
+		hash := commit.Hash
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		fmt.Fprintf(&sb, "- %s %s (%s)", hash, commit.Subject, commit.Author)
+		if len(commit.JiraKeys) > 0 {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(commit.JiraKeys, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	if keys := c.JiraKeys(); len(keys) > 0 {
+		fmt.Fprintf(&sb, "\nJira: %s\n", strings.Join(keys, ", "))
+	}
+
+	if len(c.Files) > 0 {
+		sb.WriteString("\nFiles changed:\n\n")
+		for _, file := range c.Files {
+			fmt.Fprintf(&sb, "%s %s\n", file.Status, file.Path)
 		}
-		return gitHash, string(commitLog), nil
 	}
+	return sb.String()
 }
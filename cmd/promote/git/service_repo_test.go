@@ -0,0 +1,62 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChangelog(t *testing.T) {
+	commits := parseChangelogCommits("1111111111111111111111111111111111111111\x1fJane Doe\x1fOSD-1234: Fix the rollout\x1fRefs SREP-56 and OSD-1234, UTF-8 safe\n\x1e\n" +
+		"2222222222222222222222222222222222222222\x1fJohn Doe\x1fBump dependencies\x1f\x1e\n")
+	assert.Equal(t, []ChangelogCommit{
+		{Hash: "1111111111111111111111111111111111111111", Author: "Jane Doe", Subject: "OSD-1234: Fix the rollout", JiraKeys: []string{"OSD-1234", "SREP-56"}},
+		{Hash: "2222222222222222222222222222222222222222", Author: "John Doe", Subject: "Bump dependencies"},
+	}, commits)
+
+	files := parseChangedFiles("M\tdeploy/operator.yaml\nR100\told.go\tnew.go\nA\tpkg/new.go\n")
+	assert.Equal(t, []ChangedFile{{"M", "deploy/operator.yaml"}, {"R", "new.go"}, {"A", "pkg/new.go"}}, files)
+
+	changelog := &Changelog{Commits: commits, Files: files}
+	assert.Equal(t, []string{"OSD-1234", "SREP-56"}, changelog.JiraKeys())
+	assert.Equal(t, `Changelog (2 commits, 3 files changed):
+
+- 1111111 OSD-1234: Fix the rollout (Jane Doe) [OSD-1234, SREP-56]
+- 2222222 Bump dependencies (John Doe)
+
+Jira: OSD-1234, SREP-56
+
+Files changed:
+
+M deploy/operator.yaml
+R new.go
+A pkg/new.go
+`, changelog.String())
+}
+
+func TestSaasFileRefDiff(t *testing.T) {
+	a := AppInterface{GitDirectory: t.TempDir()}
+	saasFile := filepath.Join(a.GitDirectory, "data", "saas-foo.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(saasFile), 0755))
+	require.NoError(t, os.WriteFile(saasFile, []byte(`name: saas-foo
+resourceTemplates:
+- name: saas-foo
+  targets:
+  - name: hivep01-prod-canary
+    ref: 1111111
+  - name: hivep02
+    ref: 1111111
+`), 0600))
+
+	diff, err := a.SaasFileRefDiff(saasFile, "1111111", "2222222")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "--- a/data/saas-foo.yaml\n+++ b/data/saas-foo.yaml\n")
+	assert.Contains(t, diff, "-    ref: 1111111\n+    ref: \"2222222\"\n   - name: hivep02\n")
+
+	diff, err = a.PackageTagDiff(saasFile, "1111111", "3333333")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+    ref: 3333333\n")
+}
@@ -2,6 +2,7 @@ package pko
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/openshift/osdctl/cmd/promote/git"
 	"github.com/openshift/osdctl/cmd/promote/saas"
@@ -78,12 +79,31 @@ func PromotePackage(appInterface git.AppInterface, serviceName string, packageTa
 	if currentTag == packageTag {
 		return fmt.Errorf("current hash is already at '%s'. Nothing to do", packageTag)
 	}
+
+	// The package tags are the git hashes of the service repository, the changelog is best effort
+	var changelog *git.Changelog
+	packageRepo, err := git.GetPackageRepoFromAppInterface(saasFile)
+	if err == nil {
+		changelog, err = git.GetChangelog(packageRepo, currentTag, packageTag)
+	}
+	if err != nil {
+		fmt.Printf("Unable to get the changelog of the promotion: %v\n", err)
+	}
+	saasDiff, err := appInterface.PackageTagDiff(saasFile, currentTag, packageTag)
+	if err != nil {
+		return err
+	}
+	saas.PrintPromotionPreview(os.Stdout, changelog, saasDiff)
+
 	branchName := fmt.Sprintf("promote-%s-package-%s", serviceName, packageTag)
 	err = appInterface.UpdatePackageTag(saasFile, currentTag, packageTag, branchName)
 	if err != nil {
 		return err
 	}
 	commitMessage := fmt.Sprintf("Promote %s package to %s", serviceName, packageTag)
+	if changelog != nil {
+		commitMessage += fmt.Sprintf("\n\nSee %s/compare/%s...%s for contents of the promotion.\n\n%s", packageRepo, currentTag, packageTag, changelog)
+	}
 	err = appInterface.CommitSaasFile(saasFile, commitMessage)
 	if err != nil {
 		return err
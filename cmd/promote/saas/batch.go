@@ -0,0 +1,315 @@
+package saas
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/cmd/promote/git"
+	"github.com/openshift/osdctl/pkg/printer"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	osdEnvironment = "osd"
+	hcpEnvironment = "hcp"
+)
+
+// promotionManifest lists the services promoted together by a batch promotion
+type promotionManifest struct {
+	Services []manifestService `yaml:"services"`
+}
+
+// manifestService is a service of the promotion manifest
+type manifestService struct {
+	Name string `yaml:"name"`
+	// Hash is the git hash the service is promoted to, HEAD of master when empty
+	Hash string `yaml:"hash"`
+	// Environments are the saas files of the service getting promoted, osd and/or hcp, osd when empty
+	Environments []string `yaml:"environments"`
+	NamespaceRef string   `yaml:"namespaceRef"`
+}
+
+// plannedPromotion is the promotion of the saas file of a service in an environment
+type plannedPromotion struct {
+	Service     string
+	Environment string
+	SaasFile    string
+	Repo        string
+	From        string
+	To          string
+	Changelog   *git.Changelog
+	Branch      string
+}
+
+// compareFunc resolves the git hash to promote in the service repository, and returns it with the changelog of the
+// promotion, like git.CheckoutAndCompareGitHash
+type compareFunc func(gitURL, gitHash, currentGitHash string) (string, *git.Changelog, error)
+
+// comparison is the result of a compareFunc
+type comparison struct {
+	hash      string
+	changelog *git.Changelog
+}
+
+// readPromotionManifest reads and validates the promotion manifest
+func readPromotionManifest(manifestFile string) (*promotionManifest, error) {
+	data, err := os.ReadFile(manifestFile) //#nosec G304 -- manifestFile is provided by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", manifestFile, err)
+	}
+
+	manifest := &promotionManifest{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(manifest); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", manifestFile, err)
+	}
+
+	if len(manifest.Services) == 0 {
+		return nil, fmt.Errorf("manifest %s doesn't list any service", manifestFile)
+	}
+	var errs []error
+	for i := range manifest.Services {
+		service := &manifest.Services[i]
+		if service.Name == "" {
+			errs = append(errs, fmt.Errorf("service #%d: name is required", i+1))
+			continue
+		}
+		if len(service.Environments) == 0 {
+			service.Environments = []string{osdEnvironment}
+		}
+		for _, environment := range service.Environments {
+			if environment != osdEnvironment && environment != hcpEnvironment {
+				errs = append(errs, fmt.Errorf("service %s: unknown environment '%s', expected %s or %s", service.Name, environment, osdEnvironment, hcpEnvironment))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s:\n%w", manifestFile, err)
+	}
+
+	return manifest, nil
+}
+
+// batchPromotion promotes all the services of the manifest, with a branch and a commit by service or a single commit
+// for all of them
+func batchPromotion(appInterface git.AppInterface, manifestFile string, singleCommit bool) error {
+	manifest, err := readPromotionManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	_, err = GetServiceNames(appInterface, OSDSaasDir, BPSaasDir, CADSaasDir)
+	if err != nil {
+		return err
+	}
+
+	promotions, err := planPromotions(manifest, git.CheckoutAndCompareGitHash)
+	if err != nil {
+		return err
+	}
+
+	for _, promotion := range promotions {
+		saasDiff, err := appInterface.SaasFileRefDiff(promotion.SaasFile, promotion.From, promotion.To)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n### %s (%s): %s -> %s ###\n", promotion.Service, promotion.Environment, promotion.From, promotion.To)
+		PrintPromotionPreview(os.Stdout, promotion.Changelog, saasDiff)
+	}
+
+	if singleCommit {
+		err = applyPromotionsInSingleCommit(appInterface, promotions, time.Now())
+	} else {
+		err = applyPromotions(appInterface, promotions)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("")
+	printPromotionSummary(os.Stdout, promotions)
+	fmt.Println("")
+	fmt.Println("READY TO PUSH,", len(promotions), "promotions are ready locally")
+	return nil
+}
+
+// planPromotions validates every service of the manifest against its repository before anything is changed in
+// app-interface, and returns the promotions of the saas files
+func planPromotions(manifest *promotionManifest, compare compareFunc) ([]plannedPromotion, error) {
+	var promotions []plannedPromotion
+	var errs []error
+	saasFiles := map[string]string{}
+	// The osd and hcp saas files of a service usually deploy the same repository
+	compared := map[string]comparison{}
+
+	for _, service := range manifest.Services {
+		serviceName, err := ValidateServiceName(ServicesSlice, service.Name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, environment := range service.Environments {
+			saasFile, err := GetSaasDir(serviceName, environment == osdEnvironment, environment == hcpEnvironment)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if previous, found := saasFiles[saasFile]; found {
+				errs = append(errs, fmt.Errorf("service %s (%s): saas file %s is already promoted by %s", serviceName, environment, saasFile, previous))
+				continue
+			}
+			saasFiles[saasFile] = fmt.Sprintf("%s (%s)", serviceName, environment)
+
+			serviceData, err := os.ReadFile(saasFile) //#nosec G304 -- saasFile is in the app-interface checkout
+			if err != nil {
+				errs = append(errs, fmt.Errorf("service %s (%s): failed to read SAAS file: %v", serviceName, environment, err))
+				continue
+			}
+			currentGitHash, serviceRepo, err := git.GetCurrentGitHashFromAppInterface(serviceData, serviceName, service.NamespaceRef)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("service %s (%s): failed to get current git hash or service repo: %v", serviceName, environment, err))
+				continue
+			}
+
+			key := strings.Join([]string{serviceRepo, service.Hash, currentGitHash}, " ")
+			result, found := compared[key]
+			if !found {
+				promotionGitHash, changelog, err := compare(serviceRepo, service.Hash, currentGitHash)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("service %s (%s): failed to checkout and compare git hash: %v", serviceName, environment, err))
+					continue
+				}
+				if promotionGitHash == "" {
+					errs = append(errs, fmt.Errorf("service %s (%s): unable to find a git hash to promote", serviceName, environment))
+					continue
+				}
+				result = comparison{promotionGitHash, changelog}
+				compared[key] = result
+			}
+
+			promotions = append(promotions, plannedPromotion{
+				Service:     serviceName,
+				Environment: environment,
+				SaasFile:    saasFile,
+				Repo:        serviceRepo,
+				From:        currentGitHash,
+				To:          result.hash,
+				Changelog:   result.changelog,
+			})
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("nothing was promoted, the manifest has invalid services:\n%w", err)
+	}
+	return promotions, nil
+}
+
+// applyPromotions creates a branch with a commit for each service of the promotions
+func applyPromotions(appInterface git.AppInterface, promotions []plannedPromotion) error {
+	var services []string
+	byService := map[string][]int{}
+	for i, promotion := range promotions {
+		if _, found := byService[promotion.Service]; !found {
+			services = append(services, promotion.Service)
+		}
+		byService[promotion.Service] = append(byService[promotion.Service], i)
+	}
+
+	for _, service := range services {
+		first := promotions[byService[service][0]]
+		branchName := fmt.Sprintf("promote-%s-%s", service, first.To)
+		if err := appInterface.CreateBranch(branchName); err != nil {
+			return err
+		}
+
+		var saasFiles []string
+		var messages []string
+		for _, i := range byService[service] {
+			promotion := &promotions[i]
+			if err := appInterface.UpdateSaasFileRef(promotion.SaasFile, promotion.From, promotion.To); err != nil {
+				return fmt.Errorf("failed to promote %s (%s): %w", promotion.Service, promotion.Environment, err)
+			}
+			promotion.Branch = branchName
+			saasFiles = append(saasFiles, promotion.SaasFile)
+			messages = append(messages, promotionCommitMessage(promotion.Service, promotion.Repo, promotion.From, promotion.To, promotion.Changelog))
+		}
+
+		if err := appInterface.CommitSaasFiles(saasFiles, uniqueMessages(messages)); err != nil {
+			return fmt.Errorf("failed to commit changes to app-interface: %w", err)
+		}
+		fmt.Printf("The branch %s is ready to be pushed\n", branchName)
+	}
+
+	return nil
+}
+
+// applyPromotionsInSingleCommit creates a single branch with a single commit for all the promotions
+func applyPromotionsInSingleCommit(appInterface git.AppInterface, promotions []plannedPromotion, now time.Time) error {
+	branchName := fmt.Sprintf("promote-batch-%s", now.UTC().Format("20060102150405"))
+	if err := appInterface.CreateBranch(branchName); err != nil {
+		return err
+	}
+
+	var saasFiles []string
+	var services []string
+	var messages []string
+	for i := range promotions {
+		promotion := &promotions[i]
+		if err := appInterface.UpdateSaasFileRef(promotion.SaasFile, promotion.From, promotion.To); err != nil {
+			return fmt.Errorf("failed to promote %s (%s): %w", promotion.Service, promotion.Environment, err)
+		}
+		promotion.Branch = branchName
+		saasFiles = append(saasFiles, promotion.SaasFile)
+		if len(services) == 0 || services[len(services)-1] != promotion.Service {
+			services = append(services, promotion.Service)
+		}
+		messages = append(messages, promotionCommitMessage(promotion.Service, promotion.Repo, promotion.From, promotion.To, promotion.Changelog))
+	}
+
+	commitMessage := fmt.Sprintf("Promote %s\n\n%s", strings.Join(services, ", "), uniqueMessages(messages))
+	if err := appInterface.CommitSaasFiles(saasFiles, commitMessage); err != nil {
+		return fmt.Errorf("failed to commit changes to app-interface: %w", err)
+	}
+	fmt.Printf("The branch %s is ready to be pushed\n", branchName)
+	return nil
+}
+
+// uniqueMessages joins the commit messages, leaving out the duplicates of the services promoted in osd and hcp
+func uniqueMessages(messages []string) string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, message := range messages {
+		if !seen[message] {
+			seen[message] = true
+			unique = append(unique, strings.TrimSpace(message))
+		}
+	}
+	return strings.Join(unique, "\n\n")
+}
+
+// promotionCommitMessage returns the commit message of the promotion of a service, with its changelog as description
+// of the MR
+func promotionCommitMessage(serviceName, serviceRepo, currentGitHash, promotionGitHash string, changelog *git.Changelog) string {
+	operatorName := strings.TrimPrefix(serviceName, "saas-")
+	commitMessage := fmt.Sprintf("Promote %s to %s\n\nMonitor rollout status here https://inscope.corp.redhat.com/catalog/default/component/%s/rollout\n\n", serviceName, promotionGitHash, operatorName)
+	commitMessage += fmt.Sprintf("See %s/compare/%s...%s for contents of the promotion.\n\n%s", serviceRepo, currentGitHash, promotionGitHash, changelog)
+	return commitMessage
+}
+
+// printPromotionSummary prints what was promoted, from which hash to which hash
+func printPromotionSummary(w io.Writer, promotions []plannedPromotion) {
+	p := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	p.AddRow([]string{"SERVICE", "ENV", "FROM", "TO", "BRANCH"})
+	for _, promotion := range promotions {
+		p.AddRow([]string{promotion.Service, promotion.Environment, promotion.From, promotion.To, promotion.Branch})
+	}
+	_ = p.Flush()
+}
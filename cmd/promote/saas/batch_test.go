@@ -0,0 +1,145 @@
+package saas
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openshift/osdctl/cmd/promote/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func testSaasFile(name, repo, ref string) string {
+	return fmt.Sprintf(`name: %s
+resourceTemplates:
+- name: %s
+  url: %s
+  targets:
+  - name: hivep01-prod-canary
+    namespace:
+      $ref: /services/hive/hivep01/namespaces/%s.yml
+    ref: %s
+`, name, name, repo, name, ref)
+}
+
+func TestReadPromotionManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	manifestFile := filepath.Join(dir, "manifest.yaml")
+	writeTestFile(t, manifestFile, `services:
+- name: saas-foo
+  hash: abc123
+- name: bar
+  environments: [osd, hcp]
+`)
+	manifest, err := readPromotionManifest(manifestFile)
+	require.NoError(t, err)
+	assert.Equal(t, []manifestService{
+		{Name: "saas-foo", Hash: "abc123", Environments: []string{"osd"}},
+		{Name: "bar", Environments: []string{"osd", "hcp"}},
+	}, manifest.Services)
+
+	invalidFile := filepath.Join(dir, "invalid.yaml")
+	writeTestFile(t, invalidFile, `services:
+- hash: abc123
+- name: bar
+  environments: [stage]
+`)
+	_, err = readPromotionManifest(invalidFile)
+	assert.ErrorContains(t, err, "service #1: name is required")
+	assert.ErrorContains(t, err, "service bar: unknown environment 'stage'")
+
+	unknownFieldFile := filepath.Join(dir, "unknown.yaml")
+	writeTestFile(t, unknownFieldFile, "services:\n- name: saas-foo\n  gitHash: abc123\n")
+	_, err = readPromotionManifest(unknownFieldFile)
+	assert.ErrorContains(t, err, "field gitHash not found")
+
+	emptyFile := filepath.Join(dir, "empty.yaml")
+	writeTestFile(t, emptyFile, "")
+	_, err = readPromotionManifest(emptyFile)
+	assert.ErrorContains(t, err, "doesn't list any service")
+}
+
+func TestPlanPromotions(t *testing.T) {
+	ServicesSlice = nil
+	ServicesFilesMap = map[string]string{}
+	appInterface := git.AppInterface{GitDirectory: t.TempDir()}
+	saasDir := filepath.Join(appInterface.GitDirectory, OSDSaasDir)
+	writeTestFile(t, filepath.Join(saasDir, "saas-foo.yaml"), testSaasFile("saas-foo", "https://github.com/openshift/foo", "1111111"))
+	writeTestFile(t, filepath.Join(saasDir, "saas-bar", "deploy.yaml"), testSaasFile("saas-bar", "https://github.com/openshift/bar", "2222222"))
+	writeTestFile(t, filepath.Join(saasDir, "saas-bar", "hypershift-deploy.yaml"), testSaasFile("saas-bar", "https://github.com/openshift/bar", "2222222"))
+	_, err := GetServiceNames(appInterface, OSDSaasDir)
+	require.NoError(t, err)
+
+	var compared []string
+	compare := func(gitURL, gitHash, currentGitHash string) (string, *git.Changelog, error) {
+		compared = append(compared, gitURL)
+		if gitHash == "" {
+			gitHash = "3333333"
+		}
+		if gitHash == "missing" {
+			return "", nil, fmt.Errorf("unknown revision %s", gitHash)
+		}
+		return gitHash, &git.Changelog{Commits: []git.ChangelogCommit{{Hash: gitHash, Subject: "commit " + gitHash}}}, nil
+	}
+
+	promotions, err := planPromotions(&promotionManifest{Services: []manifestService{
+		{Name: "foo", Hash: "4444444", Environments: []string{"osd"}},
+		{Name: "saas-bar", Environments: []string{"osd", "hcp"}},
+	}}, compare)
+	require.NoError(t, err)
+	require.Len(t, promotions, 3)
+	assert.Equal(t, plannedPromotion{
+		Service:     "saas-foo",
+		Environment: "osd",
+		SaasFile:    filepath.Join(saasDir, "saas-foo.yaml"),
+		Repo:        "https://github.com/openshift/foo",
+		From:        "1111111",
+		To:          "4444444",
+		Changelog:   &git.Changelog{Commits: []git.ChangelogCommit{{Hash: "4444444", Subject: "commit 4444444"}}},
+	}, promotions[0])
+	// Both saas files of saas-bar share the changelog
+	assert.Same(t, promotions[1].Changelog, promotions[2].Changelog)
+	assert.Equal(t, "3333333", promotions[1].To)
+	assert.Equal(t, "hcp", promotions[2].Environment)
+	// The repository deployed by both saas files of saas-bar is only compared once
+	assert.Equal(t, []string{"https://github.com/openshift/foo", "https://github.com/openshift/bar"}, compared)
+
+	_, err = planPromotions(&promotionManifest{Services: []manifestService{
+		{Name: "foo", Hash: "missing", Environments: []string{"osd"}},
+		{Name: "baz", Environments: []string{"osd"}},
+		{Name: "saas-bar", Environments: []string{"osd"}},
+		{Name: "bar", Environments: []string{"osd"}},
+	}}, compare)
+	assert.ErrorContains(t, err, "nothing was promoted")
+	assert.ErrorContains(t, err, "service saas-foo (osd): failed to checkout and compare git hash: unknown revision missing")
+	assert.ErrorContains(t, err, "service baz not found")
+	assert.ErrorContains(t, err, "is already promoted by saas-bar (osd)")
+}
+
+func TestPromotionSummaryAndCommitMessage(t *testing.T) {
+	out := &bytes.Buffer{}
+	printPromotionSummary(out, []plannedPromotion{
+		{Service: "saas-foo", Environment: "osd", From: "1111111", To: "4444444", Branch: "promote-saas-foo-4444444"},
+	})
+	assert.Regexp(t, `SERVICE\s+ENV\s+FROM\s+TO\s+BRANCH`, out.String())
+	assert.Regexp(t, `saas-foo\s+osd\s+1111111\s+4444444\s+promote-saas-foo-4444444`, out.String())
+
+	message := promotionCommitMessage("saas-foo", "https://github.com/openshift/foo", "1111111", "4444444", &git.Changelog{
+		Commits: []git.ChangelogCommit{{Hash: "4444444", Author: "Jane Doe", Subject: "Fix the rollout", JiraKeys: []string{"OSD-1234"}}},
+	})
+	assert.Contains(t, message, "Promote saas-foo to 4444444")
+	assert.Contains(t, message, "https://inscope.corp.redhat.com/catalog/default/component/foo/rollout")
+	assert.Contains(t, message, "See https://github.com/openshift/foo/compare/1111111...4444444")
+	assert.Contains(t, message, "- 4444444 Fix the rollout (Jane Doe) [OSD-1234]")
+	assert.Equal(t, strings.TrimSpace(message), uniqueMessages([]string{message, message}))
+}
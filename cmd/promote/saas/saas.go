@@ -17,6 +17,8 @@ type saasOptions struct {
 	serviceName             string
 	gitHash                 string
 	namespaceRef            string
+	manifest                string
+	singleCommit            bool
 }
 
 // newCmdSaas implementes the saas command to interact with promoting SaaS services/operators
@@ -34,13 +36,26 @@ func NewCmdSaas() *cobra.Command {
 		# Promote a SaaS service/operator
 		osdctl promote saas --serviceName <service-name> --gitHash <git-hash> --osd
 		or
-		osdctl promote saas --serviceName <service-name> --gitHash <git-hash> --hcp`,
+		osdctl promote saas --serviceName <service-name> --gitHash <git-hash> --hcp
+
+		# Promote several SaaS services/operators listed in a manifest, with a branch and a commit by service
+		osdctl promote saas --manifest promotions.yaml
+
+		# The manifest lists the services, their target git hash (HEAD of master when empty) and environments (osd when empty)
+		services:
+		- name: saas-managed-cluster-config
+		  hash: 0123456789abcdef0123456789abcdef01234567
+		- name: saas-configure-alertmanager-operator
+		  environments: [osd, hcp]
+
+		# Promote the services of the manifest in a single commit
+		osdctl promote saas --manifest promotions.yaml --single-commit`,
 		Run: func(cmd *cobra.Command, args []string) {
 			ops.validateSaasFlow()
 			appInterface := git.BootstrapOsdCtlForAppInterfaceAndServicePromotions(ops.appInterfaceCheckoutDir)
 
 			if ops.list {
-				if ops.serviceName != "" || ops.gitHash != "" || ops.osd || ops.hcp {
+				if ops.serviceName != "" || ops.gitHash != "" || ops.osd || ops.hcp || ops.manifest != "" {
 					fmt.Printf("Error: --list cannot be used with any other flags\n\n")
 					cmd.Help()
 					os.Exit(1)
@@ -49,6 +64,26 @@ func NewCmdSaas() *cobra.Command {
 				os.Exit(0)
 			}
 
+			if ops.singleCommit && ops.manifest == "" {
+				fmt.Printf("Error: --single-commit cannot be used without --manifest\n\n")
+				cmd.Help()
+				os.Exit(1)
+			}
+
+			if ops.manifest != "" {
+				if ops.serviceName != "" || ops.gitHash != "" || ops.namespaceRef != "" || ops.osd || ops.hcp {
+					fmt.Printf("Error: --manifest cannot be used with --serviceName, --gitHash, --namespaceRef, --osd or --hcp\n\n")
+					cmd.Help()
+					os.Exit(1)
+				}
+				err := batchPromotion(appInterface, ops.manifest, ops.singleCommit)
+				if err != nil {
+					fmt.Printf("Error while promoting services: %v\n", err)
+					os.Exit(1)
+				}
+				os.Exit(0)
+			}
+
 			if !(ops.osd || ops.hcp) && ops.serviceName != "" {
 				fmt.Printf("Error: --serviceName cannot be used without either --osd or --hcp\n\n")
 				cmd.Help()
@@ -71,13 +106,15 @@ func NewCmdSaas() *cobra.Command {
 	saasCmd.Flags().StringVarP(&ops.namespaceRef, "namespaceRef", "n", "", "SaaS target namespace reference name")
 	saasCmd.Flags().BoolVarP(&ops.osd, "osd", "", false, "OSD service/operator getting promoted")
 	saasCmd.Flags().BoolVarP(&ops.hcp, "hcp", "", false, "HCP service/operator getting promoted")
+	saasCmd.Flags().StringVarP(&ops.manifest, "manifest", "", "", "YAML manifest listing the services/operators getting promoted, with their git hash and environments")
+	saasCmd.Flags().BoolVarP(&ops.singleCommit, "single-commit", "", false, "Promote all the services/operators of the manifest in a single commit instead of a branch and a commit by service")
 	saasCmd.Flags().StringVarP(&ops.appInterfaceCheckoutDir, "appInterfaceDir", "", "", "location of app-interface checkout. Falls back to current working directory")
 
 	return saasCmd
 }
 
 func (o *saasOptions) validateSaasFlow() {
-	if o.serviceName == "" && o.gitHash == "" {
+	if o.serviceName == "" && o.gitHash == "" && o.manifest == "" {
 		fmt.Printf("Usage: For SaaS services/operators, please provide --serviceName and (optional) --gitHash\n")
 		fmt.Printf("--serviceName is the name of the service, i.e. saas-managed-cluster-config\n")
 		fmt.Printf("--gitHash is the target git commit in the service, if not specified defaults to HEAD of master\n\n")
@@ -2,6 +2,7 @@ package saas
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -64,7 +65,7 @@ func servicePromotion(appInterface git.AppInterface, serviceName, gitHash string
 	}
 	fmt.Printf("Current Git Hash: %v\nGit Repo: %v\n\n", currentGitHash, serviceRepo)
 
-	promotionGitHash, commitLog, err := git.CheckoutAndCompareGitHash(serviceRepo, gitHash, currentGitHash)
+	promotionGitHash, changelog, err := git.CheckoutAndCompareGitHash(serviceRepo, gitHash, currentGitHash)
 	if err != nil {
 		return fmt.Errorf("failed to checkout and compare git hash: %v", err)
 	} else if promotionGitHash == "" {
@@ -73,15 +74,18 @@ func servicePromotion(appInterface git.AppInterface, serviceName, gitHash string
 	}
 	fmt.Printf("Service: %s will be promoted to %s\n", serviceName, promotionGitHash)
 
+	saasDiff, err := appInterface.SaasFileRefDiff(saasDir, currentGitHash, promotionGitHash)
+	if err != nil {
+		return err
+	}
+	PrintPromotionPreview(os.Stdout, changelog, saasDiff)
+
 	branchName := fmt.Sprintf("promote-%s-%s", serviceName, promotionGitHash)
 	err = appInterface.UpdateAppInterface(serviceName, saasDir, currentGitHash, promotionGitHash, branchName)
 	if err != nil {
 		fmt.Printf("FAILURE: %v\n", err)
 	}
-	prefix := "saas-"
-	operatorName := strings.TrimPrefix(serviceName, prefix)
-	commitMessage := fmt.Sprintf("Promote %s to %s\n\nMonitor rollout status here https://inscope.corp.redhat.com/catalog/default/component/%s/rollout\n\n", serviceName, promotionGitHash, operatorName)
-	commitMessage += fmt.Sprintf("See %s/compare/%s...%s for contents of the promotion. clog:\n\n%s", serviceRepo, currentGitHash, promotionGitHash, commitLog)
+	commitMessage := promotionCommitMessage(serviceName, serviceRepo, currentGitHash, promotionGitHash, changelog)
 	err = appInterface.CommitSaasFile(saasDir, commitMessage)
 	if err != nil {
 		return fmt.Errorf("failed to commit changes to app-interface: %w", err)
@@ -97,6 +101,15 @@ func servicePromotion(appInterface git.AppInterface, serviceName, gitHash string
 	return nil
 }
 
+// PrintPromotionPreview prints the changelog of the promotion in the service repository, when known, and the diff
+// applied to the saas file in app-interface
+func PrintPromotionPreview(w io.Writer, changelog *git.Changelog, saasDiff string) {
+	if changelog != nil {
+		fmt.Fprintf(w, "\n### Changelog ###\n%s", changelog)
+	}
+	fmt.Fprintf(w, "\n### app-interface diff ###\n%s\n", saasDiff)
+}
+
 func GetServiceNames(appInterface git.AppInterface, saaDirs ...string) ([]string, error) {
 	baseDir := appInterface.GitDirectory
 
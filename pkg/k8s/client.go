@@ -27,25 +27,12 @@ type lazyClientInitializer struct {
 }
 
 func (b *lazyClientInitializer) initialize(s *LazyClient) {
-	configLoader := s.flags.ToRawKubeConfigLoader()
-	cfg, err := configLoader.ClientConfig()
+	cfg, err := s.RESTConfig()
 	if err != nil {
 		//The stub is to allow commands that don't need a connection to a Kubernetes cluster.
 		//We'll produce a warning and the stub itself will error when a command is trying to use it.
 		panic(s.err())
 	}
-	if len(s.userName) > 0 || len(s.elevationReasons) > 0 {
-		if len(s.userName) == 0 {
-			s.userName = "backplane-cluster-admin"
-		}
-		impersonationConfig := rest.ImpersonationConfig{
-			UserName: s.userName,
-		}
-		if len(s.elevationReasons) > 0 {
-			impersonationConfig.Extra = map[string][]string{"reason": s.elevationReasons}
-		}
-		cfg.Impersonate = impersonationConfig
-	}
 	setRuntimeLoggerDiscard()
 	s.client, err = client.New(cfg, client.Options{})
 	if err != nil {
@@ -127,6 +114,33 @@ func (s *LazyClient) Impersonate(userName string, elevationReasons ...string) {
 	s.elevationReasons = elevationReasons
 }
 
+// RESTConfig returns the REST config the client connects with, including any impersonation, e.g. for streaming
+// requests the client doesn't support such as exec
+func (s *LazyClient) RESTConfig() (*rest.Config, error) {
+	if s.flags == nil {
+		return nil, s.err()
+	}
+
+	cfg, err := s.flags.ToRawKubeConfigLoader().ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(s.userName) > 0 || len(s.elevationReasons) > 0 {
+		if len(s.userName) == 0 {
+			s.userName = "backplane-cluster-admin"
+		}
+		impersonationConfig := rest.ImpersonationConfig{
+			UserName: s.userName,
+		}
+		if len(s.elevationReasons) > 0 {
+			impersonationConfig.Extra = map[string][]string{"reason": s.elevationReasons}
+		}
+		cfg.Impersonate = impersonationConfig
+	}
+
+	return cfg, nil
+}
+
 func NewClient(flags *genericclioptions.ConfigFlags) *LazyClient {
 	return &LazyClient{&lazyClientInitializer{}, nil, flags, "", nil}
 }
@@ -2,11 +2,18 @@ package osdCloud
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	elbTypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2Types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
 	sdk "github.com/openshift-online/ocm-sdk-go"
@@ -381,3 +388,421 @@ func (a *AwsCluster) GetAllVirtualMachines(string) ([]VirtualMachine, error) {
 	}
 	return vms, nil
 }
+
+// clusterTag returns the tag key the installer and the cloud provider put on every AWS resource owned by the cluster
+func (a *AwsCluster) clusterTag() string {
+	return "kubernetes.io/cluster/" + a.Cluster.InfraID()
+}
+
+func (a *AwsCluster) GetLoadBalancers() ([]LoadBalancer, error) {
+	classic, err := a.getClassicLoadBalancers()
+	if err != nil {
+		return nil, err
+	}
+	v2, err := a.getV2LoadBalancers()
+	if err != nil {
+		return nil, err
+	}
+	return append(classic, v2...), nil
+}
+
+func (a *AwsCluster) getClassicLoadBalancers() ([]LoadBalancer, error) {
+	var descriptions []elbTypes.LoadBalancerDescription
+	var marker *string
+	for {
+		output, err := a.AwsClient.DescribeLoadBalancers(&elasticloadbalancing.DescribeLoadBalancersInput{Marker: marker})
+		if err != nil {
+			return nil, err
+		}
+		descriptions = append(descriptions, output.LoadBalancerDescriptions...)
+		if output.NextMarker == nil {
+			break
+		}
+		marker = output.NextMarker
+	}
+
+	// Tags can only be described for up to 20 load balancers at once
+	tags := map[string]map[string]string{}
+	for start := 0; start < len(descriptions); start += 20 {
+		var names []string
+		for _, description := range descriptions[start:min(start+20, len(descriptions))] {
+			names = append(names, awsSdk.ToString(description.LoadBalancerName))
+		}
+		output, err := a.AwsClient.DescribeTags(&elasticloadbalancing.DescribeTagsInput{LoadBalancerNames: names})
+		if err != nil {
+			return nil, err
+		}
+		for _, description := range output.TagDescriptions {
+			labels := map[string]string{}
+			for _, tag := range description.Tags {
+				labels[awsSdk.ToString(tag.Key)] = awsSdk.ToString(tag.Value)
+			}
+			tags[awsSdk.ToString(description.LoadBalancerName)] = labels
+		}
+	}
+
+	var loadBalancers []LoadBalancer
+	for _, description := range descriptions {
+		name := awsSdk.ToString(description.LoadBalancerName)
+		if _, ok := tags[name][a.clusterTag()]; !ok {
+			continue
+		}
+
+		// Classic load balancers report the health of their instances rather than of each listener
+		health, err := a.AwsClient.DescribeInstanceHealth(&elasticloadbalancing.DescribeInstanceHealthInput{LoadBalancerName: description.LoadBalancerName})
+		if err != nil {
+			return nil, err
+		}
+		var healthy, unhealthy []string
+		for _, state := range health.InstanceStates {
+			if awsSdk.ToString(state.State) == "InService" {
+				healthy = append(healthy, awsSdk.ToString(state.InstanceId))
+			} else {
+				unhealthy = append(unhealthy, awsSdk.ToString(state.InstanceId))
+			}
+		}
+
+		var listeners []LoadBalancerListener
+		for _, listener := range description.ListenerDescriptions {
+			if listener.Listener == nil {
+				continue
+			}
+			listeners = append(listeners, LoadBalancerListener{
+				Protocol:         awsSdk.ToString(listener.Listener.Protocol),
+				Port:             strconv.Itoa(int(listener.Listener.LoadBalancerPort)),
+				HealthyTargets:   healthy,
+				UnhealthyTargets: unhealthy,
+			})
+		}
+
+		loadBalancers = append(loadBalancers, LoadBalancer{
+			Original:  description,
+			Name:      name,
+			Type:      "classic",
+			Internal:  awsSdk.ToString(description.Scheme) == "internal",
+			Address:   awsSdk.ToString(description.DNSName),
+			Listeners: listeners,
+			Labels:    tags[name],
+		})
+	}
+	return loadBalancers, nil
+}
+
+func (a *AwsCluster) getV2LoadBalancers() ([]LoadBalancer, error) {
+	var descriptions []elbv2Types.LoadBalancer
+	var marker *string
+	for {
+		output, err := a.AwsClient.DescribeV2LoadBalancers(&elasticloadbalancingv2.DescribeLoadBalancersInput{Marker: marker})
+		if err != nil {
+			return nil, err
+		}
+		descriptions = append(descriptions, output.LoadBalancers...)
+		if output.NextMarker == nil {
+			break
+		}
+		marker = output.NextMarker
+	}
+
+	// Tags can only be described for up to 20 load balancers at once
+	tags := map[string]map[string]string{}
+	for start := 0; start < len(descriptions); start += 20 {
+		var arns []string
+		for _, description := range descriptions[start:min(start+20, len(descriptions))] {
+			arns = append(arns, awsSdk.ToString(description.LoadBalancerArn))
+		}
+		output, err := a.AwsClient.DescribeV2Tags(&elasticloadbalancingv2.DescribeTagsInput{ResourceArns: arns})
+		if err != nil {
+			return nil, err
+		}
+		for _, description := range output.TagDescriptions {
+			labels := map[string]string{}
+			for _, tag := range description.Tags {
+				labels[awsSdk.ToString(tag.Key)] = awsSdk.ToString(tag.Value)
+			}
+			tags[awsSdk.ToString(description.ResourceArn)] = labels
+		}
+	}
+
+	var loadBalancers []LoadBalancer
+	for _, description := range descriptions {
+		arn := awsSdk.ToString(description.LoadBalancerArn)
+		if _, ok := tags[arn][a.clusterTag()]; !ok {
+			continue
+		}
+
+		var listeners []LoadBalancerListener
+		var marker *string
+		for {
+			output, err := a.AwsClient.DescribeV2Listeners(&elasticloadbalancingv2.DescribeListenersInput{
+				LoadBalancerArn: description.LoadBalancerArn,
+				Marker:          marker,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, listener := range output.Listeners {
+				healthy, unhealthy, err := a.getListenerTargetHealth(listener)
+				if err != nil {
+					return nil, err
+				}
+				listeners = append(listeners, LoadBalancerListener{
+					Protocol:         string(listener.Protocol),
+					Port:             strconv.Itoa(int(awsSdk.ToInt32(listener.Port))),
+					HealthyTargets:   healthy,
+					UnhealthyTargets: unhealthy,
+				})
+			}
+			if output.NextMarker == nil {
+				break
+			}
+			marker = output.NextMarker
+		}
+
+		loadBalancers = append(loadBalancers, LoadBalancer{
+			Original:  description,
+			Name:      awsSdk.ToString(description.LoadBalancerName),
+			Type:      string(description.Type),
+			Internal:  description.Scheme == elbv2Types.LoadBalancerSchemeEnumInternal,
+			Address:   awsSdk.ToString(description.DNSName),
+			Listeners: listeners,
+			Labels:    tags[arn],
+		})
+	}
+	return loadBalancers, nil
+}
+
+// getListenerTargetHealth returns the IDs of the healthy and unhealthy targets of the target groups a listener forwards
+// to by default
+func (a *AwsCluster) getListenerTargetHealth(listener elbv2Types.Listener) ([]string, []string, error) {
+	var targetGroupArns []*string
+	for _, action := range listener.DefaultActions {
+		if action.TargetGroupArn != nil {
+			targetGroupArns = append(targetGroupArns, action.TargetGroupArn)
+			continue
+		}
+		if action.ForwardConfig != nil {
+			for _, targetGroup := range action.ForwardConfig.TargetGroups {
+				targetGroupArns = append(targetGroupArns, targetGroup.TargetGroupArn)
+			}
+		}
+	}
+
+	var healthy, unhealthy []string
+	for _, targetGroupArn := range targetGroupArns {
+		output, err := a.AwsClient.DescribeV2TargetHealth(&elasticloadbalancingv2.DescribeTargetHealthInput{TargetGroupArn: targetGroupArn})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, description := range output.TargetHealthDescriptions {
+			if description.Target == nil {
+				continue
+			}
+			if description.TargetHealth != nil && description.TargetHealth.State == elbv2Types.TargetHealthStateEnumHealthy {
+				healthy = append(healthy, awsSdk.ToString(description.Target.Id))
+			} else {
+				unhealthy = append(unhealthy, awsSdk.ToString(description.Target.Id))
+			}
+		}
+	}
+	return healthy, unhealthy, nil
+}
+
+func (a *AwsCluster) GetVolumes() ([]Volume, error) {
+	var volumes []Volume
+	var nextToken *string
+	for {
+		output, err := a.AwsClient.DescribeVolumes(&ec2.DescribeVolumesInput{
+			Filters: []ec2Types.Filter{
+				{
+					Name:   awsSdk.String("tag-key"),
+					Values: []string{a.clusterTag()},
+				},
+			},
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, volume := range output.Volumes {
+			labels := map[string]string{}
+			var name string
+			for _, t := range volume.Tags {
+				labels[awsSdk.ToString(t.Key)] = awsSdk.ToString(t.Value)
+				if awsSdk.ToString(t.Key) == "Name" {
+					name = awsSdk.ToString(t.Value)
+				}
+			}
+			var attachments []VolumeAttachment
+			for _, attachment := range volume.Attachments {
+				attachments = append(attachments, VolumeAttachment{
+					VirtualMachine: awsSdk.ToString(attachment.InstanceId),
+					Device:         awsSdk.ToString(attachment.Device),
+					State:          string(attachment.State),
+				})
+			}
+			volumes = append(volumes, Volume{
+				Original:    volume,
+				ID:          awsSdk.ToString(volume.VolumeId),
+				Name:        name,
+				Type:        string(volume.VolumeType),
+				Zone:        awsSdk.ToString(volume.AvailabilityZone),
+				SizeGiB:     int64(awsSdk.ToInt32(volume.Size)),
+				State:       string(volume.State),
+				Attachments: attachments,
+				Labels:      labels,
+			})
+		}
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+	return volumes, nil
+}
+
+func (a *AwsCluster) GetDNSZones() ([]DNSZone, error) {
+	domain := a.ClusterDomain()
+
+	var zones []DNSZone
+	var marker *string
+	for {
+		output, err := a.AwsClient.ListHostedZones(&route53.ListHostedZonesInput{Marker: marker})
+		if err != nil {
+			return nil, err
+		}
+		for _, hostedZone := range output.HostedZones {
+			// The cluster's records live in its private zone and in the public zone of its base domain
+			name := awsSdk.ToString(hostedZone.Name)
+			if !inDomain(domain, name) {
+				continue
+			}
+			records, err := a.getDNSRecords(hostedZone.Id, domain)
+			if err != nil {
+				return nil, err
+			}
+			zones = append(zones, DNSZone{
+				Original: hostedZone,
+				ID:       strings.TrimPrefix(awsSdk.ToString(hostedZone.Id), "/hostedzone/"),
+				Name:     name,
+				Private:  hostedZone.Config != nil && hostedZone.Config.PrivateZone,
+				Records:  records,
+			})
+		}
+		if !output.IsTruncated {
+			break
+		}
+		marker = output.NextMarker
+	}
+	return zones, nil
+}
+
+// getDNSRecords returns the records of a hosted zone which are within the domain
+func (a *AwsCluster) getDNSRecords(hostedZoneID *string, domain string) ([]DNSRecord, error) {
+	var records []DNSRecord
+	input := &route53.ListResourceRecordSetsInput{HostedZoneId: hostedZoneID}
+	for {
+		output, err := a.AwsClient.ListResourceRecordSets(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, recordSet := range output.ResourceRecordSets {
+			name := awsSdk.ToString(recordSet.Name)
+			if !inDomain(name, domain) {
+				continue
+			}
+			var values []string
+			for _, record := range recordSet.ResourceRecords {
+				values = append(values, awsSdk.ToString(record.Value))
+			}
+			if recordSet.AliasTarget != nil {
+				values = append(values, awsSdk.ToString(recordSet.AliasTarget.DNSName))
+			}
+			records = append(records, DNSRecord{
+				// Route53 escapes the wildcard of wildcard records
+				Name:   strings.ReplaceAll(name, `\052`, "*"),
+				Type:   string(recordSet.Type),
+				TTL:    awsSdk.ToInt64(recordSet.TTL),
+				Values: values,
+			})
+		}
+		if !output.IsTruncated {
+			break
+		}
+		input.StartRecordName = output.NextRecordName
+		input.StartRecordType = output.NextRecordType
+		input.StartRecordIdentifier = output.NextRecordIdentifier
+	}
+	return records, nil
+}
+
+func (a *AwsCluster) GetFirewallRules() ([]FirewallRule, error) {
+	var rules []FirewallRule
+	var nextToken *string
+	for {
+		output, err := a.AwsClient.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+			Filters: []ec2Types.Filter{
+				{
+					Name:   awsSdk.String("tag-key"),
+					Values: []string{a.clusterTag()},
+				},
+			},
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, securityGroup := range output.SecurityGroups {
+			for _, permission := range securityGroup.IpPermissions {
+				rules = append(rules, awsFirewallRule(securityGroup, permission, true))
+			}
+			for _, permission := range securityGroup.IpPermissionsEgress {
+				rules = append(rules, awsFirewallRule(securityGroup, permission, false))
+			}
+		}
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+	return rules, nil
+}
+
+func awsFirewallRule(securityGroup ec2Types.SecurityGroup, permission ec2Types.IpPermission, ingress bool) FirewallRule {
+	protocol := awsSdk.ToString(permission.IpProtocol)
+	from, to := awsSdk.ToInt32(permission.FromPort), awsSdk.ToInt32(permission.ToPort)
+	var ports string
+	switch {
+	case protocol == "-1":
+		protocol = "all"
+	case from == -1:
+		// ICMP rules for all types don't have ports
+	case from == to:
+		ports = strconv.Itoa(int(from))
+	default:
+		ports = fmt.Sprintf("%d-%d", from, to)
+	}
+
+	var peers []string
+	for _, ipRange := range permission.IpRanges {
+		peers = append(peers, awsSdk.ToString(ipRange.CidrIp))
+	}
+	for _, ipRange := range permission.Ipv6Ranges {
+		peers = append(peers, awsSdk.ToString(ipRange.CidrIpv6))
+	}
+	for _, prefixList := range permission.PrefixListIds {
+		peers = append(peers, awsSdk.ToString(prefixList.PrefixListId))
+	}
+	for _, pair := range permission.UserIdGroupPairs {
+		peers = append(peers, awsSdk.ToString(pair.GroupId))
+	}
+
+	return FirewallRule{
+		Original: permission,
+		Name:     awsSdk.ToString(securityGroup.GroupName),
+		Ingress:  ingress,
+		Allow:    true,
+		Protocol: protocol,
+		Ports:    ports,
+		Peers:    peers,
+	}
+}
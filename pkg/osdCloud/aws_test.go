@@ -0,0 +1,203 @@
+package osdCloud
+
+import (
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	elbTypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2Types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53Types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestAwsCluster(t *testing.T) (*AwsCluster, *mock.MockClient) {
+	cluster, err := cmv1.NewCluster().Name("mycluster").InfraID("infra-abc").
+		DNS(cmv1.NewDNS().BaseDomain("abcd.s1.devshift.org")).Build()
+	require.NoError(t, err)
+	awsClient := mock.NewMockClient(gomock.NewController(t))
+
+	return &AwsCluster{BaseClient: &BaseClient{Cluster: cluster}, AwsClient: awsClient}, awsClient
+}
+
+func TestAwsClusterGetLoadBalancers(t *testing.T) {
+	a, awsClient := newTestAwsCluster(t)
+	ownedTag := []elbTypes.Tag{{Key: awsSdk.String("kubernetes.io/cluster/infra-abc"), Value: awsSdk.String("owned")}}
+
+	awsClient.EXPECT().DescribeLoadBalancers(gomock.Any()).Return(&elasticloadbalancing.DescribeLoadBalancersOutput{
+		LoadBalancerDescriptions: []elbTypes.LoadBalancerDescription{
+			{
+				LoadBalancerName:     awsSdk.String("router"),
+				Scheme:               awsSdk.String("internet-facing"),
+				ListenerDescriptions: []elbTypes.ListenerDescription{{Listener: &elbTypes.Listener{Protocol: awsSdk.String("TCP"), LoadBalancerPort: 443}}},
+			},
+			{LoadBalancerName: awsSdk.String("someone-elses")},
+		},
+	}, nil)
+	awsClient.EXPECT().DescribeTags(gomock.Any()).Return(&elasticloadbalancing.DescribeTagsOutput{
+		TagDescriptions: []elbTypes.TagDescription{
+			{LoadBalancerName: awsSdk.String("router"), Tags: ownedTag},
+			{LoadBalancerName: awsSdk.String("someone-elses")},
+		},
+	}, nil)
+	awsClient.EXPECT().DescribeInstanceHealth(gomock.Any()).Return(&elasticloadbalancing.DescribeInstanceHealthOutput{
+		InstanceStates: []elbTypes.InstanceState{
+			{InstanceId: awsSdk.String("i-1"), State: awsSdk.String("InService")},
+			{InstanceId: awsSdk.String("i-2"), State: awsSdk.String("OutOfService")},
+		},
+	}, nil)
+
+	awsClient.EXPECT().DescribeV2LoadBalancers(gomock.Any()).Return(&elasticloadbalancingv2.DescribeLoadBalancersOutput{
+		LoadBalancers: []elbv2Types.LoadBalancer{
+			{
+				LoadBalancerArn:  awsSdk.String("arn:int"),
+				LoadBalancerName: awsSdk.String("infra-abc-int"),
+				Scheme:           elbv2Types.LoadBalancerSchemeEnumInternal,
+				Type:             elbv2Types.LoadBalancerTypeEnumNetwork,
+			},
+		},
+	}, nil)
+	awsClient.EXPECT().DescribeV2Tags(gomock.Any()).Return(&elasticloadbalancingv2.DescribeTagsOutput{
+		TagDescriptions: []elbv2Types.TagDescription{
+			{ResourceArn: awsSdk.String("arn:int"), Tags: []elbv2Types.Tag{{Key: awsSdk.String("kubernetes.io/cluster/infra-abc"), Value: awsSdk.String("owned")}}},
+		},
+	}, nil)
+	awsClient.EXPECT().DescribeV2Listeners(gomock.Any()).Return(&elasticloadbalancingv2.DescribeListenersOutput{
+		Listeners: []elbv2Types.Listener{
+			{
+				Port:           awsSdk.Int32(6443),
+				Protocol:       elbv2Types.ProtocolEnumTcp,
+				DefaultActions: []elbv2Types.Action{{TargetGroupArn: awsSdk.String("arn:tg")}},
+			},
+		},
+	}, nil)
+	awsClient.EXPECT().DescribeV2TargetHealth(&elasticloadbalancingv2.DescribeTargetHealthInput{TargetGroupArn: awsSdk.String("arn:tg")}).Return(&elasticloadbalancingv2.DescribeTargetHealthOutput{
+		TargetHealthDescriptions: []elbv2Types.TargetHealthDescription{
+			{Target: &elbv2Types.TargetDescription{Id: awsSdk.String("i-master")}, TargetHealth: &elbv2Types.TargetHealth{State: elbv2Types.TargetHealthStateEnumHealthy}},
+			{Target: &elbv2Types.TargetDescription{Id: awsSdk.String("i-old")}, TargetHealth: &elbv2Types.TargetHealth{State: elbv2Types.TargetHealthStateEnumDraining}},
+		},
+	}, nil)
+
+	loadBalancers, err := a.GetLoadBalancers()
+	require.NoError(t, err)
+	require.Len(t, loadBalancers, 2)
+
+	assert.Equal(t, "router", loadBalancers[0].Name)
+	assert.Equal(t, "classic", loadBalancers[0].Type)
+	assert.False(t, loadBalancers[0].Internal)
+	assert.Equal(t, []LoadBalancerListener{{Protocol: "TCP", Port: "443", HealthyTargets: []string{"i-1"}, UnhealthyTargets: []string{"i-2"}}}, loadBalancers[0].Listeners)
+
+	assert.Equal(t, "infra-abc-int", loadBalancers[1].Name)
+	assert.Equal(t, "network", loadBalancers[1].Type)
+	assert.True(t, loadBalancers[1].Internal)
+	assert.Equal(t, []LoadBalancerListener{{Protocol: "TCP", Port: "6443", HealthyTargets: []string{"i-master"}, UnhealthyTargets: []string{"i-old"}}}, loadBalancers[1].Listeners)
+	assert.False(t, loadBalancers[1].Listeners[0].Healthy())
+}
+
+func TestAwsClusterGetVolumes(t *testing.T) {
+	a, awsClient := newTestAwsCluster(t)
+	awsClient.EXPECT().DescribeVolumes(gomock.Any()).DoAndReturn(func(input *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+		assert.Equal(t, []string{"kubernetes.io/cluster/infra-abc"}, input.Filters[0].Values)
+		return &ec2.DescribeVolumesOutput{
+			Volumes: []ec2Types.Volume{
+				{
+					VolumeId:         awsSdk.String("vol-1"),
+					Size:             awsSdk.Int32(100),
+					VolumeType:       ec2Types.VolumeTypeGp3,
+					AvailabilityZone: awsSdk.String("us-east-1a"),
+					State:            ec2Types.VolumeStateInUse,
+					Attachments: []ec2Types.VolumeAttachment{
+						{InstanceId: awsSdk.String("i-1"), Device: awsSdk.String("/dev/xvdba"), State: ec2Types.VolumeAttachmentStateDetaching},
+					},
+					Tags: []ec2Types.Tag{{Key: awsSdk.String("Name"), Value: awsSdk.String("infra-abc-dynamic-pvc-1")}},
+				},
+			},
+		}, nil
+	})
+
+	volumes, err := a.GetVolumes()
+	require.NoError(t, err)
+	require.Len(t, volumes, 1)
+	assert.Equal(t, "vol-1", volumes[0].ID)
+	assert.Equal(t, "infra-abc-dynamic-pvc-1", volumes[0].Name)
+	assert.Equal(t, int64(100), volumes[0].SizeGiB)
+	assert.Equal(t, "in-use", volumes[0].State)
+	assert.Equal(t, []VolumeAttachment{{VirtualMachine: "i-1", Device: "/dev/xvdba", State: "detaching"}}, volumes[0].Attachments)
+	assert.True(t, volumes[0].Stuck())
+}
+
+func TestAwsClusterGetDNSZones(t *testing.T) {
+	a, awsClient := newTestAwsCluster(t)
+	awsClient.EXPECT().ListHostedZones(gomock.Any()).Return(&route53.ListHostedZonesOutput{
+		HostedZones: []route53Types.HostedZone{
+			{Id: awsSdk.String("/hostedzone/Z1"), Name: awsSdk.String("mycluster.abcd.s1.devshift.org."), Config: &route53Types.HostedZoneConfig{PrivateZone: true}},
+			{Id: awsSdk.String("/hostedzone/Z2"), Name: awsSdk.String("abcd.s1.devshift.org.")},
+			{Id: awsSdk.String("/hostedzone/Z3"), Name: awsSdk.String("othercluster.abcd.s1.devshift.org.")},
+		},
+	}, nil)
+	awsClient.EXPECT().ListResourceRecordSets(&route53.ListResourceRecordSetsInput{HostedZoneId: awsSdk.String("/hostedzone/Z1")}).Return(&route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: []route53Types.ResourceRecordSet{
+			{Name: awsSdk.String("api.mycluster.abcd.s1.devshift.org."), Type: route53Types.RRTypeA, AliasTarget: &route53Types.AliasTarget{DNSName: awsSdk.String("infra-abc-int.elb.amazonaws.com.")}},
+			{Name: awsSdk.String(`\052.apps.mycluster.abcd.s1.devshift.org.`), Type: route53Types.RRTypeA, AliasTarget: &route53Types.AliasTarget{DNSName: awsSdk.String("router.elb.amazonaws.com.")}},
+		},
+	}, nil)
+	awsClient.EXPECT().ListResourceRecordSets(&route53.ListResourceRecordSetsInput{HostedZoneId: awsSdk.String("/hostedzone/Z2")}).Return(&route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: []route53Types.ResourceRecordSet{
+			{Name: awsSdk.String("abcd.s1.devshift.org."), Type: route53Types.RRTypeNs, TTL: awsSdk.Int64(172800), ResourceRecords: []route53Types.ResourceRecord{{Value: awsSdk.String("ns-1.awsdns-00.com.")}}},
+			{Name: awsSdk.String("api.mycluster.abcd.s1.devshift.org."), Type: route53Types.RRTypeA, AliasTarget: &route53Types.AliasTarget{DNSName: awsSdk.String("infra-abc-ext.elb.amazonaws.com.")}},
+		},
+	}, nil)
+
+	zones, err := a.GetDNSZones()
+	require.NoError(t, err)
+	require.Len(t, zones, 2)
+
+	assert.Equal(t, "Z1", zones[0].ID)
+	assert.True(t, zones[0].Private)
+	require.Len(t, zones[0].Records, 2)
+	assert.Equal(t, "*.apps.mycluster.abcd.s1.devshift.org.", zones[0].Records[1].Name)
+	assert.Equal(t, []string{"router.elb.amazonaws.com."}, zones[0].Records[1].Values)
+
+	assert.Equal(t, "Z2", zones[1].ID)
+	assert.False(t, zones[1].Private)
+	assert.Equal(t, []DNSRecord{{Name: "api.mycluster.abcd.s1.devshift.org.", Type: "A", Values: []string{"infra-abc-ext.elb.amazonaws.com."}}}, zones[1].Records)
+}
+
+func TestAwsClusterGetFirewallRules(t *testing.T) {
+	a, awsClient := newTestAwsCluster(t)
+	awsClient.EXPECT().DescribeSecurityGroups(gomock.Any()).Return(&ec2.DescribeSecurityGroupsOutput{
+		SecurityGroups: []ec2Types.SecurityGroup{
+			{
+				GroupName: awsSdk.String("infra-abc-controlplane"),
+				IpPermissions: []ec2Types.IpPermission{
+					{IpProtocol: awsSdk.String("tcp"), FromPort: awsSdk.Int32(6443), ToPort: awsSdk.Int32(6443), IpRanges: []ec2Types.IpRange{{CidrIp: awsSdk.String("10.0.0.0/16")}}},
+					{IpProtocol: awsSdk.String("tcp"), FromPort: awsSdk.Int32(30000), ToPort: awsSdk.Int32(32767), UserIdGroupPairs: []ec2Types.UserIdGroupPair{{GroupId: awsSdk.String("sg-node")}}},
+				},
+				IpPermissionsEgress: []ec2Types.IpPermission{
+					{IpProtocol: awsSdk.String("-1"), IpRanges: []ec2Types.IpRange{{CidrIp: awsSdk.String("0.0.0.0/0")}}},
+				},
+			},
+		},
+	}, nil)
+
+	rules, err := a.GetFirewallRules()
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	assert.Equal(t, "6443", rules[0].Ports)
+	assert.Equal(t, []string{"10.0.0.0/16"}, rules[0].Peers)
+	assert.True(t, rules[0].Ingress)
+	assert.Equal(t, "30000-32767", rules[1].Ports)
+	assert.Equal(t, []string{"sg-node"}, rules[1].Peers)
+	assert.False(t, rules[2].Ingress)
+	assert.Equal(t, "all", rules[2].Protocol)
+	assert.Equal(t, "", rules[2].Ports)
+}
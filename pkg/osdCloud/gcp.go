@@ -3,12 +3,16 @@ package osdCloud
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"path"
+	"slices"
 	"strings"
 
 	compute "cloud.google.com/go/compute/apiv1"
 	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	dns "google.golang.org/api/dns/v1"
 	"google.golang.org/api/iterator"
 	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
 )
@@ -29,6 +33,32 @@ func ParseGcpProjectClaim(raw string) (*GcpProjectClaim, error) {
 	return &projectClaim, nil
 }
 
+// GetGcpProjectID returns the GCP project a cluster's VMs are in. Non-CCS clusters only record their project in the
+// project claim.
+func GetGcpProjectID(ocmClient *sdk.Connection, cluster *cmv1.Cluster) (string, error) {
+	if projectID := cluster.GCP().ProjectID(); projectID != "" {
+		return projectID, nil
+	}
+
+	resources, err := ocmClient.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).Resources().Live().Get().Send()
+	if err != nil {
+		return "", fmt.Errorf("failed to get live resources for %s: %w", cluster.ID(), err)
+	}
+	projectClaimRaw, found := resources.Body().Resources()["gcp_project_claim"]
+	if !found {
+		return "", errors.New("the gcp_project_claim was not found in the ocm resource")
+	}
+	projectClaim, err := ParseGcpProjectClaim(projectClaimRaw)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse gcp_project_claim: %w", err)
+	}
+	if projectClaim.Spec.GcpProjectID == "" {
+		return "", fmt.Errorf("could not determine the GCP project of %s", cluster.ID())
+	}
+
+	return projectClaim.Spec.GcpProjectID, nil
+}
+
 func GenerateGCPComputeInstancesClient() (*compute.InstancesClient, error) {
 	ctx := context.Background()
 	client, err := compute.NewInstancesRESTClient(ctx)
@@ -47,9 +77,15 @@ func ListInstances(client *compute.InstancesClient, projectID, zone string) *com
 // Concrete struct with fields required only for interacting with the GCP cloud.
 type GcpCluster struct {
 	*BaseClient
-	ComputeClient *compute.InstancesClient
-	ProjectId     string
-	Zones         []string
+	ComputeClient         *compute.InstancesClient
+	ForwardingRulesClient *compute.ForwardingRulesClient
+	TargetPoolsClient     *compute.TargetPoolsClient
+	BackendServicesClient *compute.RegionBackendServicesClient
+	DisksClient           *compute.DisksClient
+	FirewallsClient       *compute.FirewallsClient
+	DNSService            *dns.Service
+	ProjectId             string
+	Zones                 []string
 }
 
 func NewGcpCluster(ocmClient *sdk.Connection, clusterId string) (ClusterHealthClient, error) {
@@ -69,26 +105,37 @@ func NewGcpCluster(ocmClient *sdk.Connection, clusterId string) (ClusterHealthCl
 }
 
 func (g *GcpCluster) Login() error {
-	clusterResources, err := g.OcmClient.ClustersMgmt().V1().Clusters().Cluster(g.ClusterId).Resources().Live().Get().Send()
+	var err error
+	g.ProjectId, err = GetGcpProjectID(g.OcmClient, g.Cluster)
 	if err != nil {
 		return err
 	}
-	projectClaimRaw, found := clusterResources.Body().Resources()["gcp_project_claim"]
-	if !found {
-		return fmt.Errorf("The gcp_project_claim was not found in the ocm resource")
+	g.Zones = g.Cluster.Nodes().AvailabilityZones()
+	if len(g.Zones) == 0 {
+		return fmt.Errorf("Zones empty - aborting")
 	}
-	projectClaim, err := ParseGcpProjectClaim(projectClaimRaw)
+	g.ComputeClient, err = GenerateGCPComputeInstancesClient()
 	if err != nil {
-		log.Printf("Unmarshalling GCP projectClaim failed: %v\n", err)
 		return err
 	}
-	g.ProjectId = projectClaim.Spec.GcpProjectID
-	g.Zones = g.Cluster.Nodes().AvailabilityZones()
-	if g.ProjectId == "" || len(g.Zones) == 0 {
-		return fmt.Errorf("ProjectID or Zones empty - aborting")
+
+	ctx := context.Background()
+	if g.ForwardingRulesClient, err = compute.NewForwardingRulesRESTClient(ctx); err != nil {
+		return err
 	}
-	g.ComputeClient, err = GenerateGCPComputeInstancesClient()
-	if err != nil {
+	if g.TargetPoolsClient, err = compute.NewTargetPoolsRESTClient(ctx); err != nil {
+		return err
+	}
+	if g.BackendServicesClient, err = compute.NewRegionBackendServicesRESTClient(ctx); err != nil {
+		return err
+	}
+	if g.DisksClient, err = compute.NewDisksRESTClient(ctx); err != nil {
+		return err
+	}
+	if g.FirewallsClient, err = compute.NewFirewallsRESTClient(ctx); err != nil {
+		return err
+	}
+	if g.DNSService, err = dns.NewService(ctx); err != nil {
 		return err
 	}
 	return nil
@@ -98,6 +145,21 @@ func (g *GcpCluster) Close() {
 	if g.ComputeClient != nil {
 		g.ComputeClient.Close()
 	}
+	if g.ForwardingRulesClient != nil {
+		g.ForwardingRulesClient.Close()
+	}
+	if g.TargetPoolsClient != nil {
+		g.TargetPoolsClient.Close()
+	}
+	if g.BackendServicesClient != nil {
+		g.BackendServicesClient.Close()
+	}
+	if g.DisksClient != nil {
+		g.DisksClient.Close()
+	}
+	if g.FirewallsClient != nil {
+		g.FirewallsClient.Close()
+	}
 }
 
 func (g *GcpCluster) GetAZs() []string {
@@ -126,3 +188,289 @@ func (g *GcpCluster) GetAllVirtualMachines(region string) ([]VirtualMachine, err
 	}
 	return vms, nil
 }
+
+// ownedByCluster returns whether the GCP resource, or the VM referenced by its URL, is named after the cluster's infra ID
+func (g *GcpCluster) ownedByCluster(nameOrURL string) bool {
+	return strings.HasPrefix(path.Base(nameOrURL), g.Cluster.InfraID()+"-")
+}
+
+// GetLoadBalancers returns the cluster's regional forwarding rules, each as a load balancer with a single listener.
+// Forwarding rules of the API are named after the infra ID, while those of the ingress controllers are recognized by
+// forwarding to the cluster's VMs.
+func (g *GcpCluster) GetLoadBalancers() ([]LoadBalancer, error) {
+	ctx := context.Background()
+	region := g.Cluster.Region().ID()
+
+	var loadBalancers []LoadBalancer
+	forwardingRules := g.ForwardingRulesClient.List(ctx, &computepb.ListForwardingRulesRequest{
+		Project: g.ProjectId,
+		Region:  region,
+	})
+	for {
+		forwardingRule, err := forwardingRules.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var health []*computepb.HealthStatus
+		switch {
+		case strings.Contains(forwardingRule.GetTarget(), "/targetPools/"):
+			health, err = g.getTargetPoolHealth(ctx, region, path.Base(forwardingRule.GetTarget()))
+		case forwardingRule.GetBackendService() != "":
+			health, err = g.getBackendServiceHealth(ctx, region, path.Base(forwardingRule.GetBackendService()))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		owned := g.ownedByCluster(forwardingRule.GetName())
+		var healthy, unhealthy []string
+		for _, status := range health {
+			owned = owned || g.ownedByCluster(status.GetInstance())
+			if status.GetHealthState() == "HEALTHY" {
+				healthy = append(healthy, path.Base(status.GetInstance()))
+			} else {
+				unhealthy = append(unhealthy, path.Base(status.GetInstance()))
+			}
+		}
+		if !owned {
+			continue
+		}
+
+		port := forwardingRule.GetPortRange()
+		if len(forwardingRule.GetPorts()) > 0 {
+			port = strings.Join(forwardingRule.GetPorts(), ",")
+		}
+		loadBalancers = append(loadBalancers, LoadBalancer{
+			Original: forwardingRule,
+			Name:     forwardingRule.GetName(),
+			Type:     strings.ToLower(forwardingRule.GetLoadBalancingScheme()),
+			Internal: strings.HasPrefix(forwardingRule.GetLoadBalancingScheme(), "INTERNAL"),
+			Address:  forwardingRule.GetIPAddress(),
+			Listeners: []LoadBalancerListener{
+				{
+					Protocol:         forwardingRule.GetIPProtocol(),
+					Port:             port,
+					HealthyTargets:   healthy,
+					UnhealthyTargets: unhealthy,
+				},
+			},
+			Labels: forwardingRule.GetLabels(),
+		})
+	}
+	return loadBalancers, nil
+}
+
+// getTargetPoolHealth returns the health of each instance of a target pool, which can only be requested per instance
+func (g *GcpCluster) getTargetPoolHealth(ctx context.Context, region, targetPool string) ([]*computepb.HealthStatus, error) {
+	pool, err := g.TargetPoolsClient.Get(ctx, &computepb.GetTargetPoolRequest{
+		Project:    g.ProjectId,
+		Region:     region,
+		TargetPool: targetPool,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var health []*computepb.HealthStatus
+	for _, instance := range pool.GetInstances() {
+		instanceHealth, err := g.TargetPoolsClient.GetHealth(ctx, &computepb.GetHealthTargetPoolRequest{
+			Project:                   g.ProjectId,
+			Region:                    region,
+			TargetPool:                targetPool,
+			InstanceReferenceResource: &computepb.InstanceReference{Instance: &instance},
+		})
+		if err != nil {
+			return nil, err
+		}
+		health = append(health, instanceHealth.GetHealthStatus()...)
+	}
+	return health, nil
+}
+
+// getBackendServiceHealth returns the health of the instances of each instance group backing a backend service
+func (g *GcpCluster) getBackendServiceHealth(ctx context.Context, region, backendService string) ([]*computepb.HealthStatus, error) {
+	service, err := g.BackendServicesClient.Get(ctx, &computepb.GetRegionBackendServiceRequest{
+		Project:        g.ProjectId,
+		Region:         region,
+		BackendService: backendService,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var health []*computepb.HealthStatus
+	for _, backend := range service.GetBackends() {
+		groupHealth, err := g.BackendServicesClient.GetHealth(ctx, &computepb.GetHealthRegionBackendServiceRequest{
+			Project:                        g.ProjectId,
+			Region:                         region,
+			BackendService:                 backendService,
+			ResourceGroupReferenceResource: &computepb.ResourceGroupReference{Group: backend.Group},
+		})
+		if err != nil {
+			return nil, err
+		}
+		health = append(health, groupHealth.GetHealthStatus()...)
+	}
+	return health, nil
+}
+
+// GetVolumes returns the disks in the cluster's zones which are named after the infra ID, labeled as owned by the
+// cluster, or attached to one of the cluster's VMs
+func (g *GcpCluster) GetVolumes() ([]Volume, error) {
+	ctx := context.Background()
+	ownedLabel := "kubernetes-io-cluster-" + g.Cluster.InfraID()
+
+	var volumes []Volume
+	for _, zone := range g.Zones {
+		disks := g.DisksClient.List(ctx, &computepb.ListDisksRequest{
+			Project: g.ProjectId,
+			Zone:    zone,
+		})
+		for {
+			disk, err := disks.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			_, owned := disk.GetLabels()[ownedLabel]
+			owned = owned || g.ownedByCluster(disk.GetName())
+			var attachments []VolumeAttachment
+			for _, user := range disk.GetUsers() {
+				owned = owned || g.ownedByCluster(user)
+				attachments = append(attachments, VolumeAttachment{
+					VirtualMachine: path.Base(user),
+					State:          "attached",
+				})
+			}
+			if !owned {
+				continue
+			}
+
+			volumes = append(volumes, Volume{
+				Original:    disk,
+				ID:          fmt.Sprint(disk.GetId()),
+				Name:        disk.GetName(),
+				Type:        path.Base(disk.GetType()),
+				Zone:        zone,
+				SizeGiB:     disk.GetSizeGb(),
+				State:       strings.ToLower(disk.GetStatus()),
+				Attachments: attachments,
+				Labels:      disk.GetLabels(),
+			})
+		}
+	}
+	return volumes, nil
+}
+
+func (g *GcpCluster) GetDNSZones() ([]DNSZone, error) {
+	ctx := context.Background()
+	domain := g.ClusterDomain()
+
+	var managedZones []*dns.ManagedZone
+	err := g.DNSService.ManagedZones.List(g.ProjectId).Pages(ctx, func(response *dns.ManagedZonesListResponse) error {
+		for _, managedZone := range response.ManagedZones {
+			// The cluster's records live in its private zone and in the public zone of its base domain
+			if inDomain(domain, managedZone.DnsName) {
+				managedZones = append(managedZones, managedZone)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []DNSZone
+	for _, managedZone := range managedZones {
+		var records []DNSRecord
+		err := g.DNSService.ResourceRecordSets.List(g.ProjectId, managedZone.Name).Pages(ctx, func(response *dns.ResourceRecordSetsListResponse) error {
+			for _, recordSet := range response.Rrsets {
+				if !inDomain(recordSet.Name, domain) {
+					continue
+				}
+				records = append(records, DNSRecord{
+					Name:   recordSet.Name,
+					Type:   recordSet.Type,
+					TTL:    recordSet.Ttl,
+					Values: recordSet.Rrdatas,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, DNSZone{
+			Original: managedZone,
+			ID:       managedZone.Name,
+			Name:     managedZone.DnsName,
+			Private:  managedZone.Visibility == "private",
+			Records:  records,
+		})
+	}
+	return zones, nil
+}
+
+// GetFirewallRules returns the firewall rules named after the infra ID, or targeting the network tags of the cluster's
+// VMs, which are named after the infra ID as well
+func (g *GcpCluster) GetFirewallRules() ([]FirewallRule, error) {
+	var rules []FirewallRule
+	firewalls := g.FirewallsClient.List(context.Background(), &computepb.ListFirewallsRequest{Project: g.ProjectId})
+	for {
+		firewall, err := firewalls.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		owned := g.ownedByCluster(firewall.GetName())
+		for _, tag := range firewall.GetTargetTags() {
+			owned = owned || g.ownedByCluster(tag)
+		}
+		if !owned || firewall.GetDisabled() {
+			continue
+		}
+
+		ingress := firewall.GetDirection() != "EGRESS"
+		peers := firewall.GetDestinationRanges()
+		if ingress {
+			peers = slices.Concat(firewall.GetSourceRanges(), firewall.GetSourceTags())
+		}
+		for _, allowed := range firewall.GetAllowed() {
+			rules = append(rules, gcpFirewallRules(firewall, allowed.GetIPProtocol(), allowed.GetPorts(), ingress, true, peers)...)
+		}
+		for _, denied := range firewall.GetDenied() {
+			rules = append(rules, gcpFirewallRules(firewall, denied.GetIPProtocol(), denied.GetPorts(), ingress, false, peers)...)
+		}
+	}
+	return rules, nil
+}
+
+// gcpFirewallRules returns a rule for each port range of a protocol allowed or denied by a firewall
+func gcpFirewallRules(firewall *computepb.Firewall, protocol string, ports []string, ingress, allow bool, peers []string) []FirewallRule {
+	if len(ports) == 0 {
+		ports = []string{""}
+	}
+	var rules []FirewallRule
+	for _, port := range ports {
+		rules = append(rules, FirewallRule{
+			Original: firewall,
+			Name:     firewall.GetName(),
+			Ingress:  ingress,
+			Allow:    allow,
+			Protocol: protocol,
+			Ports:    port,
+			Peers:    peers,
+		})
+	}
+	return rules
+}
@@ -1,6 +1,9 @@
 package osdCloud
 
 import (
+	"fmt"
+	"strings"
+
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	ocmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 )
@@ -15,6 +18,14 @@ type ClusterHealthClient interface {
 	GetCluster() *ocmv1.Cluster
 	GetAZs() []string
 	GetAllVirtualMachines(region string) ([]VirtualMachine, error)
+	// GetLoadBalancers returns the cluster's load balancers along with the health of each listener's targets
+	GetLoadBalancers() ([]LoadBalancer, error)
+	// GetVolumes returns the cluster's block volumes along with the VMs they are attached to
+	GetVolumes() ([]Volume, error)
+	// GetDNSZones returns the zones serving the cluster's domain, holding only the records within the cluster's domain
+	GetDNSZones() ([]DNSZone, error)
+	// GetFirewallRules returns the security group rules on AWS, or the firewall rules on GCP, of the cluster
+	GetFirewallRules() ([]FirewallRule, error)
 	Close()
 }
 
@@ -29,6 +40,21 @@ func (b *BaseClient) GetCluster() *ocmv1.Cluster {
 	return b.Cluster
 }
 
+// ClusterDomain returns the fully qualified domain of the cluster, e.g. "mycluster.abcd.s1.devshift.org."
+func (b *BaseClient) ClusterDomain() string {
+	prefix := b.Cluster.DomainPrefix()
+	if prefix == "" {
+		prefix = b.Cluster.Name()
+	}
+	return fmt.Sprintf("%s.%s.", prefix, b.Cluster.DNS().BaseDomain())
+}
+
+// inDomain returns whether the fully qualified name is the domain or one of its subdomains
+func inDomain(name, domain string) bool {
+	name, domain = strings.ToLower(name), strings.ToLower(domain)
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
+
 // VirtualMachine Abstract the AWS instances and GCP instances into a common type.
 // The Original field should store the data returned by the cloud directly, so it can be accessed via casting if needed.
 type VirtualMachine struct {
@@ -38,3 +64,96 @@ type VirtualMachine struct {
 	State    string
 	Labels   map[string]string
 }
+
+// LoadBalancer Abstract the AWS load balancers and GCP forwarding rules into a common type.
+type LoadBalancer struct {
+	Original interface{}
+	Name     string
+	// Type is the kind of load balancer, e.g. "classic", "network" or "application" on AWS, and the load balancing
+	// scheme on GCP
+	Type      string
+	Internal  bool
+	Address   string
+	Listeners []LoadBalancerListener
+	Labels    map[string]string
+}
+
+// LoadBalancerListener A port a load balancer listens on, along with the IDs of the VMs it forwards to, split by their
+// health.
+type LoadBalancerListener struct {
+	Protocol         string
+	Port             string
+	HealthyTargets   []string
+	UnhealthyTargets []string
+}
+
+// Healthy returns whether the listener has targets and all of them are healthy
+func (l LoadBalancerListener) Healthy() bool {
+	return len(l.HealthyTargets) > 0 && len(l.UnhealthyTargets) == 0
+}
+
+// Volume Abstract the AWS EBS volumes and GCP persistent disks into a common type.
+type Volume struct {
+	Original interface{}
+	ID       string
+	Name     string
+	Type     string
+	Zone     string
+	SizeGiB  int64
+	// State is the state of the volume itself, e.g. "available" or "in-use" on AWS and "ready" on GCP
+	State       string
+	Attachments []VolumeAttachment
+	Labels      map[string]string
+}
+
+// VolumeAttachment The attachment of a volume to a VM. GCP only reports attached disks, so State is always "attached"
+// there.
+type VolumeAttachment struct {
+	VirtualMachine string
+	Device         string
+	State          string
+}
+
+// Stuck returns whether any of the volume's attachments is still attaching or detaching
+func (v Volume) Stuck() bool {
+	for _, attachment := range v.Attachments {
+		if attachment.State != "attached" {
+			return true
+		}
+	}
+	return false
+}
+
+// DNSZone Abstract the AWS Route53 hosted zones and GCP Cloud DNS managed zones into a common type.
+type DNSZone struct {
+	Original interface{}
+	ID       string
+	Name     string
+	Private  bool
+	Records  []DNSRecord
+}
+
+// DNSRecord A record set of a DNS zone. Alias records on AWS hold their target's DNS name as their value.
+type DNSRecord struct {
+	Name   string
+	Type   string
+	TTL    int64
+	Values []string
+}
+
+// FirewallRule Abstract the rules of AWS security groups and GCP firewall rules into a common type.
+type FirewallRule struct {
+	Original interface{}
+	// Name is the name of the security group on AWS, and of the firewall rule on GCP
+	Name string
+	// Ingress is false for egress rules
+	Ingress bool
+	// Allow is false for deny rules, which only exist on GCP
+	Allow    bool
+	Protocol string
+	// Ports is the port range of the rule, e.g. "6443" or "30000-32767", and empty for all ports
+	Ports string
+	// Peers are the CIDRs, security groups, or network tags the traffic comes from for ingress rules and goes to for
+	// egress rules
+	Peers []string
+}
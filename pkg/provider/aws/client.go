@@ -91,6 +91,8 @@ type Client interface {
 	DescribeVpcEndpoints(*ec2.DescribeVpcEndpointsInput) (*ec2.DescribeVpcEndpointsOutput, error)
 	DescribeVpcEndpointConnections(*ec2.DescribeVpcEndpointConnectionsInput) (*ec2.DescribeVpcEndpointConnectionsOutput, error)
 	DescribeVpcEndpointServices(*ec2.DescribeVpcEndpointServicesInput) (*ec2.DescribeVpcEndpointServicesOutput, error)
+	DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error)
+	DescribeSecurityGroups(*ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error)
 
 	// Service Quotas
 	ListServiceQuotas(*servicequotas.ListServiceQuotasInput) (*servicequotas.ListServiceQuotasOutput, error)
@@ -130,8 +132,12 @@ type Client interface {
 	// ELB
 	DescribeLoadBalancers(input *elasticloadbalancing.DescribeLoadBalancersInput) (*elasticloadbalancing.DescribeLoadBalancersOutput, error)
 	DescribeTags(input *elasticloadbalancing.DescribeTagsInput) (*elasticloadbalancing.DescribeTagsOutput, error)
+	DescribeInstanceHealth(input *elasticloadbalancing.DescribeInstanceHealthInput) (*elasticloadbalancing.DescribeInstanceHealthOutput, error)
 	DescribeV2LoadBalancers(input *elasticloadbalancingv2.DescribeLoadBalancersInput) (*elasticloadbalancingv2.DescribeLoadBalancersOutput, error)
 	DescribeV2Tags(input *elasticloadbalancingv2.DescribeTagsInput) (*elasticloadbalancingv2.DescribeTagsOutput, error)
+	DescribeV2Listeners(input *elasticloadbalancingv2.DescribeListenersInput) (*elasticloadbalancingv2.DescribeListenersOutput, error)
+	DescribeV2TargetGroups(input *elasticloadbalancingv2.DescribeTargetGroupsInput) (*elasticloadbalancingv2.DescribeTargetGroupsOutput, error)
+	DescribeV2TargetHealth(input *elasticloadbalancingv2.DescribeTargetHealthInput) (*elasticloadbalancingv2.DescribeTargetHealthOutput, error)
 }
 
 type AwsClient struct {
@@ -491,6 +497,14 @@ func (c *AwsClient) DescribeVpcEndpointServices(input *ec2.DescribeVpcEndpointSe
 	return c.ec2Client.DescribeVpcEndpointServices(context.TODO(), input)
 }
 
+func (c *AwsClient) DescribeVolumes(input *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	return c.ec2Client.DescribeVolumes(context.TODO(), input)
+}
+
+func (c *AwsClient) DescribeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return c.ec2Client.DescribeSecurityGroups(context.TODO(), input)
+}
+
 func (c *AwsClient) DescribeVpcEndpointConnections(input *ec2.DescribeVpcEndpointConnectionsInput) (*ec2.DescribeVpcEndpointConnectionsOutput, error) {
 	return c.ec2Client.DescribeVpcEndpointConnections(context.TODO(), input)
 }
@@ -534,3 +548,19 @@ func (c *AwsClient) DescribeV2LoadBalancers(input *elasticloadbalancingv2.Descri
 func (c *AwsClient) DescribeV2Tags(input *elasticloadbalancingv2.DescribeTagsInput) (*elasticloadbalancingv2.DescribeTagsOutput, error) {
 	return c.elbv2Client.DescribeTags(context.TODO(), input)
 }
+
+func (c *AwsClient) DescribeInstanceHealth(input *elasticloadbalancing.DescribeInstanceHealthInput) (*elasticloadbalancing.DescribeInstanceHealthOutput, error) {
+	return c.elbClient.DescribeInstanceHealth(context.TODO(), input)
+}
+
+func (c *AwsClient) DescribeV2Listeners(input *elasticloadbalancingv2.DescribeListenersInput) (*elasticloadbalancingv2.DescribeListenersOutput, error) {
+	return c.elbv2Client.DescribeListeners(context.TODO(), input)
+}
+
+func (c *AwsClient) DescribeV2TargetGroups(input *elasticloadbalancingv2.DescribeTargetGroupsInput) (*elasticloadbalancingv2.DescribeTargetGroupsOutput, error) {
+	return c.elbv2Client.DescribeTargetGroups(context.TODO(), input)
+}
+
+func (c *AwsClient) DescribeV2TargetHealth(input *elasticloadbalancingv2.DescribeTargetHealthInput) (*elasticloadbalancingv2.DescribeTargetHealthOutput, error) {
+	return c.elbv2Client.DescribeTargetHealth(context.TODO(), input)
+}
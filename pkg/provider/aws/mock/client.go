@@ -336,6 +336,21 @@ func (mr *MockClientMockRecorder) DescribeCreateAccountStatus(input any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeCreateAccountStatus", reflect.TypeOf((*MockClient)(nil).DescribeCreateAccountStatus), input)
 }
 
+// DescribeInstanceHealth mocks base method.
+func (m *MockClient) DescribeInstanceHealth(input *elasticloadbalancing.DescribeInstanceHealthInput) (*elasticloadbalancing.DescribeInstanceHealthOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeInstanceHealth", input)
+	ret0, _ := ret[0].(*elasticloadbalancing.DescribeInstanceHealthOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeInstanceHealth indicates an expected call of DescribeInstanceHealth.
+func (mr *MockClientMockRecorder) DescribeInstanceHealth(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeInstanceHealth", reflect.TypeOf((*MockClient)(nil).DescribeInstanceHealth), input)
+}
+
 // DescribeInstances mocks base method.
 func (m *MockClient) DescribeInstances(arg0 *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
 	m.ctrl.T.Helper()
@@ -396,6 +411,21 @@ func (mr *MockClientMockRecorder) DescribeRouteTables(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeRouteTables", reflect.TypeOf((*MockClient)(nil).DescribeRouteTables), arg0)
 }
 
+// DescribeSecurityGroups mocks base method.
+func (m *MockClient) DescribeSecurityGroups(arg0 *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeSecurityGroups", arg0)
+	ret0, _ := ret[0].(*ec2.DescribeSecurityGroupsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeSecurityGroups indicates an expected call of DescribeSecurityGroups.
+func (mr *MockClientMockRecorder) DescribeSecurityGroups(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeSecurityGroups", reflect.TypeOf((*MockClient)(nil).DescribeSecurityGroups), arg0)
+}
+
 // DescribeSubnets mocks base method.
 func (m *MockClient) DescribeSubnets(arg0 *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
 	m.ctrl.T.Helper()
@@ -426,6 +456,21 @@ func (mr *MockClientMockRecorder) DescribeTags(input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeTags", reflect.TypeOf((*MockClient)(nil).DescribeTags), input)
 }
 
+// DescribeV2Listeners mocks base method.
+func (m *MockClient) DescribeV2Listeners(input *elasticloadbalancingv2.DescribeListenersInput) (*elasticloadbalancingv2.DescribeListenersOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeV2Listeners", input)
+	ret0, _ := ret[0].(*elasticloadbalancingv2.DescribeListenersOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeV2Listeners indicates an expected call of DescribeV2Listeners.
+func (mr *MockClientMockRecorder) DescribeV2Listeners(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeV2Listeners", reflect.TypeOf((*MockClient)(nil).DescribeV2Listeners), input)
+}
+
 // DescribeV2LoadBalancers mocks base method.
 func (m *MockClient) DescribeV2LoadBalancers(input *elasticloadbalancingv2.DescribeLoadBalancersInput) (*elasticloadbalancingv2.DescribeLoadBalancersOutput, error) {
 	m.ctrl.T.Helper()
@@ -456,6 +501,51 @@ func (mr *MockClientMockRecorder) DescribeV2Tags(input any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeV2Tags", reflect.TypeOf((*MockClient)(nil).DescribeV2Tags), input)
 }
 
+// DescribeV2TargetGroups mocks base method.
+func (m *MockClient) DescribeV2TargetGroups(input *elasticloadbalancingv2.DescribeTargetGroupsInput) (*elasticloadbalancingv2.DescribeTargetGroupsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeV2TargetGroups", input)
+	ret0, _ := ret[0].(*elasticloadbalancingv2.DescribeTargetGroupsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeV2TargetGroups indicates an expected call of DescribeV2TargetGroups.
+func (mr *MockClientMockRecorder) DescribeV2TargetGroups(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeV2TargetGroups", reflect.TypeOf((*MockClient)(nil).DescribeV2TargetGroups), input)
+}
+
+// DescribeV2TargetHealth mocks base method.
+func (m *MockClient) DescribeV2TargetHealth(input *elasticloadbalancingv2.DescribeTargetHealthInput) (*elasticloadbalancingv2.DescribeTargetHealthOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeV2TargetHealth", input)
+	ret0, _ := ret[0].(*elasticloadbalancingv2.DescribeTargetHealthOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeV2TargetHealth indicates an expected call of DescribeV2TargetHealth.
+func (mr *MockClientMockRecorder) DescribeV2TargetHealth(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeV2TargetHealth", reflect.TypeOf((*MockClient)(nil).DescribeV2TargetHealth), input)
+}
+
+// DescribeVolumes mocks base method.
+func (m *MockClient) DescribeVolumes(arg0 *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeVolumes", arg0)
+	ret0, _ := ret[0].(*ec2.DescribeVolumesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeVolumes indicates an expected call of DescribeVolumes.
+func (mr *MockClientMockRecorder) DescribeVolumes(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeVolumes", reflect.TypeOf((*MockClient)(nil).DescribeVolumes), arg0)
+}
+
 // DescribeVpcEndpointConnections mocks base method.
 func (m *MockClient) DescribeVpcEndpointConnections(arg0 *ec2.DescribeVpcEndpointConnectionsInput) (*ec2.DescribeVpcEndpointConnectionsOutput, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// WriteFileAtomic replaces the file at path with data, through a temporary file in the same directory so readers
+// never see a partially written file
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// WriteTarball writes the files, keyed by their path relative to dir, under dir of a gzipped tarball
+func WriteTarball(file, dir string, files map[string][]byte, modTime time.Time) error {
+	tarballFile, err := os.Create(file) //#nosec G304 -- file is chosen by the caller
+	if err != nil {
+		return err
+	}
+	defer tarballFile.Close()
+	gzipWriter := gzip.NewWriter(tarballFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header := &tar.Header{
+			Name:    path.Join(dir, name),
+			Mode:    0600,
+			Size:    int64(len(files[name])),
+			ModTime: modTime,
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write header for %s: %w", name, err)
+		}
+		if _, err := tarWriter.Write(files[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	return tarballFile.Close()
+}
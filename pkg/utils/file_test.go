@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFileAtomic() failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" {
+		t.Errorf("expected the file to be replaced, got %q", data)
+	}
+
+	// The temporary files are cleaned up
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only %s to be left, got %d files", path, len(entries))
+	}
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "state.json"), []byte("data")); err == nil {
+		t.Error("expected an error writing to a missing directory")
+	}
+}
+
+func TestWriteTarball(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	files := map[string][]byte{
+		"summary.txt":  []byte("summary"),
+		"logs/pod.log": []byte("logs"),
+	}
+	if err := WriteTarball(file, "bundle", files, time.Now()); err != nil {
+		t.Fatalf("WriteTarball() failed: %v", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	var names []string
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	expected := []string{"bundle/logs/pod.log", "bundle/summary.txt"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
@@ -373,6 +373,21 @@ func IsClusterCCS(ocmClient *sdk.Connection, clusterID string) (bool, error) {
 	return false, nil
 }
 
+// GetCurrentOCMUsername returns the OCM username of the current user, falling back to the local username
+func GetCurrentOCMUsername(ocmClient *sdk.Connection) string {
+	if ocmClient == nil {
+		return os.Getenv("USER")
+	}
+
+	account, err := ocmClient.AccountsMgmt().V1().CurrentAccount().Get().Send()
+	if err != nil {
+		log.Printf("failed to determine OCM username, using the local username instead: %s", err)
+		return os.Getenv("USER")
+	}
+
+	return account.Body().Username()
+}
+
 // Returns the hive shard corresponding to a cluster
 // e.g. https://api.<hive_cluster>.byo5.p1.openshiftapps.com:6443
 func GetHiveShard(clusterID string) (string, error) {
@@ -0,0 +1,80 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	fpath "path/filepath"
+
+	"github.com/openshift/osdctl/pkg/utils"
+)
+
+// Store persists the state of workflow runs as one file per workflow and cluster
+type Store struct {
+	dir string
+}
+
+// DefaultStore returns a Store in the user's config directory
+func DefaultStore() (*Store, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine config directory: %w", err)
+	}
+
+	return NewStore(fpath.Join(configDir, "osdctl", "workflows")), nil
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) path(name, clusterID string) string {
+	return fpath.Join(s.dir, fmt.Sprintf("%s-%s.json", name, clusterID))
+}
+
+// Load returns the state of the workflow's incomplete run for the cluster, or nil if there isn't one
+func (s *Store) Load(name, clusterID string) (*State, error) {
+	data, err := os.ReadFile(s.path(name, clusterID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s state: %w", name, err)
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s state: %w", name, err)
+	}
+
+	return state, nil
+}
+
+// Save atomically replaces the state of the workflow's run for the cluster
+func (s *Store) Save(state *State) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", s.dir, err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := utils.WriteFileAtomic(s.path(state.Workflow, state.ClusterID), data); err != nil {
+		return fmt.Errorf("failed to save %s state: %w", state.Workflow, err)
+	}
+
+	return nil
+}
+
+// Delete discards the state of the workflow's run for the cluster
+func (s *Store) Delete(name, clusterID string) error {
+	if err := os.Remove(s.path(name, clusterID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s state: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,323 @@
+// Package workflow runs multi-step cluster operations as a sequence of checkpointed steps, so that an operation which
+// fails partway can be resumed from the failed step, or rolled back, instead of leaving the cluster half-migrated with
+// no record of which steps completed.
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/spf13/pflag"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusDone       = "done"
+	StatusFailed     = "failed"
+	StatusRolledBack = "rolled-back"
+)
+
+// Step is a single step of a workflow
+type Step struct {
+	// Name identifies the step in the checkpointed state, so it must not change between versions of a workflow
+	Name string
+	// Description is a human-readable summary printed by Plan
+	Description string
+
+	// Do performs the step. It is retried when resuming a workflow which failed at this step, so it should tolerate
+	// having partially run before, e.g. by ignoring already existing or already deleted resources.
+	Do func(ctx context.Context) error
+	// Verify optionally checks whether the step is complete. It is run after Do to confirm the step succeeded, and
+	// before retrying a failed step to skip Do if it turns out to have completed after all.
+	Verify func(ctx context.Context) (bool, error)
+	// Rollback optionally undoes the step. It is also run for the step a workflow failed at, so it should tolerate the
+	// step having only partially run. Steps without a Rollback, e.g. waits, have nothing to undo.
+	Rollback func(ctx context.Context) error
+}
+
+// StepState is the checkpointed state of a step
+type StepState struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+}
+
+// State is the checkpointed state of a workflow run
+type State struct {
+	Workflow  string                     `json:"workflow"`
+	ClusterID string                     `json:"clusterId"`
+	StartedAt time.Time                  `json:"startedAt"`
+	UpdatedAt time.Time                  `json:"updatedAt"`
+	Steps     []StepState                `json:"steps"`
+	Values    map[string]json.RawMessage `json:"values,omitempty"`
+}
+
+// Workflow is a named sequence of steps run against a cluster. Its state is checkpointed to a Store after every step.
+type Workflow struct {
+	Name      string
+	ClusterID string
+
+	steps []Step
+	store *Store
+	state *State
+	// resumable is true if the state was loaded from a previous run which didn't complete
+	resumable bool
+	out       io.Writer
+	now       func() time.Time
+}
+
+// New returns a workflow for the cluster, loading the state of a previous run which didn't complete if there is one
+func New(store *Store, name, clusterID string, out io.Writer) (*Workflow, error) {
+	w := &Workflow{
+		Name:      name,
+		ClusterID: clusterID,
+		store:     store,
+		out:       out,
+		now:       time.Now,
+	}
+
+	state, err := store.Load(name, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		w.state = state
+		w.resumable = true
+	} else {
+		w.state = &State{Workflow: name, ClusterID: clusterID, Values: map[string]json.RawMessage{}}
+	}
+	if w.state.Values == nil {
+		w.state.Values = map[string]json.RawMessage{}
+	}
+
+	return w, nil
+}
+
+// Resumable returns true if a previous run of the workflow didn't complete
+func (w *Workflow) Resumable() bool {
+	return w.resumable
+}
+
+// AddStep appends a step to the workflow
+func (w *Workflow) AddStep(step Step) {
+	w.steps = append(w.steps, step)
+}
+
+// SetValue records a value which later steps need, such as the original state of a resource about to be modified, so
+// that it is still available when the workflow is resumed. It is checkpointed along with the next step.
+func (w *Workflow) SetValue(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to record %s: %w", key, err)
+	}
+	w.state.Values[key] = data
+
+	return nil
+}
+
+// Value reads a value recorded with SetValue into v, returning false if it wasn't recorded
+func (w *Workflow) Value(key string, v any) (bool, error) {
+	data, ok := w.state.Values[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// Plan prints the steps of the workflow without executing them, along with their status if a previous run didn't
+// complete
+func (w *Workflow) Plan() error {
+	if w.resumable {
+		fmt.Fprintf(w.out, "A previous run of %s for cluster %s started at %s did not complete\n\n", w.Name, w.ClusterID, w.state.StartedAt.Format(time.RFC3339))
+	}
+
+	p := printer.NewTablePrinter(w.out, 20, 1, 3, ' ')
+	p.AddRow([]string{"STEP", "NAME", "STATUS", "DESCRIPTION"})
+	for i, step := range w.steps {
+		p.AddRow([]string{fmt.Sprintf("%d", i+1), step.Name, w.stepState(step.Name).Status, step.Description})
+	}
+
+	return p.Flush()
+}
+
+// Run executes the steps in order, checkpointing the state after each. When resume is set, steps completed by the
+// previous run are skipped. Starting a new run while a previous one didn't complete fails, since running the steps
+// again from the start could undo the progress made.
+func (w *Workflow) Run(ctx context.Context, resume bool) error {
+	if err := w.checkResume(resume); err != nil {
+		return err
+	}
+	if !w.resumable {
+		w.state.StartedAt = w.now().UTC()
+	}
+
+	for i, step := range w.steps {
+		state := w.stepState(step.Name)
+		if state.Status == StatusDone {
+			fmt.Fprintf(w.out, "[%d/%d] %s: already done\n", i+1, len(w.steps), step.Name)
+			continue
+		}
+
+		fmt.Fprintf(w.out, "[%d/%d] %s: %s\n", i+1, len(w.steps), step.Name, step.Description)
+		if err := w.runStep(ctx, step, state.Status == StatusFailed); err != nil {
+			w.setStepState(StepState{Name: step.Name, Status: StatusFailed, Error: err.Error()})
+			if saveErr := w.store.Save(w.state); saveErr != nil {
+				return errors.Join(fmt.Errorf("step %s failed: %w", step.Name, err), saveErr)
+			}
+			return fmt.Errorf("step %s failed: %w\nfix the problem and re-run with --resume to continue from this step, or --rollback to undo the completed steps", step.Name, err)
+		}
+
+		w.setStepState(StepState{Name: step.Name, Status: StatusDone, FinishedAt: w.now().UTC()})
+		if err := w.store.Save(w.state); err != nil {
+			return err
+		}
+	}
+
+	return w.store.Delete(w.Name, w.ClusterID)
+}
+
+// runStep runs a single step. When retrying a failed step, it is skipped if Verify reports that it completed after all.
+func (w *Workflow) runStep(ctx context.Context, step Step, retry bool) error {
+	if retry && step.Verify != nil {
+		done, err := step.Verify(ctx)
+		if err == nil && done {
+			fmt.Fprintf(w.out, "%s already completed\n", step.Name)
+			return nil
+		}
+	}
+
+	if err := step.Do(ctx); err != nil {
+		return err
+	}
+
+	if step.Verify == nil {
+		return nil
+	}
+	done, err := step.Verify(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to verify: %w", err)
+	}
+	if !done {
+		return errors.New("verification failed")
+	}
+
+	return nil
+}
+
+// Rollback undoes the steps completed by a previous run which didn't complete in reverse order, including the step it
+// failed at since that may have partially run. The state is discarded once every step has been rolled back.
+func (w *Workflow) Rollback(ctx context.Context) error {
+	if !w.resumable {
+		return fmt.Errorf("no incomplete run of %s found for cluster %s to roll back", w.Name, w.ClusterID)
+	}
+
+	for i := len(w.steps) - 1; i >= 0; i-- {
+		step := w.steps[i]
+		state := w.stepState(step.Name)
+		if state.Status != StatusDone && state.Status != StatusFailed {
+			continue
+		}
+		if step.Rollback == nil {
+			continue
+		}
+
+		fmt.Fprintf(w.out, "rolling back %s\n", step.Name)
+		if err := step.Rollback(ctx); err != nil {
+			if saveErr := w.store.Save(w.state); saveErr != nil {
+				return errors.Join(fmt.Errorf("failed to roll back step %s: %w", step.Name, err), saveErr)
+			}
+			return fmt.Errorf("failed to roll back step %s: %w\nfix the problem and re-run with --rollback to continue rolling back", step.Name, err)
+		}
+
+		w.setStepState(StepState{Name: step.Name, Status: StatusRolledBack, FinishedAt: w.now().UTC()})
+		if err := w.store.Save(w.state); err != nil {
+			return err
+		}
+	}
+
+	return w.store.Delete(w.Name, w.ClusterID)
+}
+
+func (w *Workflow) checkResume(resume bool) error {
+	if resume && !w.resumable {
+		return fmt.Errorf("no incomplete run of %s found for cluster %s to resume", w.Name, w.ClusterID)
+	}
+	if !resume && w.resumable {
+		return fmt.Errorf("a previous run of %s for cluster %s started at %s did not complete, use --resume to continue it, --rollback to undo it, or --plan to see its progress",
+			w.Name, w.ClusterID, w.state.StartedAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (w *Workflow) stepState(name string) StepState {
+	for _, s := range w.state.Steps {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return StepState{Name: name, Status: StatusPending}
+}
+
+func (w *Workflow) setStepState(state StepState) {
+	w.state.UpdatedAt = w.now().UTC()
+	for i, s := range w.state.Steps {
+		if s.Name == state.Name {
+			w.state.Steps[i] = state
+			return
+		}
+	}
+	w.state.Steps = append(w.state.Steps, state)
+}
+
+// Options are the flags controlling how a command runs its workflow
+type Options struct {
+	Resume   bool
+	Plan     bool
+	Rollback bool
+}
+
+// AddFlags adds the --resume, --plan, and --rollback flags
+func (o *Options) AddFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.Resume, "resume", false, "Continue a previous run which failed partway from the step it failed at")
+	flags.BoolVar(&o.Plan, "plan", false, "Print the steps, and the progress of a previous run which failed partway, without executing them")
+	flags.BoolVar(&o.Rollback, "rollback", false, "Undo the steps completed by a previous run which failed partway")
+}
+
+// Validate returns an error if mutually exclusive flags are set
+func (o *Options) Validate() error {
+	set := 0
+	for _, b := range []bool{o.Resume, o.Plan, o.Rollback} {
+		if b {
+			set++
+		}
+	}
+	if set > 1 {
+		return errors.New("only one of --resume, --plan, and --rollback can be used")
+	}
+
+	return nil
+}
+
+// Execute plans, runs, or rolls back the workflow according to the options
+func (o *Options) Execute(ctx context.Context, w *Workflow) error {
+	switch {
+	case o.Plan:
+		return w.Plan()
+	case o.Rollback:
+		return w.Rollback(ctx)
+	default:
+		return w.Run(ctx, o.Resume)
+	}
+}
@@ -0,0 +1,184 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the steps run by a workflow
+type recorder struct {
+	calls []string
+	// fail makes the named step's Do fail
+	fail map[string]bool
+}
+
+func (r *recorder) step(name string) Step {
+	return Step{
+		Name:        name,
+		Description: "run " + name,
+		Do: func(ctx context.Context) error {
+			r.calls = append(r.calls, "do "+name)
+			if r.fail[name] {
+				return errors.New("boom")
+			}
+			return nil
+		},
+		Rollback: func(ctx context.Context) error {
+			r.calls = append(r.calls, "rollback "+name)
+			return nil
+		},
+	}
+}
+
+func newTestWorkflow(t *testing.T, store *Store, r *recorder) *Workflow {
+	w, err := New(store, "test", "cluster-id", &bytes.Buffer{})
+	assert.NoError(t, err)
+	for _, name := range []string{"one", "two", "three"} {
+		w.AddStep(r.step(name))
+	}
+
+	return w
+}
+
+func TestRun(t *testing.T) {
+	store := NewStore(t.TempDir())
+	r := &recorder{}
+
+	assert.NoError(t, newTestWorkflow(t, store, r).Run(context.Background(), false))
+	assert.Equal(t, []string{"do one", "do two", "do three"}, r.calls)
+
+	// The state is discarded once the workflow completes
+	state, err := store.Load("test", "cluster-id")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func TestRunResume(t *testing.T) {
+	store := NewStore(t.TempDir())
+	r := &recorder{fail: map[string]bool{"two": true}}
+
+	err := newTestWorkflow(t, store, r).Run(context.Background(), false)
+	assert.ErrorContains(t, err, "step two failed")
+	assert.Equal(t, []string{"do one", "do two"}, r.calls)
+
+	state, err := store.Load("test", "cluster-id")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusDone, state.Steps[0].Status)
+	assert.Equal(t, StatusFailed, state.Steps[1].Status)
+	assert.Equal(t, "boom", state.Steps[1].Error)
+
+	// Starting over without --resume is refused
+	w := newTestWorkflow(t, store, r)
+	assert.True(t, w.Resumable())
+	assert.ErrorContains(t, w.Run(context.Background(), false), "did not complete")
+
+	r.calls = nil
+	r.fail = nil
+	assert.NoError(t, newTestWorkflow(t, store, r).Run(context.Background(), true))
+	assert.Equal(t, []string{"do two", "do three"}, r.calls)
+}
+
+func TestRunResumeWithoutState(t *testing.T) {
+	w := newTestWorkflow(t, NewStore(t.TempDir()), &recorder{})
+	assert.ErrorContains(t, w.Run(context.Background(), true), "no incomplete run")
+}
+
+func TestRunVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified bool
+		retry    bool
+		wantDo   bool
+		wantErr  bool
+	}{
+		{name: "verified after do", verified: true, wantDo: true},
+		{name: "not verified after do", verified: false, wantDo: true, wantErr: true},
+		{name: "retry skips do when already verified", verified: true, retry: true, wantDo: false},
+		{name: "retry runs do when not verified", verified: false, retry: true, wantDo: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			did := false
+			w := &Workflow{out: &bytes.Buffer{}}
+			err := w.runStep(context.Background(), Step{
+				Name:   "step",
+				Do:     func(ctx context.Context) error { did = true; return nil },
+				Verify: func(ctx context.Context) (bool, error) { return tt.verified, nil },
+			}, tt.retry)
+
+			assert.Equal(t, tt.wantDo, did)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestRollback(t *testing.T) {
+	store := NewStore(t.TempDir())
+	r := &recorder{fail: map[string]bool{"two": true}}
+	assert.Error(t, newTestWorkflow(t, store, r).Run(context.Background(), false))
+
+	r.calls = nil
+	assert.NoError(t, newTestWorkflow(t, store, r).Rollback(context.Background()))
+	assert.Equal(t, []string{"rollback two", "rollback one"}, r.calls)
+
+	state, err := store.Load("test", "cluster-id")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func TestValues(t *testing.T) {
+	store := NewStore(t.TempDir())
+	w, err := New(store, "test", "cluster-id", &bytes.Buffer{})
+	assert.NoError(t, err)
+	w.AddStep(Step{
+		Name: "record",
+		Do: func(ctx context.Context) error {
+			return w.SetValue("original", map[string]string{"instanceType": "r5.xlarge"})
+		},
+	})
+	w.AddStep(Step{Name: "fail", Do: func(ctx context.Context) error { return errors.New("boom") }})
+	assert.Error(t, w.Run(context.Background(), false))
+
+	resumed, err := New(store, "test", "cluster-id", &bytes.Buffer{})
+	assert.NoError(t, err)
+	original := map[string]string{}
+	found, err := resumed.Value("original", &original)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "r5.xlarge", original["instanceType"])
+
+	found, err = resumed.Value("missing", &original)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestPlan(t *testing.T) {
+	store := NewStore(t.TempDir())
+	r := &recorder{fail: map[string]bool{"two": true}}
+	assert.Error(t, newTestWorkflow(t, store, r).Run(context.Background(), false))
+
+	out := &bytes.Buffer{}
+	r.calls = nil
+	w, err := New(store, "test", "cluster-id", out)
+	assert.NoError(t, err)
+	for _, name := range []string{"one", "two", "three"} {
+		w.AddStep(r.step(name))
+	}
+	assert.NoError(t, w.Plan())
+
+	assert.Empty(t, r.calls)
+	assert.Contains(t, out.String(), "did not complete")
+	assert.Regexp(t, `one\s+done`, out.String())
+	assert.Regexp(t, `two\s+failed`, out.String())
+	assert.Regexp(t, `three\s+pending`, out.String())
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, (&Options{Resume: true}).Validate())
+	assert.Error(t, (&Options{Resume: true, Rollback: true}).Validate())
+}